@@ -4,16 +4,27 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/lemavisaitov/lk-api/config"
 	"github.com/lemavisaitov/lk-api/internal/app"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/blobstore"
 	"github.com/lemavisaitov/lk-api/internal/cache"
+	"github.com/lemavisaitov/lk-api/internal/cursor"
 	"github.com/lemavisaitov/lk-api/internal/handler"
+	"github.com/lemavisaitov/lk-api/internal/hasher"
+	"github.com/lemavisaitov/lk-api/internal/lockout"
 	"github.com/lemavisaitov/lk-api/internal/logger"
 	"github.com/lemavisaitov/lk-api/internal/metrics"
+	"github.com/lemavisaitov/lk-api/internal/notifier"
+	"github.com/lemavisaitov/lk-api/internal/oauthclient"
+	"github.com/lemavisaitov/lk-api/internal/passpolicy"
 	"github.com/lemavisaitov/lk-api/internal/repository"
+	"github.com/lemavisaitov/lk-api/internal/sealer"
 	"github.com/lemavisaitov/lk-api/internal/storage"
 	"github.com/lemavisaitov/lk-api/internal/usecase"
+	"github.com/lemavisaitov/lk-api/internal/webauthn"
 	"github.com/lemavisaitov/lk-api/migrations"
 
 	"github.com/pkg/errors"
@@ -44,7 +55,7 @@ func main() {
 			zap.Error(errors.Wrap(err, "")),
 		)
 	}
-	pool, err := storage.GetConnect(withTimeout, connStr)
+	pool, err := storage.GetConnect(withTimeout, connStr, cfg.DBRowLevelSecurity)
 	if err != nil {
 		logger.Fatal("error while connecting to storage",
 			zap.Error(errors.Wrap(err, "")),
@@ -60,9 +71,202 @@ func main() {
 	defer cacheProvider.Close()
 	defer pool.Close()
 
-	userUC := usecase.NewUserProvider(cacheProvider)
-	handle := handler.New(userUC)
-	router := app.GetRouter(handle)
+	passwordHasher, err := hasher.New(cfg.PasswordHashAlgo, hasher.Argon2Params{
+		Memory:      cfg.Argon2Memory,
+		Iterations:  cfg.Argon2Iterations,
+		Parallelism: cfg.Argon2Parallelism,
+	}, cfg.BcryptCost)
+	if err != nil {
+		logger.Fatal("error while initializing password hasher",
+			zap.Error(errors.Wrap(err, "")),
+		)
+	}
+
+	var breached passpolicy.BreachedList
+	if cfg.PasswordBreachedDir != "" {
+		rangeDir, err := passpolicy.NewRangeDir(cfg.PasswordBreachedDir)
+		if err != nil {
+			logger.Fatal("error while opening breached passwords list",
+				zap.Error(errors.Wrap(err, "")),
+			)
+		}
+		breached = rangeDir
+	}
+	passwordPolicy := passpolicy.NewChecker(passpolicy.Policy{
+		MinLength:        cfg.PasswordMinLength,
+		MaxLength:        cfg.PasswordMaxLength,
+		RequireUpper:     cfg.PasswordRequireUpper,
+		RequireLower:     cfg.PasswordRequireLower,
+		RequireDigit:     cfg.PasswordRequireDigit,
+		RequireSymbol:    cfg.PasswordRequireSymbol,
+		DisallowUserInfo: cfg.PasswordDisallowUserInfo,
+	}, breached)
+
+	keySet, err := auth.LoadKeySet(cfg.JWTKeyFiles, cfg.JWTActiveKID)
+	if err != nil {
+		logger.Fatal("error while loading JWT keys",
+			zap.Error(errors.Wrap(err, "")),
+		)
+	}
+	issuer := auth.NewIssuer(keySet, cfg.JWTIssuer, cfg.AccessTokenTTL)
+	refreshTokenRepo := repository.NewRefreshTokenProvider(pool)
+	sessionRepo := repository.NewSessionProvider(pool)
+
+	var attemptStore lockout.Store
+	switch cfg.LockoutStore {
+	case "memory":
+		memoryStore := lockout.NewMemoryStore(cfg.CacheCleanupInterval, cfg.LockoutWindow)
+		defer memoryStore.Close()
+		attemptStore = memoryStore
+	case "postgres":
+		postgresStore := lockout.NewPostgresStore(pool, cfg.CacheCleanupInterval, cfg.LockoutWindow)
+		defer postgresStore.Close()
+		attemptStore = postgresStore
+	default:
+		logger.Fatal("unknown lockout store",
+			zap.String("store", cfg.LockoutStore),
+		)
+	}
+	loginPolicy := lockout.Policy{
+		MaxFailures:   cfg.LockoutMaxFailures,
+		LockoutPeriod: cfg.LockoutPeriod,
+		BaseDelay:     cfg.LockoutBaseDelay,
+		MaxDelay:      cfg.LockoutMaxDelay,
+		Window:        cfg.LockoutWindow,
+	}
+	ipPolicy := loginPolicy
+	ipPolicy.MaxFailures = cfg.LockoutIPMaxFailures
+	attempts := lockout.NewLimiter(attemptStore, loginPolicy, ipPolicy)
+
+	cursors, err := cursor.NewFromBase64(cfg.CursorKey)
+	if err != nil {
+		logger.Fatal("error while initializing cursor signing",
+			zap.Error(errors.Wrap(err, "")),
+		)
+	}
+	auditRepo := repository.NewAuditProvider(pool)
+	auditUC := usecase.NewAuditProvider(auditRepo, cursors)
+
+	attributeUC := usecase.NewAttributeProvider(repository.NewAttributeProvider(pool))
+	organizationRepo := repository.NewOrganizationProvider(pool)
+	organizationUC := usecase.NewOrganizationProvider(organizationRepo)
+
+	oneTimeTokenRepo := repository.NewOneTimeTokenProvider(pool)
+	userUC := usecase.NewUserProvider(cacheProvider, sessionRepo, oneTimeTokenRepo, passwordHasher, passwordPolicy, attempts,
+		auditUC, attributeUC, cursors, cfg.UserRestorePeriod, cfg.RequireVerifiedEmail)
+
+	var avatarStore blobstore.BlobStore
+	switch cfg.AvatarStore {
+	case "s3":
+		avatarStore = blobstore.NewS3(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey,
+			cfg.S3PublicURL)
+	case "local":
+		avatarStore = blobstore.NewLocal(cfg.AvatarDir, cfg.AvatarBaseURL)
+	default:
+		logger.Fatal("unknown avatar store",
+			zap.String("store", cfg.AvatarStore),
+		)
+	}
+	avatarUC := usecase.NewAvatarProvider(cacheProvider, avatarStore, auditUC, cfg.AvatarMaxSize, cfg.AvatarMaxPixels)
+
+	userPurger := usecase.NewUserPurger(userRepo, organizationRepo, auditRepo, avatarUC, cfg.UserRetentionPeriod,
+		cfg.UserPurgeInterval)
+	defer userPurger.Close()
+	authUC := usecase.NewAuthProvider(cacheProvider, refreshTokenRepo, sessionRepo, issuer, cfg.RefreshTokenTTL)
+	sessionUC := usecase.NewSessionProvider(sessionRepo)
+
+	var notify notifier.Notifier
+	switch cfg.NotifierType {
+	case "smtp":
+		notify = notifier.NewSMTP(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+	case "file":
+		notify = notifier.NewFile(cfg.NotifierFile)
+	case "log":
+		notify = notifier.NewLog()
+	default:
+		logger.Fatal("unknown notifier",
+			zap.String("notifier", cfg.NotifierType),
+		)
+	}
+	passwordResetUC := usecase.NewPasswordResetProvider(cacheProvider, oneTimeTokenRepo, sessionRepo,
+		passwordHasher, passwordPolicy, notify, cfg.PasswordResetTTL, cfg.PasswordResetURL)
+	emailUC := usecase.NewEmailVerificationProvider(cacheProvider, oneTimeTokenRepo, notify,
+		cfg.EmailVerificationTTL, cfg.EmailConfirmURL)
+
+	membershipRepo := repository.NewMembershipProvider(pool)
+	membershipUC := usecase.NewMembershipProvider(membershipRepo, auditUC)
+	invitationUC := usecase.NewInvitationProvider(repository.NewInvitationProvider(pool),
+		cacheProvider, userUC, notify, cfg.InvitationTTL, cfg.InvitationURL)
+
+	mfaSealer, err := sealer.NewFromBase64(cfg.MFAEncryptionKey)
+	if err != nil {
+		logger.Fatal("error while initializing mfa encryption",
+			zap.Error(errors.Wrap(err, "")),
+		)
+	}
+	mfaUC := usecase.NewMFAProvider(cacheProvider, repository.NewTOTPProvider(pool), issuer, mfaSealer,
+		attempts, cfg.TOTPIssuer, cfg.MFAChallengeTTL)
+
+	apiKeyUC := usecase.NewAPIKeyProvider(repository.NewAPIKeyProvider(pool), cacheProvider)
+
+	oidcIssuer := auth.NewIssuer(keySet, cfg.OIDCIssuerURL, cfg.AccessTokenTTL)
+	oidcUC := usecase.NewOIDCProvider(repository.NewOIDCProvider(pool), cacheProvider, oidcIssuer, cfg.OIDCCodeTTL)
+
+	externalClients := make([]*oauthclient.Client, 0, len(cfg.ExternalProviders))
+	for _, provider := range cfg.ExternalProviders {
+		redirectURL := provider.RedirectURL
+		if redirectURL == "" {
+			redirectURL = strings.TrimSuffix(cfg.ExternalCallbackBaseURL, "/") +
+				"/auth/external/" + provider.Name + "/callback"
+		}
+		externalClients = append(externalClients, oauthclient.New(oauthclient.Config{
+			Name:               provider.Name,
+			ClientID:           provider.ClientID,
+			ClientSecret:       provider.ClientSecret,
+			AuthURL:            provider.AuthURL,
+			TokenURL:           provider.TokenURL,
+			UserInfoURL:        provider.UserInfoURL,
+			RedirectURL:        redirectURL,
+			Scopes:             provider.Scopes,
+			AuthStyle:          provider.AuthStyle,
+			SubjectField:       provider.SubjectField,
+			EmailField:         provider.EmailField,
+			EmailVerifiedField: provider.EmailVerifiedField,
+			NameField:          provider.NameField,
+			LoginField:         provider.LoginField,
+		}, nil))
+	}
+	externalUC := usecase.NewExternalLoginProvider(repository.NewIdentityProvider(pool), cacheProvider, userUC,
+		externalClients, mfaSealer, cfg.ExternalStateTTL)
+
+	relyingParty := webauthn.New(webauthn.Config{
+		RPID:             cfg.WebAuthnRPID,
+		RPName:           cfg.WebAuthnRPName,
+		Origins:          cfg.WebAuthnOrigins,
+		Timeout:          cfg.WebAuthnTimeout,
+		UserVerification: cfg.WebAuthnUserVerification,
+	})
+	webAuthnUC := usecase.NewWebAuthnProvider(repository.NewWebAuthnProvider(pool), cacheProvider, relyingParty,
+		mfaSealer, cfg.WebAuthnTimeout)
+
+	permissionCache, err := cache.NewPermissionDecorator(repository.NewRBACProvider(pool), cfg.CacheCleanupInterval,
+		cfg.PermissionsCacheTTL)
+	if err != nil {
+		logger.Fatal("error while initializing permissions cache",
+			zap.Error(errors.Wrap(err, "")),
+		)
+	}
+	defer permissionCache.Close()
+	rbacUC := usecase.NewRBACProvider(permissionCache, cacheProvider)
+	impersonationUC := usecase.NewImpersonationProvider(repository.NewImpersonationProvider(pool), cacheProvider,
+		sessionRepo, permissionCache, issuer, cfg.ImpersonationTTL)
+
+	handle := handler.New(userUC, authUC, sessionUC, passwordResetUC, emailUC, mfaUC, apiKeyUC, oidcUC, externalUC,
+		webAuthnUC, rbacUC, impersonationUC, auditUC, attributeUC, avatarUC, organizationUC, membershipUC, invitationUC)
+	router := app.GetRouter(handle, authUC, apiKeyUC, rbacUC, organizationUC, membershipUC)
+	if cfg.AvatarStore == "local" {
+		router.Static("/avatars", cfg.AvatarDir)
+	}
 
 	metrics.InitMetrics(cfg.MetricsAddress, cacheProvider)
 
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
@@ -14,6 +16,21 @@ type Config struct {
 	LogLevel       string `env:"LOG_LEVEL" env-default:"info"`
 	DB
 	Cache
+	Hasher
+	PasswordPolicy
+	JWT
+	Lockout
+	PasswordReset
+	EmailVerification
+	MFA
+	WebAuthn
+	OIDC
+	ExternalIdentity
+	Pagination
+	UserDeletion
+	Notifier
+	Avatar
+	Invitation
 }
 
 type DB struct {
@@ -22,11 +39,163 @@ type DB struct {
 	DBHost        string        `env:"DB_HOST" env-default:"postgres"`
 	DBPort        string        `env:"DB_PORT" env-default:"5432"`
 	DBConnTimeout time.Duration `env:"DB_CONN_TIMEOUT" env-default:"5s"`
+	// Row-level security действует, только если DB_USER не владелец таблицы users
+	DBRowLevelSecurity bool `env:"DB_ROW_LEVEL_SECURITY" env-default:"false"`
 }
 
 type Cache struct {
 	CacheCleanupInterval time.Duration `env:"CACHE_CLEANUP_INTERVAL" env-default:"5s"`
 	CacheTTL             time.Duration `env:"CACHE_TTL" env-default:"10s"`
+	// Сколько другие экземпляры сервиса могут видеть старые права после их изменения
+	PermissionsCacheTTL time.Duration `env:"PERMISSIONS_CACHE_TTL" env-default:"1m"`
+}
+
+type Hasher struct {
+	PasswordHashAlgo  string `env:"PASSWORD_HASH_ALGO" env-default:"argon2id"`
+	Argon2Memory      uint32 `env:"ARGON2_MEMORY" env-default:"65536"`
+	Argon2Iterations  uint32 `env:"ARGON2_ITERATIONS" env-default:"3"`
+	Argon2Parallelism uint8  `env:"ARGON2_PARALLELISM" env-default:"2"`
+	BcryptCost        int    `env:"BCRYPT_COST" env-default:"12"`
+}
+
+type PasswordPolicy struct {
+	PasswordMinLength        int  `env:"PASSWORD_MIN_LENGTH" env-default:"8"`
+	PasswordMaxLength        int  `env:"PASSWORD_MAX_LENGTH" env-default:"128"`
+	PasswordRequireUpper     bool `env:"PASSWORD_REQUIRE_UPPER" env-default:"false"`
+	PasswordRequireLower     bool `env:"PASSWORD_REQUIRE_LOWER" env-default:"false"`
+	PasswordRequireDigit     bool `env:"PASSWORD_REQUIRE_DIGIT" env-default:"false"`
+	PasswordRequireSymbol    bool `env:"PASSWORD_REQUIRE_SYMBOL" env-default:"false"`
+	PasswordDisallowUserInfo bool `env:"PASSWORD_DISALLOW_USER_INFO" env-default:"true"`
+	// Каталог с файлами <префикс SHA-1>.txt, пустое значение отключает проверку
+	PasswordBreachedDir string `env:"PASSWORD_BREACHED_DIR"`
+}
+
+type JWT struct {
+	JWTKeyFiles     map[string]string `env:"JWT_KEY_FILES" env-required:"true"`
+	JWTActiveKID    string            `env:"JWT_ACTIVE_KID" env-required:"true"`
+	JWTIssuer       string            `env:"JWT_ISSUER" env-default:"lk-api"`
+	AccessTokenTTL  time.Duration     `env:"ACCESS_TOKEN_TTL" env-default:"15m"`
+	RefreshTokenTTL time.Duration     `env:"REFRESH_TOKEN_TTL" env-default:"720h"`
+	// Срок жизни токена имперсонации, продлить его нельзя
+	ImpersonationTTL time.Duration `env:"IMPERSONATION_TTL" env-default:"15m"`
+}
+
+type Lockout struct {
+	LockoutStore         string        `env:"LOCKOUT_STORE" env-default:"postgres"`
+	LockoutMaxFailures   int           `env:"LOCKOUT_MAX_FAILURES" env-default:"5"`
+	LockoutIPMaxFailures int           `env:"LOCKOUT_IP_MAX_FAILURES" env-default:"50"`
+	LockoutPeriod        time.Duration `env:"LOCKOUT_PERIOD" env-default:"15m"`
+	LockoutBaseDelay     time.Duration `env:"LOCKOUT_BASE_DELAY" env-default:"1s"`
+	LockoutMaxDelay      time.Duration `env:"LOCKOUT_MAX_DELAY" env-default:"1m"`
+	LockoutWindow        time.Duration `env:"LOCKOUT_WINDOW" env-default:"1h"`
+}
+
+type PasswordReset struct {
+	PasswordResetTTL time.Duration `env:"PASSWORD_RESET_TTL" env-default:"1h"`
+	PasswordResetURL string        `env:"PASSWORD_RESET_URL" env-default:"http://localhost:8080/reset-password"`
+}
+
+type EmailVerification struct {
+	EmailVerificationTTL time.Duration `env:"EMAIL_VERIFICATION_TTL" env-default:"24h"`
+	EmailConfirmURL      string        `env:"EMAIL_CONFIRM_URL" env-default:"http://localhost:8080/user/email/confirm"`
+	RequireVerifiedEmail bool          `env:"REQUIRE_VERIFIED_EMAIL" env-default:"false"`
+}
+
+type Invitation struct {
+	InvitationTTL time.Duration `env:"INVITATION_TTL" env-default:"168h"`
+	// Страница, на которой приглашённый принимает или отклоняет приглашение
+	InvitationURL string `env:"INVITATION_URL" env-default:"http://localhost:8080/invitations"`
+}
+
+type MFA struct {
+	// Ключ AES-256 в base64 для шифрования TOTP-секретов в БД
+	MFAEncryptionKey string        `env:"MFA_ENCRYPTION_KEY" env-required:"true"`
+	TOTPIssuer       string        `env:"TOTP_ISSUER" env-default:"lk-api"`
+	MFAChallengeTTL  time.Duration `env:"MFA_CHALLENGE_TTL" env-default:"5m"`
+}
+
+type WebAuthn struct {
+	// Домен сайта, к которому привязываются ключи; менять его нельзя, иначе ключи перестанут подходить
+	WebAuthnRPID             string        `env:"WEBAUTHN_RP_ID" env-default:"localhost"`
+	WebAuthnRPName           string        `env:"WEBAUTHN_RP_NAME" env-default:"lk-api"`
+	WebAuthnOrigins          []string      `env:"WEBAUTHN_ORIGINS" env-default:"http://localhost:8080"`
+	WebAuthnTimeout          time.Duration `env:"WEBAUTHN_TIMEOUT" env-default:"2m"`
+	WebAuthnUserVerification string        `env:"WEBAUTHN_USER_VERIFICATION" env-default:"preferred"`
+}
+
+type OIDC struct {
+	// Внешний адрес сервиса: iss в токенах и база для адресов в discovery
+	OIDCIssuerURL string        `env:"OIDC_ISSUER_URL" env-default:"http://localhost:8080"`
+	OIDCCodeTTL   time.Duration `env:"OIDC_CODE_TTL" env-default:"1m"`
+}
+
+type ExternalIdentity struct {
+	// JSON-файл со списком провайдеров (ExternalProvider), пустое значение отключает вход через них
+	ExternalProvidersFile   string        `env:"EXTERNAL_PROVIDERS_FILE"`
+	ExternalCallbackBaseURL string        `env:"EXTERNAL_CALLBACK_BASE_URL" env-default:"http://localhost:8080"`
+	ExternalStateTTL        time.Duration `env:"EXTERNAL_STATE_TTL" env-default:"10m"`
+	ExternalProviders       []ExternalProvider
+}
+
+// ExternalProvider - настройки OAuth2/OIDC-провайдера. Пустые поля профиля
+// означают стандартные claims OpenID Connect.
+type ExternalProvider struct {
+	Name         string   `json:"name"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	AuthURL      string   `json:"auth_url"`
+	TokenURL     string   `json:"token_url"`
+	UserInfoURL  string   `json:"userinfo_url"`
+	Scopes       []string `json:"scopes"`
+	// По умолчанию <EXTERNAL_CALLBACK_BASE_URL>/auth/external/<name>/callback
+	RedirectURL        string `json:"redirect_url"`
+	AuthStyle          string `json:"auth_style"`
+	SubjectField       string `json:"subject_field"`
+	EmailField         string `json:"email_field"`
+	EmailVerifiedField string `json:"email_verified_field"`
+	NameField          string `json:"name_field"`
+	LoginField         string `json:"login_field"`
+}
+
+type Pagination struct {
+	// Ключ HMAC в base64 (не короче 32 байт) для подписи курсоров постраничной выдачи
+	CursorKey string `env:"CURSOR_KEY" env-required:"true"`
+}
+
+type UserDeletion struct {
+	// Сколько после удаления пользователя можно восстановить
+	UserRestorePeriod time.Duration `env:"USER_RESTORE_PERIOD" env-default:"168h"`
+	// Через сколько после удаления данные стираются безвозвратно, не меньше USER_RESTORE_PERIOD
+	UserRetentionPeriod time.Duration `env:"USER_RETENTION_PERIOD" env-default:"720h"`
+	UserPurgeInterval   time.Duration `env:"USER_PURGE_INTERVAL" env-default:"1h"`
+}
+
+type Notifier struct {
+	NotifierType string `env:"NOTIFIER" env-default:"log"`
+	NotifierFile string `env:"NOTIFIER_FILE" env-default:"logs/mail.log"`
+	SMTPHost     string `env:"SMTP_HOST"`
+	SMTPPort     string `env:"SMTP_PORT" env-default:"587"`
+	SMTPUsername string `env:"SMTP_USERNAME"`
+	SMTPPassword string `env:"SMTP_PASSWORD"`
+	SMTPFrom     string `env:"SMTP_FROM" env-default:"no-reply@localhost"`
+}
+
+type Avatar struct {
+	// local - файлы в AVATAR_DIR, их раздаёт сам сервис по /avatars; s3 - S3-совместимое хранилище
+	AvatarStore   string `env:"AVATAR_STORE" env-default:"local"`
+	AvatarDir     string `env:"AVATAR_DIR" env-default:"data/avatars"`
+	AvatarBaseURL string `env:"AVATAR_BASE_URL" env-default:"http://localhost:8080/avatars"`
+	// Ограничение на размер загружаемого файла в байтах
+	AvatarMaxSize int64 `env:"AVATAR_MAX_SIZE" env-default:"5242880"`
+	// Ограничение на число пикселей исходного изображения
+	AvatarMaxPixels int    `env:"AVATAR_MAX_PIXELS" env-default:"40000000"`
+	S3Endpoint      string `env:"S3_ENDPOINT"`
+	S3Region        string `env:"S3_REGION" env-default:"us-east-1"`
+	S3Bucket        string `env:"S3_BUCKET"`
+	S3AccessKey     string `env:"S3_ACCESS_KEY"`
+	S3SecretKey     string `env:"S3_SECRET_KEY"`
+	// Адрес CDN перед бакетом, по умолчанию файлы отдаются по <S3_ENDPOINT>/<S3_BUCKET>
+	S3PublicURL string `env:"S3_PUBLIC_URL"`
 }
 
 func Load() (*Config, error) {
@@ -36,6 +205,20 @@ func Load() (*Config, error) {
 		return nil, errors.Wrap(err, "failed to read env")
 	}
 
+	if cfg.UserRetentionPeriod < cfg.UserRestorePeriod {
+		return nil, errors.New("USER_RETENTION_PERIOD must not be shorter than USER_RESTORE_PERIOD")
+	}
+
+	if cfg.ExternalProvidersFile != "" {
+		data, err := os.ReadFile(cfg.ExternalProvidersFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read external providers")
+		}
+		if err := json.Unmarshal(data, &cfg.ExternalProviders); err != nil {
+			return nil, errors.Wrap(err, "failed to parse external providers")
+		}
+	}
+
 	return &cfg, nil
 }
 
@@ -0,0 +1,490 @@
+package app
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/handler"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+	"github.com/lemavisaitov/lk-api/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOIDCRepo хранит клиентов, коды и согласия в памяти
+type fakeOIDCRepo struct {
+	mu       sync.Mutex
+	clients  map[string]model.OAuthClient
+	codes    map[string]model.AuthorizationCode
+	used     map[string]bool
+	consents map[string]model.OAuthConsent
+}
+
+func newFakeOIDCRepo() *fakeOIDCRepo {
+	return &fakeOIDCRepo{
+		clients:  make(map[string]model.OAuthClient),
+		codes:    make(map[string]model.AuthorizationCode),
+		used:     make(map[string]bool),
+		consents: make(map[string]model.OAuthConsent),
+	}
+}
+
+func (f *fakeOIDCRepo) AddClient(_ context.Context, client model.OAuthClient) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.clients[client.ID] = client
+	return nil
+}
+
+func (f *fakeOIDCRepo) GetClient(_ context.Context, id string) (*model.OAuthClient, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	client, ok := f.clients[id]
+	if !ok {
+		return nil, errors.Wrap(apperr.ErrNotFound, "client not found")
+	}
+	return &client, nil
+}
+
+func (f *fakeOIDCRepo) AddAuthorizationCode(_ context.Context, code model.AuthorizationCode) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.codes[code.CodeHash] = code
+	return nil
+}
+
+func (f *fakeOIDCRepo) ConsumeAuthorizationCode(_ context.Context, hash string) (*model.AuthorizationCode, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	code, ok := f.codes[hash]
+	if !ok || f.used[hash] || time.Now().After(code.ExpiresAt) {
+		return nil, errors.Wrap(apperr.ErrNotFound, "authorization code not found")
+	}
+	f.used[hash] = true
+	return &code, nil
+}
+
+func (f *fakeOIDCRepo) GetConsent(_ context.Context, userID uuid.UUID, clientID string) (*model.OAuthConsent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	consent, ok := f.consents[userID.String()+clientID]
+	if !ok {
+		return nil, errors.Wrap(apperr.ErrNotFound, "consent not found")
+	}
+	return &consent, nil
+}
+
+func (f *fakeOIDCRepo) SaveConsent(_ context.Context, consent model.OAuthConsent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	consent.GrantedAt = time.Now()
+	f.consents[consent.UserID.String()+consent.ClientID] = consent
+	return nil
+}
+
+func (f *fakeOIDCRepo) ListUserConsents(_ context.Context, userID uuid.UUID) ([]model.OAuthConsent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	consents := make([]model.OAuthConsent, 0)
+	for _, consent := range f.consents {
+		if consent.UserID == userID {
+			consents = append(consents, consent)
+		}
+	}
+	return consents, nil
+}
+
+func (f *fakeOIDCRepo) DeleteConsent(_ context.Context, userID uuid.UUID, clientID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.consents[userID.String()+clientID]; !ok {
+		return errors.Wrap(apperr.ErrNotFound, "consent not found")
+	}
+	delete(f.consents, userID.String()+clientID)
+	return nil
+}
+
+// fakeUserRepo реализует только чтение пользователя, остальное провайдеру не нужно
+type fakeUserRepo struct {
+	repository.UserProvider
+	users map[uuid.UUID]*model.User
+}
+
+func (f *fakeUserRepo) GetUser(_ context.Context, id uuid.UUID) (*model.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return nil, errors.Wrap(apperr.ErrNotFound, "user not found")
+	}
+	return user, nil
+}
+
+func (f *fakeUserRepo) GetUserOrganizationID(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	user, err := f.GetUser(ctx, id)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return user.OrganizationID, nil
+}
+
+// stubAuthenticator принимает заранее известные пользовательские токены
+type stubAuthenticator map[string]*auth.Claims
+
+func (s stubAuthenticator) AuthenticateAccessToken(_ context.Context, token string) (*auth.Claims, error) {
+	claims, ok := s[token]
+	if !ok {
+		return nil, apperr.ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// permissionsStub отдаёт права по subject токена
+type permissionsStub map[string][]string
+
+func (s permissionsStub) UserPermissions(_ context.Context, id uuid.UUID) ([]string, error) {
+	return s[id.String()], nil
+}
+
+// relyingParty - минимальное приложение, входящее через lk-api:
+// discovery, code flow с PKCE, проверка ID-токена по JWKS и запрос /userinfo.
+type relyingParty struct {
+	t            *testing.T
+	server       *httptest.Server
+	providerURL  string
+	clientID     string
+	clientSecret string
+
+	mu       sync.Mutex
+	state    string
+	nonce    string
+	verifier string
+	code     string
+	tokens   model.OIDCTokenResponse
+}
+
+func newRelyingParty(t *testing.T, providerURL string) *relyingParty {
+	rp := &relyingParty{t: t, providerURL: providerURL}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", rp.login)
+	mux.HandleFunc("/callback", rp.callback)
+	rp.server = httptest.NewServer(mux)
+	t.Cleanup(rp.server.Close)
+	return rp
+}
+
+func (rp *relyingParty) redirectURI() string {
+	return rp.server.URL + "/callback"
+}
+
+func (rp *relyingParty) discovery() model.OIDCDiscovery {
+	var doc model.OIDCDiscovery
+	rp.getJSON(rp.providerURL+model.OIDCDiscoveryPath, &doc)
+	return doc
+}
+
+func (rp *relyingParty) login(w http.ResponseWriter, r *http.Request) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	rp.state = randomString(rp.t)
+	rp.nonce = randomString(rp.t)
+	rp.verifier = randomString(rp.t)
+	sum := sha256.Sum256([]byte(rp.verifier))
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {rp.clientID},
+		"redirect_uri":          {rp.redirectURI()},
+		"scope":                 {"openid profile email"},
+		"state":                 {rp.state},
+		"nonce":                 {rp.nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+		"code_challenge_method": {"S256"},
+	}
+	http.Redirect(w, r, rp.discovery().AuthorizationEndpoint+"?"+query.Encode(), http.StatusFound)
+}
+
+func (rp *relyingParty) callback(w http.ResponseWriter, r *http.Request) {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	if r.URL.Query().Get("state") != rp.state {
+		http.Error(w, "state mismatch", http.StatusBadRequest)
+		return
+	}
+	if errCode := r.URL.Query().Get("error"); errCode != "" {
+		http.Error(w, errCode, http.StatusForbidden)
+		return
+	}
+	rp.code = r.URL.Query().Get("code")
+	doc := rp.discovery()
+
+	resp, err := rp.exchange(doc.TokenEndpoint, rp.code)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		http.Error(w, "token endpoint: "+resp.Status, http.StatusBadGateway)
+		return
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rp.tokens); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	// ID-токен проверяется только по опубликованным ключам провайдера
+	var jwks auth.JWKS
+	rp.getJSON(doc.JWKSURI, &jwks)
+	var idClaims auth.IDClaims
+	_, err = jwt.ParseWithClaims(rp.tokens.IDToken, &idClaims, func(token *jwt.Token) (any, error) {
+		for _, key := range jwks.Keys {
+			if key.Kid == token.Header["kid"] {
+				return key.PublicKey()
+			}
+		}
+		return nil, errors.New("unknown kid")
+	}, jwt.WithIssuer(doc.Issuer), jwt.WithAudience(rp.clientID), jwt.WithExpirationRequired())
+	if err != nil || idClaims.Nonce != rp.nonce {
+		http.Error(w, "invalid id token", http.StatusUnauthorized)
+		return
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, doc.UserinfoEndpoint, nil)
+	req.Header.Set("Authorization", "Bearer "+rp.tokens.AccessToken)
+	infoResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer infoResp.Body.Close()
+
+	var info model.UserInfo
+	if err := json.NewDecoder(infoResp.Body).Decode(&info); err != nil || info.Subject != idClaims.Subject {
+		http.Error(w, "userinfo subject mismatch", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(info)
+}
+
+func (rp *relyingParty) exchange(tokenEndpoint string, code string) (*http.Response, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {rp.redirectURI()},
+		"code_verifier": {rp.verifier},
+	}
+	req, err := http.NewRequest(http.MethodPost, tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(rp.clientID), url.QueryEscape(rp.clientSecret))
+	return http.DefaultClient.Do(req)
+}
+
+func (rp *relyingParty) getJSON(rawURL string, v any) {
+	resp, err := http.Get(rawURL)
+	require.NoError(rp.t, err)
+	defer resp.Body.Close()
+	require.Equal(rp.t, http.StatusOK, resp.StatusCode)
+	require.NoError(rp.t, json.NewDecoder(resp.Body).Decode(v))
+}
+
+func randomString(t *testing.T) string {
+	buf := make([]byte, 32)
+	_, err := rand.Read(buf)
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// browser ходит по редиректам вручную и подставляет токен пользователя
+// в запросы к провайдеру, как это делал бы фронтенд lk-api
+type browser struct {
+	client      *http.Client
+	providerURL string
+	token       string
+}
+
+func (b *browser) get(t *testing.T, rawURL string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	require.NoError(t, err)
+	if strings.HasPrefix(rawURL, b.providerURL) {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+	resp, err := b.client.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestOIDCProvider_EndToEnd(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := auth.NewKey("k1", private)
+	require.NoError(t, err)
+	keys, err := auth.NewKeySet("k1", key)
+	require.NoError(t, err)
+
+	verifiedAt := time.Now()
+	user := &model.User{ID: uuid.New(), Login: "jane", Name: "Jane", Email: "jane@example.com",
+		EmailVerifiedAt: &verifiedAt}
+	admin := auth.NewClaims(uuid.New())
+	admin.Roles = []string{model.RoleAdmin}
+	authenticator := stubAuthenticator{
+		"user-token":  auth.NewClaims(user.ID),
+		"admin-token": admin,
+	}
+
+	var router http.Handler
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		router.ServeHTTP(w, r)
+	}))
+	defer provider.Close()
+
+	oidcIssuer := auth.NewIssuer(keys, provider.URL, time.Minute)
+	oidcUC := usecase.NewOIDCProvider(newFakeOIDCRepo(),
+		&fakeUserRepo{users: map[uuid.UUID]*model.User{user.ID: user}}, oidcIssuer, time.Minute)
+	router = GetRouter(handler.New(nil, nil, nil, nil, nil, nil, nil, oidcUC, nil, nil, nil, nil, nil, nil, nil, nil,
+		nil, nil), authenticator, nil, permissionsStub{admin.Subject: {model.PermissionAdminManage}}, nil, nil)
+
+	rp := newRelyingParty(t, provider.URL)
+	b := &browser{
+		client: &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}},
+		providerURL: provider.URL,
+		token:       "user-token",
+	}
+
+	// Кейс 1: discovery публикует адреса относительно issuer
+	doc := rp.discovery()
+	assert.Equal(t, provider.URL, doc.Issuer)
+	assert.Equal(t, provider.URL+model.OIDCTokenPath, doc.TokenEndpoint)
+	assert.Equal(t, []string{"EdDSA"}, doc.IDTokenSigningAlgValuesSupported)
+
+	// Кейс 2: регистрировать клиентов может только администратор
+	body := `{"name":"Wiki","redirect_uris":["` + rp.redirectURI() + `"]}`
+	req, _ := http.NewRequest(http.MethodPost, provider.URL+"/oauth/clients", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer user-token")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	req, _ = http.NewRequest(http.MethodPost, provider.URL+"/oauth/clients", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer admin-token")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var client model.RegisteredClient
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&client))
+	resp.Body.Close()
+	require.NotEmpty(t, client.Secret)
+	rp.clientID, rp.clientSecret = client.ID, client.Secret
+
+	// Кейс 3: первый вход - провайдер спрашивает согласие
+	resp = b.get(t, rp.server.URL+"/login")
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	authorizeURL := resp.Header.Get("Location")
+	require.True(t, strings.HasPrefix(authorizeURL, provider.URL+model.OIDCAuthorizePath))
+
+	resp = b.get(t, authorizeURL)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var prompt model.ConsentPrompt
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&prompt))
+	assert.True(t, prompt.ConsentRequired)
+	assert.Equal(t, "Wiki", prompt.ClientName)
+	assert.Equal(t, []string{"openid", "profile", "email"}, prompt.Scopes)
+
+	// Кейс 4: после согласия приложение получает код, токены и данные пользователя
+	resp = b.get(t, authorizeURL+"&consent=granted")
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	callbackURL := resp.Header.Get("Location")
+	require.True(t, strings.HasPrefix(callbackURL, rp.redirectURI()))
+
+	resp = b.get(t, callbackURL)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var info model.UserInfo
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+	assert.Equal(t, user.ID.String(), info.Subject)
+	assert.Equal(t, "jane", info.PreferredUsername)
+	assert.Equal(t, "jane@example.com", info.Email)
+	require.NotNil(t, info.EmailVerified)
+	assert.True(t, *info.EmailVerified)
+
+	// Кейс 5: код одноразовый
+	replay, err := rp.exchange(doc.TokenEndpoint, rp.code)
+	require.NoError(t, err)
+	defer replay.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, replay.StatusCode)
+	var oauthErr map[string]string
+	require.NoError(t, json.NewDecoder(replay.Body).Decode(&oauthErr))
+	assert.Equal(t, "invalid_grant", oauthErr["error"])
+
+	// Кейс 6: токен приложения помечен целью oidc, поэтому API lk-api его не примет
+	claims, err := oidcIssuer.Parse(rp.tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, auth.PurposeOIDC, claims.Purpose)
+	assert.Equal(t, jwt.ClaimStrings{client.ID}, claims.Audience)
+
+	// Кейс 7: повторный вход проходит без вопроса о согласии
+	resp = b.get(t, rp.server.URL+"/login")
+	resp = b.get(t, resp.Header.Get("Location"))
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	resp = b.get(t, resp.Header.Get("Location"))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Кейс 8: после отзыва согласия /userinfo перестаёт принимать токен
+	req, _ = http.NewRequest(http.MethodGet, provider.URL+"/user/"+user.ID.String()+"/oauth/consents", nil)
+	req.Header.Set("Authorization", "Bearer user-token")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	var consents struct {
+		Consents []model.OAuthConsent `json:"consents"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&consents))
+	resp.Body.Close()
+	require.Len(t, consents.Consents, 1)
+	assert.True(t, slices.Contains(consents.Consents[0].Scopes, "email"))
+
+	req, _ = http.NewRequest(http.MethodDelete,
+		provider.URL+"/user/"+user.ID.String()+"/oauth/consents/"+client.ID, nil)
+	req.Header.Set("Authorization", "Bearer user-token")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	req, _ = http.NewRequest(http.MethodGet, doc.UserinfoEndpoint, nil)
+	req.Header.Set("Authorization", "Bearer "+rp.tokens.AccessToken)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("WWW-Authenticate"), "invalid_token")
+}
@@ -4,18 +4,166 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/lemavisaitov/lk-api/internal/handler"
 	"github.com/lemavisaitov/lk-api/internal/middleware"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
 )
 
-func GetRouter(handler *handler.Handle) *gin.Engine {
+func GetRouter(handler *handler.Handle,
+	authenticator middleware.TokenAuthenticator,
+	keyAuthenticator middleware.APIKeyAuthenticator,
+	permissions middleware.PermissionChecker,
+	organizations middleware.OrganizationResolver,
+	members middleware.MemberRoleChecker) *gin.Engine {
 	router := gin.Default()
+	// Значения из контекста запроса (claims) доступны через *gin.Context
+	router.ContextWithFallback = true
 
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Tenant(organizations))
 	router.Use(middleware.HttpStatusMetric())
+	router.Use(middleware.AuditImpersonation())
 
-	router.POST("/user/signup", handler.Signup)
+	authenticated := middleware.Auth(authenticator)
+	// Эти маршруты доступны и по API-ключу с нужным правом
+	usersWrite := middleware.AuthWithAPIKey(authenticator, keyAuthenticator, model.ScopeUsersWrite)
+	sessionsRead := middleware.AuthWithAPIKey(authenticator, keyAuthenticator, model.ScopeSessionsRead)
+	sessionsWrite := middleware.AuthWithAPIKey(authenticator, keyAuthenticator, model.ScopeSessionsWrite)
+	// Владелец аккаунта либо пользователь с нужным правом
+	selfOrRead := middleware.RequireSelfOrPermission("id", permissions, model.PermissionUsersRead)
+	selfOrWrite := middleware.RequireSelfOrPermission("id", permissions, model.PermissionUsersWrite)
+	selfOrDelete := middleware.RequireSelfOrPermission("id", permissions, model.PermissionUsersDelete)
+	self := middleware.RequireSelf("id")
+	// Права проверяются по ролям в БД, а не по токену: отзыв действует сразу, роли групп учитываются
+	admin := middleware.RequirePermission(permissions, model.PermissionAdminManage)
+	// Действия, которые сотрудник не может выполнить от имени пользователя
+	noImpersonation := middleware.ForbidImpersonation()
+	impersonate := middleware.RequirePermission(permissions, model.PermissionUsersImpersonate)
+	// Настройки и журналы, общие для всех организаций, доступны только сотрудникам организации по умолчанию
+	operator := middleware.RequireOrganization(tenant.DefaultID)
+	// Самостоятельная регистрация только в организации по умолчанию, в остальные попадают по приглашению
+	openSignup := middleware.RequireOrganization(tenant.DefaultID)
+
+	router.POST("/user/signup", openSignup, handler.Signup)
 	router.GET("user/:id", handler.GetUser)
+	router.GET("/users", authenticated,
+		middleware.RequirePermission(permissions, model.PermissionUsersRead), handler.ListUsers)
+	router.GET("/users/search", authenticated,
+		middleware.RequirePermission(permissions, model.PermissionUsersRead), handler.SearchUsers)
 	router.POST("/user/login", handler.Login)
-	router.PUT("/user/:id", handler.UpdateUser)
-	router.DELETE("/user/:id", handler.DeleteUser)
+	router.POST("/user/login/mfa", handler.LoginMFA)
+	router.POST("/user/login/webauthn/options", handler.BeginWebAuthnLogin)
+	router.POST("/user/login/webauthn", handler.LoginWebAuthn)
+	router.POST("/user/token/refresh", handler.RefreshToken)
+	router.POST("/user/password/forgot", handler.ForgotPassword)
+	router.POST("/user/password/reset", handler.ResetPassword)
+	router.GET("/user/email/confirm", handler.ConfirmEmail)
+	// Уже зарегистрированный приглашённый принимает приглашение, войдя в свою учётную запись
+	router.POST("/invitations/accept", middleware.OptionalAuth(authenticator), noImpersonation,
+		handler.AcceptInvitation)
+	router.POST("/invitations/decline", handler.DeclineInvitation)
+	router.PUT("/user/:id", usersWrite, selfOrWrite, handler.UpdateUser)
+	router.PATCH("/user/:id", usersWrite, selfOrWrite, handler.PatchUser)
+	router.PUT("/user/:id/avatar", usersWrite, selfOrWrite, handler.PutAvatar)
+	router.DELETE("/user/:id/avatar", usersWrite, selfOrWrite, handler.DeleteAvatar)
+	router.DELETE("/user/:id", usersWrite, noImpersonation, selfOrDelete, handler.DeleteUser)
+	// Удалённый пользователь войти не может, восстанавливает его сотрудник
+	router.POST("/user/:id/restore", authenticated, noImpersonation,
+		middleware.RequirePermission(permissions, model.PermissionUsersDelete), handler.RestoreUser)
+	router.POST("/user/:id/email/verify", authenticated, selfOrWrite, handler.SendEmailVerification)
+
+	router.GET("/user/:id/sessions", sessionsRead, selfOrRead, handler.ListSessions)
+	router.DELETE("/user/:id/sessions", sessionsWrite, selfOrWrite, handler.RevokeAllSessions)
+	router.DELETE("/user/:id/sessions/:sid", sessionsWrite, selfOrWrite, handler.RevokeSession)
+
+	router.POST("/user/:id/2fa/totp", authenticated, noImpersonation, self, handler.EnrollTOTP)
+	router.POST("/user/:id/2fa/totp/confirm", authenticated, noImpersonation, self, handler.ConfirmTOTP)
+	router.DELETE("/user/:id/2fa/totp", authenticated, noImpersonation, self, handler.DisableTOTP)
+	router.POST("/user/:id/2fa/recovery-codes", authenticated, noImpersonation, self, handler.RegenerateRecoveryCodes)
+
+	router.POST("/user/:id/webauthn/register/options", authenticated, noImpersonation, self, handler.BeginWebAuthnRegistration)
+	router.POST("/user/:id/webauthn/register", authenticated, noImpersonation, self, handler.FinishWebAuthnRegistration)
+	router.GET("/user/:id/webauthn/credentials", authenticated, selfOrRead, handler.ListWebAuthnCredentials)
+	router.DELETE("/user/:id/webauthn/credentials/:cid", authenticated, noImpersonation, self, handler.DeleteWebAuthnCredential)
+
+	// Ключами управляют только по токену пользователя
+	router.POST("/user/:id/api-keys", authenticated, noImpersonation, selfOrWrite, handler.CreateAPIKey)
+	router.GET("/user/:id/api-keys", authenticated, selfOrRead, handler.ListAPIKeys)
+	router.DELETE("/user/:id/api-keys/:kid", authenticated, noImpersonation, selfOrWrite, handler.RevokeAPIKey)
+
+	router.GET(model.OIDCDiscoveryPath, handler.OIDCDiscovery)
+	router.GET(model.OIDCJWKSPath, handler.JWKS)
+	router.GET(model.OIDCAuthorizePath, authenticated, noImpersonation, handler.Authorize)
+	router.POST(model.OIDCAuthorizePath, authenticated, noImpersonation, handler.Authorize)
+	router.POST(model.OIDCTokenPath, handler.Token)
+	router.GET(model.OIDCUserInfoPath, handler.UserInfo)
+	router.POST(model.OIDCUserInfoPath, handler.UserInfo)
+	router.POST("/oauth/clients", authenticated, noImpersonation, operator, admin, handler.RegisterOAuthClient)
+
+	router.GET("/user/:id/oauth/consents", authenticated, self, handler.ListOAuthConsents)
+	router.DELETE("/user/:id/oauth/consents/:client_id", authenticated, self, handler.RevokeOAuthConsent)
+
+	router.GET("/auth/external", handler.ListExternalProviders)
+	router.GET("/auth/external/:provider/login", handler.StartExternalLogin)
+	router.GET("/auth/external/:provider/callback", handler.ExternalCallback)
+	router.GET("/user/:id/identities", authenticated, selfOrRead, handler.ListIdentities)
+	router.POST("/user/:id/identities/:provider", authenticated, noImpersonation, self, handler.LinkIdentity)
+	router.DELETE("/user/:id/identities/:provider", authenticated, noImpersonation, self, handler.UnlinkIdentity)
+
+	router.GET("/user/:id/permissions", authenticated, selfOrRead, handler.GetUserPermissions)
+
+	router.POST("/user/:id/impersonate", authenticated, noImpersonation, impersonate, handler.StartImpersonation)
+	router.POST("/impersonation/end", authenticated, handler.EndImpersonation)
+	router.GET("/user/:id/impersonations", authenticated, selfOrRead, handler.ListUserImpersonations)
+	router.GET("/impersonations", authenticated, operator,
+		middleware.RequirePermission(permissions, model.PermissionUsersRead), handler.ListImpersonations)
+
+	router.GET("/admin/audit", authenticated, operator,
+		middleware.RequirePermission(permissions, model.PermissionAuditRead), handler.ListAuditEvents)
+
+	// Справочник атрибутов нужен и при регистрации, а меняет его только администратор
+	router.GET("/attributes", handler.ListAttributeDefinitions)
+	attributes := router.Group("/admin/attributes", authenticated, noImpersonation, operator, admin)
+	attributes.GET("", handler.ListAttributeDefinitions)
+	attributes.POST("", handler.CreateAttributeDefinition)
+	attributes.GET("/:name", handler.GetAttributeDefinition)
+	attributes.PUT("/:name", handler.UpdateAttributeDefinition)
+	attributes.DELETE("/:name", handler.DeleteAttributeDefinition)
+
+	// Управление ролями меняет права всех пользователей, поэтому доступно только администратору
+	rbac := router.Group("/rbac", authenticated, noImpersonation, operator, admin)
+	rbac.GET("/permissions", handler.ListPermissions)
+	rbac.GET("/roles", handler.ListRoles)
+	rbac.POST("/roles", handler.CreateRole)
+	rbac.GET("/roles/:role", handler.GetRole)
+	rbac.PUT("/roles/:role", handler.UpdateRole)
+	rbac.DELETE("/roles/:role", handler.DeleteRole)
+	rbac.PUT("/users/:id/roles/:role", handler.AssignUserRole)
+	rbac.DELETE("/users/:id/roles/:role", handler.RevokeUserRole)
+	rbac.GET("/groups", handler.ListGroups)
+	rbac.POST("/groups", handler.CreateGroup)
+	rbac.GET("/groups/:gid", handler.GetGroup)
+	rbac.DELETE("/groups/:gid", handler.DeleteGroup)
+	rbac.GET("/groups/:gid/members", handler.ListGroupMembers)
+	rbac.PUT("/groups/:gid/members/:uid", handler.AddGroupMember)
+	rbac.DELETE("/groups/:gid/members/:uid", handler.RemoveGroupMember)
+	rbac.PUT("/groups/:gid/roles/:role", handler.AssignGroupRole)
+	rbac.DELETE("/groups/:gid/roles/:role", handler.RevokeGroupRole)
+
+	organizationsAdmin := router.Group("/admin/organizations", authenticated, noImpersonation, operator, admin)
+	organizationsAdmin.GET("", handler.ListOrganizations)
+	organizationsAdmin.POST("", handler.CreateOrganization)
+	organizationsAdmin.POST("/:id/owner-invitation", handler.InviteOwner)
+
+	// Роли участников берутся из БД, см. middleware.RequireMemberRole
+	organization := router.Group("/organization", authenticated, noImpersonation)
+	owner := middleware.RequireMemberRole(members, model.MemberRoleOwner)
+	managers := middleware.RequireMemberRole(members, model.MemberRoleOwner, model.MemberRoleAdmin)
+	organization.GET("/members", handler.ListMembers)
+	organization.PUT("/members/:id/role", managers, handler.UpdateMemberRole)
+	organization.POST("/transfer-ownership", owner, handler.TransferOwnership)
+	organization.GET("/invitations", managers, handler.ListInvitations)
+	organization.POST("/invitations", managers, handler.CreateInvitation)
+	organization.DELETE("/invitations/:iid", managers, handler.RevokeInvitation)
 
 	return router
 }
@@ -2,6 +2,90 @@ package apperr
 
 import (
 	"errors"
+	"strings"
+	"time"
 )
 
-var ErrNotFound = errors.New("not found")
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrWrongPassword    = errors.New("wrong password")
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrTooManyAttempts  = errors.New("too many attempts")
+	ErrAccountLocked    = errors.New("account locked")
+	ErrAlreadyExists    = errors.New("already exists")
+	ErrEmailNotSet      = errors.New("email is not set")
+	ErrEmailVerified    = errors.New("email already verified")
+	ErrEmailNotVerified = errors.New("email not verified")
+	ErrMFAEnabled       = errors.New("mfa already enabled")
+	ErrMFANotEnrolled   = errors.New("mfa not enrolled")
+	ErrInvalidMFACode   = errors.New("invalid mfa code")
+	ErrValidation       = errors.New("validation failed")
+	ErrExternalAuth     = errors.New("external authentication failed")
+	ErrInvalidPasskey   = errors.New("invalid passkey")
+	ErrForbidden        = errors.New("forbidden")
+	ErrNotImpersonating = errors.New("not impersonating")
+	ErrVersionConflict  = errors.New("version conflict")
+	ErrTooLarge         = errors.New("payload too large")
+	ErrNoTenant         = errors.New("organization is not set")
+	// Пользователь состоит ровно в одной организации, почта занята в другой
+	ErrOtherOrganization = errors.New("email belongs to another organization")
+)
+
+// RetryError сообщает, через сколько можно повторить запрос.
+type RetryError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// FieldError описывает нарушение в конкретном поле запроса.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError собирает все нарушения, чтобы клиент показал их разом.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+": "+field.Message)
+	}
+	return ErrValidation.Error() + ": " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// Коды ошибок OAuth 2.0 (RFC 6749), клиенты OIDC разбирают их по полю error
+const (
+	OAuthInvalidRequest          = "invalid_request"
+	OAuthInvalidClient           = "invalid_client"
+	OAuthInvalidGrant            = "invalid_grant"
+	OAuthInvalidScope            = "invalid_scope"
+	OAuthAccessDenied            = "access_denied"
+	OAuthUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthUnsupportedResponseType = "unsupported_response_type"
+)
+
+// OAuthError - ошибка протокола OAuth, которую нужно вернуть клиенту как есть.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
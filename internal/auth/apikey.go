@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Ключ выглядит как lk_<prefix>_<secret>. Префикс хранится открыто и служит
+	// для поиска ключа и меток в метриках, секрет хранится только хэшем
+	apiKeyMarker     = "lk_"
+	apiKeyPrefixSize = 6
+)
+
+// NewAPIKey возвращает ключ для клиента, его префикс и хэш секрета для БД.
+func NewAPIKey() (string, string, string, error) {
+	buf := make([]byte, apiKeyPrefixSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", errors.Wrap(err, "generate api key prefix")
+	}
+	prefix := hex.EncodeToString(buf)
+
+	secret, hash, err := NewOpaqueToken()
+	if err != nil {
+		return "", "", "", errors.Wrap(err, "generate api key secret")
+	}
+
+	return apiKeyMarker + prefix + "_" + secret, prefix, hash, nil
+}
+
+// ParseAPIKey разбирает ключ на префикс и хэш секрета.
+func ParseAPIKey(key string) (string, string, error) {
+	rest, ok := strings.CutPrefix(key, apiKeyMarker)
+	if !ok {
+		return "", "", errors.Wrap(apperr.ErrInvalidToken, "not an api key")
+	}
+
+	// Префикс в hex, поэтому первое подчёркивание отделяет секрет,
+	// который сам может содержать подчёркивания
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != apiKeyPrefixSize*2 || secret == "" {
+		return "", "", errors.Wrap(apperr.ErrInvalidToken, "malformed api key")
+	}
+
+	return prefix, HashOpaqueToken(secret), nil
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+)
+
+// Key - ключ подписи токенов. У ключей, выведенных из ротации,
+// может не быть приватной части: ими только проверяют старые токены.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	private crypto.PrivateKey
+	public  crypto.PublicKey
+}
+
+func NewKey(kid string, key any) (*Key, error) {
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, private: k, public: k.Public()}, nil
+	case *rsa.PrivateKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, private: k, public: &k.PublicKey}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodEdDSA, public: k}, nil
+	case *rsa.PublicKey:
+		return &Key{ID: kid, Method: jwt.SigningMethodRS256, public: k}, nil
+	default:
+		return nil, errors.Errorf("unsupported key type %T", key)
+	}
+}
+
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+func (k *Key) Public() crypto.PublicKey {
+	return k.public
+}
+
+func ParseKey(kid string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.Errorf("key %q: no PEM data found", kid)
+	}
+
+	var (
+		key any
+		err error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, errors.Errorf("key %q: unsupported PEM block %q", kid, block.Type)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "key %q: parse PEM", kid)
+	}
+
+	return NewKey(kid, key)
+}
+
+type KeySet struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+func NewKeySet(activeKID string, keys ...*Key) (*KeySet, error) {
+	set := &KeySet{
+		keys: make(map[string]*Key, len(keys)),
+	}
+	for _, key := range keys {
+		if _, ok := set.keys[key.ID]; ok {
+			return nil, errors.Errorf("duplicate key id %q", key.ID)
+		}
+		set.keys[key.ID] = key
+	}
+
+	active, ok := set.keys[activeKID]
+	if !ok {
+		return nil, errors.Errorf("active key %q not found", activeKID)
+	}
+	if !active.CanSign() {
+		return nil, errors.Errorf("active key %q has no private part", activeKID)
+	}
+	set.active = active
+
+	return set, nil
+}
+
+// LoadKeySet читает ключи из файлов вида kid -> путь к PEM.
+func LoadKeySet(files map[string]string, activeKID string) (*KeySet, error) {
+	keys := make([]*Key, 0, len(files))
+	for kid, path := range files {
+		data, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, errors.Wrapf(err, "read key %q", kid)
+		}
+
+		key, err := ParseKey(kid, data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return NewKeySet(activeKID, keys...)
+}
+
+func (s *KeySet) Active() *Key {
+	return s.active
+}
+
+func (s *KeySet) Lookup(kid string) (*Key, bool) {
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func (s *KeySet) Keys() []*Key {
+	keys := make([]*Key, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID < keys[j].ID
+	})
+	return keys
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySet_JWKS(t *testing.T) {
+	ed := newEd25519Key(t, "ed")
+	rsaKey := newRSAKey(t, "rsa")
+
+	for _, active := range []*Key{ed, rsaKey} {
+		t.Run(active.Method.Alg(), func(t *testing.T) {
+			keys, err := NewKeySet(active.ID, ed, rsaKey)
+			require.NoError(t, err)
+			issuer := NewIssuer(keys, "https://lk.example.com", time.Minute)
+
+			claims := &IDClaims{Nonce: "n-0S6_WzA2Mj"}
+			claims.Subject = uuid.NewString()
+			claims.Audience = jwt.ClaimStrings{"client"}
+			token, err := issuer.IssueIDToken(claims)
+			require.NoError(t, err)
+
+			set := keys.JWKS()
+			require.Len(t, set.Keys, 2)
+
+			// Клиент проверяет подпись только по опубликованным ключам
+			var parsed IDClaims
+			_, err = jwt.ParseWithClaims(token, &parsed, func(token *jwt.Token) (any, error) {
+				for _, jwk := range set.Keys {
+					if jwk.Kid == token.Header["kid"] {
+						return jwk.PublicKey()
+					}
+				}
+				return nil, assert.AnError
+			}, jwt.WithAudience("client"), jwt.WithIssuer("https://lk.example.com"))
+			require.NoError(t, err)
+			assert.Equal(t, claims.Subject, parsed.Subject)
+			assert.Equal(t, "n-0S6_WzA2Mj", parsed.Nonce)
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"slices"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	opaqueTokenSize = 32
+	// PurposeMFA помечает промежуточный токен между вводом пароля и второго фактора.
+	// Доступа к API он не даёт.
+	PurposeMFA = "mfa"
+	// PurposeOIDC - access-токен, выданный стороннему приложению. Годится только для /userinfo.
+	PurposeOIDC = "oidc"
+)
+
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles   []string `json:"roles,omitempty"`
+	SID     string   `json:"sid,omitempty"`
+	Purpose string   `json:"purpose,omitempty"`
+	// Права OAuth-клиента через пробел, только у токенов PurposeOIDC
+	OAuthScope string `json:"scope,omitempty"`
+	// Сотрудник, действующий от имени sub (RFC 8693). Есть только у токенов имперсонации
+	Act *Actor `json:"act,omitempty"`
+	// Организация пользователя. Токены, выданные до её появления, относятся к организации по умолчанию
+	Org string `json:"org,omitempty"`
+	// Заполняются только при входе по API-ключу и в JWT не попадают
+	APIKeyPrefix string   `json:"-"`
+	Scopes       []string `json:"-"`
+}
+
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+func NewClaims(userID uuid.UUID) *Claims {
+	return &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: userID.String(),
+		},
+	}
+}
+
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+// HasScope ограничивает только API-ключи: пользовательский токен даёт все права владельца.
+func (c *Claims) HasScope(scope string) bool {
+	return c.APIKeyPrefix == "" || slices.Contains(c.Scopes, scope)
+}
+
+func (c *Claims) Impersonated() bool {
+	return c.Act != nil
+}
+
+// ActorID возвращает сотрудника, который действует от имени пользователя.
+func (c *Claims) ActorID() (uuid.UUID, error) {
+	if c.Act == nil {
+		return uuid.Nil, errors.Wrap(apperr.ErrNotImpersonating, "token has no actor")
+	}
+	id, err := uuid.Parse(c.Act.Subject)
+	if err != nil {
+		return uuid.Nil, errors.Wrap(apperr.ErrInvalidToken, "act is not a user id")
+	}
+	return id, nil
+}
+
+func (c *Claims) SessionID() (uuid.UUID, error) {
+	id, err := uuid.Parse(c.SID)
+	if err != nil {
+		return uuid.Nil, errors.Wrap(apperr.ErrInvalidToken, "sid is not a session id")
+	}
+	return id, nil
+}
+
+func (c *Claims) OrganizationID() (uuid.UUID, error) {
+	if c.Org == "" {
+		return tenant.DefaultID, nil
+	}
+	id, err := uuid.Parse(c.Org)
+	if err != nil {
+		return uuid.Nil, errors.Wrap(apperr.ErrInvalidToken, "org is not an organization id")
+	}
+	return id, nil
+}
+
+func (c *Claims) UserID() (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Subject)
+	if err != nil {
+		return uuid.Nil, errors.Wrap(apperr.ErrInvalidToken, "subject is not a user id")
+	}
+	return id, nil
+}
+
+type Issuer struct {
+	keys   *KeySet
+	issuer string
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func NewIssuer(keys *KeySet, issuer string, ttl time.Duration) *Issuer {
+	return &Issuer{
+		keys:   keys,
+		issuer: issuer,
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// WithClock возвращает копию Issuer с другими часами (для тестов).
+func (i *Issuer) WithClock(now func() time.Time) *Issuer {
+	clone := *i
+	clone.now = now
+	return &clone
+}
+
+func (i *Issuer) TTL() time.Duration {
+	return i.ttl
+}
+
+func (i *Issuer) Name() string {
+	return i.issuer
+}
+
+func (i *Issuer) Keys() *KeySet {
+	return i.keys
+}
+
+// Issue подписывает claims активным ключом. Заполняет iss, iat, jti
+// и exp, если срок жизни не задан вызывающей стороной.
+func (i *Issuer) Issue(claims *Claims) (string, error) {
+	i.stamp(&claims.RegisteredClaims)
+
+	signed, err := i.sign(claims)
+	if err != nil {
+		return "", errors.Wrap(err, "Issuer Issue")
+	}
+	return signed, nil
+}
+
+// IssueIDToken подписывает ID-токен OpenID Connect тем же ключом, что и access-токены.
+func (i *Issuer) IssueIDToken(claims *IDClaims) (string, error) {
+	i.stamp(&claims.RegisteredClaims)
+
+	signed, err := i.sign(claims)
+	if err != nil {
+		return "", errors.Wrap(err, "Issuer IssueIDToken")
+	}
+	return signed, nil
+}
+
+func (i *Issuer) stamp(claims *jwt.RegisteredClaims) {
+	now := i.now()
+
+	claims.Issuer = i.issuer
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	if claims.ExpiresAt == nil {
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(i.ttl))
+	}
+	if claims.ID == "" {
+		claims.ID = uuid.NewString()
+	}
+}
+
+func (i *Issuer) sign(claims jwt.Claims) (string, error) {
+	key := i.keys.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	return token.SignedString(key.private)
+}
+
+func (i *Issuer) Parse(token string) (*Claims, error) {
+	var claims Claims
+
+	_, err := jwt.ParseWithClaims(token, &claims, i.keyFunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(i.issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(i.now),
+	)
+	if err != nil {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, err.Error())
+	}
+
+	return &claims, nil
+}
+
+func (i *Issuer) keyFunc(token *jwt.Token) (any, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		return nil, errors.New("kid header is missing")
+	}
+
+	key, ok := i.keys.Lookup(kid)
+	if !ok {
+		return nil, errors.Errorf("unknown kid %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.Errorf("alg %q does not match key %q", token.Method.Alg(), kid)
+	}
+
+	return key.public, nil
+}
+
+// NewOpaqueToken генерирует случайный токен для клиента и его хэш для хранения в БД.
+func NewOpaqueToken() (string, string, error) {
+	buf := make([]byte, opaqueTokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", errors.Wrap(err, "generate opaque token")
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashOpaqueToken(token), nil
+}
+
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEd25519Key(t *testing.T, kid string) *Key {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := NewKey(kid, private)
+	require.NoError(t, err)
+	return key
+}
+
+func newRSAKey(t *testing.T, kid string) *Key {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key, err := NewKey(kid, private)
+	require.NoError(t, err)
+	return key
+}
+
+func TestIssuer_IssueAndParse(t *testing.T) {
+	for _, key := range []*Key{newEd25519Key(t, "ed"), newRSAKey(t, "rsa")} {
+		t.Run(key.Method.Alg(), func(t *testing.T) {
+			keys, err := NewKeySet(key.ID, key)
+			require.NoError(t, err)
+			issuer := NewIssuer(keys, "lk-api", time.Minute)
+
+			userID := uuid.New()
+			token, err := issuer.Issue(NewClaims(userID))
+			require.NoError(t, err)
+
+			parsed, err := jwt.NewParser().ParseWithClaims(token, &Claims{}, func(*jwt.Token) (any, error) {
+				return key.Public(), nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, key.ID, parsed.Header["kid"])
+
+			claims, err := issuer.Parse(token)
+			require.NoError(t, err)
+			id, err := claims.UserID()
+			require.NoError(t, err)
+			assert.Equal(t, userID, id)
+		})
+	}
+}
+
+func TestIssuer_KeyRotation(t *testing.T) {
+	oldKey := newEd25519Key(t, "old")
+	newKey := newRSAKey(t, "new")
+
+	oldKeys, err := NewKeySet("old", oldKey)
+	require.NoError(t, err)
+	oldToken, err := NewIssuer(oldKeys, "lk-api", time.Minute).Issue(NewClaims(uuid.New()))
+	require.NoError(t, err)
+
+	// Новый активный ключ, старый оставлен только для проверки
+	retired, err := NewKey("old", oldKey.Public())
+	require.NoError(t, err)
+	keys, err := NewKeySet("new", newKey, retired)
+	require.NoError(t, err)
+	issuer := NewIssuer(keys, "lk-api", time.Minute)
+
+	_, err = issuer.Parse(oldToken)
+	require.NoError(t, err)
+
+	token, err := issuer.Issue(NewClaims(uuid.New()))
+	require.NoError(t, err)
+	_, err = issuer.Parse(token)
+	require.NoError(t, err)
+
+	// Без старого ключа его токены больше не принимаются
+	withoutOld, err := NewKeySet("new", newKey)
+	require.NoError(t, err)
+	_, err = NewIssuer(withoutOld, "lk-api", time.Minute).Parse(oldToken)
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+}
+
+func TestIssuer_RejectsInvalidTokens(t *testing.T) {
+	key := newEd25519Key(t, "ed")
+	keys, err := NewKeySet("ed", key)
+	require.NoError(t, err)
+	issuer := NewIssuer(keys, "lk-api", time.Minute)
+
+	// Кейс 1: истёкший токен
+	expired := NewClaims(uuid.New())
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	token, err := issuer.Issue(expired)
+	require.NoError(t, err)
+	_, err = issuer.Parse(token)
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+
+	// Кейс 2: чужой издатель
+	other := NewIssuer(keys, "other", time.Minute)
+	token, err = other.Issue(NewClaims(uuid.New()))
+	require.NoError(t, err)
+	_, err = issuer.Parse(token)
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+
+	// Кейс 3: подпись другим ключом с тем же kid
+	forged, err := NewKeySet("ed", newEd25519Key(t, "ed"))
+	require.NoError(t, err)
+	token, err = NewIssuer(forged, "lk-api", time.Minute).Issue(NewClaims(uuid.New()))
+	require.NoError(t, err)
+	_, err = issuer.Parse(token)
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edDER, err := x509.MarshalPKCS8PrivateKey(edPrivate)
+	require.NoError(t, err)
+	edPath := filepath.Join(dir, "ed.pem")
+	require.NoError(t, os.WriteFile(edPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: edDER}), 0o600))
+
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaDER, err := x509.MarshalPKIXPublicKey(&rsaPrivate.PublicKey)
+	require.NoError(t, err)
+	rsaPath := filepath.Join(dir, "rsa.pem")
+	require.NoError(t, os.WriteFile(rsaPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rsaDER}), 0o600))
+
+	keys, err := LoadKeySet(map[string]string{"ed": edPath, "rsa": rsaPath}, "ed")
+	require.NoError(t, err)
+	assert.Equal(t, "ed", keys.Active().ID)
+	assert.Len(t, keys.Keys(), 2)
+
+	// Ключ без приватной части не может быть активным
+	_, err = LoadKeySet(map[string]string{"rsa": rsaPath}, "rsa")
+	require.Error(t, err)
+}
+
+func TestOpaqueToken(t *testing.T) {
+	token, hash, err := NewOpaqueToken()
+	require.NoError(t, err)
+	assert.Equal(t, hash, HashOpaqueToken(token))
+	assert.NotEqual(t, token, hash)
+}
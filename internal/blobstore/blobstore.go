@@ -0,0 +1,32 @@
+// Package blobstore хранит неизменяемые файлы (аватары) по ключу и отдаёт их публичные адреса.
+package blobstore
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidKey = errors.New("invalid blob key")
+
+type BlobStore interface {
+	Put(ctx context.Context, key string, contentType string, data []byte) error
+	// Delete не считает ошибкой отсутствие файла
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// validateKey допускает только относительные пути без "." и "..", чтобы ключ
+// нельзя было вывести за пределы каталога или бакета.
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return errors.Wrap(ErrInvalidKey, key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." || strings.ContainsAny(part, "\\\x00") {
+			return errors.Wrap(ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
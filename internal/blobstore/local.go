@@ -0,0 +1,79 @@
+package blobstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Local хранит файлы в каталоге на диске; отдавать их должен сам сервис или прокси.
+type Local struct {
+	dir     string
+	baseURL string
+}
+
+func NewLocal(dir string, baseURL string) *Local {
+	return &Local{
+		dir:     filepath.Clean(dir),
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Put пишет во временный файл и переименовывает его, чтобы читатели
+// не увидели недописанное изображение.
+func (l *Local) Put(_ context.Context, key string, _ string, data []byte) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	path := filepath.Join(l.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.Wrap(err, "local blob mkdir")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return errors.Wrap(err, "local blob create")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "local blob write")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "local blob close")
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return errors.Wrap(err, "local blob chmod")
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.Wrap(err, "local blob rename")
+	}
+	return nil
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	path := filepath.Join(l.dir, filepath.FromSlash(key))
+	err := os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrap(err, "local blob delete")
+	}
+
+	// Убираем опустевшие каталоги ключа; непустой каталог os.Remove не удалит
+	for dir := filepath.Dir(path); dir != l.dir; dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func (l *Local) URL(key string) string {
+	return l.baseURL + "/" + key
+}
@@ -0,0 +1,195 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	s3Timeout = 30 * time.Second
+	// Ключи содержат идентификатор аватара, поэтому файл по ключу никогда не меняется
+	s3CacheControl = "public, max-age=31536000, immutable"
+
+	amzDateFormat = "20060102T150405Z"
+	signAlgorithm = "AWS4-HMAC-SHA256"
+)
+
+// S3 работает с S3-совместимым хранилищем (AWS, MinIO и т.п.) по адресам вида
+// <endpoint>/<bucket>/<key> и подписывает запросы AWS Signature V4.
+// Бакет должен разрешать публичное чтение либо стоять за CDN из S3_PUBLIC_URL.
+type S3 struct {
+	endpoint  string
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	publicURL string
+	client    *http.Client
+	now       func() time.Time
+}
+
+func NewS3(endpoint string, region string, bucket string, accessKey string, secretKey string,
+	publicURL string) *S3 {
+	return &S3{
+		endpoint:  strings.TrimSuffix(endpoint, "/"),
+		region:    region,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+		client:    &http.Client{Timeout: s3Timeout},
+		now:       time.Now,
+	}
+}
+
+func (s *S3) Put(ctx context.Context, key string, contentType string, data []byte) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrap(err, "s3 put request")
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Cache-Control", s3CacheControl)
+
+	return s.do(req, data, http.StatusOK)
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key), nil)
+	if err != nil {
+		return errors.Wrap(err, "s3 delete request")
+	}
+
+	// S3 отвечает 204 и на удаление несуществующего объекта, MinIO бывает 404
+	return s.do(req, nil, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+}
+
+func (s *S3) URL(key string) string {
+	if s.publicURL != "" {
+		return s.publicURL + "/" + uriEncode(key, false)
+	}
+	return s.objectURL(key)
+}
+
+func (s *S3) objectURL(key string) string {
+	return s.endpoint + "/" + uriEncode(s.bucket, true) + "/" + uriEncode(key, false)
+}
+
+func (s *S3) do(req *http.Request, payload []byte, expected ...int) error {
+	sum := sha256.Sum256(payload)
+	s.sign(req, hex.EncodeToString(sum[:]), s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "s3 request")
+	}
+	defer resp.Body.Close()
+
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return errors.Errorf("s3 %s: unexpected status %d: %s", req.Method, resp.StatusCode, body)
+}
+
+// sign добавляет заголовки x-amz-* и Authorization. Подписываются host и все
+// заголовки, уже установленные в запросе.
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format(amzDateFormat)
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := signAlgorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgorithm, s.accessKey, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(query url.Values) string {
+	pairs := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode кодирует всё, кроме незарезервированных символов RFC 3986, как требует
+// SigV4; url.PathEscape для этого не подходит - он оставляет, например, "+" и "=".
+func uriEncode(value string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -6,9 +6,11 @@ import (
 	"time"
 	"unsafe"
 
+	"github.com/lemavisaitov/lk-api/internal/apperr"
 	"github.com/lemavisaitov/lk-api/internal/logger"
 	"github.com/lemavisaitov/lk-api/internal/model"
 	"github.com/lemavisaitov/lk-api/internal/repository"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
@@ -24,11 +26,17 @@ type userDTOWithTTL struct {
 	lastUsedAt time.Time
 }
 
+// loginKey - логин уникален только внутри организации
+type loginKey struct {
+	organizationID uuid.UUID
+	login          string
+}
+
 type CacheDecorator struct {
 	userRepo  repository.UserProvider
 	mu        sync.RWMutex
 	user      map[uuid.UUID]*userDTOWithTTL
-	userLogin map[string]uuid.UUID
+	userLogin map[loginKey]uuid.UUID
 	done      chan struct{}
 }
 
@@ -42,7 +50,7 @@ func NewDecorator(userRepo repository.UserProvider,
 	cache := &CacheDecorator{
 		userRepo:  userRepo,
 		user:      make(map[uuid.UUID]*userDTOWithTTL, cacheInitCapacity),
-		userLogin: make(map[string]uuid.UUID, cacheInitCapacity),
+		userLogin: make(map[loginKey]uuid.UUID, cacheInitCapacity),
 		done:      make(chan struct{}),
 	}
 
@@ -75,7 +83,7 @@ func (c *CacheDecorator) cleanExpired(ttl time.Duration) {
 				zap.String("userID", key.String()),
 				zap.String("user login", val.user.Login),
 			)
-			delete(c.userLogin, val.user.Login)
+			delete(c.userLogin, userLoginKey(val.user))
 			delete(c.user, key)
 		}
 	}
@@ -88,32 +96,60 @@ func (c *CacheDecorator) getUser(id uuid.UUID) (*userDTOWithTTL, bool) {
 	return val, ok
 }
 
-func (c *CacheDecorator) getUserIDByLogin(login string) (uuid.UUID, bool) {
+func (c *CacheDecorator) getUserIDByLogin(key loginKey) (uuid.UUID, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	val, ok := c.userLogin[login]
+	val, ok := c.userLogin[key]
 	return val, ok
 }
 
+func userLoginKey(user *model.User) loginKey {
+	return loginKey{organizationID: user.OrganizationID, login: user.Login}
+}
+
+// contextOrganizationID возвращает организацию запроса; без неё - uuid.Nil,
+// который не совпадёт ни с одной записью из БД.
+func contextOrganizationID(ctx context.Context) uuid.UUID {
+	organizationID, _ := tenant.FromContext(ctx)
+	return organizationID
+}
+
+// setUser не заменяет запись более старой версией: чтение, начатое до
+// изменения, может завершиться позже него.
 func (c *CacheDecorator) setUser(id uuid.UUID, user *model.User) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if cached, ok := c.user[id]; ok && cached.user.Version > user.Version {
+		return
+	}
 	c.user[id] = &userDTOWithTTL{
 		user:       user,
 		lastUsedAt: time.Now(),
 	}
-	c.userLogin[user.Login] = id
+	c.userLogin[userLoginKey(user)] = id
 }
 
 func (c *CacheDecorator) deleteUser(id uuid.UUID) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	delete(c.userLogin, c.user[id].user.Login)
+	cached, ok := c.user[id]
+	if !ok {
+		return
+	}
+	delete(c.userLogin, userLoginKey(cached.user))
 	delete(c.user, id)
 }
 
+// InvalidateUser убирает пользователя из кэша. Нужен при изменениях, которые идут
+// мимо декоратора, например при назначении ролей.
+func (c *CacheDecorator) InvalidateUser(id uuid.UUID) {
+	c.deleteUser(id)
+}
+
+// GetUser отдаёт из кэша только пользователя организации запроса, иначе идёт в БД,
+// которая ограничит выборку сама.
 func (c *CacheDecorator) GetUser(ctx context.Context, userID uuid.UUID) (*model.User, error) {
-	if user, ok := c.getUser(userID); ok {
+	if user, ok := c.getUser(userID); ok && user.user.OrganizationID == contextOrganizationID(ctx) {
 		user.lastUsedAt = time.Now()
 		return user.user, nil
 	}
@@ -128,7 +164,7 @@ func (c *CacheDecorator) GetUser(ctx context.Context, userID uuid.UUID) (*model.
 }
 
 func (c *CacheDecorator) GetUserIDByLogin(ctx context.Context, login string) (*uuid.UUID, error) {
-	if id, ok := c.getUserIDByLogin(login); ok {
+	if id, ok := c.getUserIDByLogin(loginKey{organizationID: contextOrganizationID(ctx), login: login}); ok {
 		return &id, nil
 	}
 
@@ -143,6 +179,10 @@ func (c *CacheDecorator) GetUserIDByLogin(ctx context.Context, login string) (*u
 func (c *CacheDecorator) UpdateUser(ctx context.Context, req model.UpdateUserRequest) (*uuid.UUID, error) {
 	id, err := c.userRepo.UpdateUser(ctx, req)
 	if err != nil {
+		// Конфликт версий значит, что в кэше может лежать устаревшая запись
+		if errors.Is(err, apperr.ErrVersionConflict) {
+			c.deleteUser(req.ID)
+		}
 		return nil, errors.Wrap(err, "from UpdateUser in CacheDecorator")
 	}
 	if id == nil {
@@ -158,19 +198,107 @@ func (c *CacheDecorator) UpdateUser(ctx context.Context, req model.UpdateUserReq
 	return id, nil
 }
 
-func (c *CacheDecorator) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	if _, ok := c.getUser(id); ok {
-		c.deleteUser(id)
+func (c *CacheDecorator) EmailInOtherOrganization(ctx context.Context, email string) (bool, error) {
+	exists, err := c.userRepo.EmailInOtherOrganization(ctx, email)
+	if err != nil {
+		return false, errors.Wrap(err, "from EmailInOtherOrganization in CacheDecorator")
+	}
+
+	return exists, nil
+}
+
+func (c *CacheDecorator) GetUserIDByEmail(ctx context.Context, email string) (*uuid.UUID, error) {
+	id, err := c.userRepo.GetUserIDByEmail(ctx, email)
+	if err != nil {
+		return nil, errors.Wrap(err, "from GetUserIDByEmail in CacheDecorator")
+	}
+
+	return id, nil
+}
+
+func (c *CacheDecorator) MarkEmailVerified(ctx context.Context, id uuid.UUID) error {
+	if err := c.userRepo.MarkEmailVerified(ctx, id); err != nil {
+		return errors.Wrap(err, "from MarkEmailVerified in CacheDecorator")
+	}
+
+	user, err := c.userRepo.GetUser(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, "from GetUser in CacheDecorator")
 	}
-	err := c.userRepo.DeleteUser(ctx, id)
+
+	c.setUser(id, user)
+	return nil
+}
+
+func (c *CacheDecorator) DeleteUser(ctx context.Context, id uuid.UUID, version int64) error {
+	err := c.userRepo.DeleteUser(ctx, id, version)
+	// Запись убирается после удаления в БД, иначе параллельный GetUser вернёт её в кэш.
+	// При конфликте версий она тоже убирается как устаревшая
+	c.deleteUser(id)
 	return errors.Wrap(err, "from DeleteUser in CacheDecorator")
 }
 
+func (c *CacheDecorator) RestoreUser(ctx context.Context, id uuid.UUID, deletedAfter time.Time) error {
+	err := c.userRepo.RestoreUser(ctx, id, deletedAfter)
+	return errors.Wrap(err, "from RestoreUser in CacheDecorator")
+}
+
+func (c *CacheDecorator) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time,
+	limit int) ([]model.PurgedUser, error) {
+	users, err := c.userRepo.PurgeDeletedUsers(ctx, deletedBefore, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "from PurgeDeletedUsers in CacheDecorator")
+	}
+
+	return users, nil
+}
+
+// SetUserAvatar убирает запись из кэша: у пользователя сменились аватар и версия.
+func (c *CacheDecorator) SetUserAvatar(ctx context.Context, id uuid.UUID, avatarID *uuid.UUID) (*uuid.UUID, error) {
+	previous, err := c.userRepo.SetUserAvatar(ctx, id, avatarID)
+	c.deleteUser(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "from SetUserAvatar in CacheDecorator")
+	}
+
+	return previous, nil
+}
+
+func (c *CacheDecorator) GetUserOrganizationID(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	organizationID, err := c.userRepo.GetUserOrganizationID(ctx, id)
+	return organizationID, errors.Wrap(err, "from GetUserOrganizationID in CacheDecorator")
+}
+
+func (c *CacheDecorator) LoginExists(ctx context.Context, login string) (bool, error) {
+	exists, err := c.userRepo.LoginExists(ctx, login)
+	return exists, errors.Wrap(err, "from LoginExists in CacheDecorator")
+}
+
 func (c *CacheDecorator) AddUser(ctx context.Context, user model.User) error {
 	err := c.userRepo.AddUser(ctx, user)
 	return errors.Wrap(err, "from AddUser in CacheDecorator")
 }
 
+// ListUsers не кэшируется: страницы зависят от фильтров и быстро устаревают.
+func (c *CacheDecorator) ListUsers(ctx context.Context, filter model.UserFilter, sort model.UserSort,
+	after *model.UserPosition, limit int) ([]model.User, error) {
+	users, err := c.userRepo.ListUsers(ctx, filter, sort, after, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "from ListUsers in CacheDecorator")
+	}
+
+	return users, nil
+}
+
+func (c *CacheDecorator) SearchUsers(ctx context.Context, text string, limit int) ([]model.UserSearchResult, error) {
+	results, err := c.userRepo.SearchUsers(ctx, text, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "from SearchUsers in CacheDecorator")
+	}
+
+	return results, nil
+}
+
 func (c *CacheDecorator) Close() {
 	close(c.done)
 }
@@ -181,7 +309,7 @@ func (c *CacheDecorator) copy() *CacheDecorator {
 
 	replica := &CacheDecorator{
 		user:      make(map[uuid.UUID]*userDTOWithTTL, len(c.user)),
-		userLogin: make(map[string]uuid.UUID, len(c.userLogin)),
+		userLogin: make(map[loginKey]uuid.UUID, len(c.userLogin)),
 		done:      make(chan struct{}),
 	}
 
@@ -220,13 +348,17 @@ func (c *CacheDecorator) MemoryUsage() uint64 {
 			size += uint64(len(v.user.Login))
 			size += uint64(len(v.user.Password))
 			size += uint64(len(v.user.Name))
+			size += uint64(len(v.user.Email))
+			for _, role := range v.user.Roles {
+				size += uint64(len(role))
+			}
 		}
 	}
 
-	// Размер карты userLogin (ключи строки + UUID)
+	// Размер карты userLogin (организация и логин + UUID)
 	size += uint64(unsafe.Sizeof(replica.userLogin))
 	for k, v := range replica.userLogin {
-		size += uint64(len(k)) + uint64(unsafe.Sizeof(v)) // Строка (длина) + UUID
+		size += uint64(unsafe.Sizeof(k)) + uint64(len(k.login)) + uint64(unsafe.Sizeof(v))
 	}
 
 	return size
@@ -5,7 +5,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/lemavisaitov/lk-api/internal/apperr"
 	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
 	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
 
 	"github.com/golang/mock/gomock"
@@ -102,15 +104,16 @@ func TestUpdateUser(t *testing.T) {
 
 	// Тестовые данные
 	userID := uuid.New()
+	name, password := "Updated Name", "newpassword"
 	req := model.UpdateUserRequest{
 		ID:       userID,
-		Name:     "Updated Name",
-		Password: "newpassword",
+		Name:     &name,
+		Password: &password,
 	}
 	updatedUser := &model.User{
 		ID:       userID,
-		Name:     req.Name,
-		Password: req.Password,
+		Name:     name,
+		Password: password,
 		Login:    "johndoe",
 	}
 
@@ -132,6 +135,50 @@ func TestUpdateUser(t *testing.T) {
 	assert.Equal(t, updatedUser, cachedUser.user)
 }
 
+func TestUserVersions(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Создаем мок репозитория
+	mockRepo := mocks.NewMockUserProvider(ctrl)
+
+	// Создаем кэш
+	cache, err := NewDecorator(mockRepo, time.Minute, time.Minute)
+	require.NoError(t, err)
+
+	// Тестовые данные
+	userID := uuid.New()
+	fresh := &model.User{ID: userID, Login: "johndoe", Name: "New", Version: 3}
+	stale := &model.User{ID: userID, Login: "johndoe", Name: "Old", Version: 2}
+
+	// Кейс 1: Запоздавшее чтение не затирает более новую версию
+	cache.setUser(userID, fresh)
+	cache.setUser(userID, stale)
+	cachedUser, _ := cache.getUser(userID)
+	assert.Equal(t, fresh, cachedUser.user)
+
+	// Кейс 2: Конфликт версий убирает запись, следующее чтение идёт в репозиторий
+	name := "Other"
+	req := model.UpdateUserRequest{ID: userID, Name: &name, Version: 2}
+	mockRepo.EXPECT().
+		UpdateUser(context.Background(), req).
+		Return(nil, apperr.ErrVersionConflict)
+	_, err = cache.UpdateUser(context.Background(), req)
+	require.ErrorIs(t, err, apperr.ErrVersionConflict)
+	_, ok := cache.getUser(userID)
+	assert.False(t, ok)
+
+	// Кейс 3: Конфликт при удалении тоже убирает запись
+	cache.setUser(userID, fresh)
+	mockRepo.EXPECT().
+		DeleteUser(context.Background(), userID, int64(2)).
+		Return(apperr.ErrVersionConflict)
+	require.ErrorIs(t, cache.DeleteUser(context.Background(), userID, 2), apperr.ErrVersionConflict)
+	_, ok = cache.getUser(userID)
+	assert.False(t, ok)
+}
+
 func TestAddUser(t *testing.T) {
 	// Создаем контроллер мока
 	ctrl := gomock.NewController(t)
@@ -161,6 +208,106 @@ func TestAddUser(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestDeleteUser(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Создаем мок репозитория
+	mockRepo := mocks.NewMockUserProvider(ctrl)
+
+	// Создаем кэш
+	cache, err := NewDecorator(mockRepo, time.Minute, time.Minute)
+	require.NoError(t, err)
+
+	// Тестовые данные
+	userID := uuid.New()
+	user := &model.User{
+		ID:    userID,
+		Name:  "John Doe",
+		Login: "johndoe",
+	}
+	cache.setUser(userID, user)
+
+	// Кейс 1: Удалённый пользователь пропадает из кэша и по id, и по логину
+	mockRepo.EXPECT().DeleteUser(context.Background(), userID, int64(0)).Return(nil)
+	require.NoError(t, cache.DeleteUser(context.Background(), userID, 0))
+	_, ok := cache.getUser(userID)
+	assert.False(t, ok)
+	_, ok = cache.getUserIDByLogin(userLoginKey(user))
+	assert.False(t, ok)
+
+	// Кейс 2: Чтение после удаления идёт в репозиторий, который его скрывает
+	mockRepo.EXPECT().
+		GetUser(context.Background(), userID).
+		Return(nil, apperr.ErrNotFound)
+	_, err = cache.GetUser(context.Background(), userID)
+	require.ErrorIs(t, err, apperr.ErrNotFound)
+}
+
+func TestInvalidateUser(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Создаем мок репозитория
+	mockRepo := mocks.NewMockUserProvider(ctrl)
+
+	// Создаем кэш
+	cache, err := NewDecorator(mockRepo, time.Minute, time.Minute)
+	require.NoError(t, err)
+
+	// Тестовые данные
+	userID := uuid.New()
+	admin := &model.User{ID: userID, Login: "johndoe", Roles: []string{model.RoleAdmin}}
+	revoked := &model.User{ID: userID, Login: "johndoe"}
+	cache.setUser(userID, admin)
+
+	// Кейс 1: после отзыва роли чтение идёт в репозиторий, а не отдаёт прежние роли
+	cache.InvalidateUser(userID)
+	mockRepo.EXPECT().GetUser(context.Background(), userID).Return(revoked, nil)
+	user, err := cache.GetUser(context.Background(), userID)
+	require.NoError(t, err)
+	assert.Empty(t, user.Roles)
+}
+
+func TestOrganizationIsolation(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Создаем мок репозитория
+	mockRepo := mocks.NewMockUserProvider(ctrl)
+
+	// Создаем кэш
+	cache, err := NewDecorator(mockRepo, time.Minute, time.Minute)
+	require.NoError(t, err)
+
+	// Тестовые данные
+	userID := uuid.New()
+	user := &model.User{ID: userID, Login: "johndoe", OrganizationID: uuid.New()}
+	cache.setUser(userID, user)
+	own := tenant.WithID(context.Background(), user.OrganizationID)
+	other := tenant.WithID(context.Background(), uuid.New())
+
+	// Кейс 1: своя организация получает пользователя из кэша
+	got, err := cache.GetUser(own, userID)
+	require.NoError(t, err)
+	assert.Equal(t, user, got)
+	id, err := cache.GetUserIDByLogin(own, user.Login)
+	require.NoError(t, err)
+	assert.Equal(t, userID, *id)
+
+	// Кейс 2: чужая организация идёт в репозиторий, даже если пользователь в кэше
+	mockRepo.EXPECT().GetUser(other, userID).Return(nil, apperr.ErrNotFound)
+	_, err = cache.GetUser(other, userID)
+	require.ErrorIs(t, err, apperr.ErrNotFound)
+
+	mockRepo.EXPECT().GetUserIDByLogin(other, user.Login).Return(nil, apperr.ErrNotFound)
+	_, err = cache.GetUserIDByLogin(other, user.Login)
+	require.ErrorIs(t, err, apperr.ErrNotFound)
+}
+
 func TestClose(t *testing.T) {
 	// Создаем контроллер мока
 	ctrl := gomock.NewController(t)
@@ -0,0 +1,171 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+type permissionsWithTTL struct {
+	permissions []string
+	expiresAt   time.Time
+}
+
+// PermissionDecorator кэширует итоговые права пользователей. Изменения
+// назначений через декоратор сбрасывают кэш сразу, изменения на других
+// экземплярах сервиса видны не позже чем через ttl.
+type PermissionDecorator struct {
+	repository.RBACProvider
+	ttl         time.Duration
+	mu          sync.Mutex
+	permissions map[uuid.UUID]permissionsWithTTL
+	// Растёт при каждом сбросе: результат запроса, начатого до сброса, не кэшируется
+	generation uint64
+	done       chan struct{}
+}
+
+func NewPermissionDecorator(rbacRepo repository.RBACProvider,
+	cleanupInterval time.Duration,
+	ttl time.Duration) (*PermissionDecorator, error) {
+	if rbacRepo == nil {
+		return nil, errors.New("rbacRepo cannot be nil")
+	}
+
+	cache := &PermissionDecorator{
+		RBACProvider: rbacRepo,
+		ttl:          ttl,
+		permissions:  make(map[uuid.UUID]permissionsWithTTL),
+		done:         make(chan struct{}),
+	}
+
+	go func() {
+		ticker := time.NewTicker(cleanupInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				cache.cleanExpired()
+			case <-cache.done:
+				return
+			}
+		}
+	}()
+
+	return cache, nil
+}
+
+func (c *PermissionDecorator) cleanExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for id, val := range c.permissions {
+		if now.After(val.expiresAt) {
+			delete(c.permissions, id)
+		}
+	}
+}
+
+func (c *PermissionDecorator) invalidateUser(id uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	delete(c.permissions, id)
+}
+
+func (c *PermissionDecorator) invalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.permissions)
+}
+
+func (c *PermissionDecorator) GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	c.mu.Lock()
+	cached, ok := c.permissions[userID]
+	generation := c.generation
+	c.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.permissions, nil
+	}
+
+	permissions, err := c.RBACProvider.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "from GetUserPermissions in PermissionDecorator")
+	}
+
+	c.mu.Lock()
+	if c.generation == generation {
+		c.permissions[userID] = permissionsWithTTL{
+			permissions: permissions,
+			expiresAt:   time.Now().Add(c.ttl),
+		}
+	}
+	c.mu.Unlock()
+	return permissions, nil
+}
+
+func (c *PermissionDecorator) AssignUserRole(ctx context.Context, userID uuid.UUID, role string) error {
+	defer c.invalidateUser(userID)
+	err := c.RBACProvider.AssignUserRole(ctx, userID, role)
+	return errors.Wrap(err, "from AssignUserRole in PermissionDecorator")
+}
+
+func (c *PermissionDecorator) RevokeUserRole(ctx context.Context, userID uuid.UUID, role string) error {
+	defer c.invalidateUser(userID)
+	err := c.RBACProvider.RevokeUserRole(ctx, userID, role)
+	return errors.Wrap(err, "from RevokeUserRole in PermissionDecorator")
+}
+
+func (c *PermissionDecorator) AddGroupMember(ctx context.Context, groupID uuid.UUID, userID uuid.UUID) error {
+	defer c.invalidateUser(userID)
+	err := c.RBACProvider.AddGroupMember(ctx, groupID, userID)
+	return errors.Wrap(err, "from AddGroupMember in PermissionDecorator")
+}
+
+func (c *PermissionDecorator) RemoveGroupMember(ctx context.Context, groupID uuid.UUID, userID uuid.UUID) error {
+	defer c.invalidateUser(userID)
+	err := c.RBACProvider.RemoveGroupMember(ctx, groupID, userID)
+	return errors.Wrap(err, "from RemoveGroupMember in PermissionDecorator")
+}
+
+// Изменения ролей и групп затрагивают неизвестно сколько пользователей, поэтому кэш сбрасывается целиком
+
+func (c *PermissionDecorator) UpdateRole(ctx context.Context, name string, req model.UpdateRoleRequest) error {
+	defer c.invalidateAll()
+	err := c.RBACProvider.UpdateRole(ctx, name, req)
+	return errors.Wrap(err, "from UpdateRole in PermissionDecorator")
+}
+
+func (c *PermissionDecorator) DeleteRole(ctx context.Context, name string) error {
+	defer c.invalidateAll()
+	err := c.RBACProvider.DeleteRole(ctx, name)
+	return errors.Wrap(err, "from DeleteRole in PermissionDecorator")
+}
+
+func (c *PermissionDecorator) DeleteGroup(ctx context.Context, id uuid.UUID) error {
+	defer c.invalidateAll()
+	err := c.RBACProvider.DeleteGroup(ctx, id)
+	return errors.Wrap(err, "from DeleteGroup in PermissionDecorator")
+}
+
+func (c *PermissionDecorator) AssignGroupRole(ctx context.Context, groupID uuid.UUID, role string) error {
+	defer c.invalidateAll()
+	err := c.RBACProvider.AssignGroupRole(ctx, groupID, role)
+	return errors.Wrap(err, "from AssignGroupRole in PermissionDecorator")
+}
+
+func (c *PermissionDecorator) RevokeGroupRole(ctx context.Context, groupID uuid.UUID, role string) error {
+	defer c.invalidateAll()
+	err := c.RBACProvider.RevokeGroupRole(ctx, groupID, role)
+	return errors.Wrap(err, "from RevokeGroupRole in PermissionDecorator")
+}
+
+func (c *PermissionDecorator) Close() {
+	close(c.done)
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionDecorator(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Создаем мок репозитория
+	mockRepo := mocks.NewMockRBACProvider(ctrl)
+
+	cache, err := NewPermissionDecorator(mockRepo, time.Minute, time.Minute)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	otherID := uuid.New()
+
+	// Кейс 1: второй запрос берётся из кэша
+	mockRepo.EXPECT().GetUserPermissions(ctx, userID).Return([]string{model.PermissionUsersRead}, nil).Times(1)
+	mockRepo.EXPECT().GetUserPermissions(ctx, otherID).Return([]string{}, nil).Times(1)
+	for i := 0; i < 2; i++ {
+		permissions, err := cache.GetUserPermissions(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{model.PermissionUsersRead}, permissions)
+		_, err = cache.GetUserPermissions(ctx, otherID)
+		require.NoError(t, err)
+	}
+
+	// Кейс 2: назначение роли сбрасывает кэш только этого пользователя
+	mockRepo.EXPECT().AssignUserRole(ctx, userID, "editor").Return(nil)
+	require.NoError(t, cache.AssignUserRole(ctx, userID, "editor"))
+
+	mockRepo.EXPECT().GetUserPermissions(ctx, userID).
+		Return([]string{model.PermissionUsersRead, model.PermissionUsersWrite}, nil)
+	permissions, err := cache.GetUserPermissions(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, permissions, 2)
+	_, err = cache.GetUserPermissions(ctx, otherID)
+	require.NoError(t, err)
+
+	// Кейс 3: изменение роли сбрасывает кэш всех пользователей
+	mockRepo.EXPECT().UpdateRole(ctx, "editor", gomock.Any()).Return(nil)
+	require.NoError(t, cache.UpdateRole(ctx, "editor", model.UpdateRoleRequest{}))
+
+	mockRepo.EXPECT().GetUserPermissions(ctx, userID).Return([]string{model.PermissionUsersRead}, nil)
+	mockRepo.EXPECT().GetUserPermissions(ctx, otherID).Return([]string{}, nil)
+	_, err = cache.GetUserPermissions(ctx, userID)
+	require.NoError(t, err)
+	_, err = cache.GetUserPermissions(ctx, otherID)
+	require.NoError(t, err)
+
+	// Кейс 4: ответ, полученный до сброса, в кэш не попадает
+	mockRepo.EXPECT().GetUserPermissions(ctx, userID).DoAndReturn(func(context.Context, uuid.UUID) ([]string, error) {
+		cache.invalidateAll()
+		return []string{model.PermissionUsersDelete}, nil
+	})
+	mockRepo.EXPECT().DeleteGroup(ctx, gomock.Any()).Return(nil)
+	require.NoError(t, cache.DeleteGroup(ctx, uuid.New()))
+	_, err = cache.GetUserPermissions(ctx, userID)
+	require.NoError(t, err)
+
+	mockRepo.EXPECT().GetUserPermissions(ctx, userID).Return([]string{}, nil)
+	permissions, err = cache.GetUserPermissions(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, permissions)
+
+	// Кейс 5: устаревшие записи не отдаются
+	cache.ttl = 0
+	mockRepo.EXPECT().AddGroupMember(ctx, gomock.Any(), userID).Return(nil)
+	require.NoError(t, cache.AddGroupMember(ctx, uuid.New(), userID))
+	mockRepo.EXPECT().GetUserPermissions(ctx, userID).Return([]string{}, nil).Times(2)
+	_, err = cache.GetUserPermissions(ctx, userID)
+	require.NoError(t, err)
+	_, err = cache.GetUserPermissions(ctx, userID)
+	require.NoError(t, err)
+}
@@ -0,0 +1,78 @@
+// Package cursor - непрозрачные курсоры постраничной выдачи. Позиция
+// подписывается HMAC, поэтому клиент не может собрать или подменить курсор.
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MinKeySize - минимальная длина ключа подписи в байтах
+const MinKeySize = 32
+
+var ErrInvalid = errors.New("invalid cursor")
+
+type Codec struct {
+	key []byte
+}
+
+func New(key []byte) (*Codec, error) {
+	if len(key) < MinKeySize {
+		return nil, errors.Errorf("cursor key must be at least %d bytes", MinKeySize)
+	}
+	return &Codec{key: key}, nil
+}
+
+func NewFromBase64(encoded string) (*Codec, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode cursor key")
+	}
+	return New(key)
+}
+
+// Encode сериализует позицию в JSON и подписывает её.
+func (c *Codec) Encode(position any) (string, error) {
+	payload, err := json.Marshal(position)
+	if err != nil {
+		return "", errors.Wrap(err, "cursor Encode")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(c.sign(payload)), nil
+}
+
+// Decode проверяет подпись и разбирает позицию в position.
+func (c *Codec) Decode(cursor string, position any) error {
+	encodedPayload, encodedMAC, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return ErrInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return ErrInvalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil {
+		return ErrInvalid
+	}
+	if !hmac.Equal(mac, c.sign(payload)) {
+		return ErrInvalid
+	}
+
+	if err := json.Unmarshal(payload, position); err != nil {
+		return ErrInvalid
+	}
+	return nil
+}
+
+func (c *Codec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,48 @@
+package cursor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type position struct {
+	ID    string `json:"id"`
+	Count int    `json:"count"`
+}
+
+func TestCodec(t *testing.T) {
+	codec, err := New(bytes.Repeat([]byte{1}, MinKeySize))
+	require.NoError(t, err)
+
+	encoded, err := codec.Encode(position{ID: "abc", Count: 3})
+	require.NoError(t, err)
+
+	// Кейс 1: курсор разбирается тем же ключом
+	var got position
+	require.NoError(t, codec.Decode(encoded, &got))
+	assert.Equal(t, position{ID: "abc", Count: 3}, got)
+
+	// Кейс 2: изменённая позиция
+	forged, err := codec.Encode(position{ID: "abd", Count: 3})
+	require.NoError(t, err)
+	payload, _, _ := strings.Cut(forged, ".")
+	_, mac, _ := strings.Cut(encoded, ".")
+	require.ErrorIs(t, codec.Decode(payload+"."+mac, &got), ErrInvalid)
+
+	// Кейс 3: другой ключ
+	other, err := New(bytes.Repeat([]byte{2}, MinKeySize))
+	require.NoError(t, err)
+	require.ErrorIs(t, other.Decode(encoded, &got), ErrInvalid)
+
+	// Кейс 4: мусор вместо курсора
+	require.ErrorIs(t, codec.Decode("not-a-cursor", &got), ErrInvalid)
+	require.ErrorIs(t, codec.Decode("!!.!!", &got), ErrInvalid)
+
+	// Кейс 5: короткий ключ
+	_, err = New([]byte("short"))
+	require.Error(t, err)
+}
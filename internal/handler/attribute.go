@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListAttributeDefinitions отдаёт справочник атрибутов, чтобы клиент мог построить форму профиля.
+func (h *Handle) ListAttributeDefinitions(c *gin.Context) {
+	definitions, err := h.attributeUC.ListAttributeDefinitions(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attributes": definitions})
+}
+
+func (h *Handle) GetAttributeDefinition(c *gin.Context) {
+	definition, err := h.attributeUC.GetAttributeDefinition(c, c.Param("name"))
+	if err != nil {
+		respondAttributeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, definition)
+}
+
+func (h *Handle) CreateAttributeDefinition(c *gin.Context) {
+	var req model.AttributeDefinition
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRequest(c, req, "error in create attribute request") {
+		return
+	}
+
+	definition, err := h.attributeUC.CreateAttributeDefinition(c, req)
+	if err != nil {
+		respondAttributeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, definition)
+}
+
+func (h *Handle) UpdateAttributeDefinition(c *gin.Context) {
+	var req model.UpdateAttributeDefinitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRequest(c, req, "error in update attribute request") {
+		return
+	}
+
+	definition, err := h.attributeUC.UpdateAttributeDefinition(c, c.Param("name"), req)
+	if err != nil {
+		respondAttributeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, definition)
+}
+
+// DeleteAttributeDefinition удаляет атрибут вместе со значениями у всех пользователей.
+func (h *Handle) DeleteAttributeDefinition(c *gin.Context) {
+	name := c.Param("name")
+	if err := h.attributeUC.DeleteAttributeDefinition(c, name); err != nil {
+		respondAttributeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"name": name})
+}
+
+func respondAttributeError(c *gin.Context, err error) {
+	if respondValidationError(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, apperr.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, apperr.ErrAlreadyExists):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "attribute already exists"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListAuditEvents отдаёт журнал аудита страницами, следующая запрашивается по next_cursor.
+func (h *Handle) ListAuditEvents(c *gin.Context) {
+	var query model.AuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRequest(c, query, "error in audit request") {
+		return
+	}
+
+	// Формат параметров уже проверен валидатором
+	filter := model.AuditFilter{Action: query.Action}
+	if query.UserID != "" {
+		filter.UserID = uuid.MustParse(query.UserID)
+	}
+	if query.From != "" {
+		from, _ := time.Parse(time.RFC3339, query.From)
+		filter.From = &from
+	}
+	if query.To != "" {
+		to, _ := time.Parse(time.RFC3339, query.To)
+		filter.To = &to
+	}
+
+	page, err := h.auditUC.ListEvents(c, filter, query.Cursor, query.Limit)
+	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
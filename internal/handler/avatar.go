@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/thumbnail"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Запас на заголовки multipart поверх размера самого файла
+const multipartOverhead = 64 << 10
+
+// PutAvatar принимает изображение в поле avatar формы multipart/form-data.
+// Формат определяется по содержимому, заявленный клиентом Content-Type не учитывается.
+func (h *Handle) PutAvatar(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	maxSize := h.avatarUC.MaxAvatarSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+multipartOverhead)
+	header, err := c.FormFile("avatar")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "avatar is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "multipart field avatar is required"})
+		return
+	}
+	if header.Size > maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "avatar is too large"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	urls, err := h.avatarUC.SetAvatar(c, id, data)
+	if err != nil {
+		respondAvatarError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"avatar": urls})
+}
+
+func (h *Handle) DeleteAvatar(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.avatarUC.DeleteAvatar(c, id); err != nil {
+		respondAvatarError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondAvatarError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, thumbnail.ErrUnsupportedFormat):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "avatar must be a JPEG, PNG or GIF image"})
+	case errors.Is(err, thumbnail.ErrInvalidImage):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "avatar is not a valid image"})
+	case errors.Is(err, thumbnail.ErrTooLarge), errors.Is(err, apperr.ErrTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "avatar is too large"})
+	case errors.Is(err, apperr.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Handle) SendEmailVerification(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.emailUC.SendVerification(c, id); err != nil {
+		switch {
+		case errors.Is(err, apperr.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, apperr.ErrEmailNotSet):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email is not set"})
+		case errors.Is(err, apperr.ErrEmailVerified):
+			c.JSON(http.StatusConflict, gin.H{"error": "email already verified"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "verification link has been sent"})
+}
+
+func (h *Handle) ConfirmEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	if err := h.emailUC.ConfirmEmail(c, token); err != nil {
+		if errors.Is(err, apperr.ErrInvalidToken) || errors.Is(err, apperr.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired token"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "email has been verified"})
+}
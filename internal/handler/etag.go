@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag пользователя - его версия в кавычках, например "3".
+func userETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ifMatchVersion достаёт ожидаемую версию из If-Match. "*" означает любую
+// версию и даёт 0. Без заголовка запрос отклоняется с 428, с чужим ETag - с 412.
+func ifMatchVersion(c *gin.Context) (int64, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required"})
+		return 0, false
+	}
+	if header == "*" {
+		return 0, true
+	}
+
+	// Слабый ETag (W/"...") для If-Match не подходит и не распознаётся
+	value, err := strconv.Unquote(header)
+	if err == nil {
+		version, err := strconv.ParseInt(value, 10, 64)
+		if err == nil && version > 0 {
+			return version, true
+		}
+	}
+
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": "version mismatch"})
+	return 0, false
+}
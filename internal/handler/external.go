@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	externalStateCookie = "lk_external_state"
+	// Cookie нужна только на возврате от провайдера
+	externalCookiePath = "/auth/external"
+)
+
+func (h *Handle) ListExternalProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.externalUC.Providers()})
+}
+
+func (h *Handle) StartExternalLogin(c *gin.Context) {
+	redirectTo, ok := h.startExternal(c, nil)
+	if !ok {
+		return
+	}
+
+	c.Redirect(http.StatusFound, redirectTo)
+}
+
+// LinkIdentity начинает привязку провайдера к вошедшему пользователю. Запрос
+// идёт с токеном, поэтому вместо редиректа адрес провайдера отдаётся в теле.
+func (h *Handle) LinkIdentity(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	redirectTo, ok := h.startExternal(c, &id)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"redirect_to": redirectTo})
+}
+
+func (h *Handle) startExternal(c *gin.Context, linkUserID *uuid.UUID) (string, bool) {
+	redirectTo, state, err := h.externalUC.StartLogin(c, c.Param("provider"), linkUserID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "unknown provider"})
+			return "", false
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return "", false
+	}
+
+	// Срок жизни state проверяется при расшифровке, cookie живёт до закрытия браузера
+	setExternalStateCookie(c, state, 0)
+	return redirectTo, true
+}
+
+func (h *Handle) ExternalCallback(c *gin.Context) {
+	provider := c.Param("provider")
+	state, _ := c.Cookie(externalStateCookie)
+	// state одноразовый: cookie удаляется при любом исходе
+	setExternalStateCookie(c, "", -1)
+
+	if errCode := c.Query("error"); errCode != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errCode})
+		return
+	}
+
+	result, err := h.externalUC.CompleteLogin(c, provider, c.Query("code"), c.Query("state"), state)
+	if err != nil {
+		logger.Info("external login failed",
+			zap.String("provider", provider),
+			zap.Error(err),
+		)
+		switch {
+		case errors.Is(err, apperr.ErrInvalidToken):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid state"})
+		case errors.Is(err, apperr.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "unknown provider"})
+		case errors.Is(err, apperr.ErrExternalAuth):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": apperr.ErrExternalAuth.Error()})
+		case errors.Is(err, apperr.ErrEmailNotSet):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "provider did not return an email"})
+		case errors.Is(err, apperr.ErrAlreadyExists):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "account already exists, log in and link the provider"})
+		case errors.Is(err, apperr.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": "organization accepts members by invitation only"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if result.Linked {
+		c.JSON(http.StatusOK, gin.H{"id": result.User.ID, "provider": provider})
+		return
+	}
+
+	if result.Created && !result.User.EmailVerified() {
+		if err := h.emailUC.SendVerification(c, result.User.ID); err != nil {
+			logger.Error("failed to send email verification",
+				zap.String("userID", result.User.ID.String()),
+				zap.Error(err),
+			)
+		}
+	}
+
+	h.completeLogin(c, result.User, provider)
+}
+
+func (h *Handle) ListIdentities(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	identities, err := h.externalUC.ListIdentities(c, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"identities": identities})
+}
+
+func (h *Handle) UnlinkIdentity(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	provider := c.Param("provider")
+	if err := h.externalUC.UnlinkIdentity(c, id, provider); err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"provider": provider})
+}
+
+func setExternalStateCookie(c *gin.Context, value string, maxAge int) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	// Lax: cookie должна прийти при переходе с сайта провайдера
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(externalStateCookie, value, maxAge, externalCookiePath, "", secure, true)
+}
@@ -1,29 +1,84 @@
 package handler
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
-	"fmt"
-	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/jsonpatch"
 	"github.com/lemavisaitov/lk-api/internal/logger"
 	"github.com/lemavisaitov/lk-api/internal/model"
 	"github.com/lemavisaitov/lk-api/internal/usecase"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
 type Handle struct {
-	userUC usecase.UserProvider
+	userUC          usecase.UserProvider
+	authUC          usecase.AuthProvider
+	sessionUC       usecase.SessionProvider
+	passwordResetUC usecase.PasswordResetProvider
+	emailUC         usecase.EmailVerificationProvider
+	mfaUC           usecase.MFAProvider
+	apiKeyUC        usecase.APIKeyProvider
+	oidcUC          usecase.OIDCProvider
+	externalUC      usecase.ExternalLoginProvider
+	webAuthnUC      usecase.WebAuthnProvider
+	rbacUC          usecase.RBACProvider
+	impersonationUC usecase.ImpersonationProvider
+	auditUC         usecase.AuditProvider
+	attributeUC     usecase.AttributeProvider
+	avatarUC        usecase.AvatarProvider
+	organizationUC  usecase.OrganizationProvider
+	membershipUC    usecase.MembershipProvider
+	invitationUC    usecase.InvitationProvider
 }
 
-func New(userProvider usecase.UserProvider) *Handle {
+func New(userProvider usecase.UserProvider,
+	authProvider usecase.AuthProvider,
+	sessionProvider usecase.SessionProvider,
+	passwordResetProvider usecase.PasswordResetProvider,
+	emailVerificationProvider usecase.EmailVerificationProvider,
+	mfaProvider usecase.MFAProvider,
+	apiKeyProvider usecase.APIKeyProvider,
+	oidcProvider usecase.OIDCProvider,
+	externalLoginProvider usecase.ExternalLoginProvider,
+	webAuthnProvider usecase.WebAuthnProvider,
+	rbacProvider usecase.RBACProvider,
+	impersonationProvider usecase.ImpersonationProvider,
+	auditProvider usecase.AuditProvider,
+	attributeProvider usecase.AttributeProvider,
+	avatarProvider usecase.AvatarProvider,
+	organizationProvider usecase.OrganizationProvider,
+	membershipProvider usecase.MembershipProvider,
+	invitationProvider usecase.InvitationProvider) *Handle {
 	return &Handle{
-		userUC: userProvider,
+		userUC:          userProvider,
+		authUC:          authProvider,
+		sessionUC:       sessionProvider,
+		passwordResetUC: passwordResetProvider,
+		emailUC:         emailVerificationProvider,
+		mfaUC:           mfaProvider,
+		apiKeyUC:        apiKeyProvider,
+		oidcUC:          oidcProvider,
+		externalUC:      externalLoginProvider,
+		webAuthnUC:      webAuthnProvider,
+		rbacUC:          rbacProvider,
+		impersonationUC: impersonationProvider,
+		auditUC:         auditProvider,
+		attributeUC:     attributeProvider,
+		avatarUC:        avatarProvider,
+		organizationUC:  organizationProvider,
+		membershipUC:    membershipProvider,
+		invitationUC:    invitationProvider,
 	}
 }
 
@@ -35,16 +90,7 @@ func (h *Handle) Signup(c *gin.Context) {
 		return
 	}
 
-	validate := validator.New()
-	if err := validate.Struct(user); err != nil {
-		errMessage := ""
-		for _, err := range err.(validator.ValidationErrors) {
-			errMessage += fmt.Sprintf("ошибка в поле %s: %s\n", err.StructField(), err.ActualTag())
-		}
-		logger.Error("error in signup request",
-			zap.Error(err),
-		)
-		c.JSON(http.StatusBadRequest, gin.H{"error": errMessage})
+	if !validateRequest(c, user, "error in signup request") {
 		return
 	}
 
@@ -63,6 +109,16 @@ func (h *Handle) Signup(c *gin.Context) {
 		return
 	}
 
+	ok, err = h.userUC.EmailExists(c, user.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email already exists"})
+		return
+	}
+
 	id, err := uuid.NewV7()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -73,10 +129,25 @@ func (h *Handle) Signup(c *gin.Context) {
 
 	_, err = h.userUC.AddUser(c, user)
 	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+		if errors.Is(err, apperr.ErrAlreadyExists) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "login or email already exists"})
+			return
+		}
+
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if err := h.emailUC.SendVerification(c, id); err != nil {
+		logger.Error("failed to send email verification",
+			zap.String("userID", id.String()),
+			zap.Error(err),
+		)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"id": id})
 }
 
@@ -87,43 +158,93 @@ func (h *Handle) Login(c *gin.Context) {
 		return
 	}
 
-	validate := validator.New()
-	if err := validate.Struct(req); err != nil {
-		errMessage := ""
-		for _, err := range err.(validator.ValidationErrors) {
-			errMessage += fmt.Sprintf("ошибка в поле %s: %s\n", err.StructField(), err.ActualTag())
-		}
-		logger.Error("error in login request",
-			zap.Error(err),
-		)
-		c.JSON(http.StatusBadRequest, gin.H{"error": errMessage})
+	if !validateRequest(c, req, "error in login request") {
 		return
 	}
 
-	userID, err := h.userUC.GetUserIDByLogin(c, req.Login)
-
+	user, err := h.userUC.Authenticate(c, req)
 	if err != nil {
+		if respondRetry(c, err) {
+			return
+		}
 		if errors.Is(err, apperr.ErrNotFound) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, apperr.ErrWrongPassword) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "wrong password"})
+			return
+		}
+		if errors.Is(err, apperr.ErrEmailNotVerified) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "email not verified"})
+			return
+		}
 
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	user, err := h.userUC.GetUser(c, *userID)
+	h.completeLogin(c, user, req.Device)
+}
+
+// completeLogin выдаёт токены пользователю, подтвердившему личность первым фактором.
+// При включённом втором факторе вместо токенов выдаётся токен для второго шага.
+func (h *Handle) completeLogin(c *gin.Context, user *model.User, device string) {
+	enabled, err := h.mfaUC.MFAEnabled(c, user.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	if user.Password != req.Password {
-		log.Println(user.Password, req.Password)
-		c.JSON(http.StatusForbidden, gin.H{"error": "wrong password"})
+	if enabled {
+		challenge, err := h.mfaUC.StartChallenge(c, user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	h.issueLoginTokens(c, user, device)
+}
+
+func (h *Handle) issueLoginTokens(c *gin.Context, user *model.User, device string) {
+	tokens, err := h.authUC.IssueTokens(c, user, device)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"id": userID})
+	c.JSON(http.StatusOK, model.LoginResponse{
+		ID:        user.ID,
+		TokenPair: *tokens,
+	})
+}
+
+func (h *Handle) RefreshToken(c *gin.Context) {
+	var req model.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !validateRequest(c, req, "error in refresh token request") {
+		return
+	}
+
+	tokens, err := h.authUC.RefreshTokens(c, req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, apperr.ErrInvalidToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
 }
 
 func (h *Handle) GetUser(c *gin.Context) {
@@ -137,17 +258,99 @@ func (h *Handle) GetUser(c *gin.Context) {
 	if err != nil {
 		if errors.Is(err, apperr.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("ETag", userETag(user.Version))
+	c.JSON(http.StatusOK, gin.H{"name": user.Name, "age": user.Age, "avatar": h.avatarUC.AvatarURLs(user)})
+}
+
+// ListUsers отдаёт пользователей страницами, следующая запрашивается по next_cursor.
+func (h *Handle) ListUsers(c *gin.Context) {
+	var query model.ListUsersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRequest(c, query, "error in list users request") {
+		return
+	}
+	if query.MaxAge != 0 && query.MaxAge < query.MinAge {
+		respondFieldErrors(c, []apperr.FieldError{{
+			Field:   "max_age",
+			Code:    "gtefield",
+			Message: "must be greater than or equal to min_age",
+		}})
+		return
+	}
+
+	// Формат параметров уже проверен валидатором
+	filter := model.UserFilter{
+		NamePrefix: query.NamePrefix,
+		MinAge:     query.MinAge,
+		MaxAge:     query.MaxAge,
+	}
+	if query.CreatedFrom != "" {
+		from, _ := time.Parse(time.RFC3339, query.CreatedFrom)
+		filter.CreatedFrom = &from
+	}
+	if query.CreatedTo != "" {
+		to, _ := time.Parse(time.RFC3339, query.CreatedTo)
+		filter.CreatedTo = &to
+	}
+	// Фильтр по атрибутам задаётся параметрами attr[name]=value
+	attributes, err := h.attributeUC.ParseAttributeFilter(c, c.QueryMap("attr"))
+	if err != nil {
+		if respondValidationError(c, err) {
+			return
 		}
 
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	filter.Attributes = attributes
+
+	page, err := h.userUC.ListUsers(c, filter, model.ParseUserSort(query.Sort), query.Cursor, query.Limit)
+	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// SearchUsers ищет пользователей по части логина или имени, допускаются опечатки.
+func (h *Handle) SearchUsers(c *gin.Context) {
+	var query model.SearchUsersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.Q = strings.TrimSpace(query.Q)
+	if !validateRequest(c, query, "error in search users request") {
+		return
+	}
+
+	results, err := h.userUC.SearchUsers(c, query.Q, query.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"name": user.Name, "age": user.Age})
+	c.JSON(http.StatusOK, gin.H{"users": results})
 }
 
+// UpdateUser заменяет пользователя целиком, частичные изменения - через PatchUser.
 func (h *Handle) UpdateUser(c *gin.Context) {
-	var req model.UpdateUserRequest
+	var req model.ReplaceUserRequest
 
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -160,33 +363,165 @@ func (h *Handle) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	validate := validator.New()
-	if err := validate.Struct(req); err != nil {
-		errMessage := ""
-		for _, err := range err.(validator.ValidationErrors) {
-			errMessage += fmt.Sprintf("ошибка в поле %s: %s\n", err.StructField(), err.ActualTag())
-		}
-		logger.Error("error in signup request",
-			zap.Error(err),
-		)
-		c.JSON(http.StatusBadRequest, gin.H{"error": errMessage})
+	if !validateRequest(c, req, "error in update user request") {
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	update := model.UpdateUserRequest{
+		ID:         id,
+		Name:       &req.Name,
+		Age:        req.Age,
+		Email:      &req.Email,
+		Attributes: req.Attributes,
+		Version:    version,
+	}
+	// Замена целиком: без attributes у пользователя не остаётся атрибутов
+	if update.Attributes == nil {
+		update.Attributes = map[string]any{}
+	}
+	if req.Password != "" {
+		update.Password = &req.Password
+	}
+	if _, err := h.userUC.UpdateUser(c, update); err != nil {
+		respondUpdateError(c, err)
+		return
+	}
+
+	// Новая версия нужна клиенту для следующего изменения
+	if user, err := h.userUC.GetUser(c, id); err == nil {
+		c.Header("ETag", userETag(user.Version))
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+// PatchUser применяет к пользователю JSON Merge Patch или JSON Patch.
+// Изменения накладываются на текущее представление, результат проверяется целиком.
+func (h *Handle) PatchUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var apply func(doc []byte, patch []byte) ([]byte, error)
+	switch c.ContentType() {
+	case jsonpatch.MergePatchType:
+		apply = jsonpatch.MergePatch
+	case jsonpatch.JSONPatchType:
+		apply = jsonpatch.Apply
+	default:
+		c.Header("Accept-Patch", jsonpatch.MergePatchType+", "+jsonpatch.JSONPatchType)
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "unsupported patch content type"})
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
 		return
 	}
 
-	req.ID = id
-	_, err = h.userUC.UpdateUser(c, req)
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	current, err := h.userUC.GetUser(c, id)
 	if err != nil {
 		if errors.Is(err, apperr.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// Патч составлен для конкретной версии, на другой он может дать не то
+	if version != 0 && version != current.Version {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "version mismatch"})
+		return
+	}
+
+	doc, err := json.Marshal(model.NewUserPatch(current))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	patched, err := apply(doc, body)
+	if err != nil {
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, jsonpatch.ErrInvalidPatch) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
 
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Поля вне представления (id, login, password) патч добавить не может
+	var patch model.UserPatch
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRequest(c, patch, "error in patch user request") {
+		return
+	}
+
+	update := patch.Changes(current)
+	if update.Empty() {
+		c.Header("ETag", userETag(current.Version))
+		c.JSON(http.StatusOK, gin.H{"id": id})
+		return
+	}
+	update.Version = current.Version
+	if _, err := h.userUC.UpdateUser(c, update); err != nil {
+		respondUpdateError(c, err)
+		return
+	}
+
+	if user, err := h.userUC.GetUser(c, id); err == nil {
+		c.Header("ETag", userETag(user.Version))
+	}
 	c.JSON(http.StatusOK, gin.H{"id": id})
 }
 
+func respondUpdateError(c *gin.Context, err error) {
+	if respondValidationError(c, err) {
+		return
+	}
+	if errors.Is(err, apperr.ErrVersionConflict) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "version mismatch"})
+		return
+	}
+	if errors.Is(err, apperr.ErrForbidden) {
+		// Сменить учётные данные за пользователя сотрудник не может
+		c.JSON(http.StatusForbidden, gin.H{"error": "not allowed while impersonating"})
+		return
+	}
+	if errors.Is(err, apperr.ErrAlreadyExists) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email already exists"})
+		return
+	}
+	if errors.Is(err, apperr.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
 func (h *Handle) DeleteUser(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -194,12 +529,43 @@ func (h *Handle) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	err = h.userUC.DeleteUser(c, id)
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	err = h.userUC.DeleteUser(c, id, version)
 	if err != nil {
 		if errors.Is(err, apperr.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, apperr.ErrVersionConflict) {
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": "version mismatch"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+// RestoreUser отменяет удаление, пока не истёк срок восстановления.
+func (h *Handle) RestoreUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.userUC.RestoreUser(c, id)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "deleted user not found or restore period expired"})
+			return
+		}
 
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -207,3 +573,24 @@ func (h *Handle) DeleteUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"id": id})
 }
+
+// respondRetry отвечает 429 или 423 с Retry-After, если запрос отклонён ограничителем попыток.
+func respondRetry(c *gin.Context, err error) bool {
+	var retryErr *apperr.RetryError
+	if !errors.As(err, &retryErr) {
+		return false
+	}
+
+	setRetryAfter(c, retryErr.RetryAfter)
+	status := http.StatusTooManyRequests
+	if errors.Is(err, apperr.ErrAccountLocked) {
+		status = http.StatusLocked
+	}
+	c.JSON(status, gin.H{"error": retryErr.Error()})
+	return true
+}
+
+func setRetryAfter(c *gin.Context, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(max(seconds, 1)))
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+	"github.com/lemavisaitov/lk-api/internal/usecase"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandle_GetUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	userUC := usecase.NewUserProvider(userRepo, nil, nil, nil, nil, nil, nil, nil, nil, 0, false)
+	avatarUC := usecase.NewAvatarProvider(userRepo, nil, nil, 0, 0)
+	h := New(userUC, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, avatarUC, nil, nil, nil)
+
+	router := gin.New()
+	router.GET("/user/:id", h.GetUser)
+
+	user := &model.User{ID: uuid.New(), Name: "Jane", Age: 30, Version: 3}
+	missing := uuid.New()
+	deleted := uuid.New()
+	broken := uuid.New()
+	userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+	userRepo.EXPECT().GetUser(gomock.Any(), missing).Return(nil, errors.Wrap(apperr.ErrNotFound, "user not found"))
+	// Удалённого пользователя репозиторий скрывает так же, как отсутствующего
+	userRepo.EXPECT().GetUser(gomock.Any(), deleted).Return(nil, errors.Wrap(apperr.ErrNotFound, "user not found"))
+	userRepo.EXPECT().GetUser(gomock.Any(), broken).Return(nil, errors.New("db is down"))
+
+	testCases := []struct {
+		caseName string
+		id       string
+		status   int
+		body     string
+	}{
+		{caseName: "valid test: user found", id: user.ID.String(), status: http.StatusOK,
+			body: `{"name":"Jane","age":30,"avatar":null}`},
+		{caseName: "invalid test: user not found", id: missing.String(), status: http.StatusNotFound},
+		{caseName: "invalid test: user deleted", id: deleted.String(), status: http.StatusNotFound},
+		{caseName: "invalid test: repository error", id: broken.String(), status: http.StatusInternalServerError},
+		{caseName: "invalid test: malformed id", id: "42", status: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/user/"+tc.id, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+			if tc.body != "" {
+				assert.JSONEq(t, tc.body, w.Body.String())
+				assert.Equal(t, userETag(user.Version), w.Header().Get("ETag"))
+				return
+			}
+			// Ответ об ошибке один, без склеенного второго тела
+			var body map[string]any
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Contains(t, body, "error")
+		})
+	}
+}
+
+func TestHandle_RequestValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// До usecase запрос не доходит, поэтому зависимости не нужны
+	h := New(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	router := gin.New()
+	router.POST("/user/login", h.Login)
+	router.POST("/auth/refresh", h.RefreshToken)
+
+	testCases := []struct {
+		caseName string
+		path     string
+		body     string
+		fields   []apperr.FieldError
+	}{
+		{
+			caseName: "invalid test: login without password",
+			path:     "/user/login",
+			body:     `{"login":"jane"}`,
+			fields: []apperr.FieldError{
+				{Field: "password", Code: "required", Message: "failed on the 'required' rule"},
+			},
+		},
+		{
+			caseName: "invalid test: login with too long device",
+			path:     "/user/login",
+			body:     `{"login":"jane","password":"secret","device":"` + strings.Repeat("d", 256) + `"}`,
+			fields: []apperr.FieldError{
+				{Field: "device", Code: "max", Message: "failed on the 'max' rule (255)"},
+			},
+		},
+		{
+			caseName: "invalid test: empty refresh token",
+			path:     "/auth/refresh",
+			body:     `{}`,
+			fields: []apperr.FieldError{
+				{Field: "refresh_token", Code: "required", Message: "failed on the 'required' rule"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var body struct {
+				Error  string              `json:"error"`
+				Fields []apperr.FieldError `json:"fields"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, apperr.ErrValidation.Error(), body.Error)
+			assert.Equal(t, tc.fields, body.Fields)
+		})
+	}
+}
+
+func TestRespondUpdateError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		caseName string
+		err      error
+		status   int
+	}{
+		{
+			caseName: "invalid test: check constraint violated",
+			err: errors.Wrap(&apperr.ValidationError{Fields: []apperr.FieldError{
+				{Field: "age", Code: "check", Message: "violates the 'users_age_check' constraint"},
+			}}, "UpdateUser Scan"),
+			status: http.StatusBadRequest,
+		},
+		{caseName: "invalid test: version conflict", err: apperr.ErrVersionConflict, status: http.StatusPreconditionFailed},
+		{caseName: "invalid test: repository error", err: errors.New("db is down"), status: http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			respondUpdateError(c, tc.err)
+
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Handle) StartImpersonation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req model.StartImpersonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRequest(c, req, "error in start impersonation request") {
+		return
+	}
+
+	token, err := h.impersonationUC.StartImpersonation(c, id, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, apperr.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, apperr.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+func (h *Handle) EndImpersonation(c *gin.Context) {
+	if err := h.impersonationUC.EndImpersonation(c); err != nil {
+		if errors.Is(err, apperr.ErrNotImpersonating) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "token is not an impersonation token"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListUserImpersonations показывает, кто и когда входил под пользователем.
+func (h *Handle) ListUserImpersonations(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.listImpersonations(c, model.ImpersonationFilter{SubjectID: id})
+}
+
+// ListImpersonations отбирает записи по ?actor_id= и ?subject_id=.
+func (h *Handle) ListImpersonations(c *gin.Context) {
+	actorID, err := queryUUID(c, "actor_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	subjectID, err := queryUUID(c, "subject_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter := model.ImpersonationFilter{ActorID: actorID, SubjectID: subjectID}
+	h.listImpersonations(c, filter)
+}
+
+func (h *Handle) listImpersonations(c *gin.Context, filter model.ImpersonationFilter) {
+	impersonations, err := h.impersonationUC.ListImpersonations(c, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"impersonations": impersonations})
+}
+
+// queryUUID читает необязательный параметр запроса, пустой означает uuid.Nil.
+func queryUUID(c *gin.Context, param string) (uuid.UUID, error) {
+	value := c.Query(param)
+	if value == "" {
+		return uuid.Nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", param, err)
+	}
+	return id, nil
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Handle) ListInvitations(c *gin.Context) {
+	invitations, err := h.invitationUC.ListInvitations(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+func (h *Handle) CreateInvitation(c *gin.Context) {
+	var req model.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRequest(c, req, "error in create invitation request") {
+		return
+	}
+
+	invitation, err := h.invitationUC.CreateInvitation(c, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// InviteOwner приглашает первого владельца организации :id.
+func (h *Handle) InviteOwner(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req model.OwnerInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRequest(c, req, "error in owner invitation request") {
+		return
+	}
+
+	invitation, err := h.invitationUC.InviteOwner(c, id, req.Email)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+func (h *Handle) RevokeInvitation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("iid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.invitationUC.RevokeInvitation(c, id); err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+// AcceptInvitation принимает приглашение по токену из письма. Если на почту из приглашения
+// ещё нет учётной записи, она создаётся из логина, пароля и имени запроса, иначе
+// приглашённый должен войти в свою учётную запись.
+func (h *Handle) AcceptInvitation(c *gin.Context) {
+	var req model.AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRequest(c, req, "error in accept invitation request") {
+		return
+	}
+
+	result, err := h.invitationUC.AcceptInvitation(c, req)
+	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, apperr.ErrInvalidToken):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired token"})
+		case errors.Is(err, apperr.ErrOtherOrganization):
+			c.JSON(http.StatusConflict, gin.H{"error": "email belongs to an account in another organization"})
+		case errors.Is(err, apperr.ErrEmailNotVerified):
+			c.JSON(http.StatusConflict, gin.H{"error": "email is held by an account that has not verified it"})
+		case errors.Is(err, apperr.ErrForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": "log in as the invited user to accept"})
+		case errors.Is(err, apperr.ErrAlreadyExists):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "login already exists or organization already has an owner"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if result.Created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, result)
+}
+
+func (h *Handle) DeclineInvitation(c *gin.Context) {
+	var req model.DeclineInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRequest(c, req, "error in decline invitation request") {
+		return
+	}
+
+	if err := h.invitationUC.DeclineInvitation(c, req.Token); err != nil {
+		if errors.Is(err, apperr.ErrInvalidToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "invitation has been declined"})
+}
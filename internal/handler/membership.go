@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Handle) ListMembers(c *gin.Context) {
+	members, err := h.membershipUC.ListMembers(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+func (h *Handle) UpdateMemberRole(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req model.UpdateMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRequest(c, req, "error in update member role request") {
+		return
+	}
+
+	if err := h.membershipUC.UpdateMemberRole(c, id, req.Role); err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user_id": id, "role": req.Role})
+}
+
+// TransferOwnership передаёт владение участнику, прежний владелец остаётся администратором.
+func (h *Handle) TransferOwnership(c *gin.Context) {
+	var req model.TransferOwnershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRequest(c, req, "error in transfer ownership request") {
+		return
+	}
+
+	if err := h.membershipUC.TransferOwnership(c, req.UserID); err != nil {
+		respondMembershipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"owner_id": req.UserID})
+}
+
+func respondMembershipError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, apperr.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "member not found"})
+	case errors.Is(err, apperr.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, apperr.ErrAlreadyExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "organization already has an owner"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
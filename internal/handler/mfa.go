@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Handle) EnrollTOTP(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	enrollment, err := h.mfaUC.EnrollTOTP(c, id)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *Handle) ConfirmTOTP(c *gin.Context) {
+	id, req, ok := bindMFACode(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.mfaUC.ConfirmTOTP(c, id, req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *Handle) DisableTOTP(c *gin.Context) {
+	id, req, ok := bindMFACode(c)
+	if !ok {
+		return
+	}
+
+	if err := h.mfaUC.DisableTOTP(c, id, req.Code); err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+func (h *Handle) RegenerateRecoveryCodes(c *gin.Context) {
+	id, req, ok := bindMFACode(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.mfaUC.RegenerateRecoveryCodes(c, id, req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, model.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// LoginMFA - второй шаг входа: токен из Login и код второго фактора.
+func (h *Handle) LoginMFA(c *gin.Context) {
+	var req model.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !validateRequest(c, req, "error in mfa login request") {
+		return
+	}
+
+	user, err := h.mfaUC.VerifyChallenge(c, req)
+	if err != nil {
+		if errors.Is(err, apperr.ErrInvalidToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid mfa token"})
+			return
+		}
+		respondMFAError(c, err)
+		return
+	}
+
+	h.issueLoginTokens(c, user, req.Device)
+}
+
+func bindMFACode(c *gin.Context) (uuid.UUID, model.MFACodeRequest, bool) {
+	var req model.MFACodeRequest
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return uuid.Nil, req, false
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return uuid.Nil, req, false
+	}
+
+	if !validateRequest(c, req, "error in mfa code request") {
+		return uuid.Nil, req, false
+	}
+
+	return id, req, true
+}
+
+func respondMFAError(c *gin.Context, err error) {
+	if respondRetry(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, apperr.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, apperr.ErrMFANotEnrolled):
+		c.JSON(http.StatusNotFound, gin.H{"error": "totp is not enrolled"})
+	case errors.Is(err, apperr.ErrMFAEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": "totp is already enabled"})
+	case errors.Is(err, apperr.ErrInvalidMFACode):
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid code"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func (h *Handle) OIDCDiscovery(c *gin.Context) {
+	c.JSON(http.StatusOK, h.oidcUC.Discovery())
+}
+
+func (h *Handle) JWKS(c *gin.Context) {
+	c.JSON(http.StatusOK, h.oidcUC.JWKS())
+}
+
+func (h *Handle) RegisterOAuthClient(c *gin.Context) {
+	var req model.RegisterClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !validateRequest(c, req, "error in register client request") {
+		return
+	}
+
+	client, err := h.oidcUC.RegisterClient(c, req)
+	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, client)
+}
+
+// Authorize принимает параметры из query (GET) или формы (POST). Пользователь
+// уже вошёл: фронтенд передаёт его токен и, если нужно, решение о согласии.
+func (h *Handle) Authorize(c *gin.Context) {
+	userID, ok := auth.SubjectFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+		return
+	}
+
+	var req model.AuthorizeRequest
+	if err := c.ShouldBind(&req); err != nil {
+		respondOAuthError(c, http.StatusBadRequest, apperr.OAuthInvalidRequest, err.Error())
+		return
+	}
+
+	result, err := h.oidcUC.Authorize(c, userID, req)
+	if err != nil {
+		var oauthErr *apperr.OAuthError
+		if errors.As(err, &oauthErr) {
+			respondOAuthError(c, http.StatusBadRequest, oauthErr.Code, oauthErr.Description)
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if result.Consent != nil {
+		c.JSON(http.StatusOK, result.Consent)
+		return
+	}
+	c.Redirect(http.StatusFound, result.RedirectTo)
+}
+
+func (h *Handle) Token(c *gin.Context) {
+	var req model.TokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		respondOAuthError(c, http.StatusBadRequest, apperr.OAuthInvalidRequest, err.Error())
+		return
+	}
+
+	// client_secret_basic: id и секрет в Basic закодированы как form-urlencoded
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		var errID, errSecret error
+		req.ClientID, errID = url.QueryUnescape(id)
+		req.ClientSecret, errSecret = url.QueryUnescape(secret)
+		if errID != nil || errSecret != nil {
+			respondOAuthError(c, http.StatusBadRequest, apperr.OAuthInvalidRequest, "malformed basic credentials")
+			return
+		}
+	}
+
+	// Токены не должны оседать в кэшах
+	c.Header("Cache-Control", "no-store")
+
+	tokens, err := h.oidcUC.Exchange(c, req)
+	if err != nil {
+		var oauthErr *apperr.OAuthError
+		if errors.As(err, &oauthErr) {
+			status := http.StatusBadRequest
+			if oauthErr.Code == apperr.OAuthInvalidClient {
+				status = http.StatusUnauthorized
+				c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+			}
+			respondOAuthError(c, status, oauthErr.Code, oauthErr.Description)
+			return
+		}
+
+		logger.Error("error while exchanging authorization code",
+			zap.Error(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *Handle) UserInfo(c *gin.Context) {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		c.Header("WWW-Authenticate", `Bearer`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+		return
+	}
+
+	info, err := h.oidcUC.UserInfo(c, token)
+	if err != nil {
+		if errors.Is(err, apperr.ErrInvalidToken) {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+func (h *Handle) ListOAuthConsents(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	consents, err := h.oidcUC.ListConsents(c, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"consents": consents})
+}
+
+func (h *Handle) RevokeOAuthConsent(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clientID := c.Param("client_id")
+	if err := h.oidcUC.RevokeConsent(c, id, clientID); err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"client_id": clientID})
+}
+
+// respondOAuthError отвечает в формате RFC 6749, 5.2: клиентские библиотеки ждут именно его.
+func respondOAuthError(c *gin.Context, status int, code string, description string) {
+	c.JSON(status, gin.H{
+		"error":             code,
+		"error_description": description,
+	})
+}
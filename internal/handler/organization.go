@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handle) ListOrganizations(c *gin.Context) {
+	organizations, err := h.organizationUC.ListOrganizations(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"organizations": organizations})
+}
+
+// CreateOrganization создаёт организацию без пользователей. Первого владельца
+// приглашают через /admin/organizations/:id/owner-invitation.
+func (h *Handle) CreateOrganization(c *gin.Context) {
+	var req model.Organization
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRequest(c, req, "error in create organization request") {
+		return
+	}
+
+	organization, err := h.organizationUC.CreateOrganization(c, req)
+	if err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+		if errors.Is(err, apperr.ErrAlreadyExists) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "organization already exists"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, organization)
+}
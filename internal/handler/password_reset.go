@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func (h *Handle) ForgotPassword(c *gin.Context) {
+	var req model.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !validateRequest(c, req, "error in forgot password request") {
+		return
+	}
+
+	if err := h.passwordResetUC.RequestPasswordReset(c, req.Login); err != nil {
+		logger.Error("failed to request password reset",
+			zap.Error(err),
+		)
+	}
+
+	// Ответ одинаковый независимо от того, есть ли такой логин
+	c.JSON(http.StatusAccepted, gin.H{"status": "if the account exists, a reset link has been sent"})
+}
+
+func (h *Handle) ResetPassword(c *gin.Context) {
+	var req model.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !validateRequest(c, req, "error in reset password request") {
+		return
+	}
+
+	if err := h.passwordResetUC.ResetPassword(c, req); err != nil {
+		if respondValidationError(c, err) {
+			return
+		}
+		if errors.Is(err, apperr.ErrInvalidToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired token"})
+			return
+		}
+
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "password has been reset"})
+}
@@ -0,0 +1,297 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (h *Handle) ListPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"permissions": model.Permissions})
+}
+
+func (h *Handle) ListRoles(c *gin.Context) {
+	roles, err := h.rbacUC.ListRoles(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+func (h *Handle) GetRole(c *gin.Context) {
+	role, err := h.rbacUC.GetRole(c, c.Param("role"))
+	if err != nil {
+		respondRBACError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+func (h *Handle) CreateRole(c *gin.Context) {
+	var req model.Role
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRequest(c, req, "error in create role request") {
+		return
+	}
+
+	role, err := h.rbacUC.CreateRole(c, req)
+	if err != nil {
+		respondRBACError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, role)
+}
+
+func (h *Handle) UpdateRole(c *gin.Context) {
+	var req model.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRequest(c, req, "error in update role request") {
+		return
+	}
+
+	role, err := h.rbacUC.UpdateRole(c, c.Param("role"), req)
+	if err != nil {
+		respondRBACError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+func (h *Handle) DeleteRole(c *gin.Context) {
+	name := c.Param("role")
+	if err := h.rbacUC.DeleteRole(c, name); err != nil {
+		respondRBACError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"name": name})
+}
+
+func (h *Handle) AssignUserRole(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role := c.Param("role")
+	if err := h.rbacUC.AssignUserRole(c, id, role); err != nil {
+		respondRBACError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id, "role": role})
+}
+
+func (h *Handle) RevokeUserRole(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role := c.Param("role")
+	if err := h.rbacUC.RevokeUserRole(c, id, role); err != nil {
+		respondRBACError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id, "role": role})
+}
+
+func (h *Handle) GetUserPermissions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	permissions, err := h.rbacUC.UserPermissions(c, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.EffectivePermissions{UserID: id, Permissions: permissions})
+}
+
+func (h *Handle) ListGroups(c *gin.Context) {
+	groups, err := h.rbacUC.ListGroups(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"groups": groups})
+}
+
+func (h *Handle) GetGroup(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("gid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := h.rbacUC.GetGroup(c, id)
+	if err != nil {
+		respondRBACError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+func (h *Handle) CreateGroup(c *gin.Context) {
+	var req model.CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRequest(c, req, "error in create group request") {
+		return
+	}
+
+	group, err := h.rbacUC.CreateGroup(c, req)
+	if err != nil {
+		respondRBACError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+func (h *Handle) DeleteGroup(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("gid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.rbacUC.DeleteGroup(c, id); err != nil {
+		respondRBACError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+func (h *Handle) ListGroupMembers(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("gid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	members, err := h.rbacUC.ListGroupMembers(c, id)
+	if err != nil {
+		respondRBACError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+func (h *Handle) AddGroupMember(c *gin.Context) {
+	groupID, userID, ok := parseGroupMember(c)
+	if !ok {
+		return
+	}
+
+	if err := h.rbacUC.AddGroupMember(c, groupID, userID); err != nil {
+		respondRBACError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"group_id": groupID, "user_id": userID})
+}
+
+func (h *Handle) RemoveGroupMember(c *gin.Context) {
+	groupID, userID, ok := parseGroupMember(c)
+	if !ok {
+		return
+	}
+
+	if err := h.rbacUC.RemoveGroupMember(c, groupID, userID); err != nil {
+		respondRBACError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"group_id": groupID, "user_id": userID})
+}
+
+func (h *Handle) AssignGroupRole(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("gid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role := c.Param("role")
+	if err := h.rbacUC.AssignGroupRole(c, id, role); err != nil {
+		respondRBACError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"group_id": id, "role": role})
+}
+
+func (h *Handle) RevokeGroupRole(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("gid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role := c.Param("role")
+	if err := h.rbacUC.RevokeGroupRole(c, id, role); err != nil {
+		respondRBACError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"group_id": id, "role": role})
+}
+
+func parseGroupMember(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	groupID, err := uuid.Parse(c.Param("gid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return uuid.Nil, uuid.Nil, false
+	}
+	userID, err := uuid.Parse(c.Param("uid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return groupID, userID, true
+}
+
+func respondRBACError(c *gin.Context, err error) {
+	if respondValidationError(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, apperr.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, apperr.ErrAlreadyExists):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "already exists"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// fieldValidator называет поля в ошибках так же, как в JSON запроса
+var fieldValidator = newFieldValidator()
+
+func newFieldValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+	return validate
+}
+
+// validateRequest проверяет тело запроса и при ошибке отвечает 400 со списком полей.
+func validateRequest(c *gin.Context, req any, logMessage string) bool {
+	err := fieldValidator.Struct(req)
+	if err == nil {
+		return true
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	fields := make([]apperr.FieldError, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		message := "failed on the '" + fieldErr.ActualTag() + "' rule"
+		if fieldErr.Param() != "" {
+			message += " (" + fieldErr.Param() + ")"
+		}
+		fields = append(fields, apperr.FieldError{
+			Field:   fieldErr.Field(),
+			Code:    fieldErr.ActualTag(),
+			Message: message,
+		})
+	}
+
+	logger.Error(logMessage,
+		zap.Error(err),
+	)
+	respondFieldErrors(c, fields)
+	return false
+}
+
+// respondValidationError отвечает 400, если usecase отклонил запрос по правилам (например, политике паролей).
+func respondValidationError(c *gin.Context, err error) bool {
+	var validationErr *apperr.ValidationError
+	if !errors.As(err, &validationErr) {
+		return false
+	}
+
+	respondFieldErrors(c, validationErr.Fields)
+	return true
+}
+
+func respondFieldErrors(c *gin.Context, fields []apperr.FieldError) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":  apperr.ErrValidation.Error(),
+		"fields": fields,
+	})
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func (h *Handle) BeginWebAuthnRegistration(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	options, err := h.webAuthnUC.BeginRegistration(c, id)
+	if err != nil {
+		respondWebAuthnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, options)
+}
+
+func (h *Handle) FinishWebAuthnRegistration(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req model.WebAuthnRegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRequest(c, req, "error in webauthn register request") {
+		return
+	}
+
+	cred, err := h.webAuthnUC.FinishRegistration(c, id, req)
+	if err != nil {
+		if errors.Is(err, apperr.ErrAlreadyExists) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "passkey already registered"})
+			return
+		}
+
+		respondWebAuthnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, cred)
+}
+
+func (h *Handle) ListWebAuthnCredentials(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	creds, err := h.webAuthnUC.ListCredentials(c, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"credentials": creds})
+}
+
+func (h *Handle) DeleteWebAuthnCredential(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	credID, err := uuid.Parse(c.Param("cid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.webAuthnUC.DeleteCredential(c, id, credID); err != nil {
+		respondWebAuthnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": credID})
+}
+
+func (h *Handle) BeginWebAuthnLogin(c *gin.Context) {
+	var req model.WebAuthnLoginOptionsRequest
+	// Тело необязательно: без логина подойдёт любой ключ с устройства
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if !validateRequest(c, req, "error in webauthn login options request") {
+		return
+	}
+
+	options, err := h.webAuthnUC.BeginLogin(c, req.Login)
+	if err != nil {
+		respondWebAuthnError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, options)
+}
+
+// LoginWebAuthn - вход по ключу доступа вместо пароля.
+func (h *Handle) LoginWebAuthn(c *gin.Context) {
+	var req model.WebAuthnLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !validateRequest(c, req, "error in webauthn login request") {
+		return
+	}
+
+	user, verified, err := h.webAuthnUC.FinishLogin(c, req)
+	if err != nil {
+		logger.Info("webauthn login failed",
+			zap.Error(err),
+		)
+		respondWebAuthnError(c, err)
+		return
+	}
+
+	// Ключ с проверкой пользователя сам по себе даёт два фактора
+	if verified {
+		h.issueLoginTokens(c, user, req.Device)
+		return
+	}
+	h.completeLogin(c, user, req.Device)
+}
+
+func respondWebAuthnError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, apperr.ErrInvalidToken):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired webauthn session"})
+	case errors.Is(err, apperr.ErrInvalidPasskey):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": apperr.ErrInvalidPasskey.Error()})
+	case errors.Is(err, apperr.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,67 @@
+package hasher
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcrypt учитывает не больше 72 байт пароля, а длиннее и вовсе не принимает
+const bcryptMaxPasswordLen = 72
+
+type Bcrypt struct {
+	cost int
+}
+
+func NewBcrypt(cost int) (*Bcrypt, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, errors.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return &Bcrypt{cost: cost}, nil
+}
+
+func (b *Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword(bcryptInput(password), b.cost)
+	if err != nil {
+		return "", errors.Wrap(err, "bcrypt GenerateFromPassword")
+	}
+	return string(hash), nil
+}
+
+func (b *Bcrypt) Verify(password, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), bcryptInput(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "bcrypt CompareHashAndPassword")
+	}
+	return true, nil
+}
+
+func (b *Bcrypt) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return true
+	}
+	return cost != b.cost
+}
+
+func (b *Bcrypt) Match(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+// bcryptInput заменяет длинный пароль его SHA-256 в base64 (44 байта). Короткие
+// пароли передаются как есть, чтобы прежние хэши продолжали проверяться.
+func bcryptInput(password string) []byte {
+	if len(password) <= bcryptMaxPasswordLen {
+		return []byte(password)
+	}
+	sum := sha256.Sum256([]byte(password))
+	return []byte(base64.StdEncoding.EncodeToString(sum[:]))
+}
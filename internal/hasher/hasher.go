@@ -0,0 +1,101 @@
+package hasher
+
+import (
+	"crypto/subtle"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	AlgoArgon2id = "argon2id"
+	AlgoBcrypt   = "bcrypt"
+)
+
+// PasswordHasher хэширует пароли и проверяет их по закодированной строке,
+// в которой хранятся алгоритм и его параметры.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, encoded string) (bool, error)
+	NeedsRehash(encoded string) bool
+}
+
+type algorithm interface {
+	PasswordHasher
+	Match(encoded string) bool
+}
+
+// Chain хэширует предпочтительным алгоритмом, но умеет проверять хэши
+// всех известных алгоритмов, а также старые пароли, хранящиеся открытым текстом.
+type Chain struct {
+	preferred algorithm
+	known     []algorithm
+}
+
+func New(algo string, argon2Params Argon2Params, bcryptCost int) (*Chain, error) {
+	argon2id := NewArgon2id(argon2Params)
+	bcrypt, err := NewBcrypt(bcryptCost)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewBcrypt")
+	}
+
+	chain := &Chain{
+		known: []algorithm{argon2id, bcrypt},
+	}
+
+	switch algo {
+	case AlgoArgon2id:
+		chain.preferred = argon2id
+	case AlgoBcrypt:
+		chain.preferred = bcrypt
+	default:
+		return nil, errors.Errorf("unknown password hash algorithm %q", algo)
+	}
+
+	return chain, nil
+}
+
+func (h *Chain) Hash(password string) (string, error) {
+	encoded, err := h.preferred.Hash(password)
+	if err != nil {
+		return "", errors.Wrap(err, "Chain Hash")
+	}
+	return encoded, nil
+}
+
+func (h *Chain) Verify(password, encoded string) (bool, error) {
+	alg := h.find(encoded)
+	if alg == nil {
+		return isPlaintextEqual(password, encoded), nil
+	}
+
+	ok, err := alg.Verify(password, encoded)
+	if err != nil {
+		return false, errors.Wrap(err, "Chain Verify")
+	}
+	return ok, nil
+}
+
+func (h *Chain) NeedsRehash(encoded string) bool {
+	if !h.preferred.Match(encoded) {
+		return true
+	}
+	return h.preferred.NeedsRehash(encoded)
+}
+
+func (h *Chain) find(encoded string) algorithm {
+	for _, alg := range h.known {
+		if alg.Match(encoded) {
+			return alg
+		}
+	}
+	return nil
+}
+
+// isPlaintextEqual нужен для строк, записанных до появления хэширования.
+// Такие пароли перехэшируются при следующем успешном входе.
+func isPlaintextEqual(password, stored string) bool {
+	if stored == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(password), []byte(stored)) == 1
+}
@@ -0,0 +1,122 @@
+package hasher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testArgon2Params = Argon2Params{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+}
+
+func TestChain_HashAndVerify(t *testing.T) {
+	for _, algo := range []string{AlgoArgon2id, AlgoBcrypt} {
+		t.Run(algo, func(t *testing.T) {
+			h, err := New(algo, testArgon2Params, 4)
+			require.NoError(t, err)
+
+			encoded, err := h.Hash("qwerty")
+			require.NoError(t, err)
+			assert.NotContains(t, encoded, "qwerty")
+
+			ok, err := h.Verify("qwerty", encoded)
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = h.Verify("wrong", encoded)
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			assert.False(t, h.NeedsRehash(encoded))
+		})
+	}
+}
+
+func TestBcrypt_LongPassword(t *testing.T) {
+	h, err := NewBcrypt(4)
+	require.NoError(t, err)
+
+	// Пароли длиннее 72 байт различаются и после 72-го байта
+	password := strings.Repeat("a", 100)
+	encoded, err := h.Hash(password)
+	require.NoError(t, err)
+
+	ok, err := h.Verify(password, encoded)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = h.Verify(strings.Repeat("a", 99)+"b", encoded)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = h.Verify(strings.Repeat("a", 72), encoded)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestChain_Argon2idEncodingCarriesParams(t *testing.T) {
+	h, err := New(AlgoArgon2id, testArgon2Params, 4)
+	require.NoError(t, err)
+
+	encoded, err := h.Hash("qwerty")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=1$"))
+}
+
+func TestChain_NeedsRehash(t *testing.T) {
+	old, err := New(AlgoBcrypt, testArgon2Params, 4)
+	require.NoError(t, err)
+	bcryptHash, err := old.Hash("qwerty")
+	require.NoError(t, err)
+
+	weak, err := New(AlgoArgon2id, testArgon2Params, 4)
+	require.NoError(t, err)
+	weakHash, err := weak.Hash("qwerty")
+	require.NoError(t, err)
+
+	// Предпочтительный алгоритм - argon2id с другими параметрами
+	stronger := testArgon2Params
+	stronger.Iterations = 2
+	h, err := New(AlgoArgon2id, stronger, 4)
+	require.NoError(t, err)
+
+	// Кейс 1: хэш другим алгоритмом всё ещё проверяется, но требует перехэширования
+	ok, err := h.Verify("qwerty", bcryptHash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, h.NeedsRehash(bcryptHash))
+
+	// Кейс 2: изменились параметры argon2id
+	ok, err = h.Verify("qwerty", weakHash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, h.NeedsRehash(weakHash))
+}
+
+func TestChain_LegacyPlaintext(t *testing.T) {
+	h, err := New(AlgoArgon2id, testArgon2Params, 4)
+	require.NoError(t, err)
+
+	ok, err := h.Verify("qwerty", "qwerty")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, h.NeedsRehash("qwerty"))
+
+	ok, err = h.Verify("wrong", "qwerty")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = h.Verify("", "")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestNew_UnknownAlgorithm(t *testing.T) {
+	_, err := New("md5", testArgon2Params, 4)
+	require.Error(t, err)
+}
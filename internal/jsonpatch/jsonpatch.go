@@ -0,0 +1,350 @@
+// Package jsonpatch применяет к JSON-документу изменения в форматах
+// JSON Merge Patch (RFC 7396) и JSON Patch (RFC 6902). Документ меняется
+// целиком или не меняется вовсе.
+package jsonpatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Типы содержимого, по которым клиент выбирает формат изменений
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrTestFailed - операция test не совпала с документом
+	ErrTestFailed = errors.New("patch test failed")
+)
+
+// MergePatch применяет patch по RFC 7396: объекты сливаются рекурсивно,
+// null удаляет поле, любое другое значение заменяет прежнее.
+func MergePatch(doc []byte, patch []byte) ([]byte, error) {
+	var target any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, errors.Wrap(err, "decode document")
+	}
+	var changes any
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, errors.Wrap(ErrInvalidPatch, err.Error())
+	}
+
+	return json.Marshal(mergeValue(target, changes))
+}
+
+func mergeValue(target any, patch any) any {
+	changes, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	object, ok := target.(map[string]any)
+	if !ok {
+		object = make(map[string]any, len(changes))
+	}
+	for key, value := range changes {
+		if value == nil {
+			delete(object, key)
+			continue
+		}
+		object[key] = mergeValue(object[key], value)
+	}
+	return object
+}
+
+// Apply применяет patch по RFC 6902: операции выполняются по порядку,
+// ошибка в любой из них отменяет все.
+func Apply(doc []byte, patch []byte) ([]byte, error) {
+	var target any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, errors.Wrap(err, "decode document")
+	}
+	// Поля операции читаются из map, чтобы отличать "value": null от отсутствующего value
+	var operations []map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, errors.Wrap(ErrInvalidPatch, err.Error())
+	}
+
+	for i, operation := range operations {
+		var err error
+		target, err = applyOperation(target, operation)
+		if err != nil {
+			return nil, errors.Wrapf(err, "operation %d", i)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func applyOperation(doc any, operation map[string]json.RawMessage) (any, error) {
+	op, err := stringMember(operation, "op")
+	if err != nil {
+		return nil, err
+	}
+	path, err := pointerMember(operation, "path")
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "add":
+		value, err := valueMember(operation)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "remove":
+		doc, _, err = remove(doc, path)
+		return doc, err
+	case "replace":
+		value, err := valueMember(operation)
+		if err != nil {
+			return nil, err
+		}
+		if doc, _, err = remove(doc, path); err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "move":
+		from, err := pointerMember(operation, "from")
+		if err != nil {
+			return nil, err
+		}
+		if isPrefix(from, path) && len(from) < len(path) {
+			return nil, errors.Wrap(ErrInvalidPatch, "cannot move a value into its own child")
+		}
+		var value any
+		if doc, value, err = remove(doc, from); err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "copy":
+		from, err := pointerMember(operation, "from")
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, clone(value))
+	case "test":
+		expected, err := valueMember(operation)
+		if err != nil {
+			return nil, err
+		}
+		actual, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			return nil, errors.Wrapf(ErrTestFailed, "value at %q differs", "/"+strings.Join(path, "/"))
+		}
+		return doc, nil
+	default:
+		return nil, errors.Wrapf(ErrInvalidPatch, "unknown op %q", op)
+	}
+}
+
+func stringMember(operation map[string]json.RawMessage, name string) (string, error) {
+	raw, ok := operation[name]
+	if !ok {
+		return "", errors.Wrapf(ErrInvalidPatch, "%q is required", name)
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", errors.Wrapf(ErrInvalidPatch, "%q must be a string", name)
+	}
+	return value, nil
+}
+
+func valueMember(operation map[string]json.RawMessage) (any, error) {
+	raw, ok := operation["value"]
+	if !ok {
+		return nil, errors.Wrap(ErrInvalidPatch, `"value" is required`)
+	}
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, errors.Wrap(ErrInvalidPatch, err.Error())
+	}
+	return value, nil
+}
+
+// pointerMember разбирает JSON Pointer (RFC 6901) на токены, "" - весь документ.
+func pointerMember(operation map[string]json.RawMessage, name string) ([]string, error) {
+	pointer, err := stringMember(operation, name)
+	if err != nil {
+		return nil, err
+	}
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errors.Wrapf(ErrInvalidPatch, "pointer %q must start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix []string, path []string) bool {
+	return len(prefix) <= len(path) && reflect.DeepEqual(prefix, path[:len(prefix)])
+}
+
+func get(doc any, path []string) (any, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, errors.Wrapf(ErrInvalidPatch, "member %q not found", token)
+			}
+			doc = value
+		case []any:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[index]
+		default:
+			return nil, errors.Wrapf(ErrInvalidPatch, "cannot descend into %q", token)
+		}
+	}
+	return doc, nil
+}
+
+// add возвращает новый корень: вставка в массив может заменить сам срез.
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token, rest := path[0], path[1:]
+	switch node := doc.(type) {
+	case map[string]any:
+		if len(rest) == 0 {
+			node[token] = value
+			return node, nil
+		}
+		child, ok := node[token]
+		if !ok {
+			return nil, errors.Wrapf(ErrInvalidPatch, "member %q not found", token)
+		}
+		child, err := add(child, rest, value)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = child
+		return node, nil
+	case []any:
+		if len(rest) == 0 {
+			if token == "-" {
+				return append(node, value), nil
+			}
+			index, err := arrayIndex(token, len(node))
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		}
+		index, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		child, err := add(node[index], rest, value)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = child
+		return node, nil
+	default:
+		return nil, errors.Wrapf(ErrInvalidPatch, "cannot add into %q", token)
+	}
+}
+
+// remove возвращает новый корень и удалённое значение.
+func remove(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+
+	token, rest := path[0], path[1:]
+	switch node := doc.(type) {
+	case map[string]any:
+		child, ok := node[token]
+		if !ok {
+			return nil, nil, errors.Wrapf(ErrInvalidPatch, "member %q not found", token)
+		}
+		if len(rest) == 0 {
+			delete(node, token)
+			return node, child, nil
+		}
+		child, removed, err := remove(child, rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		node[token] = child
+		return node, removed, nil
+	case []any:
+		index, err := arrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rest) == 0 {
+			removed := node[index]
+			return append(node[:index], node[index+1:]...), removed, nil
+		}
+		child, removed, err := remove(node[index], rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		node[index] = child
+		return node, removed, nil
+	default:
+		return nil, nil, errors.Wrapf(ErrInvalidPatch, "cannot remove from %q", token)
+	}
+}
+
+// arrayIndex разбирает индекс массива без ведущих нулей и знаков, не больше maxIndex.
+func arrayIndex(token string, maxIndex int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, errors.Wrapf(ErrInvalidPatch, "invalid array index %q", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index > maxIndex {
+		return 0, errors.Wrapf(ErrInvalidPatch, "array index %q out of range", token)
+	}
+	return index, nil
+}
+
+func clone(value any) any {
+	switch node := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(node))
+		for key, child := range node {
+			copied[key] = clone(child)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(node))
+		for i, child := range node {
+			copied[i] = clone(child)
+		}
+		return copied
+	default:
+		return value
+	}
+}
@@ -0,0 +1,112 @@
+package jsonpatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch(t *testing.T) {
+	// Примеры из приложения A RFC 7396
+	testCases := []struct {
+		caseName string
+		doc      string
+		patch    string
+		want     string
+	}{
+		{"replace member", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"add member", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"null removes member", `{"a":"b"}`, `{"a":null}`, `{}`},
+		{"nested merge", `{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{"arrays are replaced", `{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{"non-object patch replaces document", `{"a":"foo"}`, `"bar"`, `"bar"`},
+		{"null inside new object is dropped", `{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			got, err := MergePatch([]byte(tc.doc), []byte(tc.patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.want, string(got))
+		})
+	}
+
+	_, err := MergePatch([]byte(`{}`), []byte(`{`))
+	require.ErrorIs(t, err, ErrInvalidPatch)
+}
+
+func TestApply(t *testing.T) {
+	// Примеры из приложения A RFC 6902
+	testCases := []struct {
+		caseName string
+		doc      string
+		patch    string
+		want     string
+	}{
+		{"add member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`,
+			`{"baz":"qux","foo":"bar"}`},
+		{"add array element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			`{"foo":["bar","qux","baz"]}`},
+		{"append to array", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc"]}]`,
+			`{"foo":["bar",["abc"]]}`},
+		{"remove member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"remove array element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`,
+			`{"foo":["bar","baz"]}`},
+		{"replace value", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`,
+			`{"baz":"boo","foo":"bar"}`},
+		{"move value", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"move array element", `{"foo":["all","grass","cows","eat"]}`,
+			`[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"copy value", `{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"}]`,
+			`{"foo":{"bar":1},"baz":{"bar":1}}`},
+		{"test passes", `{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`},
+		{"escaped pointer", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10},{"op":"remove","path":"/~1"}]`,
+			`{"~1":10}`},
+		{"add null value", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":null}]`,
+			`{"foo":"bar","child":null}`},
+		{"replace whole document", `{"foo":"bar"}`, `[{"op":"replace","path":"","value":{"baz":1}}]`,
+			`{"baz":1}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			got, err := Apply([]byte(tc.doc), []byte(tc.patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.want, string(got))
+		})
+	}
+}
+
+func TestApply_Errors(t *testing.T) {
+	testCases := []struct {
+		caseName string
+		doc      string
+		patch    string
+		want     error
+	}{
+		{"test fails", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, ErrTestFailed},
+		{"remove missing member", `{"foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, ErrInvalidPatch},
+		{"replace missing member", `{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":1}]`, ErrInvalidPatch},
+		{"add to missing parent", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, ErrInvalidPatch},
+		{"index out of range", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":1}]`, ErrInvalidPatch},
+		{"leading zero index", `{"foo":["bar","baz"]}`, `[{"op":"remove","path":"/foo/01"}]`, ErrInvalidPatch},
+		{"missing value", `{"foo":"bar"}`, `[{"op":"add","path":"/baz"}]`, ErrInvalidPatch},
+		{"unknown op", `{"foo":"bar"}`, `[{"op":"merge","path":"/foo","value":1}]`, ErrInvalidPatch},
+		{"move into own child", `{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`,
+			ErrInvalidPatch},
+		{"relative pointer", `{"foo":"bar"}`, `[{"op":"remove","path":"foo"}]`, ErrInvalidPatch},
+		{"not an array", `{"foo":"bar"}`, `{"op":"remove","path":"/foo"}`, ErrInvalidPatch},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			_, err := Apply([]byte(tc.doc), []byte(tc.patch))
+			require.ErrorIs(t, err, tc.want)
+		})
+	}
+}
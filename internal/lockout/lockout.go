@@ -0,0 +1,202 @@
+package lockout
+
+import (
+	"context"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	loginKeyPrefix = "login:"
+	ipKeyPrefix    = "ip:"
+)
+
+// Tracker учитывает неудачные попытки входа по логину и по IP. Логин уникален
+// только в организации, поэтому счётчик логина свой у каждой организации из контекста.
+type Tracker interface {
+	Check(ctx context.Context, login, ip string) (Decision, error)
+	Fail(ctx context.Context, login, ip string) (bool, error)
+	Reset(ctx context.Context, login string) error
+}
+
+// Store хранит счётчики попыток. Реализации: MemoryStore для одной реплики
+// и PostgresStore, общий для всех реплик.
+type Store interface {
+	Get(ctx context.Context, key string) (*Attempt, error)
+	RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (*Attempt, error)
+	// Lock блокирует ключ до until и обнуляет счётчик неудач
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+type Attempt struct {
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+type Policy struct {
+	// После MaxFailures неудач подряд ключ блокируется на LockoutPeriod
+	MaxFailures   int
+	LockoutPeriod time.Duration
+	// Между неудачами выдерживается пауза BaseDelay * 2^(n-1), но не больше MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Неудачи старше Window забываются
+	Window time.Duration
+}
+
+func (p Policy) backoff(failures int) time.Duration {
+	if failures <= 0 || p.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < failures; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return min(delay, p.MaxDelay)
+}
+
+type Decision struct {
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+func (d Decision) Allowed() bool {
+	return d.RetryAfter <= 0
+}
+
+type Limiter struct {
+	store       Store
+	loginPolicy Policy
+	ipPolicy    Policy
+	now         func() time.Time
+}
+
+func NewLimiter(store Store, loginPolicy Policy, ipPolicy Policy) *Limiter {
+	return &Limiter{
+		store:       store,
+		loginPolicy: loginPolicy,
+		ipPolicy:    ipPolicy,
+		now:         time.Now,
+	}
+}
+
+// Check возвращает самое строгое из ограничений по логину и по IP.
+func (l *Limiter) Check(ctx context.Context, login, ip string) (Decision, error) {
+	key, err := loginKey(ctx, login)
+	if err != nil {
+		return Decision{}, errors.Wrap(err, "Limiter Check")
+	}
+	byLogin, err := l.check(ctx, key, l.loginPolicy)
+	if err != nil {
+		return Decision{}, err
+	}
+	byIP, err := l.check(ctx, ipKeyPrefix+ip, l.ipPolicy)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	return stricter(byLogin, byIP), nil
+}
+
+// Fail записывает неудачную попытку и сообщает, привела ли она к блокировке.
+func (l *Limiter) Fail(ctx context.Context, login, ip string) (bool, error) {
+	key, err := loginKey(ctx, login)
+	if err != nil {
+		return false, errors.Wrap(err, "Limiter Fail")
+	}
+	lockedLogin, err := l.fail(ctx, key, l.loginPolicy)
+	if err != nil {
+		return false, err
+	}
+	lockedIP, err := l.fail(ctx, ipKeyPrefix+ip, l.ipPolicy)
+	if err != nil {
+		return false, err
+	}
+	return lockedLogin || lockedIP, nil
+}
+
+// Reset сбрасывает счётчик логина после успешного входа. Счётчик IP не
+// сбрасывается: иначе одним своим аккаунтом можно обнулять перебор чужих.
+func (l *Limiter) Reset(ctx context.Context, login string) error {
+	key, err := loginKey(ctx, login)
+	if err != nil {
+		return errors.Wrap(err, "Limiter Reset")
+	}
+	if err := l.store.Reset(ctx, key); err != nil {
+		return errors.Wrap(err, "Limiter Reset")
+	}
+	return nil
+}
+
+// loginKey - ключ счётчика логина: login:<организация>:<логин>.
+func loginKey(ctx context.Context, login string) (string, error) {
+	organizationID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return "", apperr.ErrNoTenant
+	}
+	return loginKeyPrefix + organizationID.String() + ":" + login, nil
+}
+
+func stricter(a, b Decision) Decision {
+	if a.Locked != b.Locked {
+		if a.Locked {
+			return a
+		}
+		return b
+	}
+	if b.RetryAfter > a.RetryAfter {
+		return b
+	}
+	return a
+}
+
+func (l *Limiter) check(ctx context.Context, key string, policy Policy) (Decision, error) {
+	attempt, err := l.store.Get(ctx, key)
+	if err != nil {
+		return Decision{}, errors.Wrap(err, "Limiter Check")
+	}
+	if attempt == nil {
+		return Decision{}, nil
+	}
+
+	now := l.now()
+	if now.Before(attempt.LockedUntil) {
+		return Decision{Locked: true, RetryAfter: attempt.LockedUntil.Sub(now)}, nil
+	}
+	if attempt.Failures == 0 || now.Sub(attempt.LastFailureAt) > policy.Window {
+		return Decision{}, nil
+	}
+
+	next := attempt.LastFailureAt.Add(policy.backoff(attempt.Failures))
+	if now.Before(next) {
+		return Decision{RetryAfter: next.Sub(now)}, nil
+	}
+	return Decision{}, nil
+}
+
+func (l *Limiter) fail(ctx context.Context, key string, policy Policy) (bool, error) {
+	now := l.now()
+
+	attempt, err := l.store.RecordFailure(ctx, key, now, policy.Window)
+	if err != nil {
+		return false, errors.Wrap(err, "Limiter Fail")
+	}
+	if policy.MaxFailures <= 0 || attempt.Failures < policy.MaxFailures {
+		return false, nil
+	}
+
+	if err := l.store.Lock(ctx, key, now.Add(policy.LockoutPeriod)); err != nil {
+		return false, errors.Wrap(err, "Limiter Fail")
+	}
+	return true, nil
+}
@@ -0,0 +1,190 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.now = f.now.Add(d)
+}
+
+func newTestLimiter(t *testing.T) (*Limiter, *fakeClock) {
+	store := NewMemoryStore(time.Hour, time.Hour)
+	t.Cleanup(store.Close)
+
+	policy := Policy{
+		MaxFailures:   3,
+		LockoutPeriod: 10 * time.Minute,
+		BaseDelay:     time.Second,
+		MaxDelay:      time.Minute,
+		Window:        time.Hour,
+	}
+	ipPolicy := policy
+	ipPolicy.MaxFailures = 5
+
+	clock := &fakeClock{now: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)}
+	limiter := NewLimiter(store, policy, ipPolicy)
+	limiter.now = clock.Now
+	return limiter, clock
+}
+
+func TestPolicy_Backoff(t *testing.T) {
+	policy := Policy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	assert.Equal(t, time.Duration(0), policy.backoff(0))
+	assert.Equal(t, time.Second, policy.backoff(1))
+	assert.Equal(t, 2*time.Second, policy.backoff(2))
+	assert.Equal(t, 4*time.Second, policy.backoff(3))
+	assert.Equal(t, 5*time.Second, policy.backoff(4))
+	assert.Equal(t, 5*time.Second, policy.backoff(100))
+}
+
+func TestLimiter_BackoffAndLockout(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), tenant.DefaultID)
+	limiter, clock := newTestLimiter(t)
+
+	decision, err := limiter.Check(ctx, "john", "10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed())
+
+	// Кейс 1: после первой неудачи нужно подождать BaseDelay
+	locked, err := limiter.Fail(ctx, "john", "10.0.0.1")
+	require.NoError(t, err)
+	assert.False(t, locked)
+
+	decision, err = limiter.Check(ctx, "john", "10.0.0.1")
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed())
+	assert.False(t, decision.Locked)
+	assert.Equal(t, time.Second, decision.RetryAfter)
+
+	// Кейс 2: пауза растёт экспоненциально
+	clock.Advance(time.Second)
+	_, err = limiter.Fail(ctx, "john", "10.0.0.1")
+	require.NoError(t, err)
+	decision, err = limiter.Check(ctx, "john", "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, decision.RetryAfter)
+
+	// Кейс 3: третья неудача блокирует логин
+	clock.Advance(2 * time.Second)
+	locked, err = limiter.Fail(ctx, "john", "10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, locked)
+
+	decision, err = limiter.Check(ctx, "john", "10.0.0.2")
+	require.NoError(t, err)
+	assert.True(t, decision.Locked)
+	assert.Equal(t, 10*time.Minute, decision.RetryAfter)
+
+	// Кейс 4: другой логин с того же IP не заблокирован
+	decision, err = limiter.Check(ctx, "jane", "10.0.0.3")
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed())
+
+	// Кейс 5: блокировка снимается по истечении срока
+	clock.Advance(10 * time.Minute)
+	decision, err = limiter.Check(ctx, "john", "10.0.0.2")
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed())
+}
+
+func TestLimiter_LockoutByIP(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), tenant.DefaultID)
+	limiter, clock := newTestLimiter(t)
+
+	// Перебор разных логинов с одного IP
+	var locked bool
+	for _, login := range []string{"a", "b", "c", "d", "e"} {
+		var err error
+		locked, err = limiter.Fail(ctx, login, "10.0.0.1")
+		require.NoError(t, err)
+		clock.Advance(time.Minute)
+	}
+	assert.True(t, locked)
+
+	decision, err := limiter.Check(ctx, "f", "10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, decision.Locked)
+
+	decision, err = limiter.Check(ctx, "f", "10.0.0.2")
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed())
+}
+
+func TestLimiter_ResetAndWindow(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), tenant.DefaultID)
+	limiter, clock := newTestLimiter(t)
+
+	_, err := limiter.Fail(ctx, "john", "10.0.0.1")
+	require.NoError(t, err)
+	clock.Advance(time.Second)
+	_, err = limiter.Fail(ctx, "john", "10.0.0.1")
+	require.NoError(t, err)
+
+	// Успешный вход сбрасывает счётчик логина
+	require.NoError(t, limiter.Reset(ctx, "john"))
+	clock.Advance(2 * time.Second)
+	locked, err := limiter.Fail(ctx, "john", "10.0.0.1")
+	require.NoError(t, err)
+	assert.False(t, locked)
+
+	// Неудачи старше окна забываются
+	clock.Advance(2 * time.Hour)
+	_, err = limiter.Fail(ctx, "john", "10.0.0.1")
+	require.NoError(t, err)
+	clock.Advance(time.Second)
+	locked, err = limiter.Fail(ctx, "john", "10.0.0.1")
+	require.NoError(t, err)
+	assert.False(t, locked)
+}
+
+func TestLimiter_OrganizationIsolation(t *testing.T) {
+	limiter, clock := newTestLimiter(t)
+	orgA := tenant.WithID(context.Background(), uuid.New())
+	orgB := tenant.WithID(context.Background(), uuid.New())
+
+	// Кейс 1: перебор alice в одной организации не блокирует alice в другой
+	var locked bool
+	for i := 0; i < 3; i++ {
+		var err error
+		locked, err = limiter.Fail(orgA, "alice", "10.0.0.1")
+		require.NoError(t, err)
+		clock.Advance(time.Minute)
+	}
+	require.True(t, locked)
+
+	decision, err := limiter.Check(orgA, "alice", "10.0.0.2")
+	require.NoError(t, err)
+	assert.True(t, decision.Locked)
+	decision, err = limiter.Check(orgB, "alice", "10.0.0.2")
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed())
+
+	// Кейс 2: успешный вход в другой организации не снимает блокировку
+	require.NoError(t, limiter.Reset(orgB, "alice"))
+	decision, err = limiter.Check(orgA, "alice", "10.0.0.2")
+	require.NoError(t, err)
+	assert.True(t, decision.Locked)
+
+	// Кейс 3: без организации счётчик не выбрать
+	_, err = limiter.Check(context.Background(), "alice", "10.0.0.2")
+	require.ErrorIs(t, err, apperr.ErrNoTenant)
+}
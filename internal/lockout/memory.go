@@ -0,0 +1,111 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type MemoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]*Attempt
+	done     chan struct{}
+}
+
+// NewMemoryStore хранит попытки в памяти процесса. Подходит только для
+// одной реплики; устаревшие записи раз в cleanupInterval удаляются.
+func NewMemoryStore(cleanupInterval time.Duration, retention time.Duration) *MemoryStore {
+	store := &MemoryStore{
+		attempts: make(map[string]*Attempt),
+		done:     make(chan struct{}),
+	}
+
+	store.runCleaner(cleanupInterval, retention)
+
+	return store
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (*Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	copied := *attempt
+	return &copied, nil
+}
+
+func (s *MemoryStore) RecordFailure(_ context.Context,
+	key string,
+	now time.Time,
+	window time.Duration) (*Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		attempt = &Attempt{}
+		s.attempts[key] = attempt
+	}
+	if now.Sub(attempt.LastFailureAt) > window {
+		attempt.Failures = 0
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = now
+
+	copied := *attempt
+	return &copied, nil
+}
+
+func (s *MemoryStore) Lock(_ context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		attempt = &Attempt{}
+		s.attempts[key] = attempt
+	}
+	attempt.Failures = 0
+	attempt.LockedUntil = until
+	return nil
+}
+
+func (s *MemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+func (s *MemoryStore) Close() {
+	close(s.done)
+}
+
+func (s *MemoryStore) runCleaner(cleanupInterval time.Duration, retention time.Duration) {
+	go func() {
+		ticker := time.NewTicker(cleanupInterval)
+		for {
+			select {
+			case <-ticker.C:
+				s.cleanExpired(time.Now(), retention)
+			case <-s.done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+func (s *MemoryStore) cleanExpired(now time.Time, retention time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, attempt := range s.attempts {
+		if now.After(attempt.LockedUntil) && now.Sub(attempt.LastFailureAt) > retention {
+			delete(s.attempts, key)
+		}
+	}
+}
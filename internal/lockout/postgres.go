@@ -0,0 +1,191 @@
+package lockout
+
+import (
+	"context"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/logger"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	attemptsTable       = "login_attempts"
+	keyColumn           = "key"
+	failuresColumn      = "failures"
+	lastFailureAtColumn = "last_failure_at"
+	lockedUntilColumn   = "locked_until"
+
+	cleanupTimeout = time.Minute
+)
+
+// PostgresStore хранит попытки в общей таблице, поэтому блокировка
+// действует сразу на всех репликах. Устаревшие записи раз в cleanupInterval
+// удаляются, иначе таблица растёт с каждым уникальным логином и IP.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+	done chan struct{}
+}
+
+func NewPostgresStore(pool *pgxpool.Pool, cleanupInterval time.Duration, retention time.Duration) *PostgresStore {
+	store := &PostgresStore{
+		pool: pool,
+		done: make(chan struct{}),
+	}
+
+	store.runCleaner(cleanupInterval, retention)
+
+	return store
+}
+
+func (s *PostgresStore) Get(ctx context.Context, key string) (*Attempt, error) {
+	builder := squirrel.Select(failuresColumn, lastFailureAtColumn, lockedUntilColumn).
+		From(attemptsTable).
+		Where(squirrel.Eq{keyColumn: key}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "PostgresStore Get ToSql")
+	}
+
+	attempt, err := scanAttempt(s.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "PostgresStore Get Scan")
+	}
+
+	return attempt, nil
+}
+
+// RecordFailure увеличивает счётчик одним запросом, чтобы параллельные
+// попытки с разных реплик не теряли инкременты.
+func (s *PostgresStore) RecordFailure(ctx context.Context,
+	key string,
+	now time.Time,
+	window time.Duration) (*Attempt, error) {
+	builder := squirrel.Insert(attemptsTable).
+		Columns(keyColumn, failuresColumn, lastFailureAtColumn).
+		Values(key, 1, now).
+		Suffix(`ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failure_at < ? THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+			RETURNING failures, last_failure_at, locked_until`, now.Add(-window)).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "PostgresStore RecordFailure ToSql")
+	}
+
+	attempt, err := scanAttempt(s.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		return nil, errors.Wrap(err, "PostgresStore RecordFailure Scan")
+	}
+
+	return attempt, nil
+}
+
+func (s *PostgresStore) Lock(ctx context.Context, key string, until time.Time) error {
+	builder := squirrel.Update(attemptsTable).
+		Set(failuresColumn, 0).
+		Set(lockedUntilColumn, until).
+		Where(squirrel.Eq{keyColumn: key}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "PostgresStore Lock ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "PostgresStore Lock Exec")
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) Reset(ctx context.Context, key string) error {
+	builder := squirrel.Delete(attemptsTable).
+		Where(squirrel.Eq{keyColumn: key}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "PostgresStore Reset ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "PostgresStore Reset Exec")
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) Close() {
+	close(s.done)
+}
+
+func (s *PostgresStore) runCleaner(cleanupInterval time.Duration, retention time.Duration) {
+	go func() {
+		ticker := time.NewTicker(cleanupInterval)
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+				if err := s.cleanExpired(ctx, time.Now(), retention); err != nil {
+					logger.Error("failed to clean login attempts",
+						zap.Error(err),
+					)
+				}
+				cancel()
+			case <-s.done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+// cleanExpired удаляет записи, у которых окно подсчёта неудач истекло и
+// блокировка уже не действует: для Limiter они неотличимы от отсутствующих.
+func (s *PostgresStore) cleanExpired(ctx context.Context, now time.Time, retention time.Duration) error {
+	builder := squirrel.Delete(attemptsTable).
+		Where(squirrel.Lt{lastFailureAtColumn: now.Add(-retention)}).
+		Where(squirrel.Or{
+			squirrel.Eq{lockedUntilColumn: nil},
+			squirrel.Lt{lockedUntilColumn: now},
+		}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "PostgresStore cleanExpired ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "PostgresStore cleanExpired Exec")
+	}
+
+	return nil
+}
+
+func scanAttempt(row pgx.Row) (*Attempt, error) {
+	var (
+		attempt     Attempt
+		lockedUntil *time.Time
+	)
+	if err := row.Scan(&attempt.Failures, &attempt.LastFailureAt, &lockedUntil); err != nil {
+		return nil, err
+	}
+	if lockedUntil != nil {
+		attempt.LockedUntil = *lockedUntil
+	}
+	return &attempt, nil
+}
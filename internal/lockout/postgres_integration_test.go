@@ -0,0 +1,59 @@
+//go:build integration
+// +build integration
+
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStore_CleanExpired(t *testing.T) {
+	connStr := "host=postgres user=postgres password=postgres dbname=testdb sslmode=disable"
+	pool, err := pgxpool.New(context.Background(), connStr)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	_, err = pool.Exec(context.Background(), "DELETE FROM login_attempts")
+	require.NoError(t, err)
+
+	store := NewPostgresStore(pool, time.Hour, time.Hour)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+	window := time.Hour
+
+	// Окно истекло, блокировки нет — запись удаляется
+	_, err = store.RecordFailure(ctx, "stale", now.Add(-2*window), window)
+	require.NoError(t, err)
+	// Окно истекло, но блокировка ещё действует — запись остаётся
+	_, err = store.RecordFailure(ctx, "locked", now.Add(-2*window), window)
+	require.NoError(t, err)
+	require.NoError(t, store.Lock(ctx, "locked", now.Add(window)))
+	// Окно истекло, блокировка уже снята — запись удаляется
+	_, err = store.RecordFailure(ctx, "unlocked", now.Add(-2*window), window)
+	require.NoError(t, err)
+	require.NoError(t, store.Lock(ctx, "unlocked", now.Add(-window)))
+	// Свежая неудача — запись остаётся
+	_, err = store.RecordFailure(ctx, "fresh", now, window)
+	require.NoError(t, err)
+
+	require.NoError(t, store.cleanExpired(ctx, now, window))
+
+	for key, kept := range map[string]bool{
+		"stale":    false,
+		"locked":   true,
+		"unlocked": false,
+		"fresh":    true,
+	} {
+		attempt, err := store.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, kept, attempt != nil, key)
+	}
+}
@@ -13,7 +13,7 @@ type zapLogger struct {
 	log *zap.Logger
 }
 
-var global zapLogger
+var global = zapLogger{log: zap.NewNop()}
 
 func Debug(msg string, fields ...zapcore.Field) {
 	global.log.Debug(msg, fields...)
@@ -22,6 +22,39 @@ var (
 		},
 		[]string{"status", "method"},
 	)
+	AuthzDeniedMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "authorization_denied_total",
+			Help: "Count of requests denied by authorization, labeled by route and method",
+		},
+		[]string{"route", "method"},
+	)
+	LoginFailuresMetric = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "login_failures_total",
+			Help: "Count of failed login attempts",
+		},
+	)
+	LoginLockoutsMetric = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "login_lockouts_total",
+			Help: "Count of lockouts caused by failed login attempts",
+		},
+	)
+	LoginRejectedMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "login_rejected_total",
+			Help: "Count of login attempts rejected before password check, labeled by reason",
+		},
+		[]string{"reason"},
+	)
+	APIKeyRequestsMetric = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "api_key_requests_total",
+			Help: "Count of requests authenticated by API key, labeled by key prefix",
+		},
+		[]string{"prefix"},
+	)
 	GoroutinesMetric = prometheus.NewGaugeFunc(
 		prometheus.GaugeOpts{
 			Name: "num_goroutines",
@@ -53,6 +86,11 @@ func InitMetrics(port string, cache *cache.CacheDecorator) {
 	c = cache
 	prometheus.MustRegister(GoroutinesMetric)
 	prometheus.MustRegister(HttpStatusMetric)
+	prometheus.MustRegister(AuthzDeniedMetric)
+	prometheus.MustRegister(LoginFailuresMetric)
+	prometheus.MustRegister(LoginLockoutsMetric)
+	prometheus.MustRegister(LoginRejectedMetric)
+	prometheus.MustRegister(APIKeyRequestsMetric)
 	prometheus.MustRegister(CacheMemoryUsage)
 	prometheus.MustRegister(CPUNumMetric)
 	http.Handle("/metrics", promhttp.Handler())
@@ -77,6 +115,26 @@ func HttpStatusMetricInc(statusCode int, method string) {
 	HttpStatusMetric.WithLabelValues(http.StatusText(statusCode), method).Inc()
 }
 
+func AuthzDeniedInc(route string, method string) {
+	AuthzDeniedMetric.WithLabelValues(route, method).Inc()
+}
+
+func LoginFailureInc() {
+	LoginFailuresMetric.Inc()
+}
+
+func LoginLockoutInc() {
+	LoginLockoutsMetric.Inc()
+}
+
+func LoginRejectedInc(reason string) {
+	LoginRejectedMetric.WithLabelValues(reason).Inc()
+}
+
+func APIKeyRequestInc(prefix string) {
+	APIKeyRequestsMetric.WithLabelValues(prefix).Inc()
+}
+
 var c *cache.CacheDecorator
 
 func GetCacheMetrics() float64 {
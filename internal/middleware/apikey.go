@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(context.Context, string) (*auth.Claims, error)
+}
+
+// AuthWithAPIKey принимает как Bearer-токен пользователя, так и заголовок
+// "Authorization: ApiKey ...". Ключ пропускается, только если ему выдано право scope.
+func AuthWithAPIKey(tokens TokenAuthenticator, keys APIKeyAuthenticator, scope string) gin.HandlerFunc {
+	bearer := Auth(tokens)
+
+	return func(c *gin.Context) {
+		scheme, key, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "ApiKey") {
+			bearer(c)
+			return
+		}
+		if key == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing api key"})
+			return
+		}
+
+		claims, err := keys.AuthenticateAPIKey(c.Request.Context(), key)
+		if err != nil {
+			logger.Debug("api key rejected",
+				zap.Error(err),
+			)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+			return
+		}
+		if !claims.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "api key lacks scope " + scope})
+			return
+		}
+
+		if !setClaims(c, claims) {
+			return
+		}
+		c.Next()
+	}
+}
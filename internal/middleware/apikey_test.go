@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type authenticatorFunc func(context.Context, string) (*auth.Claims, error)
+
+func (f authenticatorFunc) AuthenticateAccessToken(ctx context.Context, token string) (*auth.Claims, error) {
+	return f(ctx, token)
+}
+
+func (f authenticatorFunc) AuthenticateAPIKey(ctx context.Context, key string) (*auth.Claims, error) {
+	return f(ctx, key)
+}
+
+func TestAuthWithAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := uuid.New()
+	tokens := authenticatorFunc(func(_ context.Context, token string) (*auth.Claims, error) {
+		if token != "user-token" {
+			return nil, apperr.ErrInvalidToken
+		}
+		return auth.NewClaims(userID), nil
+	})
+	keys := authenticatorFunc(func(_ context.Context, key string) (*auth.Claims, error) {
+		if key != "lk_key" {
+			return nil, apperr.ErrInvalidToken
+		}
+		claims := auth.NewClaims(userID)
+		claims.APIKeyPrefix = "prefix"
+		claims.Scopes = []string{model.ScopeUsersRead}
+		return claims, nil
+	})
+
+	testCases := []struct {
+		caseName string
+		header   string
+		scope    string
+		status   int
+	}{
+		{caseName: "valid test: bearer token", header: "Bearer user-token", scope: model.ScopeUsersWrite, status: http.StatusOK},
+		{caseName: "valid test: api key with scope", header: "ApiKey lk_key", scope: model.ScopeUsersRead, status: http.StatusOK},
+		{caseName: "invalid test: api key without scope", header: "ApiKey lk_key", scope: model.ScopeUsersWrite, status: http.StatusForbidden},
+		{caseName: "invalid test: unknown api key", header: "ApiKey lk_other", scope: model.ScopeUsersRead, status: http.StatusUnauthorized},
+		{caseName: "invalid test: no header", scope: model.ScopeUsersRead, status: http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", AuthWithAPIKey(tokens, keys, tc.scope), func(c *gin.Context) {
+				subject, ok := auth.SubjectFromContext(c.Request.Context())
+				assert.True(t, ok)
+				assert.Equal(t, userID, subject)
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+}
+
+func TestAuthRejectsAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/", Auth(authenticatorFunc(func(context.Context, string) (*auth.Claims, error) {
+		return auth.NewClaims(uuid.New()), nil
+	})), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "ApiKey lk_key")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestOptionalAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := uuid.New()
+	tokens := authenticatorFunc(func(_ context.Context, token string) (*auth.Claims, error) {
+		if token != "user-token" {
+			return nil, apperr.ErrInvalidToken
+		}
+		return auth.NewClaims(userID), nil
+	})
+
+	testCases := []struct {
+		caseName string
+		header   string
+		status   int
+		signedIn bool
+	}{
+		{caseName: "valid test: no header", status: http.StatusOK},
+		{caseName: "valid test: bearer token", header: "Bearer user-token", status: http.StatusOK, signedIn: true},
+		{caseName: "invalid test: unknown token", header: "Bearer other", status: http.StatusUnauthorized},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/", OptionalAuth(tokens), func(c *gin.Context) {
+				_, ok := auth.ClaimsFromContext(c.Request.Context())
+				assert.Equal(t, tc.signedIn, ok)
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const ClaimsKey = "claims"
+
+type TokenAuthenticator interface {
+	AuthenticateAccessToken(context.Context, string) (*auth.Claims, error)
+}
+
+// Auth проверяет Bearer-токен и кладёт claims и организацию из них в контекст запроса.
+func Auth(authenticator TokenAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		claims, err := authenticator.AuthenticateAccessToken(c.Request.Context(), token)
+		if err != nil {
+			logger.Debug("access token rejected",
+				zap.Error(err),
+			)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		if !setClaims(c, claims) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// OptionalAuth пропускает запрос без токена, а переданный токен проверяет как Auth.
+// Нужен публичным маршрутам, которые ведут себя иначе для вошедшего пользователя.
+func OptionalAuth(authenticator TokenAuthenticator) gin.HandlerFunc {
+	required := Auth(authenticator)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		required(c)
+	}
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+	"github.com/lemavisaitov/lk-api/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// PermissionChecker возвращает итоговые права пользователя.
+type PermissionChecker interface {
+	UserPermissions(context.Context, uuid.UUID) ([]string, error)
+}
+
+// RequireSelfOrRole пропускает запрос, если пользователь из токена совпадает
+// с :param маршрута либо у него есть роль role. Должен стоять после Auth.
+func RequireSelfOrRole(param string, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := auth.ClaimsFromContext(c.Request.Context())
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		if claims.Subject == c.Param(param) || claims.HasRole(role) {
+			c.Next()
+			return
+		}
+
+		Forbid(c)
+	}
+}
+
+// RequireSelf пропускает только владельца :param, роли не учитываются.
+// Для действий, которые никто не должен делать за пользователя (второй фактор).
+func RequireSelf(param string) gin.HandlerFunc {
+	return RequireSelfOrRole(param, "")
+}
+
+// RequireRole пропускает только пользователей с ролью role. Должен стоять после Auth.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := auth.ClaimsFromContext(c.Request.Context())
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		if claims.HasRole(role) {
+			c.Next()
+			return
+		}
+
+		Forbid(c)
+	}
+}
+
+// RequirePermission пропускает пользователей, у которых есть все перечисленные
+// права. Права берутся из ролей, а не из токена, поэтому отзыв действует сразу.
+// Должен стоять после Auth.
+func RequirePermission(checker PermissionChecker, permissions ...string) gin.HandlerFunc {
+	return requirePermission("", checker, permissions)
+}
+
+// RequireSelfOrPermission пропускает владельца :param или пользователя со всеми правами.
+func RequireSelfOrPermission(param string, checker PermissionChecker, permissions ...string) gin.HandlerFunc {
+	return requirePermission(param, checker, permissions)
+}
+
+func requirePermission(param string, checker PermissionChecker, permissions []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := auth.ClaimsFromContext(c.Request.Context())
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+		if param != "" && claims.Subject == c.Param(param) {
+			c.Next()
+			return
+		}
+
+		userID, err := claims.UserID()
+		if err != nil {
+			Forbid(c)
+			return
+		}
+		granted, err := checker.UserPermissions(c, userID)
+		if err != nil {
+			logger.Error("failed to load permissions",
+				zap.String("subject", claims.Subject),
+				zap.Error(err),
+			)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check permissions"})
+			return
+		}
+
+		for _, permission := range permissions {
+			if !slices.Contains(granted, permission) {
+				Forbid(c)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// Forbid отвечает 403 с единым телом ошибки и учитывает отказ в метриках.
+func Forbid(c *gin.Context) {
+	logger.Info("access denied",
+		zap.String("subject", subjectOf(c)),
+		zap.String("actor", actorOf(c)),
+		zap.String("route", c.FullPath()),
+		zap.String("method", c.Request.Method),
+	)
+	metrics.AuthzDeniedInc(c.FullPath(), c.Request.Method)
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+}
+
+func subjectOf(c *gin.Context) string {
+	claims, ok := auth.ClaimsFromContext(c.Request.Context())
+	if !ok {
+		return ""
+	}
+	return claims.Subject
+}
+
+// actorOf возвращает сотрудника, если запрос сделан по токену имперсонации.
+func actorOf(c *gin.Context) string {
+	claims, ok := auth.ClaimsFromContext(c.Request.Context())
+	if !ok || !claims.Impersonated() {
+		return ""
+	}
+	return claims.Act.Subject
+}
@@ -0,0 +1,238 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireSelfOrRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	self := uuid.New()
+	other := uuid.New()
+
+	admin := auth.NewClaims(uuid.New())
+	admin.Roles = []string{model.RoleAdmin}
+
+	testCases := []struct {
+		caseName string
+		claims   *auth.Claims
+		target   uuid.UUID
+		status   int
+	}{
+		{
+			caseName: "valid test: self",
+			claims:   auth.NewClaims(self),
+			target:   self,
+			status:   http.StatusOK,
+		},
+		{
+			caseName: "valid test: admin",
+			claims:   admin,
+			target:   other,
+			status:   http.StatusOK,
+		},
+		{
+			caseName: "invalid test: other user",
+			claims:   auth.NewClaims(self),
+			target:   other,
+			status:   http.StatusForbidden,
+		},
+		{
+			caseName: "invalid test: no claims",
+			target:   self,
+			status:   http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			router := gin.New()
+			router.PUT("/user/:id", func(c *gin.Context) {
+				if tc.claims != nil {
+					c.Request = c.Request.WithContext(auth.WithClaims(c.Request.Context(), tc.claims))
+				}
+			}, RequireSelfOrRole("id", model.RoleAdmin), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/user/"+tc.target.String(), nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+			if tc.status == http.StatusForbidden {
+				assert.JSONEq(t, `{"error":"forbidden"}`, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireSelf(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	self := uuid.New()
+	admin := auth.NewClaims(uuid.New())
+	admin.Roles = []string{model.RoleAdmin}
+
+	for _, tc := range []struct {
+		caseName string
+		claims   *auth.Claims
+		status   int
+	}{
+		{caseName: "valid test: self", claims: auth.NewClaims(self), status: http.StatusOK},
+		{caseName: "invalid test: admin", claims: admin, status: http.StatusForbidden},
+	} {
+		t.Run(tc.caseName, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/user/:id/2fa/totp", func(c *gin.Context) {
+				c.Request = c.Request.WithContext(auth.WithClaims(c.Request.Context(), tc.claims))
+			}, RequireSelf("id"), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/user/"+self.String()+"/2fa/totp", nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+}
+
+// permissionsFunc отдаёт права из карты, для ключа uuid.Nil возвращает ошибку
+type permissionsFunc map[uuid.UUID][]string
+
+func (f permissionsFunc) UserPermissions(_ context.Context, id uuid.UUID) ([]string, error) {
+	if _, ok := f[uuid.Nil]; ok {
+		return nil, errors.New("db is down")
+	}
+	return f[id], nil
+}
+
+func TestRequireSelfOrPermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	self := uuid.New()
+	other := uuid.New()
+	manager := uuid.New()
+	reader := uuid.New()
+	checker := permissionsFunc{
+		manager: {model.PermissionUsersRead, model.PermissionUsersWrite},
+		reader:  {model.PermissionUsersRead},
+	}
+	admin := auth.NewClaims(other)
+	admin.Roles = []string{model.RoleAdmin}
+
+	testCases := []struct {
+		caseName string
+		claims   *auth.Claims
+		checker  permissionsFunc
+		target   uuid.UUID
+		status   int
+	}{
+		{
+			caseName: "valid test: self without permissions",
+			claims:   auth.NewClaims(self),
+			checker:  checker,
+			target:   self,
+			status:   http.StatusOK,
+		},
+		{
+			caseName: "valid test: all permissions granted",
+			claims:   auth.NewClaims(manager),
+			checker:  checker,
+			target:   other,
+			status:   http.StatusOK,
+		},
+		{
+			caseName: "invalid test: one permission missing",
+			claims:   auth.NewClaims(reader),
+			checker:  checker,
+			target:   other,
+			status:   http.StatusForbidden,
+		},
+		{
+			caseName: "invalid test: admin role in token is not a permission",
+			claims:   admin,
+			checker:  checker,
+			target:   self,
+			status:   http.StatusForbidden,
+		},
+		{
+			caseName: "invalid test: checker error",
+			claims:   auth.NewClaims(manager),
+			checker:  permissionsFunc{uuid.Nil: nil},
+			target:   other,
+			status:   http.StatusInternalServerError,
+		},
+		{
+			caseName: "invalid test: no claims",
+			checker:  checker,
+			target:   self,
+			status:   http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			router := gin.New()
+			router.PUT("/user/:id", func(c *gin.Context) {
+				if tc.claims != nil {
+					c.Request = c.Request.WithContext(auth.WithClaims(c.Request.Context(), tc.claims))
+				}
+			}, RequireSelfOrPermission("id", tc.checker, model.PermissionUsersRead, model.PermissionUsersWrite),
+				func(c *gin.Context) {
+					c.Status(http.StatusOK)
+				})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/user/"+tc.target.String(), nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	self := uuid.New()
+	impersonator := uuid.New()
+	checker := permissionsFunc{impersonator: {model.PermissionUsersImpersonate}}
+
+	for _, tc := range []struct {
+		caseName string
+		claims   *auth.Claims
+		status   int
+	}{
+		{caseName: "valid test: permission granted", claims: auth.NewClaims(impersonator), status: http.StatusOK},
+		{caseName: "invalid test: self is not enough", claims: auth.NewClaims(self), status: http.StatusForbidden},
+	} {
+		t.Run(tc.caseName, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/user/:id/impersonate", func(c *gin.Context) {
+				c.Request = c.Request.WithContext(auth.WithClaims(c.Request.Context(), tc.claims))
+			}, RequirePermission(checker, model.PermissionUsersImpersonate), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/user/"+self.String()+"/impersonate", nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+}
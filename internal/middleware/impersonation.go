@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+	"github.com/lemavisaitov/lk-api/internal/metrics"
+	"github.com/lemavisaitov/lk-api/internal/requestid"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ForbidImpersonation закрывает маршрут для токенов имперсонации: смену
+// учётных данных, удаление аккаунта и т.п. Должен стоять после Auth.
+func ForbidImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := auth.ClaimsFromContext(c.Request.Context())
+		if !ok || !claims.Impersonated() {
+			c.Next()
+			return
+		}
+
+		logger.Info("action forbidden while impersonating",
+			zap.String("actor", claims.Act.Subject),
+			zap.String("subject", claims.Subject),
+			zap.String("route", c.FullPath()),
+			zap.String("method", c.Request.Method),
+		)
+		metrics.AuthzDeniedInc(c.FullPath(), c.Request.Method)
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "not allowed while impersonating"})
+	}
+}
+
+// AuditImpersonation пишет в лог каждый запрос, сделанный по токену
+// имперсонации, вместе с сотрудником. Ставится на весь роутер: claims
+// появляются в контексте позже, поэтому проверяются после обработки.
+func AuditImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		claims, ok := auth.ClaimsFromContext(c.Request.Context())
+		if !ok || !claims.Impersonated() {
+			return
+		}
+		logger.Info("impersonated request",
+			zap.String("impersonationID", claims.SID),
+			zap.String("requestID", requestid.FromContext(c.Request.Context())),
+			zap.String("actor", claims.Act.Subject),
+			zap.String("subject", claims.Subject),
+			zap.String("route", c.FullPath()),
+			zap.String("method", c.Request.Method),
+			zap.Int("status", c.Writer.Status()),
+		)
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lemavisaitov/lk-api/internal/auth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForbidImpersonation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	impersonated := auth.NewClaims(uuid.New())
+	impersonated.Act = &auth.Actor{Subject: uuid.NewString()}
+
+	testCases := []struct {
+		caseName string
+		claims   *auth.Claims
+		status   int
+	}{
+		{
+			caseName: "valid test: user token",
+			claims:   auth.NewClaims(uuid.New()),
+			status:   http.StatusOK,
+		},
+		{
+			caseName: "valid test: no claims",
+			status:   http.StatusOK,
+		},
+		{
+			caseName: "invalid test: impersonation token",
+			claims:   impersonated,
+			status:   http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			router := gin.New()
+			router.DELETE("/user/:id", func(c *gin.Context) {
+				if tc.claims != nil {
+					c.Request = c.Request.WithContext(auth.WithClaims(c.Request.Context(), tc.claims))
+				}
+			}, ForbidImpersonation(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodDelete, "/user/"+uuid.NewString(), nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+			if tc.status == http.StatusForbidden {
+				assert.JSONEq(t, `{"error":"not allowed while impersonating"}`, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// MemberRoleChecker возвращает роль пользователя в организации из контекста.
+type MemberRoleChecker interface {
+	MemberRole(context.Context, uuid.UUID) (string, error)
+}
+
+// RequireMemberRole пропускает участников организации с одной из ролей. Роль берётся
+// из БД, а не из токена: после передачи владения прежний владелец сразу теряет права.
+// Должен стоять после Auth.
+func RequireMemberRole(members MemberRoleChecker, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := auth.ClaimsFromContext(c.Request.Context())
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		userID, err := claims.UserID()
+		if err != nil {
+			Forbid(c)
+			return
+		}
+		role, err := members.MemberRole(c.Request.Context(), userID)
+		if errors.Is(err, apperr.ErrNotFound) {
+			Forbid(c)
+			return
+		}
+		if err != nil {
+			logger.Error("failed to load member role",
+				zap.String("subject", claims.Subject),
+				zap.Error(err),
+			)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check permissions"})
+			return
+		}
+
+		if !slices.Contains(roles, role) {
+			Forbid(c)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// memberRolesFunc отдаёт роль из карты, для ключа uuid.Nil возвращает ошибку
+type memberRolesFunc map[uuid.UUID]string
+
+func (f memberRolesFunc) MemberRole(_ context.Context, id uuid.UUID) (string, error) {
+	if _, ok := f[uuid.Nil]; ok {
+		return "", errors.New("db is down")
+	}
+	role, ok := f[id]
+	if !ok {
+		return "", apperr.ErrNotFound
+	}
+	return role, nil
+}
+
+func TestRequireMemberRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	owner := uuid.New()
+	admin := uuid.New()
+	member := uuid.New()
+	stranger := uuid.New()
+	members := memberRolesFunc{
+		owner:  model.MemberRoleOwner,
+		admin:  model.MemberRoleAdmin,
+		member: model.MemberRoleMember,
+	}
+
+	for _, tc := range []struct {
+		caseName string
+		members  memberRolesFunc
+		claims   *auth.Claims
+		status   int
+	}{
+		{caseName: "valid test: owner", members: members, claims: auth.NewClaims(owner), status: http.StatusOK},
+		{caseName: "valid test: admin", members: members, claims: auth.NewClaims(admin), status: http.StatusOK},
+		{caseName: "invalid test: member", members: members, claims: auth.NewClaims(member),
+			status: http.StatusForbidden},
+		{caseName: "invalid test: not a member", members: members, claims: auth.NewClaims(stranger),
+			status: http.StatusForbidden},
+		{caseName: "invalid test: no token", members: members, status: http.StatusUnauthorized},
+		{caseName: "invalid test: checker error", members: memberRolesFunc{uuid.Nil: ""},
+			claims: auth.NewClaims(owner), status: http.StatusInternalServerError},
+	} {
+		t.Run(tc.caseName, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/organization/invitations", func(c *gin.Context) {
+				if tc.claims != nil {
+					c.Request = c.Request.WithContext(auth.WithClaims(c.Request.Context(), tc.claims))
+				}
+			}, RequireMemberRole(tc.members, model.MemberRoleOwner, model.MemberRoleAdmin), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/organization/invitations", nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/lemavisaitov/lk-api/internal/requestid"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID берёт X-Request-ID из запроса или создаёт новый, возвращает его
+// в ответе и кладёт в контекст запроса для логов и аудита.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := requestid.FromHeader(c.GetHeader(requestid.Header))
+
+		c.Header(requestid.Header, id)
+		c.Request = c.Request.WithContext(requestid.WithID(c.Request.Context(), id))
+		c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lemavisaitov/lk-api/internal/requestid"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var seen string
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/", func(c *gin.Context) {
+		seen = requestid.FromContext(c.Request.Context())
+	})
+
+	// Кейс 1: идентификатор от прокси сохраняется
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(requestid.Header, "edge-42.a_b")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "edge-42.a_b", seen)
+	assert.Equal(t, "edge-42.a_b", w.Header().Get(requestid.Header))
+
+	// Кейс 2: недопустимый идентификатор заменяется новым
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(requestid.Header, "bad id\r\nX-Injected: 1")
+	router.ServeHTTP(w, req)
+	_, err := uuid.Parse(seen)
+	require.NoError(t, err)
+	assert.Equal(t, seen, w.Header().Get(requestid.Header))
+}
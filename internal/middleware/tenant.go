@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// OrganizationResolver находит организацию по slug из заголовка.
+type OrganizationResolver interface {
+	OrganizationIDBySlug(context.Context, string) (uuid.UUID, error)
+}
+
+// Tenant кладёт в контекст организацию из заголовка X-Organization или параметра
+// organization, без них - организацию по умолчанию. Auth затем заменяет её
+// организацией из токена, так что заголовок не даёт доступа к чужим пользователям.
+func Tenant(resolver OrganizationResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		slug := c.GetHeader(tenant.Header)
+		if slug == "" {
+			slug = c.Query(tenant.QueryParam)
+		}
+
+		organizationID := tenant.DefaultID
+		if slug != "" {
+			id, err := resolver.OrganizationIDBySlug(c.Request.Context(), slug)
+			if errors.Is(err, apperr.ErrNotFound) {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "unknown organization"})
+				return
+			}
+			if err != nil {
+				logger.Error("failed to resolve organization",
+					zap.String("organization", slug),
+					zap.Error(err),
+				)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to resolve organization"})
+				return
+			}
+			organizationID = id
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), organizationID))
+		c.Next()
+	}
+}
+
+// RequireOrganization пропускает только запросы в организацию id. Нужен для
+// настроек, общих для всех организаций, и открытой регистрации. После Auth
+// проверяется организация из токена, без него - из заголовка.
+func RequireOrganization(id uuid.UUID) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		organizationID, ok := tenant.FromContext(c.Request.Context())
+		if ok && organizationID == id {
+			c.Next()
+			return
+		}
+
+		Forbid(c)
+	}
+}
+
+// setClaims кладёт claims и организацию из них в контекст запроса.
+func setClaims(c *gin.Context, claims *auth.Claims) bool {
+	organizationID, err := claims.OrganizationID()
+	if err != nil {
+		logger.Debug("token rejected",
+			zap.Error(err),
+		)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return false
+	}
+
+	c.Set(ClaimsKey, claims)
+	ctx := auth.WithClaims(c.Request.Context(), claims)
+	c.Request = c.Request.WithContext(tenant.WithID(ctx, organizationID))
+	return true
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// organizationsFunc ищет организацию по slug, для "broken" возвращает ошибку
+type organizationsFunc map[string]uuid.UUID
+
+func (f organizationsFunc) OrganizationIDBySlug(_ context.Context, slug string) (uuid.UUID, error) {
+	if slug == "broken" {
+		return uuid.Nil, errors.New("db is down")
+	}
+	id, ok := f[slug]
+	if !ok {
+		return uuid.Nil, apperr.ErrNotFound
+	}
+	return id, nil
+}
+
+func TestTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	acme := uuid.New()
+	other := uuid.New()
+	token := auth.NewClaims(uuid.New())
+	token.Org = other.String()
+
+	var seen uuid.UUID
+	router := gin.New()
+	router.Use(Tenant(organizationsFunc{"acme": acme}))
+	router.GET("/", func(c *gin.Context) {
+		seen, _ = tenant.FromContext(c.Request.Context())
+	})
+	router.GET("/me", func(c *gin.Context) {
+		setClaims(c, token)
+	}, func(c *gin.Context) {
+		seen, _ = tenant.FromContext(c.Request.Context())
+	})
+
+	testCases := []struct {
+		caseName string
+		path     string
+		header   string
+		status   int
+		tenant   uuid.UUID
+	}{
+		{caseName: "valid test: default organization", path: "/", status: http.StatusOK, tenant: tenant.DefaultID},
+		{caseName: "valid test: header", path: "/", header: "acme", status: http.StatusOK, tenant: acme},
+		{caseName: "valid test: query", path: "/?organization=acme", status: http.StatusOK, tenant: acme},
+		{caseName: "valid test: token wins over header", path: "/me", header: "acme", status: http.StatusOK,
+			tenant: other},
+		{caseName: "invalid test: unknown organization", path: "/", header: "nope", status: http.StatusBadRequest},
+		{caseName: "invalid test: resolver error", path: "/", header: "broken",
+			status: http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			seen = uuid.Nil
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.header != "" {
+				req.Header.Set(tenant.Header, tc.header)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, tc.tenant, seen)
+		})
+	}
+}
+
+func TestRequireOrganization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, tc := range []struct {
+		caseName string
+		claims   *auth.Claims
+		status   int
+	}{
+		{caseName: "valid test: token without org", claims: auth.NewClaims(uuid.New()), status: http.StatusOK},
+		{caseName: "invalid test: other organization",
+			claims: &auth.Claims{Org: uuid.NewString()}, status: http.StatusForbidden},
+		{caseName: "invalid test: malformed org", claims: &auth.Claims{Org: "acme"}, status: http.StatusUnauthorized},
+	} {
+		t.Run(tc.caseName, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/rbac/roles", func(c *gin.Context) {
+				setClaims(c, tc.claims)
+			}, RequireOrganization(tenant.DefaultID), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/rbac/roles", nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+}
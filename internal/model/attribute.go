@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// Типы значений дополнительных атрибутов профиля
+const (
+	AttributeTypeString  = "string"
+	AttributeTypeNumber  = "number"
+	AttributeTypeBoolean = "boolean"
+)
+
+// AttributeDefinition описывает дополнительный атрибут профиля. MaxLength и
+// Enum применимы только к строкам, MaxLength 0 - без ограничения длины.
+type AttributeDefinition struct {
+	Name        string    `json:"name" validate:"required,max=64"`
+	Type        string    `json:"type" validate:"required,oneof=string number boolean"`
+	Required    bool      `json:"required"`
+	MaxLength   int       `json:"max_length" validate:"gte=0"`
+	Enum        []string  `json:"enum" validate:"dive,required,max=255"`
+	Description string    `json:"description" validate:"max=255"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// UpdateAttributeDefinitionRequest заменяет ограничения атрибута. Тип не меняется:
+// уже сохранённые значения перестали бы ему соответствовать.
+type UpdateAttributeDefinitionRequest struct {
+	Required    bool     `json:"required"`
+	MaxLength   int      `json:"max_length" validate:"gte=0"`
+	Enum        []string `json:"enum" validate:"dive,required,max=255"`
+	Description string   `json:"description" validate:"max=255"`
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Действия, которые попадают в журнал аудита
+const (
+	AuditUserCreate       = "user.create"
+	AuditUserUpdate       = "user.update"
+	AuditUserDelete       = "user.delete"
+	AuditUserRestore      = "user.restore"
+	AuditUserPurge        = "user.purge"
+	AuditUserLogin        = "user.login"
+	AuditUserLoginFailure = "user.login_failed"
+	AuditMemberRole       = "member.role"
+)
+
+var AuditActions = []string{
+	AuditUserCreate,
+	AuditUserUpdate,
+	AuditUserDelete,
+	AuditUserRestore,
+	AuditUserPurge,
+	AuditUserLogin,
+	AuditUserLoginFailure,
+	AuditMemberRole,
+}
+
+// AuditRedacted заменяет значения секретных полей в изменениях
+const AuditRedacted = "[REDACTED]"
+
+// AuditEvent - неизменяемая запись журнала. ActorID пуст у анонимных
+// действий (регистрация, неудачный вход), ImpersonatorID заполнен, если
+// действие сделал сотрудник от имени пользователя.
+type AuditEvent struct {
+	ID             uuid.UUID              `json:"id"`
+	Action         string                 `json:"action"`
+	ActorID        *uuid.UUID             `json:"actor_id,omitempty"`
+	ImpersonatorID *uuid.UUID             `json:"impersonator_id,omitempty"`
+	TargetID       *uuid.UUID             `json:"target_id,omitempty"`
+	Changes        map[string]AuditChange `json:"changes,omitempty"`
+	Details        map[string]string      `json:"details,omitempty"`
+	IP             string                 `json:"ip"`
+	RequestID      string                 `json:"request_id"`
+	CreatedAt      time.Time              `json:"created_at"`
+}
+
+// AuditChange - значение поля до и после действия. У созданных полей нет Old, у удалённых - New.
+type AuditChange struct {
+	Old any `json:"old,omitempty"`
+	New any `json:"new,omitempty"`
+}
+
+// AuditFilter отбирает события. UserID совпадает и с автором, и с целью события.
+type AuditFilter struct {
+	UserID uuid.UUID
+	Action string
+	From   *time.Time
+	To     *time.Time
+}
+
+// AuditPosition - последнее событие предыдущей страницы
+type AuditPosition struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+type AuditQuery struct {
+	UserID string `form:"user_id" validate:"omitempty,uuid"`
+	Action string `form:"action" validate:"omitempty,max=64"`
+	// RFC 3339, граница From включается, To - нет
+	From   string `form:"from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To     string `form:"to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" validate:"gte=0,lte=200"`
+}
+
+type AuditPage struct {
+	Events     []AuditEvent `json:"events"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
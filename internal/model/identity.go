@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Identity связывает пользователя с аккаунтом у внешнего провайдера.
+// У пользователя не больше одной привязки к каждому провайдеру.
+type Identity struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"subject"`
+	Email       string     `json:"email,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// ExternalLoginResult - итог возврата от провайдера: вход, регистрация или привязка.
+type ExternalLoginResult struct {
+	User    *User
+	Created bool
+	Linked  bool
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Impersonation - запись о входе сотрудника (actor) под пользователем (subject).
+// ID совпадает с сессией, которая открывается для токена имперсонации.
+type Impersonation struct {
+	ID        uuid.UUID  `json:"id"`
+	ActorID   uuid.UUID  `json:"actor_id"`
+	SubjectID uuid.UUID  `json:"subject_id"`
+	Reason    string     `json:"reason"`
+	IP        string     `json:"ip"`
+	StartedAt time.Time  `json:"started_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+}
+
+func (i *Impersonation) Active(now time.Time) bool {
+	return i.EndedAt == nil && now.Before(i.ExpiresAt)
+}
+
+// ImpersonationFilter отбирает записи по сотруднику и/или пользователю. Пустые поля не учитываются.
+type ImpersonationFilter struct {
+	ActorID   uuid.UUID
+	SubjectID uuid.UUID
+}
+
+type StartImpersonationRequest struct {
+	// Причина обязательна: по ней разбирают записи аудита
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// ImpersonationToken выдаётся без refresh-токена: продлить имперсонацию можно только заново.
+type ImpersonationToken struct {
+	ImpersonationID uuid.UUID `json:"impersonation_id"`
+	AccessToken     string    `json:"access_token"`
+	TokenType       string    `json:"token_type"`
+	ExpiresIn       int       `json:"expires_in"`
+}
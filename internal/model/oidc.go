@@ -0,0 +1,135 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Права, которые может запросить приложение, входящее через lk-api
+const (
+	OIDCScopeOpenID  = "openid"
+	OIDCScopeProfile = "profile"
+	OIDCScopeEmail   = "email"
+)
+
+// OAuthClient - приложение, зарегистрированное для входа через lk-api.
+// У публичных клиентов (SPA, мобильные) секрета нет, их защищает только PKCE.
+type OAuthClient struct {
+	ID           string    `json:"client_id"`
+	Name         string    `json:"name"`
+	SecretHash   string    `json:"-"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Public       bool      `json:"public"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type RegisterClientRequest struct {
+	Name         string   `json:"name" validate:"required,max=100"`
+	RedirectURIs []string `json:"redirect_uris" validate:"required,min=1,dive,url"`
+	Public       bool     `json:"public"`
+}
+
+// RegisteredClient возвращается один раз при регистрации: секрет хранится только хэшем.
+type RegisteredClient struct {
+	OAuthClient
+	Secret string `json:"client_secret,omitempty"`
+}
+
+// AuthorizationCode живёт до обмена на токены и используется один раз.
+type AuthorizationCode struct {
+	CodeHash      string
+	ClientID      string
+	UserID        uuid.UUID
+	RedirectURI   string
+	Scopes        []string
+	Nonce         string
+	CodeChallenge string
+	ExpiresAt     time.Time
+}
+
+// OAuthConsent - согласие пользователя выдать приложению перечисленные права.
+type OAuthConsent struct {
+	UserID    uuid.UUID `json:"user_id"`
+	ClientID  string    `json:"client_id"`
+	Scopes    []string  `json:"scopes"`
+	GrantedAt time.Time `json:"granted_at"`
+}
+
+// AuthorizeRequest - параметры /oauth/authorize. Consent передаёт
+// решение пользователя: granted или denied.
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	Nonce               string `form:"nonce"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+	Consent             string `form:"consent"`
+}
+
+// AuthorizeResult - либо адрес возврата в приложение, либо запрос согласия.
+type AuthorizeResult struct {
+	RedirectTo string
+	Consent    *ConsentPrompt
+}
+
+type ConsentPrompt struct {
+	ConsentRequired bool     `json:"consent_required"`
+	ClientID        string   `json:"client_id"`
+	ClientName      string   `json:"client_name"`
+	Scopes          []string `json:"scopes"`
+}
+
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	CodeVerifier string `form:"code_verifier"`
+}
+
+type OIDCTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	IDToken     string `json:"id_token"`
+	Scope       string `json:"scope"`
+}
+
+// UserInfo - ответ /oauth/userinfo. Поля заполняются по выданным правам.
+type UserInfo struct {
+	Subject           string `json:"sub"`
+	Name              string `json:"name,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Email             string `json:"email,omitempty"`
+	EmailVerified     *bool  `json:"email_verified,omitempty"`
+}
+
+type OIDCDiscovery struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// Пути протокола: их публикует discovery-документ, поэтому они заданы здесь, а не только в роутере
+const (
+	OIDCDiscoveryPath = "/.well-known/openid-configuration"
+	OIDCJWKSPath      = "/.well-known/jwks.json"
+	OIDCAuthorizePath = "/oauth/authorize"
+	OIDCTokenPath     = "/oauth/token"
+	OIDCUserInfoPath  = "/oauth/userinfo"
+)
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Organization - клиент, чьи пользователи изолированы от остальных. Slug
+// передаётся в заголовке X-Organization при входе и регистрации.
+type Organization struct {
+	ID        uuid.UUID `json:"id"`
+	Slug      string    `json:"slug" validate:"required,max=63"`
+	Name      string    `json:"name" validate:"required,max=255"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Роли пользователя в его организации. Это не роли RBAC: они дают право управлять
+// участниками и приглашениями своей организации, но не пользователями вообще.
+const (
+	MemberRoleOwner  = "owner"
+	MemberRoleAdmin  = "admin"
+	MemberRoleMember = "member"
+)
+
+// Чем меньше, тем больше прав
+var memberRoleRank = map[string]int{
+	MemberRoleOwner:  0,
+	MemberRoleAdmin:  1,
+	MemberRoleMember: 2,
+}
+
+// MemberRoleOutranks сообщает, даёт ли role больше прав, чем other.
+func MemberRoleOutranks(role string, other string) bool {
+	return memberRoleRank[role] < memberRoleRank[other]
+}
+
+type Member struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Login     string    `json:"login"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email,omitempty"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UpdateMemberRoleRequest struct {
+	// Владельца назначает только передача владения
+	Role string `json:"role" validate:"required,oneof=admin member"`
+}
+
+type TransferOwnershipRequest struct {
+	UserID uuid.UUID `json:"user_id" validate:"required"`
+}
+
+// Invitation - приглашение в организацию на адрес почты. Токен уходит в письме,
+// в БД хранится только хэш.
+type Invitation struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	Email          string     `json:"email"`
+	Role           string     `json:"role"`
+	InvitedBy      *uuid.UUID `json:"invited_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	TokenHash      string     `json:"-"`
+}
+
+type CreateInvitationRequest struct {
+	Email string `json:"email" validate:"required,email,max=320"`
+	Role  string `json:"role" validate:"required,oneof=admin member"`
+}
+
+type OwnerInvitationRequest struct {
+	Email string `json:"email" validate:"required,email,max=320"`
+}
+
+// AcceptInvitationRequest - данные учётной записи нужны, только если на почту
+// из приглашения в организации ещё никто не зарегистрирован. Age необязателен:
+// 0 - возраст неизвестен, как у входа через внешнего провайдера.
+type AcceptInvitationRequest struct {
+	Token      string         `json:"token" validate:"required"`
+	Login      string         `json:"login"`
+	Password   string         `json:"password"`
+	Name       string         `json:"name"`
+	Age        int            `json:"age" validate:"gte=0"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// Invitee - кто принимает приглашение: участник организации UserID или, если задан
+// NewUser, новый пользователь. Пароль NewUser уже захэширован.
+type Invitee struct {
+	UserID  uuid.UUID
+	NewUser *User
+}
+
+type DeclineInvitationRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type AcceptInvitationResult struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Role    string    `json:"role"`
+	Created bool      `json:"created"`
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Права, из которых собираются роли
+const (
+	PermissionUsersRead        = "users:read"
+	PermissionUsersWrite       = "users:write"
+	PermissionUsersDelete      = "users:delete"
+	PermissionUsersImpersonate = "users:impersonate"
+	PermissionAuditRead        = "audit:read"
+	// Управление ролями, атрибутами, организациями и OAuth-клиентами
+	PermissionAdminManage = "admin:manage"
+)
+
+var Permissions = []string{
+	PermissionUsersRead,
+	PermissionUsersWrite,
+	PermissionUsersDelete,
+	PermissionUsersImpersonate,
+	PermissionAuditRead,
+	PermissionAdminManage,
+}
+
+type Role struct {
+	Name        string    `json:"name" validate:"required,max=64,excludesall=/"`
+	Description string    `json:"description" validate:"max=255"`
+	Permissions []string  `json:"permissions" validate:"dive,oneof=users:read users:write users:delete users:impersonate audit:read admin:manage"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type UpdateRoleRequest struct {
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions" validate:"dive,oneof=users:read users:write users:delete users:impersonate audit:read admin:manage"`
+}
+
+// Group даёт свои роли всем участникам.
+type Group struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Roles       []string  `json:"roles"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CreateGroupRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=255"`
+}
+
+// EffectivePermissions - права пользователя с учётом ролей его групп.
+type EffectivePermissions struct {
+	UserID      uuid.UUID `json:"user_id"`
+	Permissions []string  `json:"permissions"`
+}
@@ -1,25 +1,217 @@
 package model
 
 import (
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
 )
 
+const RoleAdmin = "admin"
+
 type User struct {
 	ID       uuid.UUID `json:"id" validate:"required,uuid"`
 	Age      int       `json:"age" validate:"gte=0"`
 	Login    string    `json:"login" validate:"required"`
 	Password string    `json:"password" validate:"required"`
 	Name     string    `json:"name" validate:"required"`
+	Email    string    `json:"email" validate:"required,email,max=320"`
+	// Заполняются только из БД, при регистрации игнорируются
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	Roles           []string   `json:"roles,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	// Дополнительные поля профиля, проверяются по справочнику атрибутов
+	Attributes map[string]any `json:"attributes,omitempty"`
+	// Текущий аватар, nil - не загружен
+	AvatarID *uuid.UUID `json:"-"`
+	// Организация задаётся контекстом запроса, а не телом
+	OrganizationID uuid.UUID `json:"-"`
+	// Растёт при каждом изменении, клиент получает её в ETag
+	Version int64 `json:"-"`
 }
 
+// UpdateUserRequest - изменения пользователя. nil-поле не меняется,
+// пустая Email удаляет почту.
 type UpdateUserRequest struct {
-	ID       uuid.UUID `json:"id" validate:"uuid"`
-	Age      int       `json:"age" validate:"gte=0"`
-	Password string    `json:"password"`
-	Name     string    `json:"name"`
+	ID       uuid.UUID
+	Age      *int
+	Password *string
+	Name     *string
+	Email    *string
+	// Заменяет атрибуты целиком
+	Attributes map[string]any
+	// Версия из If-Match; 0 - изменить без проверки версии
+	Version int64
+}
+
+func (r UpdateUserRequest) Empty() bool {
+	return r.Age == nil && r.Password == nil && r.Name == nil && r.Email == nil && r.Attributes == nil
+}
+
+// ReplaceUserRequest - тело PUT, заменяет пользователя целиком.
+type ReplaceUserRequest struct {
+	Name string `json:"name" validate:"required"`
+	Age  *int   `json:"age" validate:"required,gte=0"`
+	// Пустая почта удаляет её, смена почты сбрасывает подтверждение
+	Email string `json:"email" validate:"omitempty,email,max=320"`
+	// Пароль не входит в представление пользователя, пустой оставляет прежний
+	Password   string         `json:"password"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// UserPatch - представление пользователя, к которому применяется PATCH.
+// Пароль через PATCH не меняется.
+type UserPatch struct {
+	Name       string         `json:"name" validate:"required"`
+	Age        *int           `json:"age" validate:"required,gte=0"`
+	Email      string         `json:"email,omitempty" validate:"omitempty,email,max=320"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+func NewUserPatch(user *User) UserPatch {
+	age := user.Age
+	attributes := user.Attributes
+	// Пустой объект нужен, чтобы JSON Patch мог добавлять в него по пути /attributes/...
+	if attributes == nil {
+		attributes = map[string]any{}
+	}
+	return UserPatch{Name: user.Name, Age: &age, Email: user.Email, Attributes: attributes}
+}
+
+// Changes возвращает только поля, которые отличаются от current.
+func (p UserPatch) Changes(current *User) UpdateUserRequest {
+	changes := UpdateUserRequest{ID: current.ID}
+	if p.Name != current.Name {
+		changes.Name = &p.Name
+	}
+	if *p.Age != current.Age {
+		changes.Age = p.Age
+	}
+	if p.Email != current.Email {
+		changes.Email = &p.Email
+	}
+	if !attributesEqual(p.Attributes, current.Attributes) {
+		changes.Attributes = p.Attributes
+		if changes.Attributes == nil {
+			changes.Attributes = map[string]any{}
+		}
+	}
+	return changes
+}
+
+// PurgedUser - безвозвратно удалённый пользователь. AvatarID нужен, чтобы
+// убрать файлы аватара, которые хранятся вне БД.
+type PurgedUser struct {
+	ID       uuid.UUID
+	AvatarID *uuid.UUID
 }
 
 type LoginRequest struct {
 	Login    string `json:"login" validate:"required"`
 	Password string `json:"password" validate:"required"`
+	Device   string `json:"device" validate:"max=255"`
+}
+
+// attributesEqual не различает пустые и отсутствующие атрибуты.
+func attributesEqual(a, b map[string]any) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+func (u *User) HasRole(role string) bool {
+	return slices.Contains(u.Roles, role)
+}
+
+// Колонки, по которым можно сортировать список пользователей
+const (
+	UserSortLogin     = "login"
+	UserSortName      = "name"
+	UserSortAge       = "age"
+	UserSortCreatedAt = "created_at"
+)
+
+// UserFilter отбирает пользователей для списка. Нулевые поля не учитываются.
+type UserFilter struct {
+	NamePrefix  string     `json:"name_prefix,omitempty"`
+	MinAge      int        `json:"min_age,omitempty"`
+	MaxAge      int        `json:"max_age,omitempty"`
+	CreatedFrom *time.Time `json:"created_from,omitempty"`
+	CreatedTo   *time.Time `json:"created_to,omitempty"`
+	// Значения атрибутов, которые должны совпасть; ключ - имя атрибута
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+type UserSort struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc"`
+}
+
+// ParseUserSort разбирает "name" или "-name" (по убыванию). Пустая строка - сначала новые.
+func ParseUserSort(value string) UserSort {
+	if value == "" {
+		return UserSort{Field: UserSortCreatedAt, Desc: true}
+	}
+	if field, ok := strings.CutPrefix(value, "-"); ok {
+		return UserSort{Field: field, Desc: true}
+	}
+	return UserSort{Field: value}
+}
+
+// UserPosition - последний пользователь предыдущей страницы.
+// Хранятся все сортируемые поля, используется нужное.
+type UserPosition struct {
+	ID        uuid.UUID `json:"id"`
+	Login     string    `json:"login"`
+	Name      string    `json:"name"`
+	Age       int       `json:"age"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ListUsersQuery struct {
+	NamePrefix string `form:"name_prefix" validate:"max=255"`
+	MinAge     int    `form:"min_age" validate:"gte=0"`
+	MaxAge     int    `form:"max_age" validate:"gte=0"`
+	// RFC 3339, граница created_from включается, created_to - нет
+	CreatedFrom string `form:"created_from" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	CreatedTo   string `form:"created_to" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Sort        string `form:"sort" validate:"omitempty,oneof=login -login name -name age -age created_at -created_at"`
+	Cursor      string `form:"cursor"`
+	Limit       int    `form:"limit" validate:"gte=0,lte=200"`
+}
+
+// UserSummary - пользователь в списке, без пароля и ролей.
+type UserSummary struct {
+	ID              uuid.UUID      `json:"id"`
+	Login           string         `json:"login"`
+	Name            string         `json:"name"`
+	Age             int            `json:"age"`
+	Email           string         `json:"email,omitempty"`
+	EmailVerifiedAt *time.Time     `json:"email_verified_at,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	Attributes      map[string]any `json:"attributes,omitempty"`
+}
+
+type UserPage struct {
+	Users      []UserSummary `json:"users"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+type SearchUsersQuery struct {
+	Q     string `form:"q" validate:"required,min=2,max=100"`
+	Limit int    `form:"limit" validate:"gte=0,lte=50"`
+}
+
+// UserSearchResult - найденный пользователь. Score от 0 до 1, чем больше,
+// тем ближе логин или имя к запросу.
+type UserSearchResult struct {
+	UserSummary
+	Score float64 `json:"score"`
 }
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/webauthn"
+
+	"github.com/google/uuid"
+)
+
+// WebAuthnCredential - ключ доступа (passkey), зарегистрированный пользователем.
+type WebAuthnCredential struct {
+	ID           uuid.UUID `json:"id"`
+	UserID       uuid.UUID `json:"-"`
+	CredentialID []byte    `json:"-"`
+	PublicKey    []byte    `json:"-"`
+	SignCount    uint32    `json:"-"`
+	Name         string    `json:"name"`
+	Transports   []string  `json:"transports"`
+	// Ключ может синхронизироваться между устройствами пользователя
+	BackupEligible bool       `json:"backup_eligible"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+}
+
+// WebAuthnRegistrationOptions передаются в navigator.credentials.create. Session
+// нужно вернуть вместе с ответом аутентификатора.
+type WebAuthnRegistrationOptions struct {
+	Session   string                   `json:"session"`
+	PublicKey webauthn.CreationOptions `json:"publicKey"`
+}
+
+type WebAuthnRegisterRequest struct {
+	Session    string                          `json:"session" validate:"required"`
+	Name       string                          `json:"name" validate:"max=100"`
+	Credential webauthn.RegistrationCredential `json:"credential" validate:"required"`
+}
+
+// WebAuthnLoginOptionsRequest: без логина вход возможен любым ключом с устройства.
+type WebAuthnLoginOptionsRequest struct {
+	Login string `json:"login" validate:"max=255"`
+}
+
+type WebAuthnLoginOptions struct {
+	Session   string                  `json:"session"`
+	PublicKey webauthn.RequestOptions `json:"publicKey"`
+}
+
+type WebAuthnLoginRequest struct {
+	Session    string                       `json:"session" validate:"required"`
+	Credential webauthn.AssertionCredential `json:"credential" validate:"required"`
+	Device     string                       `json:"device" validate:"max=255"`
+}
@@ -0,0 +1,60 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/logger"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Log пишет сообщения в лог вместо отправки. Только для локальной разработки:
+// в лог попадают ссылки с секретными токенами.
+type Log struct{}
+
+func NewLog() *Log {
+	return &Log{}
+}
+
+func (l *Log) Send(_ context.Context, msg Message) error {
+	logger.Info("notification",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body),
+	)
+	return nil
+}
+
+// File дописывает сообщения в файл, чтобы их можно было прочитать при локальной разработке.
+type File struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFile(path string) *File {
+	return &File{path: filepath.Clean(path)}
+}
+
+func (f *File) Send(_ context.Context, msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "open notification file")
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	if err != nil {
+		return errors.Wrap(err, "write notification file")
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package notifier
+
+import (
+	"context"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Notifier interface {
+	Send(context.Context, Message) error
+}
@@ -0,0 +1,105 @@
+package notifier
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const smtpTimeout = 10 * time.Second
+
+type SMTP struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func NewSMTP(host string, port string, username string, password string, from string) *SMTP {
+	return &SMTP{
+		addr:     net.JoinHostPort(host, port),
+		host:     host,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return errors.Wrap(err, "smtp dial")
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return errors.Wrap(err, "smtp set deadline")
+		}
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return errors.Wrap(err, "smtp new client")
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return errors.Wrap(err, "smtp starttls")
+		}
+	}
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return errors.Wrap(err, "smtp auth")
+		}
+	}
+
+	if err := client.Mail(s.from); err != nil {
+		return errors.Wrap(err, "smtp mail")
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return errors.Wrap(err, "smtp rcpt")
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return errors.Wrap(err, "smtp data")
+	}
+	if _, err := w.Write(s.compose(msg)); err != nil {
+		return errors.Wrap(err, "smtp write")
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "smtp close data")
+	}
+
+	return errors.Wrap(client.Quit(), "smtp quit")
+}
+
+func (s *SMTP) compose(msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", headerValue(s.from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerValue(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerValue(msg.Subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// headerValue не даёт подставить дополнительные заголовки через перевод строки.
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
@@ -0,0 +1,238 @@
+// Package oauthclient - клиент OAuth2 / OpenID Connect для входа через внешних
+// провайдеров: authorization code с PKCE и чтение профиля из userinfo.
+package oauthclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	AuthStyleBasic = "basic"
+	AuthStylePost  = "post"
+
+	// Ответы провайдера больше этого не читаем
+	maxResponseSize = 1 << 20
+	defaultTimeout  = 10 * time.Second
+)
+
+// Config описывает одного провайдера. Пустые поля профиля означают
+// стандартные claims OpenID Connect.
+type Config struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	RedirectURL  string
+	Scopes       []string
+	// Как передавать секрет клиента: basic (по умолчанию) или post
+	AuthStyle string
+
+	SubjectField       string
+	EmailField         string
+	EmailVerifiedField string
+	NameField          string
+	LoginField         string
+}
+
+// Profile - данные пользователя у внешнего провайдера.
+type Profile struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Login         string
+}
+
+type Client struct {
+	cfg  Config
+	http *http.Client
+}
+
+func New(cfg Config, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	if cfg.AuthStyle == "" {
+		cfg.AuthStyle = AuthStyleBasic
+	}
+	if cfg.SubjectField == "" {
+		cfg.SubjectField = "sub"
+	}
+	if cfg.EmailField == "" {
+		cfg.EmailField = "email"
+	}
+	if cfg.EmailVerifiedField == "" {
+		cfg.EmailVerifiedField = "email_verified"
+	}
+	if cfg.NameField == "" {
+		cfg.NameField = "name"
+	}
+	if cfg.LoginField == "" {
+		cfg.LoginField = "preferred_username"
+	}
+
+	return &Client{
+		cfg:  cfg,
+		http: httpClient,
+	}
+}
+
+func (c *Client) Name() string {
+	return c.cfg.Name
+}
+
+func (c *Client) RedirectURL() string {
+	return c.cfg.RedirectURL
+}
+
+// AuthCodeURL - адрес, на который отправляется браузер пользователя.
+func (c *Client) AuthCodeURL(state string, verifier string) string {
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.cfg.ClientID},
+		"redirect_uri":          {c.cfg.RedirectURL},
+		"state":                 {state},
+		"code_challenge":        {CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	if len(c.cfg.Scopes) > 0 {
+		query.Set("scope", strings.Join(c.cfg.Scopes, " "))
+	}
+
+	separator := "?"
+	if strings.Contains(c.cfg.AuthURL, "?") {
+		separator = "&"
+	}
+	return c.cfg.AuthURL + separator + query.Encode()
+}
+
+// Exchange меняет код на access-токен провайдера.
+func (c *Client) Exchange(ctx context.Context, code string, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.cfg.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	if c.cfg.AuthStyle == AuthStylePost {
+		form.Set("client_id", c.cfg.ClientID)
+		form.Set("client_secret", c.cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", errors.Wrap(err, "oauthclient Exchange")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// Без этого некоторые провайдеры (GitHub) отвечают form-urlencoded
+	req.Header.Set("Accept", "application/json")
+	if c.cfg.AuthStyle == AuthStyleBasic {
+		req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+	}
+
+	// GitHub сообщает об ошибке с кодом 200, поэтому error проверяется и здесь
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := c.doJSON(req, &token); err != nil {
+		return "", errors.Wrap(err, "oauthclient Exchange")
+	}
+	if token.Error != "" {
+		return "", errors.Errorf("oauthclient Exchange: %s: %s", token.Error, token.ErrorDescription)
+	}
+	if token.AccessToken == "" {
+		return "", errors.New("oauthclient Exchange: empty access token")
+	}
+
+	return token.AccessToken, nil
+}
+
+// Profile читает userinfo и раскладывает поля по настройкам провайдера.
+func (c *Client) Profile(ctx context.Context, accessToken string) (*Profile, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.cfg.UserInfoURL, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "oauthclient Profile")
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	var fields map[string]any
+	if err := c.doJSON(req, &fields); err != nil {
+		return nil, errors.Wrap(err, "oauthclient Profile")
+	}
+
+	profile := &Profile{
+		Subject: stringField(fields, c.cfg.SubjectField),
+		Email:   stringField(fields, c.cfg.EmailField),
+		Name:    stringField(fields, c.cfg.NameField),
+		Login:   stringField(fields, c.cfg.LoginField),
+	}
+	verified, _ := fields[c.cfg.EmailVerifiedField].(bool)
+	profile.EmailVerified = verified
+	if profile.Subject == "" {
+		return nil, errors.Errorf("oauthclient Profile: field %q is empty", c.cfg.SubjectField)
+	}
+
+	return profile, nil
+}
+
+func (c *Client) doJSON(req *http.Request, v any) error {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "request")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return errors.Wrap(err, "read response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &oauthErr) == nil && oauthErr.Error != "" {
+			return errors.Errorf("%s: %s", oauthErr.Error, oauthErr.ErrorDescription)
+		}
+		return errors.Errorf("unexpected status %s", resp.Status)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return errors.Wrap(err, "decode response")
+	}
+
+	return nil
+}
+
+// CodeChallenge - S256-преобразование code_verifier (RFC 7636).
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// stringField приводит значение к строке: у некоторых провайдеров id числовой.
+func stringField(fields map[string]any, name string) string {
+	switch value := fields[name].(type) {
+	case string:
+		return value
+	case float64:
+		return fmt.Sprintf("%.0f", value)
+	default:
+		return ""
+	}
+}
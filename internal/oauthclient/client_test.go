@@ -0,0 +1,68 @@
+package oauthclient_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/lemavisaitov/lk-api/internal/oauthclient"
+	"github.com/lemavisaitov/lk-api/internal/testutils/fakeidp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// authorize проходит /authorize провайдера и возвращает выданный код
+func authorize(t *testing.T, client *oauthclient.Client, verifier string) string {
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := noRedirect.Get(client.AuthCodeURL("state-1", verifier))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", location.Query().Get("state"))
+	return location.Query().Get("code")
+}
+
+func TestClient_ExchangeAndProfile(t *testing.T) {
+	idp := fakeidp.New()
+	defer idp.Close()
+
+	verifier := "verifier-verifier-verifier-verifier-verifier"
+
+	// Кейс 1: стандартные claims OpenID Connect
+	idp.SetUser(map[string]any{"sub": "42", "email": "jane@example.com", "email_verified": true, "name": "Jane"})
+	client := oauthclient.New(idp.Config("fake", "https://lk.example.com/cb"), nil)
+
+	token, err := client.Exchange(context.Background(), authorize(t, client, verifier), verifier)
+	require.NoError(t, err)
+	profile, err := client.Profile(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, &oauthclient.Profile{Subject: "42", Email: "jane@example.com", EmailVerified: true, Name: "Jane"}, profile)
+
+	// Кейс 2: провайдер со своими именами полей и числовым id, секрет в теле запроса
+	idp.SetUser(map[string]any{"id": float64(1234567), "login": "jane", "email": "jane@example.com"})
+	cfg := idp.Config("github", "https://lk.example.com/cb")
+	cfg.AuthStyle = oauthclient.AuthStylePost
+	cfg.SubjectField = "id"
+	cfg.LoginField = "login"
+	client = oauthclient.New(cfg, nil)
+
+	token, err = client.Exchange(context.Background(), authorize(t, client, verifier), verifier)
+	require.NoError(t, err)
+	profile, err = client.Profile(context.Background(), token)
+	require.NoError(t, err)
+	assert.Equal(t, "1234567", profile.Subject)
+	assert.Equal(t, "jane", profile.Login)
+	assert.False(t, profile.EmailVerified)
+
+	// Кейс 3: неверный code_verifier
+	code := authorize(t, client, verifier)
+	_, err = client.Exchange(context.Background(), code, "another-verifier-another-verifier-another")
+	require.ErrorContains(t, err, "invalid_grant")
+}
@@ -0,0 +1,63 @@
+package passpolicy
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const rangePrefixLength = 5
+
+// RangeDir ищет пароль в локальной копии базы утёкших паролей в формате
+// k-anonymity: файл <первые 5 символов SHA-1>.txt содержит строки
+// "<остальные 35 символов>:<число утечек>". Читается только файл нужного префикса.
+type RangeDir struct {
+	dir string
+}
+
+func NewRangeDir(dir string) (*RangeDir, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "breached passwords dir")
+	}
+	if !info.IsDir() {
+		return nil, errors.Errorf("breached passwords path %q is not a directory", dir)
+	}
+
+	return &RangeDir{dir: dir}, nil
+}
+
+func (r *RangeDir) Contains(_ context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:rangePrefixLength], hash[rangePrefixLength:]
+
+	file, err := os.Open(filepath.Join(r.dir, prefix+".txt"))
+	if err != nil {
+		// Нет файла - нет и паролей с таким префиксом
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "RangeDir Contains")
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if strings.EqualFold(line, suffix) {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, errors.Wrap(err, "RangeDir Contains")
+	}
+
+	return false, nil
+}
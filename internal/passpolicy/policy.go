@@ -0,0 +1,140 @@
+package passpolicy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	passwordField = "password"
+	// Более короткие части логина и имени встречаются в паролях случайно
+	minUserInfoLength = 3
+)
+
+// Коды нарушений, на которые может опираться клиент
+const (
+	CodeMinLength = "min_length"
+	CodeMaxLength = "max_length"
+	CodeUpper     = "uppercase"
+	CodeLower     = "lowercase"
+	CodeDigit     = "digit"
+	CodeSymbol    = "symbol"
+	CodeUserInfo  = "contains_user_info"
+	CodeBreached  = "breached"
+)
+
+type Policy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// Запрещает пароли, содержащие логин, имя или почту пользователя
+	DisallowUserInfo bool
+}
+
+// BreachedList проверяет пароль по списку утёкших.
+type BreachedList interface {
+	Contains(ctx context.Context, password string) (bool, error)
+}
+
+type Checker struct {
+	policy   Policy
+	breached BreachedList
+}
+
+// NewChecker создаёт проверку пароля. breached может быть nil,
+// тогда список утёкших паролей не используется.
+func NewChecker(policy Policy, breached BreachedList) *Checker {
+	return &Checker{
+		policy:   policy,
+		breached: breached,
+	}
+}
+
+// Validate возвращает *apperr.ValidationError со всеми нарушениями сразу.
+// userInfo - логин, имя и почта, которых не должно быть в пароле.
+func (c *Checker) Validate(ctx context.Context, password string, userInfo ...string) error {
+	var fields []apperr.FieldError
+	violate := func(code, message string) {
+		fields = append(fields, apperr.FieldError{Field: passwordField, Code: code, Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if c.policy.MinLength > 0 && length < c.policy.MinLength {
+		violate(CodeMinLength, fmt.Sprintf("must be at least %d characters long", c.policy.MinLength))
+	}
+	if c.policy.MaxLength > 0 && length > c.policy.MaxLength {
+		violate(CodeMaxLength, fmt.Sprintf("must be at most %d characters long", c.policy.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if c.policy.RequireUpper && !hasUpper {
+		violate(CodeUpper, "must contain an uppercase letter")
+	}
+	if c.policy.RequireLower && !hasLower {
+		violate(CodeLower, "must contain a lowercase letter")
+	}
+	if c.policy.RequireDigit && !hasDigit {
+		violate(CodeDigit, "must contain a digit")
+	}
+	if c.policy.RequireSymbol && !hasSymbol {
+		violate(CodeSymbol, "must contain a symbol")
+	}
+
+	if c.policy.DisallowUserInfo && containsUserInfo(password, userInfo) {
+		violate(CodeUserInfo, "must not contain your login, name or email")
+	}
+
+	// Список проверяется последним и только для пароля, прошедшего остальные
+	// правила: незачем читать диск ради заведомо отклоняемого пароля
+	if len(fields) == 0 && c.breached != nil {
+		breached, err := c.breached.Contains(ctx, password)
+		if err != nil {
+			return errors.Wrap(err, "check breached passwords")
+		}
+		if breached {
+			violate(CodeBreached, "has appeared in a data breach, choose another one")
+		}
+	}
+
+	if len(fields) > 0 {
+		return &apperr.ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+func containsUserInfo(password string, userInfo []string) bool {
+	lower := strings.ToLower(password)
+	for _, info := range userInfo {
+		// У почты проверяем только имя ящика
+		if at := strings.LastIndex(info, "@"); at > 0 {
+			info = info[:at]
+		}
+		info = strings.ToLower(strings.TrimSpace(info))
+		if utf8.RuneCountInString(info) >= minUserInfoLength && strings.Contains(lower, info) {
+			return true
+		}
+	}
+	return false
+}
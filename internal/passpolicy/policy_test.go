@@ -0,0 +1,108 @@
+package passpolicy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func codesOf(t *testing.T, err error) []string {
+	t.Helper()
+
+	var validationErr *apperr.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+
+	codes := make([]string, 0, len(validationErr.Fields))
+	for _, field := range validationErr.Fields {
+		assert.Equal(t, "password", field.Field)
+		codes = append(codes, field.Code)
+	}
+	return codes
+}
+
+func TestChecker_Validate(t *testing.T) {
+	checker := NewChecker(Policy{
+		MinLength:        8,
+		MaxLength:        16,
+		RequireUpper:     true,
+		RequireLower:     true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		DisallowUserInfo: true,
+	}, nil)
+
+	testCases := []struct {
+		caseName string
+		password string
+		codes    []string
+	}{
+		{
+			caseName: "valid test",
+			password: "Correct-Horse1",
+		},
+		{
+			caseName: "invalid test: too short, only lowercase",
+			password: "abc",
+			codes:    []string{CodeMinLength, CodeUpper, CodeDigit, CodeSymbol},
+		},
+		{
+			caseName: "invalid test: too long",
+			password: "Correct-Horse1-Battery",
+			codes:    []string{CodeMaxLength},
+		},
+		{
+			caseName: "invalid test: contains login",
+			password: "xJohnDoe-2025",
+			codes:    []string{CodeUserInfo},
+		},
+		{
+			caseName: "invalid test: contains email local part",
+			password: "Mail-jdoe-99",
+			codes:    []string{CodeUserInfo},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			err := checker.Validate(context.Background(), tc.password, "johndoe", "Jo", "jdoe@example.com")
+			if tc.codes == nil {
+				require.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tc.codes, codesOf(t, err))
+		})
+	}
+}
+
+func TestRangeDir(t *testing.T) {
+	dir := t.TempDir()
+
+	// SHA-1("password") = 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	err := os.WriteFile(filepath.Join(dir, "5BAA6.txt"),
+		[]byte("003D68EB55068C33ACE09247EE4C639306B:3\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\r\n"), 0o600)
+	require.NoError(t, err)
+
+	list, err := NewRangeDir(dir)
+	require.NoError(t, err)
+
+	// Кейс 1: пароль есть в списке
+	ok, err := list.Contains(context.Background(), "password")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Кейс 2: файла с таким префиксом нет
+	ok, err = list.Contains(context.Background(), "Correct-Horse1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Кейс 3: список подключён к проверке
+	checker := NewChecker(Policy{MinLength: 4}, list)
+	assert.Equal(t, []string{CodeBreached}, codesOf(t, checker.Validate(context.Background(), "password")))
+	require.NoError(t, checker.Validate(context.Background(), "Correct-Horse1"))
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	apiKeysTable      = "api_keys"
+	prefixColumn      = "prefix"
+	secretHashColumn  = "secret_hash"
+	scopesColumn      = "scopes"
+	lastUsedAtColumn  = "last_used_at"
+	apiKeyColumnsList = "id, user_id, name, prefix, secret_hash, scopes, expires_at, created_at, last_used_at, revoked_at"
+)
+
+type APIKeyRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewAPIKeyProvider(pool *pgxpool.Pool) *APIKeyRepo {
+	return &APIKeyRepo{
+		pool: pool,
+	}
+}
+
+// AddAPIKey выпускает ключ только пользователю организации из контекста.
+func (s *APIKeyRepo) AddAPIKey(ctx context.Context, key model.APIKey) error {
+	exists, err := tenantUserExists(ctx, s.pool, key.UserID)
+	if err != nil {
+		return errors.Wrap(err, "AddAPIKey")
+	}
+	if !exists {
+		return errors.Wrap(apperr.ErrNotFound, "user not found")
+	}
+
+	builder := squirrel.Insert(apiKeysTable).
+		Columns(idColumn, userIDColumn, nameColumn, prefixColumn, secretHashColumn, scopesColumn, expiresAtColumn).
+		Values(key.ID, key.UserID, key.Name, key.Prefix, key.SecretHash, key.Scopes, key.ExpiresAt).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "AddAPIKey ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "AddAPIKey Exec")
+	}
+
+	return nil
+}
+
+func (s *APIKeyRepo) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	builder := squirrel.Select(apiKeyColumnsList).
+		From(apiKeysTable).
+		Where(squirrel.Eq{prefixColumn: prefix}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetAPIKeyByPrefix ToSql")
+	}
+
+	key, err := scanAPIKey(s.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "api key not found")
+		}
+		return nil, errors.Wrap(err, "GetAPIKeyByPrefix Scan")
+	}
+
+	return key, nil
+}
+
+func (s *APIKeyRepo) ListUserAPIKeys(ctx context.Context, userID uuid.UUID) ([]model.APIKey, error) {
+	scope, err := tenantUserScope(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListUserAPIKeys")
+	}
+
+	builder := squirrel.Select(apiKeyColumnsList).
+		From(apiKeysTable).
+		Where(squirrel.Eq{userIDColumn: userID, revokedAtColumn: nil}).
+		Where(scope).
+		OrderBy(createdAtColumn + " DESC").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "ListUserAPIKeys ToSql")
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListUserAPIKeys Query")
+	}
+	defer rows.Close()
+
+	keys := make([]model.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "ListUserAPIKeys Scan")
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "ListUserAPIKeys Rows")
+	}
+
+	return keys, nil
+}
+
+func (s *APIKeyRepo) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	builder := squirrel.Update(apiKeysTable).
+		Set(lastUsedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{idColumn: id}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "TouchAPIKey ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "TouchAPIKey Exec")
+	}
+
+	return nil
+}
+
+// RevokeAPIKey отзывает активный ключ пользователя userID.
+func (s *APIKeyRepo) RevokeAPIKey(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	scope, err := tenantUserScope(ctx)
+	if err != nil {
+		return errors.Wrap(err, "RevokeAPIKey")
+	}
+
+	builder := squirrel.Update(apiKeysTable).
+		Set(revokedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{idColumn: id, userIDColumn: userID, revokedAtColumn: nil}).
+		Where(scope).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "RevokeAPIKey ToSql")
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "RevokeAPIKey Exec")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(apperr.ErrNotFound, "api key not found")
+	}
+
+	return nil
+}
+
+func scanAPIKey(row pgx.Row) (*model.APIKey, error) {
+	var key model.APIKey
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.SecretHash, &key.Scopes,
+		&key.ExpiresAt, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
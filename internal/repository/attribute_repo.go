@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	attributeDefinitionsTable = "attribute_definitions"
+
+	attributesColumn = "attributes"
+	typeColumn       = "type"
+	requiredColumn   = "required"
+	maxLengthColumn  = "max_length"
+	enumColumn       = "enum"
+)
+
+var attributeDefinitionColumns = []string{
+	nameColumn, typeColumn, requiredColumn, maxLengthColumn, enumColumn, descriptionColumn, createdAtColumn,
+}
+
+type AttributeRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewAttributeProvider(pool *pgxpool.Pool) *AttributeRepo {
+	return &AttributeRepo{
+		pool: pool,
+	}
+}
+
+func (s *AttributeRepo) ListAttributeDefinitions(ctx context.Context) ([]model.AttributeDefinition, error) {
+	query, args, err := squirrel.Select(attributeDefinitionColumns...).
+		From(attributeDefinitionsTable).
+		OrderBy(nameColumn).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "ListAttributeDefinitions ToSql")
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListAttributeDefinitions Query")
+	}
+	defer rows.Close()
+
+	definitions := make([]model.AttributeDefinition, 0)
+	for rows.Next() {
+		definition, err := scanAttributeDefinition(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "ListAttributeDefinitions Scan")
+		}
+		definitions = append(definitions, *definition)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "ListAttributeDefinitions Rows")
+	}
+
+	return definitions, nil
+}
+
+func (s *AttributeRepo) GetAttributeDefinition(ctx context.Context, name string) (*model.AttributeDefinition, error) {
+	query, args, err := squirrel.Select(attributeDefinitionColumns...).
+		From(attributeDefinitionsTable).
+		Where(squirrel.Eq{nameColumn: name}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetAttributeDefinition ToSql")
+	}
+
+	definition, err := scanAttributeDefinition(s.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "attribute not found")
+		}
+		return nil, errors.Wrap(err, "GetAttributeDefinition Scan")
+	}
+
+	return definition, nil
+}
+
+func (s *AttributeRepo) AddAttributeDefinition(ctx context.Context, definition model.AttributeDefinition) error {
+	query, args, err := squirrel.Insert(attributeDefinitionsTable).
+		Columns(nameColumn, typeColumn, requiredColumn, maxLengthColumn, enumColumn, descriptionColumn).
+		Values(definition.Name, definition.Type, definition.Required, definition.MaxLength,
+			nonNilStrings(definition.Enum), definition.Description).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "AddAttributeDefinition ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			return errors.Wrap(apperr.ErrAlreadyExists, "attribute already exists")
+		}
+		return errors.Wrap(err, "AddAttributeDefinition Exec")
+	}
+
+	return nil
+}
+
+func (s *AttributeRepo) UpdateAttributeDefinition(ctx context.Context, name string,
+	req model.UpdateAttributeDefinitionRequest) error {
+	query, args, err := squirrel.Update(attributeDefinitionsTable).
+		Set(requiredColumn, req.Required).
+		Set(maxLengthColumn, req.MaxLength).
+		Set(enumColumn, nonNilStrings(req.Enum)).
+		Set(descriptionColumn, req.Description).
+		Where(squirrel.Eq{nameColumn: name}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "UpdateAttributeDefinition ToSql")
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "UpdateAttributeDefinition Exec")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(apperr.ErrNotFound, "attribute not found")
+	}
+
+	return nil
+}
+
+// DeleteAttributeDefinition удаляет атрибут и его значения у всех пользователей,
+// иначе их профили перестали бы проходить проверку.
+func (s *AttributeRepo) DeleteAttributeDefinition(ctx context.Context, name string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "DeleteAttributeDefinition Begin")
+	}
+	defer tx.Rollback(ctx)
+
+	query, args, err := squirrel.Delete(attributeDefinitionsTable).
+		Where(squirrel.Eq{nameColumn: name}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "DeleteAttributeDefinition ToSql")
+	}
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "DeleteAttributeDefinition Exec")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(apperr.ErrNotFound, "attribute not found")
+	}
+
+	// Справочник общий для всех организаций, поэтому значения чистятся функцией БД,
+	// которую не ограничивает row-level security
+	if _, err := tx.Exec(ctx, "SELECT strip_user_attribute($1)", name); err != nil {
+		return errors.Wrap(err, "DeleteAttributeDefinition Exec")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "DeleteAttributeDefinition Commit")
+	}
+	return nil
+}
+
+func scanAttributeDefinition(row pgx.Row) (*model.AttributeDefinition, error) {
+	var definition model.AttributeDefinition
+	err := row.Scan(&definition.Name, &definition.Type, &definition.Required, &definition.MaxLength,
+		&definition.Enum, &definition.Description, &definition.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// nonNilStrings нужен для колонок TEXT[] NOT NULL: nil-срез pgx передаёт как NULL.
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
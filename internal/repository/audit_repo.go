@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	auditEventsTable      = "audit_events"
+	actionColumn          = "action"
+	impersonatorIDColumn  = "impersonator_id"
+	targetIDColumn        = "target_id"
+	changesColumn         = "changes"
+	detailsColumn         = "details"
+	requestIDColumn       = "request_id"
+	auditEventColumnsList = "id, action, actor_id, impersonator_id, target_id, changes, details, ip, request_id, created_at"
+)
+
+type AuditRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewAuditProvider(pool *pgxpool.Pool) *AuditRepo {
+	return &AuditRepo{
+		pool: pool,
+	}
+}
+
+func (s *AuditRepo) AddAuditEvent(ctx context.Context, event model.AuditEvent) error {
+	changes, err := json.Marshal(nonNilMap(event.Changes))
+	if err != nil {
+		return errors.Wrap(err, "AddAuditEvent marshal changes")
+	}
+	details, err := json.Marshal(nonNilMap(event.Details))
+	if err != nil {
+		return errors.Wrap(err, "AddAuditEvent marshal details")
+	}
+
+	builder := squirrel.Insert(auditEventsTable).
+		Columns(idColumn, actionColumn, actorIDColumn, impersonatorIDColumn, targetIDColumn, changesColumn,
+			detailsColumn, ipColumn, requestIDColumn).
+		Values(event.ID, event.Action, event.ActorID, event.ImpersonatorID, event.TargetID, string(changes),
+			string(details), event.IP, event.RequestID).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "AddAuditEvent ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "AddAuditEvent Exec")
+	}
+
+	return nil
+}
+
+// ListAuditEvents возвращает до limit событий от новых к старым, начиная после позиции after.
+func (s *AuditRepo) ListAuditEvents(ctx context.Context, filter model.AuditFilter, after *model.AuditPosition,
+	limit int) ([]model.AuditEvent, error) {
+	builder := squirrel.Select(auditEventColumnsList).
+		From(auditEventsTable).
+		OrderBy(createdAtColumn+" DESC", idColumn+" DESC").
+		Limit(uint64(limit)).
+		PlaceholderFormat(squirrel.Dollar)
+	if filter.UserID != uuid.Nil {
+		builder = builder.Where(squirrel.Or{
+			squirrel.Eq{actorIDColumn: filter.UserID},
+			squirrel.Eq{targetIDColumn: filter.UserID},
+		})
+	}
+	if filter.Action != "" {
+		builder = builder.Where(squirrel.Eq{actionColumn: filter.Action})
+	}
+	if filter.From != nil {
+		builder = builder.Where(squirrel.GtOrEq{createdAtColumn: *filter.From})
+	}
+	if filter.To != nil {
+		builder = builder.Where(squirrel.Lt{createdAtColumn: *filter.To})
+	}
+	if after != nil {
+		builder = builder.Where("("+createdAtColumn+", "+idColumn+") < (?, ?)", after.CreatedAt, after.ID)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "ListAuditEvents ToSql")
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListAuditEvents Query")
+	}
+	defer rows.Close()
+
+	events := make([]model.AuditEvent, 0)
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "ListAuditEvents Scan")
+		}
+		events = append(events, *event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "ListAuditEvents Rows")
+	}
+
+	return events, nil
+}
+
+func scanAuditEvent(row pgx.Row) (*model.AuditEvent, error) {
+	var (
+		event   model.AuditEvent
+		changes []byte
+		details []byte
+	)
+	err := row.Scan(&event.ID, &event.Action, &event.ActorID, &event.ImpersonatorID, &event.TargetID, &changes,
+		&details, &event.IP, &event.RequestID, &event.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(changes, &event.Changes); err != nil {
+		return nil, errors.Wrap(err, "decode changes")
+	}
+	if err := json.Unmarshal(details, &event.Details); err != nil {
+		return nil, errors.Wrap(err, "decode details")
+	}
+	return &event, nil
+}
+
+// nonNilMap сохраняет пустой map как {}, а не null.
+func nonNilMap[V any](m map[string]V) map[string]V {
+	if m == nil {
+		return map[string]V{}
+	}
+	return m
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	identitiesTable     = "identities"
+	providerColumn      = "provider"
+	subjectColumn       = "subject"
+	lastLoginAtColumn   = "last_login_at"
+	identityColumnsList = "id, user_id, provider, subject, COALESCE(email, ''), created_at, last_login_at"
+)
+
+type IdentityRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewIdentityProvider(pool *pgxpool.Pool) *IdentityRepo {
+	return &IdentityRepo{
+		pool: pool,
+	}
+}
+
+// AddIdentity возвращает ErrAlreadyExists, если аккаунт провайдера уже привязан
+// или у пользователя уже есть привязка к этому провайдеру.
+func (s *IdentityRepo) AddIdentity(ctx context.Context, identity model.Identity) error {
+	builder := squirrel.Insert(identitiesTable).
+		Columns(idColumn, userIDColumn, providerColumn, subjectColumn, emailColumn).
+		Values(identity.ID, identity.UserID, identity.Provider, identity.Subject, nullableString(identity.Email)).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "AddIdentity ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			return errors.Wrap(apperr.ErrAlreadyExists, "identity already linked")
+		}
+		return errors.Wrap(err, "AddIdentity Exec")
+	}
+
+	return nil
+}
+
+func (s *IdentityRepo) GetIdentity(ctx context.Context, provider string, subject string) (*model.Identity, error) {
+	builder := squirrel.Select(identityColumnsList).
+		From(identitiesTable).
+		Where(squirrel.Eq{providerColumn: provider, subjectColumn: subject}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetIdentity ToSql")
+	}
+
+	identity, err := scanIdentity(s.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "identity not found")
+		}
+		return nil, errors.Wrap(err, "GetIdentity Scan")
+	}
+
+	return identity, nil
+}
+
+func (s *IdentityRepo) ListUserIdentities(ctx context.Context, userID uuid.UUID) ([]model.Identity, error) {
+	scope, err := tenantUserScope(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListUserIdentities")
+	}
+
+	builder := squirrel.Select(identityColumnsList).
+		From(identitiesTable).
+		Where(squirrel.Eq{userIDColumn: userID}).
+		Where(scope).
+		OrderBy(createdAtColumn).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "ListUserIdentities ToSql")
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListUserIdentities Query")
+	}
+	defer rows.Close()
+
+	identities := make([]model.Identity, 0)
+	for rows.Next() {
+		identity, err := scanIdentity(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "ListUserIdentities Scan")
+		}
+		identities = append(identities, *identity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "ListUserIdentities Rows")
+	}
+
+	return identities, nil
+}
+
+func (s *IdentityRepo) TouchIdentity(ctx context.Context, id uuid.UUID) error {
+	builder := squirrel.Update(identitiesTable).
+		Set(lastLoginAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{idColumn: id}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "TouchIdentity ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "TouchIdentity Exec")
+	}
+
+	return nil
+}
+
+func (s *IdentityRepo) DeleteIdentity(ctx context.Context, userID uuid.UUID, provider string) error {
+	scope, err := tenantUserScope(ctx)
+	if err != nil {
+		return errors.Wrap(err, "DeleteIdentity")
+	}
+
+	builder := squirrel.Delete(identitiesTable).
+		Where(squirrel.Eq{userIDColumn: userID, providerColumn: provider}).
+		Where(scope).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "DeleteIdentity ToSql")
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "DeleteIdentity Exec")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(apperr.ErrNotFound, "identity not found")
+	}
+
+	return nil
+}
+
+func scanIdentity(row pgx.Row) (*model.Identity, error) {
+	var identity model.Identity
+	err := row.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &identity.Email,
+		&identity.CreatedAt, &identity.LastLoginAt)
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
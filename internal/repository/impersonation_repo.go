@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	impersonationsTable      = "impersonations"
+	actorIDColumn            = "actor_id"
+	subjectIDColumn          = "subject_id"
+	reasonColumn             = "reason"
+	startedAtColumn          = "started_at"
+	endedAtColumn            = "ended_at"
+	impersonationColumnsList = "id, actor_id, subject_id, reason, ip, started_at, expires_at, ended_at"
+	// Больше последних записей в одном ответе не отдаём
+	impersonationListLimit = 100
+)
+
+type ImpersonationRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewImpersonationProvider(pool *pgxpool.Pool) *ImpersonationRepo {
+	return &ImpersonationRepo{
+		pool: pool,
+	}
+}
+
+// AddImpersonation записывает имперсонацию в организацию из контекста.
+func (s *ImpersonationRepo) AddImpersonation(ctx context.Context, impersonation model.Impersonation) error {
+	organizationID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return errors.Wrap(apperr.ErrNoTenant, "AddImpersonation")
+	}
+
+	builder := squirrel.Insert(impersonationsTable).
+		Columns(idColumn, organizationIDColumn, actorIDColumn, subjectIDColumn, reasonColumn, ipColumn,
+			expiresAtColumn).
+		Values(impersonation.ID, organizationID, impersonation.ActorID, impersonation.SubjectID,
+			impersonation.Reason, impersonation.IP, impersonation.ExpiresAt).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "AddImpersonation ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "AddImpersonation Exec")
+	}
+
+	return nil
+}
+
+func (s *ImpersonationRepo) GetImpersonation(ctx context.Context, id uuid.UUID) (*model.Impersonation, error) {
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetImpersonation")
+	}
+
+	builder := squirrel.Select(impersonationColumnsList).
+		From(impersonationsTable).
+		Where(squirrel.Eq{idColumn: id}).
+		Where(scope).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetImpersonation ToSql")
+	}
+
+	impersonation, err := scanImpersonation(s.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "impersonation not found")
+		}
+		return nil, errors.Wrap(err, "GetImpersonation Scan")
+	}
+
+	return impersonation, nil
+}
+
+func (s *ImpersonationRepo) ListImpersonations(ctx context.Context,
+	filter model.ImpersonationFilter) ([]model.Impersonation, error) {
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListImpersonations")
+	}
+
+	builder := squirrel.Select(impersonationColumnsList).
+		From(impersonationsTable).
+		Where(scope).
+		OrderBy(startedAtColumn + " DESC").
+		Limit(impersonationListLimit).
+		PlaceholderFormat(squirrel.Dollar)
+	if filter.ActorID != uuid.Nil {
+		builder = builder.Where(squirrel.Eq{actorIDColumn: filter.ActorID})
+	}
+	if filter.SubjectID != uuid.Nil {
+		builder = builder.Where(squirrel.Eq{subjectIDColumn: filter.SubjectID})
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "ListImpersonations ToSql")
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListImpersonations Query")
+	}
+	defer rows.Close()
+
+	impersonations := make([]model.Impersonation, 0)
+	for rows.Next() {
+		impersonation, err := scanImpersonation(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "ListImpersonations Scan")
+		}
+		impersonations = append(impersonations, *impersonation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "ListImpersonations Rows")
+	}
+
+	return impersonations, nil
+}
+
+// EndImpersonation отмечает время завершения. false - имперсонация уже была завершена.
+func (s *ImpersonationRepo) EndImpersonation(ctx context.Context, id uuid.UUID) (bool, error) {
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "EndImpersonation")
+	}
+
+	builder := squirrel.Update(impersonationsTable).
+		Set(endedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{idColumn: id, endedAtColumn: nil}).
+		Where(scope).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return false, errors.Wrap(err, "EndImpersonation ToSql")
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, errors.Wrap(err, "EndImpersonation Exec")
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func scanImpersonation(row pgx.Row) (*model.Impersonation, error) {
+	var impersonation model.Impersonation
+	err := row.Scan(&impersonation.ID, &impersonation.ActorID, &impersonation.SubjectID, &impersonation.Reason,
+		&impersonation.IP, &impersonation.StartedAt, &impersonation.ExpiresAt, &impersonation.EndedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &impersonation, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -13,5 +14,144 @@ type UserProvider interface {
 	AddUser(context.Context, model.User) error
 	GetUser(context.Context, uuid.UUID) (*model.User, error)
 	GetUserIDByLogin(context.Context, string) (*uuid.UUID, error)
-	DeleteUser(context.Context, uuid.UUID) error
+	GetUserIDByEmail(context.Context, string) (*uuid.UUID, error)
+	EmailInOtherOrganization(context.Context, string) (bool, error)
+	MarkEmailVerified(context.Context, uuid.UUID) error
+	DeleteUser(context.Context, uuid.UUID, int64) error
+	ListUsers(context.Context, model.UserFilter, model.UserSort, *model.UserPosition, int) ([]model.User, error)
+	SearchUsers(context.Context, string, int) ([]model.UserSearchResult, error)
+	LoginExists(context.Context, string) (bool, error)
+	RestoreUser(context.Context, uuid.UUID, time.Time) error
+	PurgeDeletedUsers(context.Context, time.Time, int) ([]model.PurgedUser, error)
+	SetUserAvatar(context.Context, uuid.UUID, *uuid.UUID) (*uuid.UUID, error)
+	GetUserOrganizationID(context.Context, uuid.UUID) (uuid.UUID, error)
+}
+
+type OrganizationProvider interface {
+	ListOrganizations(context.Context) ([]model.Organization, error)
+	GetOrganizationBySlug(context.Context, string) (*model.Organization, error)
+	AddOrganization(context.Context, model.Organization) error
+}
+
+type MembershipProvider interface {
+	GetMemberRole(context.Context, uuid.UUID) (string, error)
+	ListMembers(context.Context) ([]model.Member, error)
+	SetMemberRole(context.Context, uuid.UUID, string) error
+	TransferOwnership(context.Context, uuid.UUID, uuid.UUID) error
+}
+
+type InvitationProvider interface {
+	AddInvitation(context.Context, model.Invitation) error
+	ListPendingInvitations(context.Context) ([]model.Invitation, error)
+	RevokeInvitation(context.Context, uuid.UUID) error
+	FindInvitation(context.Context, string) (*model.Invitation, error)
+	AcceptInvitation(context.Context, string, model.Invitee) (*model.AcceptInvitationResult, error)
+	DeclineInvitation(context.Context, string) (*model.Invitation, error)
+}
+
+type RefreshTokenProvider interface {
+	AddRefreshToken(context.Context, model.RefreshToken) error
+	GetRefreshTokenByHash(context.Context, string) (*model.RefreshToken, error)
+	RevokeRefreshToken(context.Context, uuid.UUID) (bool, error)
+}
+
+type SessionProvider interface {
+	AddSession(context.Context, model.Session) error
+	GetSession(context.Context, uuid.UUID) (*model.Session, error)
+	ListUserSessions(context.Context, uuid.UUID) ([]model.Session, error)
+	TouchSession(context.Context, uuid.UUID) error
+	RevokeSession(context.Context, uuid.UUID, uuid.UUID) error
+	RevokeUserSessions(context.Context, uuid.UUID, uuid.UUID) error
+}
+
+type OneTimeTokenProvider interface {
+	AddToken(context.Context, model.OneTimeToken) error
+	FindToken(context.Context, string, string) (*model.OneTimeToken, error)
+	ConsumeToken(context.Context, string, string) (*model.OneTimeToken, error)
+	InvalidateUserTokens(context.Context, uuid.UUID, string) error
+}
+
+type TOTPProvider interface {
+	SaveTOTP(context.Context, model.TOTP) error
+	GetTOTP(context.Context, uuid.UUID) (*model.TOTP, error)
+	ConfirmTOTP(context.Context, uuid.UUID) error
+	UseTOTPStep(context.Context, uuid.UUID, int64) (bool, error)
+	DeleteTOTP(context.Context, uuid.UUID) error
+	ReplaceRecoveryCodes(context.Context, uuid.UUID, []string) error
+	UseRecoveryCode(context.Context, uuid.UUID, string) (bool, error)
+}
+
+type APIKeyProvider interface {
+	AddAPIKey(context.Context, model.APIKey) error
+	GetAPIKeyByPrefix(context.Context, string) (*model.APIKey, error)
+	ListUserAPIKeys(context.Context, uuid.UUID) ([]model.APIKey, error)
+	TouchAPIKey(context.Context, uuid.UUID) error
+	RevokeAPIKey(context.Context, uuid.UUID, uuid.UUID) error
+}
+
+type OIDCProvider interface {
+	AddClient(context.Context, model.OAuthClient) error
+	GetClient(context.Context, string) (*model.OAuthClient, error)
+	AddAuthorizationCode(context.Context, model.AuthorizationCode) error
+	ConsumeAuthorizationCode(context.Context, string) (*model.AuthorizationCode, error)
+	GetConsent(context.Context, uuid.UUID, string) (*model.OAuthConsent, error)
+	SaveConsent(context.Context, model.OAuthConsent) error
+	ListUserConsents(context.Context, uuid.UUID) ([]model.OAuthConsent, error)
+	DeleteConsent(context.Context, uuid.UUID, string) error
+}
+
+type IdentityProvider interface {
+	AddIdentity(context.Context, model.Identity) error
+	GetIdentity(context.Context, string, string) (*model.Identity, error)
+	ListUserIdentities(context.Context, uuid.UUID) ([]model.Identity, error)
+	TouchIdentity(context.Context, uuid.UUID) error
+	DeleteIdentity(context.Context, uuid.UUID, string) error
+}
+
+type WebAuthnProvider interface {
+	AddCredential(context.Context, model.WebAuthnCredential) error
+	GetCredential(context.Context, []byte) (*model.WebAuthnCredential, error)
+	ListUserCredentials(context.Context, uuid.UUID) ([]model.WebAuthnCredential, error)
+	UpdateSignCount(context.Context, uuid.UUID, uint32, uint32) (bool, error)
+	DeleteCredential(context.Context, uuid.UUID, uuid.UUID) error
+}
+
+type RBACProvider interface {
+	ListRoles(context.Context) ([]model.Role, error)
+	GetRole(context.Context, string) (*model.Role, error)
+	AddRole(context.Context, model.Role) error
+	UpdateRole(context.Context, string, model.UpdateRoleRequest) error
+	DeleteRole(context.Context, string) error
+	AssignUserRole(context.Context, uuid.UUID, string) error
+	RevokeUserRole(context.Context, uuid.UUID, string) error
+	ListGroups(context.Context) ([]model.Group, error)
+	GetGroup(context.Context, uuid.UUID) (*model.Group, error)
+	AddGroup(context.Context, model.Group) error
+	DeleteGroup(context.Context, uuid.UUID) error
+	ListGroupMembers(context.Context, uuid.UUID) ([]uuid.UUID, error)
+	AddGroupMember(context.Context, uuid.UUID, uuid.UUID) error
+	RemoveGroupMember(context.Context, uuid.UUID, uuid.UUID) error
+	AssignGroupRole(context.Context, uuid.UUID, string) error
+	RevokeGroupRole(context.Context, uuid.UUID, string) error
+	GetUserPermissions(context.Context, uuid.UUID) ([]string, error)
+}
+
+type AttributeProvider interface {
+	ListAttributeDefinitions(context.Context) ([]model.AttributeDefinition, error)
+	GetAttributeDefinition(context.Context, string) (*model.AttributeDefinition, error)
+	AddAttributeDefinition(context.Context, model.AttributeDefinition) error
+	UpdateAttributeDefinition(context.Context, string, model.UpdateAttributeDefinitionRequest) error
+	DeleteAttributeDefinition(context.Context, string) error
+}
+
+type ImpersonationProvider interface {
+	AddImpersonation(context.Context, model.Impersonation) error
+	GetImpersonation(context.Context, uuid.UUID) (*model.Impersonation, error)
+	ListImpersonations(context.Context, model.ImpersonationFilter) ([]model.Impersonation, error)
+	EndImpersonation(context.Context, uuid.UUID) (bool, error)
+}
+
+type AuditProvider interface {
+	AddAuditEvent(context.Context, model.AuditEvent) error
+	ListAuditEvents(context.Context, model.AuditFilter, *model.AuditPosition, int) ([]model.AuditEvent, error)
 }
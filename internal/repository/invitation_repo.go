@@ -0,0 +1,307 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	invitationsTable = "organization_invitations"
+
+	invitedByColumn  = "invited_by"
+	acceptedAtColumn = "accepted_at"
+	declinedAtColumn = "declined_at"
+)
+
+var invitationColumns = []string{
+	idColumn, organizationIDColumn, emailColumn, roleColumn, invitedByColumn, createdAtColumn, expiresAtColumn,
+	tokenHashColumn,
+}
+
+// pendingInvitation - приглашение не принято, не отклонено, не отозвано и не истекло
+var pendingInvitation = squirrel.And{
+	squirrel.Eq{acceptedAtColumn: nil, declinedAtColumn: nil, revokedAtColumn: nil},
+	squirrel.Expr(expiresAtColumn + " > now()"),
+}
+
+// queryRower - пул или открытая транзакция
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type InvitationRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewInvitationProvider(pool *pgxpool.Pool) *InvitationRepo {
+	return &InvitationRepo{
+		pool: pool,
+	}
+}
+
+// AddInvitation отзывает прежние приглашения на тот же адрес в ту же организацию:
+// действует только последнее письмо.
+func (s *InvitationRepo) AddInvitation(ctx context.Context, invitation model.Invitation) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "AddInvitation Begin")
+	}
+	defer tx.Rollback(ctx)
+
+	query, args, err := squirrel.Update(invitationsTable).
+		Set(revokedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{organizationIDColumn: invitation.OrganizationID}).
+		Where(squirrel.Expr("lower("+emailColumn+") = lower(?)", invitation.Email)).
+		Where(pendingInvitation).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "AddInvitation ToSql")
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "AddInvitation Exec")
+	}
+
+	query, args, err = squirrel.Insert(invitationsTable).
+		Columns(idColumn, organizationIDColumn, emailColumn, roleColumn, invitedByColumn, expiresAtColumn,
+			tokenHashColumn).
+		Values(invitation.ID, invitation.OrganizationID, invitation.Email, invitation.Role, invitation.InvitedBy,
+			invitation.ExpiresAt, invitation.TokenHash).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "AddInvitation ToSql")
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		if isForeignKeyViolation(err) {
+			return errors.Wrap(apperr.ErrNotFound, "organization not found")
+		}
+		return errors.Wrap(err, "AddInvitation Exec")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "AddInvitation Commit")
+	}
+	return nil
+}
+
+// ListPendingInvitations возвращает ожидающие ответа приглашения организации из контекста.
+func (s *InvitationRepo) ListPendingInvitations(ctx context.Context) ([]model.Invitation, error) {
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListPendingInvitations")
+	}
+
+	query, args, err := squirrel.Select(invitationColumns...).
+		From(invitationsTable).
+		Where(scope).
+		Where(pendingInvitation).
+		OrderBy(createdAtColumn, idColumn).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "ListPendingInvitations ToSql")
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListPendingInvitations Query")
+	}
+	defer rows.Close()
+
+	invitations := make([]model.Invitation, 0)
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "ListPendingInvitations Scan")
+		}
+		invitations = append(invitations, *invitation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "ListPendingInvitations Rows")
+	}
+
+	return invitations, nil
+}
+
+func (s *InvitationRepo) RevokeInvitation(ctx context.Context, id uuid.UUID) error {
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return errors.Wrap(err, "RevokeInvitation")
+	}
+
+	query, args, err := squirrel.Update(invitationsTable).
+		Set(revokedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{idColumn: id}).
+		Where(scope).
+		Where(pendingInvitation).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "RevokeInvitation ToSql")
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "RevokeInvitation Exec")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(apperr.ErrNotFound, "invitation not found")
+	}
+
+	return nil
+}
+
+// FindInvitation ищет ожидающее приглашение по хэшу токена в любой организации:
+// организацию запроса задаёт само приглашение.
+func (s *InvitationRepo) FindInvitation(ctx context.Context, hash string) (*model.Invitation, error) {
+	query, args, err := squirrel.Select(invitationColumns...).
+		From(invitationsTable).
+		Where(squirrel.Eq{tokenHashColumn: hash}).
+		Where(pendingInvitation).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "FindInvitation ToSql")
+	}
+
+	invitation, err := scanInvitation(s.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "invitation not found")
+		}
+		return nil, errors.Wrap(err, "FindInvitation Scan")
+	}
+
+	return invitation, nil
+}
+
+// AcceptInvitation помечает приглашение принятым и вносит приглашённого в организацию
+// приглашения одной транзакцией: при любой ошибке не остаётся ни закрытого приглашения
+// без участника, ни созданного пользователя без закрытого приглашения. Роль участника
+// приглашение только повышает.
+func (s *InvitationRepo) AcceptInvitation(ctx context.Context, hash string,
+	invitee model.Invitee) (*model.AcceptInvitationResult, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "AcceptInvitation Begin")
+	}
+	defer tx.Rollback(ctx)
+
+	invitation, err := closeInvitation(ctx, tx, hash, acceptedAtColumn)
+	if err != nil {
+		return nil, errors.Wrap(err, "AcceptInvitation")
+	}
+
+	result := &model.AcceptInvitationResult{UserID: invitee.UserID, Role: invitation.Role}
+	if invitee.NewUser != nil {
+		result.UserID = invitee.NewUser.ID
+		result.Created = true
+		if err := insertUser(ctx, tx, invitation.OrganizationID, *invitee.NewUser, true); err != nil {
+			return nil, errors.Wrap(err, "AcceptInvitation")
+		}
+		if err := insertMember(ctx, tx, invitation.OrganizationID, result.UserID, invitation.Role); err != nil {
+			return nil, errors.Wrap(err, "AcceptInvitation")
+		}
+	} else {
+		result.Role, err = raiseMemberRole(ctx, tx, invitation.OrganizationID, invitee.UserID, invitation.Role)
+		if err != nil {
+			return nil, errors.Wrap(err, "AcceptInvitation")
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errors.Wrap(err, "AcceptInvitation Commit")
+	}
+	return result, nil
+}
+
+func (s *InvitationRepo) DeclineInvitation(ctx context.Context, hash string) (*model.Invitation, error) {
+	return closeInvitation(ctx, s.pool, hash, declinedAtColumn)
+}
+
+// raiseMemberRole назначает участнику role, если она даёт больше прав, чем текущая,
+// и возвращает итоговую роль. Строка участника блокируется до конца транзакции.
+func raiseMemberRole(ctx context.Context, tx pgx.Tx, organizationID uuid.UUID, userID uuid.UUID,
+	role string) (string, error) {
+	query, args, err := squirrel.Select("m." + roleColumn).
+		From(membersTable + " m").
+		Join(tableName + " u ON u." + idColumn + " = m." + userIDColumn).
+		Where(squirrel.Eq{"m." + userIDColumn: userID, "m." + organizationIDColumn: organizationID}).
+		Where("u." + deletedAtColumn + " IS NULL").
+		Suffix("FOR UPDATE OF m").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return "", errors.Wrap(err, "raiseMemberRole ToSql")
+	}
+
+	var current string
+	if err := tx.QueryRow(ctx, query, args...).Scan(&current); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", errors.Wrap(apperr.ErrNotFound, "member not found")
+		}
+		return "", errors.Wrap(err, "raiseMemberRole Scan")
+	}
+	if !model.MemberRoleOutranks(role, current) {
+		return current, nil
+	}
+
+	query, args, err = squirrel.Update(membersTable).
+		Set(roleColumn, role).
+		Where(squirrel.Eq{userIDColumn: userID, organizationIDColumn: organizationID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return "", errors.Wrap(err, "raiseMemberRole ToSql")
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			return "", errors.Wrap(apperr.ErrAlreadyExists, "organization already has an owner")
+		}
+		return "", errors.Wrap(err, "raiseMemberRole Exec")
+	}
+	return role, nil
+}
+
+func closeInvitation(ctx context.Context, db queryRower, hash string, column string) (*model.Invitation, error) {
+	query, args, err := squirrel.Update(invitationsTable).
+		Set(column, squirrel.Expr("now()")).
+		Where(squirrel.Eq{tokenHashColumn: hash}).
+		Where(pendingInvitation).
+		Suffix("RETURNING " + strings.Join(invitationColumns, ", ")).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "closeInvitation ToSql")
+	}
+
+	invitation, err := scanInvitation(db.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "invitation not found")
+		}
+		return nil, errors.Wrap(err, "closeInvitation Scan")
+	}
+
+	return invitation, nil
+}
+
+func scanInvitation(row pgx.Row) (*model.Invitation, error) {
+	var invitation model.Invitation
+	err := row.Scan(&invitation.ID, &invitation.OrganizationID, &invitation.Email, &invitation.Role,
+		&invitation.InvitedBy, &invitation.CreatedAt, &invitation.ExpiresAt, &invitation.TokenHash)
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const membersTable = "organization_members"
+
+// MembershipRepo работает с участниками организации из контекста, как и UserRepo.
+type MembershipRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewMembershipProvider(pool *pgxpool.Pool) *MembershipRepo {
+	return &MembershipRepo{
+		pool: pool,
+	}
+}
+
+func (s *MembershipRepo) GetMemberRole(ctx context.Context, userID uuid.UUID) (string, error) {
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "GetMemberRole")
+	}
+
+	query, args, err := squirrel.Select(roleColumn).
+		From(membersTable).
+		Where(squirrel.Eq{userIDColumn: userID}).
+		Where(scope).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return "", errors.Wrap(err, "GetMemberRole ToSql")
+	}
+
+	var role string
+	if err := s.pool.QueryRow(ctx, query, args...).Scan(&role); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", errors.Wrap(apperr.ErrNotFound, "member not found")
+		}
+		return "", errors.Wrap(err, "GetMemberRole Scan")
+	}
+
+	return role, nil
+}
+
+// ListMembers возвращает участников организации, кроме удалённых пользователей.
+func (s *MembershipRepo) ListMembers(ctx context.Context) ([]model.Member, error) {
+	organizationID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, errors.Wrap(apperr.ErrNoTenant, "ListMembers")
+	}
+
+	query, args, err := squirrel.Select("m."+userIDColumn, "u."+loginColumn, "u."+nameColumn,
+		"COALESCE(u."+emailColumn+", '')", "m."+roleColumn, "m."+createdAtColumn).
+		From(membersTable+" m").
+		Join(tableName+" u ON u."+idColumn+" = m."+userIDColumn).
+		Where(squirrel.Eq{"m." + organizationIDColumn: organizationID}).
+		Where("u."+deletedAtColumn+" IS NULL").
+		OrderBy("m."+createdAtColumn, "m."+userIDColumn).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "ListMembers ToSql")
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListMembers Query")
+	}
+	defer rows.Close()
+
+	members := make([]model.Member, 0)
+	for rows.Next() {
+		var member model.Member
+		if err := rows.Scan(&member.UserID, &member.Login, &member.Name, &member.Email, &member.Role,
+			&member.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, "ListMembers Scan")
+		}
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "ListMembers Rows")
+	}
+
+	return members, nil
+}
+
+// SetMemberRole меняет роль участника. Второй владелец организации - ErrAlreadyExists.
+func (s *MembershipRepo) SetMemberRole(ctx context.Context, userID uuid.UUID, role string) error {
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return errors.Wrap(err, "SetMemberRole")
+	}
+
+	query, args, err := squirrel.Update(membersTable).
+		Set(roleColumn, role).
+		Where(squirrel.Eq{userIDColumn: userID}).
+		Where(scope).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "SetMemberRole ToSql")
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args...)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return errors.Wrap(apperr.ErrAlreadyExists, "organization already has an owner")
+		}
+		return errors.Wrap(err, "SetMemberRole Exec")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(apperr.ErrNotFound, "member not found")
+	}
+
+	return nil
+}
+
+// TransferOwnership делает newOwnerID владельцем, а прежний владелец становится admin.
+// Одной транзакцией: организация не остаётся без владельца и не получает двух.
+func (s *MembershipRepo) TransferOwnership(ctx context.Context, ownerID uuid.UUID, newOwnerID uuid.UUID) error {
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return errors.Wrap(err, "TransferOwnership")
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "TransferOwnership Begin")
+	}
+	defer tx.Rollback(ctx)
+
+	// Сначала снимается прежний владелец, иначе сработает уникальный индекс
+	query, args, err := squirrel.Update(membersTable).
+		Set(roleColumn, model.MemberRoleAdmin).
+		Where(squirrel.Eq{userIDColumn: ownerID, roleColumn: model.MemberRoleOwner}).
+		Where(scope).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "TransferOwnership ToSql")
+	}
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "TransferOwnership Exec")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(apperr.ErrForbidden, "user is not the owner")
+	}
+
+	query, args, err = squirrel.Update(membersTable).
+		Set(roleColumn, model.MemberRoleOwner).
+		Where(squirrel.Eq{userIDColumn: newOwnerID}).
+		Where(scope).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "TransferOwnership ToSql")
+	}
+	tag, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "TransferOwnership Exec")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(apperr.ErrNotFound, "member not found")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "TransferOwnership Commit")
+	}
+	return nil
+}
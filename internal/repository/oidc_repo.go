@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	oauthClientsTable  = "oauth_clients"
+	oauthCodesTable    = "oauth_authorization_codes"
+	oauthConsentsTable = "oauth_consents"
+
+	clientIDColumn      = "client_id"
+	redirectURIsColumn  = "redirect_uris"
+	redirectURIColumn   = "redirect_uri"
+	publicColumn        = "public"
+	nonceColumn         = "nonce"
+	codeChallengeColumn = "code_challenge"
+	grantedAtColumn     = "granted_at"
+)
+
+type OIDCRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewOIDCProvider(pool *pgxpool.Pool) *OIDCRepo {
+	return &OIDCRepo{
+		pool: pool,
+	}
+}
+
+func (s *OIDCRepo) AddClient(ctx context.Context, client model.OAuthClient) error {
+	builder := squirrel.Insert(oauthClientsTable).
+		Columns(idColumn, nameColumn, secretHashColumn, redirectURIsColumn, publicColumn).
+		Values(client.ID, client.Name, nullableString(client.SecretHash), client.RedirectURIs, client.Public).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "AddClient ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "AddClient Exec")
+	}
+
+	return nil
+}
+
+func (s *OIDCRepo) GetClient(ctx context.Context, id string) (*model.OAuthClient, error) {
+	builder := squirrel.Select(idColumn, nameColumn, "COALESCE("+secretHashColumn+", '')",
+		redirectURIsColumn, publicColumn, createdAtColumn).
+		From(oauthClientsTable).
+		Where(squirrel.Eq{idColumn: id}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetClient ToSql")
+	}
+
+	var client model.OAuthClient
+	err = s.pool.QueryRow(ctx, query, args...).Scan(&client.ID, &client.Name, &client.SecretHash,
+		&client.RedirectURIs, &client.Public, &client.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "client not found")
+		}
+		return nil, errors.Wrap(err, "GetClient Scan")
+	}
+
+	return &client, nil
+}
+
+func (s *OIDCRepo) AddAuthorizationCode(ctx context.Context, code model.AuthorizationCode) error {
+	builder := squirrel.Insert(oauthCodesTable).
+		Columns(codeHashColumn, clientIDColumn, userIDColumn, redirectURIColumn, scopesColumn,
+			nonceColumn, codeChallengeColumn, expiresAtColumn).
+		Values(code.CodeHash, code.ClientID, code.UserID, code.RedirectURI, code.Scopes,
+			code.Nonce, code.CodeChallenge, code.ExpiresAt).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "AddAuthorizationCode ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "AddAuthorizationCode Exec")
+	}
+
+	return nil
+}
+
+// ConsumeAuthorizationCode помечает код использованным и возвращает его,
+// если он ещё не был обменян и не истёк.
+func (s *OIDCRepo) ConsumeAuthorizationCode(ctx context.Context, hash string) (*model.AuthorizationCode, error) {
+	builder := squirrel.Update(oauthCodesTable).
+		Set(usedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{codeHashColumn: hash, usedAtColumn: nil}).
+		Where(squirrel.Expr(expiresAtColumn + " > now()")).
+		Suffix("RETURNING code_hash, client_id, user_id, redirect_uri, scopes, nonce, code_challenge, expires_at").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "ConsumeAuthorizationCode ToSql")
+	}
+
+	var code model.AuthorizationCode
+	err = s.pool.QueryRow(ctx, query, args...).Scan(&code.CodeHash, &code.ClientID, &code.UserID,
+		&code.RedirectURI, &code.Scopes, &code.Nonce, &code.CodeChallenge, &code.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "authorization code not found")
+		}
+		return nil, errors.Wrap(err, "ConsumeAuthorizationCode Scan")
+	}
+
+	return &code, nil
+}
+
+func (s *OIDCRepo) GetConsent(ctx context.Context, userID uuid.UUID, clientID string) (*model.OAuthConsent, error) {
+	builder := squirrel.Select(userIDColumn, clientIDColumn, scopesColumn, grantedAtColumn).
+		From(oauthConsentsTable).
+		Where(squirrel.Eq{userIDColumn: userID, clientIDColumn: clientID}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetConsent ToSql")
+	}
+
+	var consent model.OAuthConsent
+	err = s.pool.QueryRow(ctx, query, args...).Scan(&consent.UserID, &consent.ClientID,
+		&consent.Scopes, &consent.GrantedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "consent not found")
+		}
+		return nil, errors.Wrap(err, "GetConsent Scan")
+	}
+
+	return &consent, nil
+}
+
+// SaveConsent создаёт согласие или заменяет набор прав в существующем.
+func (s *OIDCRepo) SaveConsent(ctx context.Context, consent model.OAuthConsent) error {
+	builder := squirrel.Insert(oauthConsentsTable).
+		Columns(userIDColumn, clientIDColumn, scopesColumn).
+		Values(consent.UserID, consent.ClientID, consent.Scopes).
+		Suffix("ON CONFLICT (user_id, client_id) DO UPDATE SET scopes = EXCLUDED.scopes, granted_at = now()").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "SaveConsent ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "SaveConsent Exec")
+	}
+
+	return nil
+}
+
+func (s *OIDCRepo) ListUserConsents(ctx context.Context, userID uuid.UUID) ([]model.OAuthConsent, error) {
+	builder := squirrel.Select(userIDColumn, clientIDColumn, scopesColumn, grantedAtColumn).
+		From(oauthConsentsTable).
+		Where(squirrel.Eq{userIDColumn: userID}).
+		OrderBy(grantedAtColumn + " DESC").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "ListUserConsents ToSql")
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListUserConsents Query")
+	}
+	defer rows.Close()
+
+	consents := make([]model.OAuthConsent, 0)
+	for rows.Next() {
+		var consent model.OAuthConsent
+		if err := rows.Scan(&consent.UserID, &consent.ClientID, &consent.Scopes, &consent.GrantedAt); err != nil {
+			return nil, errors.Wrap(err, "ListUserConsents Scan")
+		}
+		consents = append(consents, consent)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "ListUserConsents Rows")
+	}
+
+	return consents, nil
+}
+
+func (s *OIDCRepo) DeleteConsent(ctx context.Context, userID uuid.UUID, clientID string) error {
+	builder := squirrel.Delete(oauthConsentsTable).
+		Where(squirrel.Eq{userIDColumn: userID, clientIDColumn: clientID}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "DeleteConsent ToSql")
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "DeleteConsent Exec")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(apperr.ErrNotFound, "consent not found")
+	}
+
+	return nil
+}
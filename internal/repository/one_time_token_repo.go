@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	userTokensTable = "user_tokens"
+	purposeColumn   = "purpose"
+	usedAtColumn    = "used_at"
+)
+
+type OneTimeTokenRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewOneTimeTokenProvider(pool *pgxpool.Pool) *OneTimeTokenRepo {
+	return &OneTimeTokenRepo{
+		pool: pool,
+	}
+}
+
+func (s *OneTimeTokenRepo) AddToken(ctx context.Context, token model.OneTimeToken) error {
+	builder := squirrel.Insert(userTokensTable).
+		Columns(idColumn, userIDColumn, purposeColumn, tokenHashColumn, expiresAtColumn).
+		Values(token.ID, token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "AddToken ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "AddToken Exec")
+	}
+
+	return nil
+}
+
+// FindToken возвращает действующий токен, не помечая его использованным.
+// Нужен, чтобы проверить запрос до того, как токен будет потрачен.
+func (s *OneTimeTokenRepo) FindToken(ctx context.Context, purpose string, hash string) (*model.OneTimeToken, error) {
+	builder := squirrel.Select(idColumn, userIDColumn, purposeColumn, tokenHashColumn,
+		expiresAtColumn, createdAtColumn, usedAtColumn).
+		From(userTokensTable).
+		Where(squirrel.Eq{tokenHashColumn: hash, purposeColumn: purpose, usedAtColumn: nil}).
+		Where(squirrel.Expr(expiresAtColumn + " > now()")).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "FindToken ToSql")
+	}
+
+	var token model.OneTimeToken
+	err = s.pool.QueryRow(ctx, query, args...).Scan(&token.ID, &token.UserID, &token.Purpose,
+		&token.TokenHash, &token.ExpiresAt, &token.CreatedAt, &token.UsedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "token not found")
+		}
+		return nil, errors.Wrap(err, "FindToken Scan")
+	}
+
+	return &token, nil
+}
+
+// ConsumeToken помечает неиспользованный и не истёкший токен использованным
+// и возвращает его. Одним запросом, чтобы токен нельзя было применить дважды.
+func (s *OneTimeTokenRepo) ConsumeToken(ctx context.Context, purpose string, hash string) (*model.OneTimeToken, error) {
+	builder := squirrel.Update(userTokensTable).
+		Set(usedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{tokenHashColumn: hash, purposeColumn: purpose, usedAtColumn: nil}).
+		Where(squirrel.Expr(expiresAtColumn + " > now()")).
+		Suffix("RETURNING id, user_id, purpose, token_hash, expires_at, created_at, used_at").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "ConsumeToken ToSql")
+	}
+
+	var token model.OneTimeToken
+	err = s.pool.QueryRow(ctx, query, args...).Scan(&token.ID, &token.UserID, &token.Purpose,
+		&token.TokenHash, &token.ExpiresAt, &token.CreatedAt, &token.UsedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "token not found")
+		}
+		return nil, errors.Wrap(err, "ConsumeToken Scan")
+	}
+
+	return &token, nil
+}
+
+// InvalidateUserTokens гасит все ещё действующие токены пользователя с данной целью.
+func (s *OneTimeTokenRepo) InvalidateUserTokens(ctx context.Context, userID uuid.UUID, purpose string) error {
+	builder := squirrel.Update(userTokensTable).
+		Set(usedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{userIDColumn: userID, purposeColumn: purpose, usedAtColumn: nil}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "InvalidateUserTokens ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "InvalidateUserTokens Exec")
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	organizationsTable = "organizations"
+
+	slugColumn = "slug"
+)
+
+var organizationColumns = []string{idColumn, slugColumn, nameColumn, createdAtColumn}
+
+type OrganizationRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewOrganizationProvider(pool *pgxpool.Pool) *OrganizationRepo {
+	return &OrganizationRepo{
+		pool: pool,
+	}
+}
+
+func (s *OrganizationRepo) ListOrganizations(ctx context.Context) ([]model.Organization, error) {
+	query, args, err := squirrel.Select(organizationColumns...).
+		From(organizationsTable).
+		OrderBy(slugColumn).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "ListOrganizations ToSql")
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListOrganizations Query")
+	}
+	defer rows.Close()
+
+	organizations := make([]model.Organization, 0)
+	for rows.Next() {
+		organization, err := scanOrganization(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "ListOrganizations Scan")
+		}
+		organizations = append(organizations, *organization)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "ListOrganizations Rows")
+	}
+
+	return organizations, nil
+}
+
+func (s *OrganizationRepo) GetOrganizationBySlug(ctx context.Context, slug string) (*model.Organization, error) {
+	query, args, err := squirrel.Select(organizationColumns...).
+		From(organizationsTable).
+		Where(squirrel.Eq{slugColumn: slug}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetOrganizationBySlug ToSql")
+	}
+
+	organization, err := scanOrganization(s.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "organization not found")
+		}
+		return nil, errors.Wrap(err, "GetOrganizationBySlug Scan")
+	}
+
+	return organization, nil
+}
+
+func (s *OrganizationRepo) AddOrganization(ctx context.Context, organization model.Organization) error {
+	query, args, err := squirrel.Insert(organizationsTable).
+		Columns(idColumn, slugColumn, nameColumn).
+		Values(organization.ID, organization.Slug, organization.Name).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "AddOrganization ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			return errors.Wrap(apperr.ErrAlreadyExists, "organization already exists")
+		}
+		return errors.Wrap(err, "AddOrganization Exec")
+	}
+
+	return nil
+}
+
+func scanOrganization(row pgx.Row) (*model.Organization, error) {
+	var organization model.Organization
+	if err := row.Scan(&organization.ID, &organization.Slug, &organization.Name, &organization.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
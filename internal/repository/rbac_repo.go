@@ -0,0 +1,471 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	rolesTable           = "roles"
+	rolePermissionsTable = "role_permissions"
+	userRolesTable       = "user_roles"
+	groupsTable          = "groups"
+	groupMembersTable    = "group_members"
+	groupRolesTable      = "group_roles"
+
+	roleColumn        = "role"
+	permissionColumn  = "permission"
+	descriptionColumn = "description"
+	groupIDColumn     = "group_id"
+
+	roleColumnsList  = "name, description, ARRAY(SELECT permission FROM role_permissions WHERE role_permissions.role = roles.name ORDER BY permission), created_at"
+	groupColumnsList = "id, name, description, ARRAY(SELECT role FROM group_roles WHERE group_roles.group_id = groups.id ORDER BY role), created_at"
+
+	// Роли пользователя: назначенные напрямую и полученные через группы
+	effectiveRolesQuery = "role IN (SELECT role FROM user_roles WHERE user_id = ? " +
+		"UNION SELECT group_roles.role FROM group_roles " +
+		"JOIN group_members ON group_members.group_id = group_roles.group_id WHERE group_members.user_id = ?)"
+)
+
+type RBACRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewRBACProvider(pool *pgxpool.Pool) *RBACRepo {
+	return &RBACRepo{
+		pool: pool,
+	}
+}
+
+func (s *RBACRepo) ListRoles(ctx context.Context) ([]model.Role, error) {
+	builder := squirrel.Select(roleColumnsList).
+		From(rolesTable).
+		OrderBy(nameColumn).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "ListRoles ToSql")
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListRoles Query")
+	}
+	defer rows.Close()
+
+	roles := make([]model.Role, 0)
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "ListRoles Scan")
+		}
+		roles = append(roles, *role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "ListRoles Rows")
+	}
+
+	return roles, nil
+}
+
+func (s *RBACRepo) GetRole(ctx context.Context, name string) (*model.Role, error) {
+	builder := squirrel.Select(roleColumnsList).
+		From(rolesTable).
+		Where(squirrel.Eq{nameColumn: name}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetRole ToSql")
+	}
+
+	role, err := scanRole(s.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "role not found")
+		}
+		return nil, errors.Wrap(err, "GetRole Scan")
+	}
+
+	return role, nil
+}
+
+func (s *RBACRepo) AddRole(ctx context.Context, role model.Role) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "AddRole Begin")
+	}
+	defer tx.Rollback(ctx)
+
+	query, args, err := squirrel.Insert(rolesTable).
+		Columns(nameColumn, descriptionColumn).
+		Values(role.Name, role.Description).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "AddRole ToSql")
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			return errors.Wrap(apperr.ErrAlreadyExists, "role already exists")
+		}
+		return errors.Wrap(err, "AddRole Exec")
+	}
+
+	if err := insertRolePermissions(ctx, tx, role.Name, role.Permissions); err != nil {
+		return errors.Wrap(err, "AddRole")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "AddRole Commit")
+	}
+	return nil
+}
+
+// UpdateRole меняет описание и заменяет набор прав роли целиком.
+func (s *RBACRepo) UpdateRole(ctx context.Context, name string, req model.UpdateRoleRequest) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "UpdateRole Begin")
+	}
+	defer tx.Rollback(ctx)
+
+	query, args, err := squirrel.Update(rolesTable).
+		Set(descriptionColumn, req.Description).
+		Where(squirrel.Eq{nameColumn: name}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "UpdateRole ToSql")
+	}
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "UpdateRole Exec")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(apperr.ErrNotFound, "role not found")
+	}
+
+	query, args, err = squirrel.Delete(rolePermissionsTable).
+		Where(squirrel.Eq{roleColumn: name}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "UpdateRole ToSql")
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "UpdateRole Exec")
+	}
+
+	if err := insertRolePermissions(ctx, tx, name, req.Permissions); err != nil {
+		return errors.Wrap(err, "UpdateRole")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "UpdateRole Commit")
+	}
+	return nil
+}
+
+// DeleteRole снимает роль со всех пользователей и групп.
+func (s *RBACRepo) DeleteRole(ctx context.Context, name string) error {
+	return s.deleteWhere(ctx, "DeleteRole", rolesTable, squirrel.Eq{nameColumn: name}, "role not found")
+}
+
+// AssignUserRole назначает роль только пользователю организации из контекста:
+// справочник ролей общий, а пользователи разделены по организациям.
+func (s *RBACRepo) AssignUserRole(ctx context.Context, userID uuid.UUID, role string) error {
+	if err := s.checkTenantUser(ctx, "AssignUserRole", userID); err != nil {
+		return err
+	}
+	return s.link(ctx, "AssignUserRole", userRolesTable, userIDColumn, userID, role)
+}
+
+func (s *RBACRepo) RevokeUserRole(ctx context.Context, userID uuid.UUID, role string) error {
+	scope, err := tenantUserScope(ctx)
+	if err != nil {
+		return errors.Wrap(err, "RevokeUserRole")
+	}
+	return s.deleteWhere(ctx, "RevokeUserRole", userRolesTable,
+		squirrel.And{squirrel.Eq{userIDColumn: userID, roleColumn: role}, scope}, "role is not assigned")
+}
+
+func (s *RBACRepo) ListGroups(ctx context.Context) ([]model.Group, error) {
+	builder := squirrel.Select(groupColumnsList).
+		From(groupsTable).
+		OrderBy(nameColumn).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "ListGroups ToSql")
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListGroups Query")
+	}
+	defer rows.Close()
+
+	groups := make([]model.Group, 0)
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "ListGroups Scan")
+		}
+		groups = append(groups, *group)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "ListGroups Rows")
+	}
+
+	return groups, nil
+}
+
+func (s *RBACRepo) GetGroup(ctx context.Context, id uuid.UUID) (*model.Group, error) {
+	builder := squirrel.Select(groupColumnsList).
+		From(groupsTable).
+		Where(squirrel.Eq{idColumn: id}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetGroup ToSql")
+	}
+
+	group, err := scanGroup(s.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "group not found")
+		}
+		return nil, errors.Wrap(err, "GetGroup Scan")
+	}
+
+	return group, nil
+}
+
+func (s *RBACRepo) AddGroup(ctx context.Context, group model.Group) error {
+	builder := squirrel.Insert(groupsTable).
+		Columns(idColumn, nameColumn, descriptionColumn).
+		Values(group.ID, group.Name, group.Description).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "AddGroup ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			return errors.Wrap(apperr.ErrAlreadyExists, "group already exists")
+		}
+		return errors.Wrap(err, "AddGroup Exec")
+	}
+
+	return nil
+}
+
+func (s *RBACRepo) DeleteGroup(ctx context.Context, id uuid.UUID) error {
+	return s.deleteWhere(ctx, "DeleteGroup", groupsTable, squirrel.Eq{idColumn: id}, "group not found")
+}
+
+func (s *RBACRepo) ListGroupMembers(ctx context.Context, groupID uuid.UUID) ([]uuid.UUID, error) {
+	builder := squirrel.Select(userIDColumn).
+		From(groupMembersTable).
+		Where(squirrel.Eq{groupIDColumn: groupID}).
+		OrderBy(userIDColumn).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "ListGroupMembers ToSql")
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListGroupMembers Query")
+	}
+
+	members, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, errors.Wrap(err, "ListGroupMembers Scan")
+	}
+
+	return members, nil
+}
+
+func (s *RBACRepo) AddGroupMember(ctx context.Context, groupID uuid.UUID, userID uuid.UUID) error {
+	if err := s.checkTenantUser(ctx, "AddGroupMember", userID); err != nil {
+		return err
+	}
+
+	builder := squirrel.Insert(groupMembersTable).
+		Columns(groupIDColumn, userIDColumn).
+		Values(groupID, userID).
+		Suffix("ON CONFLICT DO NOTHING").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "AddGroupMember ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		if isForeignKeyViolation(err) {
+			return errors.Wrap(apperr.ErrNotFound, "group or user not found")
+		}
+		return errors.Wrap(err, "AddGroupMember Exec")
+	}
+
+	return nil
+}
+
+func (s *RBACRepo) RemoveGroupMember(ctx context.Context, groupID uuid.UUID, userID uuid.UUID) error {
+	scope, err := tenantUserScope(ctx)
+	if err != nil {
+		return errors.Wrap(err, "RemoveGroupMember")
+	}
+	return s.deleteWhere(ctx, "RemoveGroupMember", groupMembersTable,
+		squirrel.And{squirrel.Eq{groupIDColumn: groupID, userIDColumn: userID}, scope}, "user is not a member")
+}
+
+func (s *RBACRepo) AssignGroupRole(ctx context.Context, groupID uuid.UUID, role string) error {
+	return s.link(ctx, "AssignGroupRole", groupRolesTable, groupIDColumn, groupID, role)
+}
+
+func (s *RBACRepo) RevokeGroupRole(ctx context.Context, groupID uuid.UUID, role string) error {
+	return s.deleteWhere(ctx, "RevokeGroupRole", groupRolesTable,
+		squirrel.Eq{groupIDColumn: groupID, roleColumn: role}, "role is not assigned")
+}
+
+// GetUserPermissions возвращает права всех ролей пользователя, включая роли его групп.
+func (s *RBACRepo) GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	builder := squirrel.Select(permissionColumn).
+		Distinct().
+		From(rolePermissionsTable).
+		Where(squirrel.Expr(effectiveRolesQuery, userID, userID)).
+		OrderBy(permissionColumn).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetUserPermissions ToSql")
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetUserPermissions Query")
+	}
+
+	permissions, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, errors.Wrap(err, "GetUserPermissions Scan")
+	}
+
+	return permissions, nil
+}
+
+// link назначает роль пользователю или группе. Повторное назначение не ошибка.
+func (s *RBACRepo) link(ctx context.Context, op string, table string, ownerColumn string, ownerID uuid.UUID,
+	role string) error {
+	builder := squirrel.Insert(table).
+		Columns(ownerColumn, roleColumn).
+		Values(ownerID, role).
+		Suffix("ON CONFLICT DO NOTHING").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, op+" ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		if isForeignKeyViolation(err) {
+			return errors.Wrap(apperr.ErrNotFound, "role or assignee not found")
+		}
+		return errors.Wrap(err, op+" Exec")
+	}
+
+	return nil
+}
+
+// checkTenantUser возвращает ErrNotFound, если пользователя нет в организации из контекста.
+func (s *RBACRepo) checkTenantUser(ctx context.Context, op string, userID uuid.UUID) error {
+	exists, err := tenantUserExists(ctx, s.pool, userID)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+	if !exists {
+		return errors.Wrap(apperr.ErrNotFound, "user not found")
+	}
+	return nil
+}
+
+func (s *RBACRepo) deleteWhere(ctx context.Context, op string, table string, where squirrel.Sqlizer,
+	notFound string) error {
+	query, args, err := squirrel.Delete(table).
+		Where(where).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, op+" ToSql")
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, op+" Exec")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(apperr.ErrNotFound, notFound)
+	}
+
+	return nil
+}
+
+func insertRolePermissions(ctx context.Context, tx pgx.Tx, role string, permissions []string) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	builder := squirrel.Insert(rolePermissionsTable).
+		Columns(roleColumn, permissionColumn).
+		Suffix("ON CONFLICT DO NOTHING").
+		PlaceholderFormat(squirrel.Dollar)
+	for _, permission := range permissions {
+		builder = builder.Values(role, permission)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "insert permissions ToSql")
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "insert permissions Exec")
+	}
+	return nil
+}
+
+func scanRole(row pgx.Row) (*model.Role, error) {
+	var role model.Role
+	if err := row.Scan(&role.Name, &role.Description, &role.Permissions, &role.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func scanGroup(row pgx.Row) (*model.Group, error) {
+	var group model.Group
+	if err := row.Scan(&group.ID, &group.Name, &group.Description, &group.Roles, &group.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	refreshTokensTable = "refresh_tokens"
+	userIDColumn       = "user_id"
+	sessionIDColumn    = "session_id"
+	tokenHashColumn    = "token_hash"
+	expiresAtColumn    = "expires_at"
+	createdAtColumn    = "created_at"
+	revokedAtColumn    = "revoked_at"
+)
+
+type RefreshTokenRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewRefreshTokenProvider(pool *pgxpool.Pool) *RefreshTokenRepo {
+	return &RefreshTokenRepo{
+		pool: pool,
+	}
+}
+
+func (s *RefreshTokenRepo) AddRefreshToken(ctx context.Context, token model.RefreshToken) error {
+	builder := squirrel.Insert(refreshTokensTable).
+		Columns(idColumn, userIDColumn, sessionIDColumn, tokenHashColumn, expiresAtColumn).
+		Values(token.ID, token.UserID, token.SessionID, token.TokenHash, token.ExpiresAt).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "AddRefreshToken ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "AddRefreshToken Exec")
+	}
+
+	return nil
+}
+
+func (s *RefreshTokenRepo) GetRefreshTokenByHash(ctx context.Context, hash string) (*model.RefreshToken, error) {
+	builder := squirrel.Select(idColumn, userIDColumn, sessionIDColumn, tokenHashColumn,
+		expiresAtColumn, createdAtColumn, revokedAtColumn).
+		From(refreshTokensTable).
+		Where(squirrel.Eq{tokenHashColumn: hash}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetRefreshTokenByHash ToSql")
+	}
+
+	var token model.RefreshToken
+	err = s.pool.QueryRow(ctx, query, args...).Scan(&token.ID, &token.UserID, &token.SessionID,
+		&token.TokenHash, &token.ExpiresAt, &token.CreatedAt, &token.RevokedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "refresh token not found")
+		}
+		return nil, errors.Wrap(err, "GetRefreshTokenByHash Scan")
+	}
+
+	return &token, nil
+}
+
+// RevokeRefreshToken отзывает токен и сообщает, был ли он активен до этого.
+// Так два параллельных обновления одним токеном не получат две новые пары.
+func (s *RefreshTokenRepo) RevokeRefreshToken(ctx context.Context, id uuid.UUID) (bool, error) {
+	builder := squirrel.Update(refreshTokensTable).
+		Set(revokedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{idColumn: id, revokedAtColumn: nil}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return false, errors.Wrap(err, "RevokeRefreshToken ToSql")
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, errors.Wrap(err, "RevokeRefreshToken Exec")
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
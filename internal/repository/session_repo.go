@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	sessionsTable      = "sessions"
+	deviceColumn       = "device"
+	ipColumn           = "ip"
+	userAgentColumn    = "user_agent"
+	lastSeenAtColumn   = "last_seen_at"
+	sessionColumnsList = "id, user_id, device, ip, user_agent, created_at, last_seen_at, revoked_at"
+)
+
+type SessionRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewSessionProvider(pool *pgxpool.Pool) *SessionRepo {
+	return &SessionRepo{
+		pool: pool,
+	}
+}
+
+func (s *SessionRepo) AddSession(ctx context.Context, session model.Session) error {
+	builder := squirrel.Insert(sessionsTable).
+		Columns(idColumn, userIDColumn, deviceColumn, ipColumn, userAgentColumn).
+		Values(session.ID, session.UserID, session.Device, session.IP, session.UserAgent).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "AddSession ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "AddSession Exec")
+	}
+
+	return nil
+}
+
+func (s *SessionRepo) GetSession(ctx context.Context, id uuid.UUID) (*model.Session, error) {
+	builder := squirrel.Select(sessionColumnsList).
+		From(sessionsTable).
+		Where(squirrel.Eq{idColumn: id}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetSession ToSql")
+	}
+
+	session, err := scanSession(s.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "session not found")
+		}
+		return nil, errors.Wrap(err, "GetSession Scan")
+	}
+
+	return session, nil
+}
+
+func (s *SessionRepo) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]model.Session, error) {
+	scope, err := tenantUserScope(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListUserSessions")
+	}
+
+	builder := squirrel.Select(sessionColumnsList).
+		From(sessionsTable).
+		Where(squirrel.Eq{userIDColumn: userID, revokedAtColumn: nil}).
+		Where(scope).
+		OrderBy(lastSeenAtColumn + " DESC").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "ListUserSessions ToSql")
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListUserSessions Query")
+	}
+	defer rows.Close()
+
+	sessions := make([]model.Session, 0)
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "ListUserSessions Scan")
+		}
+		sessions = append(sessions, *session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "ListUserSessions Rows")
+	}
+
+	return sessions, nil
+}
+
+func (s *SessionRepo) TouchSession(ctx context.Context, id uuid.UUID) error {
+	builder := squirrel.Update(sessionsTable).
+		Set(lastSeenAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{idColumn: id}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "TouchSession ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "TouchSession Exec")
+	}
+
+	return nil
+}
+
+// RevokeSession отзывает активную сессию пользователя userID.
+func (s *SessionRepo) RevokeSession(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	scope, err := tenantUserScope(ctx)
+	if err != nil {
+		return errors.Wrap(err, "RevokeSession")
+	}
+
+	builder := squirrel.Update(sessionsTable).
+		Set(revokedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{idColumn: id, userIDColumn: userID, revokedAtColumn: nil}).
+		Where(scope).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "RevokeSession ToSql")
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "RevokeSession Exec")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(apperr.ErrNotFound, "session not found")
+	}
+
+	return nil
+}
+
+// RevokeUserSessions отзывает все активные сессии пользователя, кроме except.
+// uuid.Nil в except означает "отозвать все".
+func (s *SessionRepo) RevokeUserSessions(ctx context.Context, userID uuid.UUID, except uuid.UUID) error {
+	scope, err := tenantUserScope(ctx)
+	if err != nil {
+		return errors.Wrap(err, "RevokeUserSessions")
+	}
+
+	builder := squirrel.Update(sessionsTable).
+		Set(revokedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{userIDColumn: userID, revokedAtColumn: nil}).
+		Where(scope).
+		PlaceholderFormat(squirrel.Dollar)
+	if except != uuid.Nil {
+		builder = builder.Where(squirrel.NotEq{idColumn: except})
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "RevokeUserSessions ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "RevokeUserSessions Exec")
+	}
+
+	return nil
+}
+
+func scanSession(row pgx.Row) (*model.Session, error) {
+	var session model.Session
+	err := row.Scan(&session.ID, &session.UserID, &session.Device, &session.IP, &session.UserAgent,
+		&session.CreatedAt, &session.LastSeenAt, &session.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
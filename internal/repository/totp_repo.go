@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	totpTable          = "user_totp"
+	recoveryCodesTable = "recovery_codes"
+	secretColumn       = "secret"
+	confirmedAtColumn  = "confirmed_at"
+	lastUsedStepColumn = "last_used_step"
+	codeHashColumn     = "code_hash"
+)
+
+type TOTPRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewTOTPProvider(pool *pgxpool.Pool) *TOTPRepo {
+	return &TOTPRepo{
+		pool: pool,
+	}
+}
+
+// SaveTOTP сохраняет новый, ещё не подтверждённый секрет вместо прежнего.
+func (s *TOTPRepo) SaveTOTP(ctx context.Context, totp model.TOTP) error {
+	builder := squirrel.Insert(totpTable).
+		Columns(userIDColumn, secretColumn).
+		Values(totp.UserID, totp.Secret).
+		Suffix("ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, " +
+			"confirmed_at = NULL, last_used_step = 0, created_at = now()").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "SaveTOTP ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "SaveTOTP Exec")
+	}
+
+	return nil
+}
+
+func (s *TOTPRepo) GetTOTP(ctx context.Context, userID uuid.UUID) (*model.TOTP, error) {
+	builder := squirrel.Select(userIDColumn, secretColumn, confirmedAtColumn, lastUsedStepColumn, createdAtColumn).
+		From(totpTable).
+		Where(squirrel.Eq{userIDColumn: userID}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetTOTP ToSql")
+	}
+
+	var totp model.TOTP
+	err = s.pool.QueryRow(ctx, query, args...).
+		Scan(&totp.UserID, &totp.Secret, &totp.ConfirmedAt, &totp.LastUsedStep, &totp.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "totp not found")
+		}
+		return nil, errors.Wrap(err, "GetTOTP Scan")
+	}
+
+	return &totp, nil
+}
+
+func (s *TOTPRepo) ConfirmTOTP(ctx context.Context, userID uuid.UUID) error {
+	builder := squirrel.Update(totpTable).
+		Set(confirmedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{userIDColumn: userID}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "ConfirmTOTP ToSql")
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "ConfirmTOTP Exec")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(apperr.ErrNotFound, "totp not found")
+	}
+
+	return nil
+}
+
+// UseTOTPStep запоминает шаг принятого кода. Возвращает false, если этот
+// или более поздний шаг уже использовался: код нельзя предъявить дважды.
+func (s *TOTPRepo) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	builder := squirrel.Update(totpTable).
+		Set(lastUsedStepColumn, step).
+		Where(squirrel.Eq{userIDColumn: userID}).
+		Where(squirrel.Lt{lastUsedStepColumn: step}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return false, errors.Wrap(err, "UseTOTPStep ToSql")
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, errors.Wrap(err, "UseTOTPStep Exec")
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// DeleteTOTP отключает второй фактор вместе с кодами восстановления.
+func (s *TOTPRepo) DeleteTOTP(ctx context.Context, userID uuid.UUID) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "DeleteTOTP Begin")
+	}
+	defer tx.Rollback(ctx)
+
+	for _, table := range []string{recoveryCodesTable, totpTable} {
+		query, args, err := squirrel.Delete(table).
+			Where(squirrel.Eq{userIDColumn: userID}).
+			PlaceholderFormat(squirrel.Dollar).
+			ToSql()
+		if err != nil {
+			return errors.Wrap(err, "DeleteTOTP ToSql")
+		}
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "DeleteTOTP Exec")
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "DeleteTOTP Commit")
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes заменяет все коды восстановления пользователя новыми.
+func (s *TOTPRepo) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, hashes []string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "ReplaceRecoveryCodes Begin")
+	}
+	defer tx.Rollback(ctx)
+
+	query, args, err := squirrel.Delete(recoveryCodesTable).
+		Where(squirrel.Eq{userIDColumn: userID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "ReplaceRecoveryCodes ToSql")
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return errors.Wrap(err, "ReplaceRecoveryCodes Exec")
+	}
+
+	if len(hashes) > 0 {
+		builder := squirrel.Insert(recoveryCodesTable).
+			Columns(idColumn, userIDColumn, codeHashColumn).
+			PlaceholderFormat(squirrel.Dollar)
+		for _, hash := range hashes {
+			id, err := uuid.NewV7()
+			if err != nil {
+				return errors.Wrap(err, "ReplaceRecoveryCodes generate id")
+			}
+			builder = builder.Values(id, userID, hash)
+		}
+
+		query, args, err := builder.ToSql()
+		if err != nil {
+			return errors.Wrap(err, "ReplaceRecoveryCodes ToSql")
+		}
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return errors.Wrap(err, "ReplaceRecoveryCodes Exec")
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "ReplaceRecoveryCodes Commit")
+	}
+	return nil
+}
+
+// UseRecoveryCode гасит неиспользованный код. Возвращает false, если такого нет.
+func (s *TOTPRepo) UseRecoveryCode(ctx context.Context, userID uuid.UUID, hash string) (bool, error) {
+	builder := squirrel.Update(recoveryCodesTable).
+		Set(usedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{userIDColumn: userID, codeHashColumn: hash, usedAtColumn: nil}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return false, errors.Wrap(err, "UseRecoveryCode ToSql")
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, errors.Wrap(err, "UseRecoveryCode Exec")
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
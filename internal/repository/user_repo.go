@@ -2,13 +2,17 @@ package repository
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/lemavisaitov/lk-api/internal/apperr"
 	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pkg/errors"
 )
@@ -20,8 +24,78 @@ const (
 	passwordColumn = "password"
 	nameColumn     = "name"
 	ageColumn      = "age"
+	emailColumn    = "email"
+
+	emailVerifiedAtColumn = "email_verified_at"
+	scoreColumn           = "score"
+	deletedAtColumn       = "deleted_at"
+	versionColumn         = "version"
+	avatarIDColumn        = "avatar_id"
+	organizationIDColumn  = "organization_id"
+
+	uniqueViolationCode     = "23505"
+	foreignKeyViolationCode = "23503"
+	checkViolationCode      = "23514"
+
+	rolesColumn = "ARRAY(SELECT role FROM user_roles WHERE user_roles.user_id = users.id)"
 )
 
+// nextVersion увеличивает версию строки, её меняет любое изменение пользователя
+var nextVersion = squirrel.Expr(versionColumn + " + 1")
+
+// notDeleted скрывает удалённых пользователей: до очистки строка остаётся в
+// таблице, но для чтения и изменения её нет.
+var notDeleted = squirrel.Eq{deletedAtColumn: nil}
+
+// tenantScope ограничивает запрос организацией из контекста. Без организации
+// запрос не выполняется: лучше ошибка, чем пользователи чужой организации.
+func tenantScope(ctx context.Context) (squirrel.Eq, error) {
+	organizationID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, apperr.ErrNoTenant
+	}
+	return squirrel.Eq{organizationIDColumn: organizationID}, nil
+}
+
+// tenantUserScope оставляет строки пользователей организации из контекста. Таблицам,
+// связанным с users по user_id, своя колонка организации не нужна.
+func tenantUserScope(ctx context.Context) (squirrel.Sqlizer, error) {
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users, args, err := squirrel.Select(idColumn).From(tableName).Where(scope).ToSql()
+	if err != nil {
+		return nil, err
+	}
+	return squirrel.Expr(userIDColumn+" IN ("+users+")", args...), nil
+}
+
+// tenantUserExists проверяет, что пользователь состоит в организации из контекста.
+func tenantUserExists(ctx context.Context, pool *pgxpool.Pool, userID uuid.UUID) (bool, error) {
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return false, err
+	}
+	query, args, err := squirrel.Select("1").
+		Prefix("SELECT EXISTS (").
+		From(tableName).
+		Where(squirrel.Eq{idColumn: userID}).
+		Where(scope).
+		Suffix(")").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	var exists bool
+	if err := pool.QueryRow(ctx, query, args...).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
 type UserRepo struct {
 	pool *pgxpool.Pool
 }
@@ -32,29 +106,97 @@ func NewUserProvider(pool *pgxpool.Pool) *UserRepo {
 	}
 }
 
+// AddUser создаёт пользователя в организации из контекста, user.OrganizationID не учитывается.
 func (s *UserRepo) AddUser(ctx context.Context, user model.User) error {
+	organizationID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return errors.Wrap(apperr.ErrNoTenant, "AddUser")
+	}
+
+	// Пользователь появляется в организации сразу рядовым участником
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "AddUser Begin")
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertUser(ctx, tx, organizationID, user, false); err != nil {
+		return errors.Wrap(err, "AddUser")
+	}
+	if err := insertMember(ctx, tx, organizationID, user.ID, model.MemberRoleMember); err != nil {
+		return errors.Wrap(err, "AddUser")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "AddUser Commit")
+	}
+	return nil
+}
+
+// insertUser добавляет строку пользователя. emailVerified - почта уже подтверждена,
+// например ссылкой из приглашения.
+func insertUser(ctx context.Context, tx pgx.Tx, organizationID uuid.UUID, user model.User, emailVerified bool) error {
+	var emailVerifiedAt any
+	if emailVerified {
+		emailVerifiedAt = squirrel.Expr("now()")
+	}
+
 	builder := squirrel.Insert(tableName).
-		Columns(idColumn, loginColumn, passwordColumn, nameColumn, ageColumn).
-		Values(user.ID, user.Login, user.Password, user.Name, user.Age).
+		Columns(idColumn, organizationIDColumn, loginColumn, passwordColumn, nameColumn, ageColumn, emailColumn,
+			attributesColumn, emailVerifiedAtColumn).
+		Values(user.ID, organizationID, user.Login, user.Password, user.Name, user.Age, nullableString(user.Email),
+			attributesValue(user.Attributes), emailVerifiedAt).
 		PlaceholderFormat(squirrel.Dollar)
 
 	query, args, err := builder.ToSql()
 	if err != nil {
-		return errors.Wrap(err, "AddUser ToSql")
+		return errors.Wrap(err, "insertUser ToSql")
+	}
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			return errors.Wrap(apperr.ErrAlreadyExists, "insertUser Exec")
+		}
+		if validationErr := checkViolation(err); validationErr != nil {
+			return errors.Wrap(validationErr, "insertUser Exec")
+		}
+		return errors.Wrap(err, "insertUser Exec")
 	}
+	return nil
+}
 
-	_, err = s.pool.Exec(ctx, query, args...)
+func insertMember(ctx context.Context, tx pgx.Tx, organizationID uuid.UUID, userID uuid.UUID, role string) error {
+	query, args, err := squirrel.Insert(membersTable).
+		Columns(userIDColumn, organizationIDColumn, roleColumn).
+		Values(userID, organizationID, role).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
 	if err != nil {
-		return errors.Wrap(err, "AddUser Exec")
+		return errors.Wrap(err, "insertMember ToSql")
 	}
 
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			return errors.Wrap(apperr.ErrAlreadyExists, "organization already has an owner")
+		}
+		return errors.Wrap(err, "insertMember Exec")
+	}
 	return nil
 }
 
 func (s *UserRepo) GetUser(ctx context.Context, id uuid.UUID) (*model.User, error) {
-	builder := squirrel.Select(idColumn, loginColumn, passwordColumn, nameColumn, ageColumn).
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetUser")
+	}
+
+	builder := squirrel.Select(idColumn, loginColumn, passwordColumn, nameColumn, ageColumn,
+		"COALESCE("+emailColumn+", '')", emailVerifiedAtColumn, rolesColumn, createdAtColumn, versionColumn,
+		attributesColumn, avatarIDColumn, organizationIDColumn).
 		From(tableName).
 		Where(squirrel.Eq{idColumn: id}).
+		Where(scope).
+		Where(notDeleted).
 		PlaceholderFormat(squirrel.Dollar)
 
 	var user model.User
@@ -65,7 +207,9 @@ func (s *UserRepo) GetUser(ctx context.Context, id uuid.UUID) (*model.User, erro
 	}
 
 	row := s.pool.QueryRow(ctx, query, args...)
-	err = row.Scan(&user.ID, &user.Login, &user.Password, &user.Name, &user.Age)
+	err = row.Scan(&user.ID, &user.Login, &user.Password, &user.Name, &user.Age,
+		&user.Email, &user.EmailVerifiedAt, &user.Roles, &user.CreatedAt, &user.Version, &user.Attributes,
+		&user.AvatarID, &user.OrganizationID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			user.ID = uuid.Nil
@@ -79,19 +223,44 @@ func (s *UserRepo) GetUser(ctx context.Context, id uuid.UUID) (*model.User, erro
 }
 
 func (s *UserRepo) UpdateUser(ctx context.Context, toUpdate model.UpdateUserRequest) (*uuid.UUID, error) {
+	// Пустой запрос не должен менять версию, иначе он сломает ETag у других клиентов
+	if toUpdate.Empty() {
+		return nil, errors.New("UpdateUser: nothing to update")
+	}
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "UpdateUser")
+	}
+
 	builder := squirrel.Update("users")
-	if toUpdate.Name != "" {
-		builder = builder.Set(nameColumn, toUpdate.Name)
+	if toUpdate.Name != nil {
+		builder = builder.Set(nameColumn, *toUpdate.Name)
+	}
+	if toUpdate.Age != nil {
+		builder = builder.Set(ageColumn, *toUpdate.Age)
 	}
-	if toUpdate.Age != 0 {
-		builder = builder.Set(ageColumn, toUpdate.Age)
+	if toUpdate.Password != nil {
+		builder = builder.Set(passwordColumn, *toUpdate.Password)
 	}
-	if toUpdate.Password != "" {
-		builder = builder.Set(passwordColumn, toUpdate.Password)
+	if toUpdate.Email != nil {
+		email := nullableString(*toUpdate.Email)
+		// SET видит старые значения строки, поэтому сравнение идёт с прежней почтой
+		builder = builder.Set(emailVerifiedAtColumn, squirrel.Expr(
+			"CASE WHEN lower("+emailColumn+") = lower(?) THEN "+emailVerifiedAtColumn+" END", email)).
+			Set(emailColumn, email)
 	}
-	builder = builder.Where(squirrel.Eq{idColumn: toUpdate.ID}).
+	if toUpdate.Attributes != nil {
+		builder = builder.Set(attributesColumn, attributesValue(toUpdate.Attributes))
+	}
+	builder = builder.Set(versionColumn, nextVersion).
+		Where(squirrel.Eq{idColumn: toUpdate.ID}).
+		Where(scope).
+		Where(notDeleted).
 		Suffix("RETURNING " + idColumn).
 		PlaceholderFormat(squirrel.Dollar)
+	if toUpdate.Version != 0 {
+		builder = builder.Where(squirrel.Eq{versionColumn: toUpdate.Version})
+	}
 
 	var id uuid.UUID
 
@@ -103,7 +272,13 @@ func (s *UserRepo) UpdateUser(ctx context.Context, toUpdate model.UpdateUserRequ
 	err = s.pool.QueryRow(ctx, query, args...).Scan(&id)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, errors.Wrap(apperr.ErrNotFound, "User ID not found")
+			return nil, errors.Wrap(s.missingOrConflict(ctx, toUpdate.ID, toUpdate.Version), "UpdateUser Scan")
+		}
+		if isUniqueViolation(err) {
+			return nil, errors.Wrap(apperr.ErrAlreadyExists, "UpdateUser Scan")
+		}
+		if validationErr := checkViolation(err); validationErr != nil {
+			return nil, errors.Wrap(validationErr, "UpdateUser Scan")
 		}
 		return nil, errors.Wrap(err, "UpdateUser Scan")
 	}
@@ -112,9 +287,16 @@ func (s *UserRepo) UpdateUser(ctx context.Context, toUpdate model.UpdateUserRequ
 }
 
 func (s *UserRepo) GetUserIDByLogin(ctx context.Context, login string) (*uuid.UUID, error) {
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetUserIDByLogin")
+	}
+
 	builder := squirrel.Select(idColumn).
 		From(tableName).
 		Where(squirrel.Eq{loginColumn: login}).
+		Where(scope).
+		Where(notDeleted).
 		PlaceholderFormat(squirrel.Dollar)
 
 	var id uuid.UUID
@@ -136,22 +318,514 @@ func (s *UserRepo) GetUserIDByLogin(ctx context.Context, login string) (*uuid.UU
 	return &id, nil
 }
 
-func (s *UserRepo) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	builder := squirrel.Delete(tableName).
+func (s *UserRepo) GetUserIDByEmail(ctx context.Context, email string) (*uuid.UUID, error) {
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "GetUserIDByEmail")
+	}
+
+	builder := squirrel.Select(idColumn).
+		From(tableName).
+		Where(squirrel.Expr("lower("+emailColumn+") = lower(?)", email)).
+		Where(scope).
+		Where(notDeleted).
+		PlaceholderFormat(squirrel.Dollar)
+
+	var id uuid.UUID
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetUserIDByEmail ToSql")
+	}
+
+	if err := s.pool.QueryRow(ctx, query, args...).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "email not found")
+		}
+		return nil, errors.Wrap(err, "GetUserIDByEmail Scan")
+	}
+
+	return &id, nil
+}
+
+// EmailInOtherOrganization проверяет, занята ли почта пользователем другой организации.
+// Чужих пользователей запросы не видят, поэтому проверку делает функция БД с правами
+// владельца таблицы: она отдаёт только да или нет.
+func (s *UserRepo) EmailInOtherOrganization(ctx context.Context, email string) (bool, error) {
+	organizationID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return false, errors.Wrap(apperr.ErrNoTenant, "EmailInOtherOrganization")
+	}
+
+	var exists bool
+	err := s.pool.QueryRow(ctx, "SELECT email_in_other_organization($1, $2)", email, organizationID).Scan(&exists)
+	if err != nil {
+		return false, errors.Wrap(err, "EmailInOtherOrganization Scan")
+	}
+
+	return exists, nil
+}
+
+func (s *UserRepo) MarkEmailVerified(ctx context.Context, id uuid.UUID) error {
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return errors.Wrap(err, "MarkEmailVerified")
+	}
+
+	builder := squirrel.Update(tableName).
+		Set(emailVerifiedAtColumn, squirrel.Expr("now()")).
+		Set(versionColumn, nextVersion).
 		Where(squirrel.Eq{idColumn: id}).
+		Where(scope).
+		Where(squirrel.NotEq{emailColumn: nil}).
+		Where(notDeleted).
 		PlaceholderFormat(squirrel.Dollar)
 
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "MarkEmailVerified ToSql")
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "MarkEmailVerified Exec")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(apperr.ErrNotFound, "User ID not found")
+	}
+
+	return nil
+}
+
+// DeleteUser помечает пользователя удалённым. Данные и логин сохраняются
+// до PurgeDeletedUsers, пользователя можно вернуть через RestoreUser.
+// Версия 0 удаляет без проверки версии.
+func (s *UserRepo) DeleteUser(ctx context.Context, id uuid.UUID, version int64) error {
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return errors.Wrap(err, "DeleteUser")
+	}
+
+	builder := squirrel.Update(tableName).
+		Set(deletedAtColumn, squirrel.Expr("now()")).
+		Set(versionColumn, nextVersion).
+		Where(squirrel.Eq{idColumn: id}).
+		Where(scope).
+		Where(notDeleted).
+		PlaceholderFormat(squirrel.Dollar)
+	if version != 0 {
+		builder = builder.Where(squirrel.Eq{versionColumn: version})
+	}
+
 	query, args, err := builder.ToSql()
 	if err != nil {
 		return errors.Wrap(err, "DeleteUser ToSql")
 	}
 
-	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+	tag, err := s.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "DeleteUser Exec")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(s.missingOrConflict(ctx, id, version), "DeleteUser Exec")
+	}
+
+	return nil
+}
+
+// SetUserAvatar заменяет аватар пользователя (nil - убирает) и возвращает
+// прежний, чтобы вызывающий удалил его файлы.
+func (s *UserRepo) SetUserAvatar(ctx context.Context, id uuid.UUID, avatarID *uuid.UUID) (*uuid.UUID, error) {
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "SetUserAvatar")
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "SetUserAvatar Begin")
+	}
+	defer tx.Rollback(ctx)
+
+	query, args, err := squirrel.Select(avatarIDColumn).
+		From(tableName).
+		Where(squirrel.Eq{idColumn: id}).
+		Where(scope).
+		Where(notDeleted).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "SetUserAvatar ToSql")
+	}
+	var previous *uuid.UUID
+	if err := tx.QueryRow(ctx, query, args...).Scan(&previous); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return errors.Wrap(apperr.ErrNotFound, "User ID not found")
+			return nil, errors.Wrap(apperr.ErrNotFound, "SetUserAvatar")
 		}
-		return errors.Wrap(err, "DeleteUser Exec")
+		return nil, errors.Wrap(err, "SetUserAvatar Scan")
+	}
+
+	query, args, err = squirrel.Update(tableName).
+		Set(avatarIDColumn, avatarID).
+		Set(versionColumn, nextVersion).
+		Where(squirrel.Eq{idColumn: id}).
+		Where(scope).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "SetUserAvatar ToSql")
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return nil, errors.Wrap(err, "SetUserAvatar Exec")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, errors.Wrap(err, "SetUserAvatar Commit")
+	}
+	return previous, nil
+}
+
+// GetUserOrganizationID - единственный запрос без организации в контексте: им входы
+// по секрету (refresh-токен, ссылка из письма) узнают, в какой организации продолжать.
+// Функция БД отдаёт только организацию и работает и под row-level security.
+func (s *UserRepo) GetUserOrganizationID(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	var organizationID *uuid.UUID
+	if err := s.pool.QueryRow(ctx, "SELECT user_organization_id($1)", id).Scan(&organizationID); err != nil {
+		return uuid.Nil, errors.Wrap(err, "GetUserOrganizationID Scan")
+	}
+	if organizationID == nil {
+		return uuid.Nil, errors.Wrap(apperr.ErrNotFound, "GetUserOrganizationID")
+	}
+
+	return *organizationID, nil
+}
+
+// missingOrConflict объясняет, почему условное изменение не затронуло строку:
+// пользователя нет или его версия уже другая.
+func (s *UserRepo) missingOrConflict(ctx context.Context, id uuid.UUID, version int64) error {
+	if version == 0 {
+		return apperr.ErrNotFound
+	}
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return errors.Wrap(err, "missingOrConflict")
+	}
+
+	query, args, err := squirrel.Select(versionColumn).
+		From(tableName).
+		Where(squirrel.Eq{idColumn: id}).
+		Where(scope).
+		Where(notDeleted).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return errors.Wrap(err, "missingOrConflict ToSql")
+	}
+
+	var current int64
+	if err := s.pool.QueryRow(ctx, query, args...).Scan(&current); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return apperr.ErrNotFound
+		}
+		return errors.Wrap(err, "missingOrConflict Scan")
+	}
+
+	return errors.Wrapf(apperr.ErrVersionConflict, "expected version %d, current %d", version, current)
+}
+
+// RestoreUser снимает пометку об удалении, если пользователь удалён не раньше deletedAfter.
+func (s *UserRepo) RestoreUser(ctx context.Context, id uuid.UUID, deletedAfter time.Time) error {
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return errors.Wrap(err, "RestoreUser")
+	}
+
+	builder := squirrel.Update(tableName).
+		Set(deletedAtColumn, nil).
+		Set(versionColumn, nextVersion).
+		Where(squirrel.Eq{idColumn: id}).
+		Where(scope).
+		Where(squirrel.GtOrEq{deletedAtColumn: deletedAfter}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "RestoreUser ToSql")
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "RestoreUser Exec")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(apperr.ErrNotFound, "deleted user not found")
 	}
 
 	return nil
 }
+
+// PurgeDeletedUsers безвозвратно удаляет до limit пользователей организации, удалённых
+// раньше deletedBefore, вместе со связанными данными, и возвращает их.
+func (s *UserRepo) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time,
+	limit int) ([]model.PurgedUser, error) {
+	organizationID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return nil, errors.Wrap(apperr.ErrNoTenant, "PurgeDeletedUsers")
+	}
+
+	query, args, err := squirrel.Delete(tableName).
+		Where(squirrel.Expr(idColumn+" IN (SELECT "+idColumn+" FROM "+tableName+
+			" WHERE "+organizationIDColumn+" = ? AND "+deletedAtColumn+" < ? LIMIT ?)",
+			organizationID, deletedBefore, limit)).
+		Suffix("RETURNING " + idColumn + ", " + avatarIDColumn).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "PurgeDeletedUsers ToSql")
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "PurgeDeletedUsers Query")
+	}
+	defer rows.Close()
+
+	users := make([]model.PurgedUser, 0)
+	for rows.Next() {
+		var user model.PurgedUser
+		if err := rows.Scan(&user.ID, &user.AvatarID); err != nil {
+			return nil, errors.Wrap(err, "PurgeDeletedUsers Scan")
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "PurgeDeletedUsers Rows")
+	}
+
+	return users, nil
+}
+
+// LoginExists учитывает и удалённых пользователей: логин освобождается только после очистки.
+func (s *UserRepo) LoginExists(ctx context.Context, login string) (bool, error) {
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "LoginExists")
+	}
+
+	query, args, err := squirrel.Select("1").
+		Prefix("SELECT EXISTS (").
+		From(tableName).
+		Where(squirrel.Eq{loginColumn: login}).
+		Where(scope).
+		Suffix(")").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return false, errors.Wrap(err, "LoginExists ToSql")
+	}
+
+	var exists bool
+	if err := s.pool.QueryRow(ctx, query, args...).Scan(&exists); err != nil {
+		return false, errors.Wrap(err, "LoginExists Scan")
+	}
+
+	return exists, nil
+}
+
+// ListUsers возвращает до limit пользователей в порядке sort, начиная после
+// позиции after. Пароль и роли не выбираются.
+func (s *UserRepo) ListUsers(ctx context.Context, filter model.UserFilter, sort model.UserSort,
+	after *model.UserPosition, limit int) ([]model.User, error) {
+	column, ok := userSortColumns[sort.Field]
+	if !ok {
+		return nil, errors.Errorf("ListUsers: unknown sort field %q", sort.Field)
+	}
+	direction, comparison := " ASC", ">"
+	if sort.Desc {
+		direction, comparison = " DESC", "<"
+	}
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListUsers")
+	}
+
+	builder := squirrel.Select(idColumn, loginColumn, nameColumn, ageColumn,
+		"COALESCE("+emailColumn+", '')", emailVerifiedAtColumn, createdAtColumn, attributesColumn).
+		From(tableName).
+		Where(scope).
+		Where(notDeleted).
+		OrderBy(column+direction, idColumn+direction).
+		Limit(uint64(limit)).
+		PlaceholderFormat(squirrel.Dollar)
+	if filter.NamePrefix != "" {
+		builder = builder.Where(squirrel.ILike{nameColumn: escapeLike(filter.NamePrefix) + "%"})
+	}
+	if filter.MinAge != 0 {
+		builder = builder.Where(squirrel.GtOrEq{ageColumn: filter.MinAge})
+	}
+	if filter.MaxAge != 0 {
+		builder = builder.Where(squirrel.LtOrEq{ageColumn: filter.MaxAge})
+	}
+	if filter.CreatedFrom != nil {
+		builder = builder.Where(squirrel.GtOrEq{createdAtColumn: *filter.CreatedFrom})
+	}
+	if filter.CreatedTo != nil {
+		builder = builder.Where(squirrel.Lt{createdAtColumn: *filter.CreatedTo})
+	}
+	if len(filter.Attributes) > 0 {
+		// @> использует GIN-индекс по attributes
+		builder = builder.Where(squirrel.Expr(attributesColumn+" @> ?", attributesValue(filter.Attributes)))
+	}
+	if after != nil {
+		builder = builder.Where("("+column+", "+idColumn+") "+comparison+" (?, ?)",
+			userSortValue(sort.Field, after), after.ID)
+	}
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "ListUsers ToSql")
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListUsers Query")
+	}
+	defer rows.Close()
+
+	users := make([]model.User, 0)
+	for rows.Next() {
+		var user model.User
+		err := rows.Scan(&user.ID, &user.Login, &user.Name, &user.Age, &user.Email, &user.EmailVerifiedAt,
+			&user.CreatedAt, &user.Attributes)
+		if err != nil {
+			return nil, errors.Wrap(err, "ListUsers Scan")
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "ListUsers Rows")
+	}
+
+	return users, nil
+}
+
+// SearchUsers ищет пользователей по части логина или имени с учётом опечаток
+// и возвращает до limit результатов, самые похожие первыми.
+func (s *UserRepo) SearchUsers(ctx context.Context, text string, limit int) ([]model.UserSearchResult, error) {
+	scope, err := tenantScope(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "SearchUsers")
+	}
+
+	// word_similarity сравнивает запрос с самым похожим фрагментом значения,
+	// поэтому часть логина находится так же, как логин целиком
+	query, args, err := squirrel.Select(idColumn, loginColumn, nameColumn, ageColumn,
+		"COALESCE("+emailColumn+", '')", emailVerifiedAtColumn, createdAtColumn, attributesColumn).
+		Column(squirrel.Alias(squirrel.Expr(
+			"GREATEST(word_similarity(?, "+loginColumn+"), word_similarity(?, "+nameColumn+"))", text, text),
+			scoreColumn)).
+		From(tableName).
+		Where(scope).
+		Where(notDeleted).
+		Where(squirrel.Or{
+			squirrel.Expr("? <% "+loginColumn, text),
+			squirrel.Expr("? <% "+nameColumn, text),
+			squirrel.ILike{loginColumn: "%" + escapeLike(text) + "%"},
+		}).
+		OrderBy(scoreColumn+" DESC", idColumn).
+		Limit(uint64(limit)).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "SearchUsers ToSql")
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "SearchUsers Query")
+	}
+	defer rows.Close()
+
+	results := make([]model.UserSearchResult, 0)
+	for rows.Next() {
+		var result model.UserSearchResult
+		err := rows.Scan(&result.ID, &result.Login, &result.Name, &result.Age, &result.Email,
+			&result.EmailVerifiedAt, &result.CreatedAt, &result.Attributes, &result.Score)
+		if err != nil {
+			return nil, errors.Wrap(err, "SearchUsers Scan")
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "SearchUsers Rows")
+	}
+
+	return results, nil
+}
+
+var userSortColumns = map[string]string{
+	model.UserSortLogin:     loginColumn,
+	model.UserSortName:      nameColumn,
+	model.UserSortAge:       ageColumn,
+	model.UserSortCreatedAt: createdAtColumn,
+}
+
+func userSortValue(field string, position *model.UserPosition) any {
+	switch field {
+	case model.UserSortLogin:
+		return position.Login
+	case model.UserSortName:
+		return position.Name
+	case model.UserSortAge:
+		return position.Age
+	default:
+		return position.CreatedAt
+	}
+}
+
+// escapeLike экранирует спецсимволы LIKE обратной косой чертой, экранирующим символом по умолчанию.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// attributesValue не даёт pgx передать nil-map как NULL: пустые атрибуты - это '{}'.
+func attributesValue(attributes map[string]any) map[string]any {
+	if attributes == nil {
+		return map[string]any{}
+	}
+	return attributes
+}
+
+func nullableString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode
+}
+
+// checkViolation превращает нарушение CHECK-ограничения в ошибку валидации поля.
+// Поле берётся из имени ограничения, которое Postgres строит как <таблица>_<колонка>_check.
+func checkViolation(err error) *apperr.ValidationError {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != checkViolationCode {
+		return nil
+	}
+
+	field := strings.TrimSuffix(strings.TrimPrefix(pgErr.ConstraintName, pgErr.TableName+"_"), "_check")
+	return &apperr.ValidationError{Fields: []apperr.FieldError{{
+		Field:   field,
+		Code:    "check",
+		Message: "violates the '" + pgErr.ConstraintName + "' constraint",
+	}}}
+}
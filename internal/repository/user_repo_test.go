@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckViolation(t *testing.T) {
+	testCases := []struct {
+		caseName string
+		err      error
+		field    string
+	}{
+		{
+			caseName: "valid test: age check",
+			err: &pgconn.PgError{Code: checkViolationCode, TableName: tableName,
+				ConstraintName: "users_age_check"},
+			field: "age",
+		},
+		{
+			caseName: "invalid test: unique violation",
+			err:      &pgconn.PgError{Code: uniqueViolationCode, TableName: tableName},
+		},
+		{
+			caseName: "invalid test: not a postgres error",
+			err:      errors.New("connection reset"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			validationErr := checkViolation(errors.Wrap(tc.err, "UpdateUser Scan"))
+			if tc.field == "" {
+				assert.Nil(t, validationErr)
+				return
+			}
+			require.NotNil(t, validationErr)
+			require.Len(t, validationErr.Fields, 1)
+			assert.Equal(t, tc.field, validationErr.Fields[0].Field)
+			assert.ErrorIs(t, validationErr, apperr.ErrValidation)
+		})
+	}
+}
+
+func TestTenantUserScope(t *testing.T) {
+	// Без организации в контексте запрос не строится
+	_, err := tenantUserScope(context.Background())
+	require.ErrorIs(t, err, apperr.ErrNoTenant)
+
+	scope, err := tenantUserScope(tenant.WithID(context.Background(), tenant.DefaultID))
+	require.NoError(t, err)
+
+	query, args, err := squirrel.Update(sessionsTable).
+		Set(revokedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{userIDColumn: uuid.Nil}).
+		Where(scope).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	require.NoError(t, err)
+	assert.Equal(t, "UPDATE sessions SET revoked_at = now() WHERE user_id = $1 "+
+		"AND user_id IN (SELECT id FROM users WHERE organization_id = $2)", query)
+	assert.Equal(t, []any{uuid.Nil.String(), tenant.DefaultID.String()}, args)
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	webAuthnCredentialsTable = "webauthn_credentials"
+	credentialIDColumn       = "credential_id"
+	publicKeyColumn          = "public_key"
+	signCountColumn          = "sign_count"
+	transportsColumn         = "transports"
+	backupEligibleColumn     = "backup_eligible"
+	webAuthnColumnsList      = "id, user_id, credential_id, public_key, sign_count, name, transports, backup_eligible, created_at, last_used_at"
+)
+
+type WebAuthnRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewWebAuthnProvider(pool *pgxpool.Pool) *WebAuthnRepo {
+	return &WebAuthnRepo{
+		pool: pool,
+	}
+}
+
+func (s *WebAuthnRepo) AddCredential(ctx context.Context, cred model.WebAuthnCredential) error {
+	builder := squirrel.Insert(webAuthnCredentialsTable).
+		Columns(idColumn, userIDColumn, credentialIDColumn, publicKeyColumn, signCountColumn, nameColumn,
+			transportsColumn, backupEligibleColumn).
+		Values(cred.ID, cred.UserID, cred.CredentialID, cred.PublicKey, int64(cred.SignCount), cred.Name,
+			cred.Transports, cred.BackupEligible).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "AddCredential ToSql")
+	}
+
+	if _, err := s.pool.Exec(ctx, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			return errors.Wrap(apperr.ErrAlreadyExists, "credential already registered")
+		}
+		return errors.Wrap(err, "AddCredential Exec")
+	}
+
+	return nil
+}
+
+// GetCredential ищет ключ по идентификатору, выданному аутентификатором.
+func (s *WebAuthnRepo) GetCredential(ctx context.Context, credentialID []byte) (*model.WebAuthnCredential, error) {
+	builder := squirrel.Select(webAuthnColumnsList).
+		From(webAuthnCredentialsTable).
+		Where(squirrel.Eq{credentialIDColumn: credentialID}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "GetCredential ToSql")
+	}
+
+	cred, err := scanWebAuthnCredential(s.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.Wrap(apperr.ErrNotFound, "credential not found")
+		}
+		return nil, errors.Wrap(err, "GetCredential Scan")
+	}
+
+	return cred, nil
+}
+
+func (s *WebAuthnRepo) ListUserCredentials(ctx context.Context, userID uuid.UUID) ([]model.WebAuthnCredential, error) {
+	scope, err := tenantUserScope(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListUserCredentials")
+	}
+
+	builder := squirrel.Select(webAuthnColumnsList).
+		From(webAuthnCredentialsTable).
+		Where(squirrel.Eq{userIDColumn: userID}).
+		Where(scope).
+		OrderBy(createdAtColumn).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return nil, errors.Wrap(err, "ListUserCredentials ToSql")
+	}
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "ListUserCredentials Query")
+	}
+	defer rows.Close()
+
+	creds := make([]model.WebAuthnCredential, 0)
+	for rows.Next() {
+		cred, err := scanWebAuthnCredential(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "ListUserCredentials Scan")
+		}
+		creds = append(creds, *cred)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "ListUserCredentials Rows")
+	}
+
+	return creds, nil
+}
+
+// UpdateSignCount сохраняет счётчик после успешного входа. Обновление проходит,
+// только если счётчик в БД не изменился: параллельный вход тем же ответом не пройдёт.
+func (s *WebAuthnRepo) UpdateSignCount(ctx context.Context, id uuid.UUID, prev uint32, next uint32) (bool, error) {
+	builder := squirrel.Update(webAuthnCredentialsTable).
+		Set(signCountColumn, int64(next)).
+		Set(lastUsedAtColumn, squirrel.Expr("now()")).
+		Where(squirrel.Eq{idColumn: id, signCountColumn: int64(prev)}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return false, errors.Wrap(err, "UpdateSignCount ToSql")
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return false, errors.Wrap(err, "UpdateSignCount Exec")
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (s *WebAuthnRepo) DeleteCredential(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	scope, err := tenantUserScope(ctx)
+	if err != nil {
+		return errors.Wrap(err, "DeleteCredential")
+	}
+
+	builder := squirrel.Delete(webAuthnCredentialsTable).
+		Where(squirrel.Eq{idColumn: id, userIDColumn: userID}).
+		Where(scope).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return errors.Wrap(err, "DeleteCredential ToSql")
+	}
+
+	tag, err := s.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.Wrap(err, "DeleteCredential Exec")
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.Wrap(apperr.ErrNotFound, "credential not found")
+	}
+
+	return nil
+}
+
+func scanWebAuthnCredential(row pgx.Row) (*model.WebAuthnCredential, error) {
+	var (
+		cred      model.WebAuthnCredential
+		signCount int64
+	)
+	err := row.Scan(&cred.ID, &cred.UserID, &cred.CredentialID, &cred.PublicKey, &signCount, &cred.Name,
+		&cred.Transports, &cred.BackupEligible, &cred.CreatedAt, &cred.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	cred.SignCount = uint32(signCount)
+	return &cred, nil
+}
@@ -0,0 +1,33 @@
+// Package requestid - идентификатор запроса для связи логов, аудита и ответа клиенту.
+package requestid
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+const Header = "X-Request-ID"
+
+// Чужой идентификатор принимаем, только если он не сломает логи и заголовки
+var validID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+type requestIDKey struct{}
+
+// FromHeader возвращает идентификатор клиента или прокси, если он допустим, иначе новый.
+func FromHeader(value string) string {
+	if validID.MatchString(value) {
+		return value
+	}
+	return uuid.NewString()
+}
+
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
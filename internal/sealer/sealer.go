@@ -0,0 +1,72 @@
+package sealer
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+)
+
+const KeySize = 32
+
+// Sealer шифрует небольшие секреты для хранения в БД (AES-256-GCM).
+// additionalData привязывает шифротекст к владельцу: секрет, скопированный
+// в строку другого пользователя, не расшифруется.
+type Sealer struct {
+	aead cipher.AEAD
+}
+
+func New(key []byte) (*Sealer, error) {
+	if len(key) != KeySize {
+		return nil, errors.Errorf("sealer key must be %d bytes, got %d", KeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "sealer New")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "sealer New")
+	}
+
+	return &Sealer{aead: aead}, nil
+}
+
+// NewFromBase64 принимает ключ в том виде, в каком он лежит в конфиге.
+func NewFromBase64(encoded string) (*Sealer, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode sealer key")
+	}
+	return New(key)
+}
+
+func (s *Sealer) Seal(plaintext, additionalData []byte) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.Wrap(err, "sealer Seal")
+	}
+
+	sealed := s.aead.Seal(nonce, nonce, plaintext, additionalData)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *Sealer) Open(encoded string, additionalData []byte) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "sealer Open")
+	}
+	if len(sealed) < s.aead.NonceSize() {
+		return nil, errors.New("sealer Open: ciphertext too short")
+	}
+
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, errors.Wrap(err, "sealer Open")
+	}
+	return plaintext, nil
+}
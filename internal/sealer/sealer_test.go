@@ -0,0 +1,37 @@
+package sealer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealer(t *testing.T) {
+	s, err := New(bytes.Repeat([]byte{1}, KeySize))
+	require.NoError(t, err)
+
+	sealed, err := s.Seal([]byte("secret"), []byte("user-1"))
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, "secret")
+
+	// Кейс 1: расшифровка с тем же владельцем
+	plain, err := s.Open(sealed, []byte("user-1"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), plain)
+
+	// Кейс 2: шифротекст другого пользователя
+	_, err = s.Open(sealed, []byte("user-2"))
+	require.Error(t, err)
+
+	// Кейс 3: другой ключ
+	other, err := New(bytes.Repeat([]byte{2}, KeySize))
+	require.NoError(t, err)
+	_, err = other.Open(sealed, []byte("user-1"))
+	require.Error(t, err)
+
+	// Кейс 4: ключ неверной длины
+	_, err = New([]byte("short"))
+	require.Error(t, err)
+}
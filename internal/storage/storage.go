@@ -3,14 +3,40 @@ package storage
 import (
 	"context"
 
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func GetConnect(ctx context.Context, connStr string) (*pgxpool.Pool, error) {
-	pool, err := pgxpool.New(ctx, connStr)
+// GetConnect с rowLevelSecurity перед каждой выдачей соединения передаёт в сессию
+// организацию из контекста, и политика users_organization_isolation скрывает
+// чужих пользователей даже от запроса без фильтра по организации.
+func GetConnect(ctx context.Context, connStr string, rowLevelSecurity bool) (*pgxpool.Pool, error) {
+	config, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		return nil, err
+	}
+	if rowLevelSecurity {
+		config.BeforeAcquire = setOrganization
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
 		return nil, err
 	}
 
 	return pool, nil
 }
+
+// setOrganization без организации в контексте сбрасывает настройку, и политика не
+// пропускает ни одной строки. Соединение, на котором не удалось выполнить запрос, отбрасывается.
+func setOrganization(ctx context.Context, conn *pgx.Conn) bool {
+	organizationID := ""
+	if id, ok := tenant.FromContext(ctx); ok {
+		organizationID = id.String()
+	}
+
+	_, err := conn.Exec(ctx, "SELECT set_config('app.organization_id', $1, false)", organizationID)
+	return err == nil
+}
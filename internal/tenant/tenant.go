@@ -0,0 +1,31 @@
+// Package tenant - организация, в рамках которой выполняется запрос. Репозиторий
+// пользователей без неё не работает, поэтому данные организаций не смешиваются.
+package tenant
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Header задаёт организацию для запросов без токена (вход, восстановление пароля).
+// В запросах с токеном организация берётся из токена.
+const Header = "X-Organization"
+
+// QueryParam - то же для переходов браузера, где заголовок не задать.
+const QueryParam = "organization"
+
+// DefaultID - организация пользователей, созданных до разделения на организации.
+// Её сотрудники управляют настройками, общими для всех организаций.
+var DefaultID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+type tenantKey struct{}
+
+func WithID(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+func FromContext(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(tenantKey{}).(uuid.UUID)
+	return id, ok
+}
@@ -0,0 +1,157 @@
+// Package fakeidp - локальный OAuth2-провайдер для тестов входа через внешние аккаунты.
+// /authorize сразу "входит" пользователем, заданным через SetUser, и возвращает код.
+package fakeidp
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/lemavisaitov/lk-api/internal/oauthclient"
+)
+
+const (
+	ClientID     = "fake-client"
+	ClientSecret = "fake-secret"
+)
+
+type grant struct {
+	claims      map[string]any
+	redirectURI string
+	challenge   string
+}
+
+type Server struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	user   map[string]any
+	codes  map[string]grant
+	tokens map[string]map[string]any
+}
+
+func New() *Server {
+	s := &Server{
+		codes:  make(map[string]grant),
+		tokens: make(map[string]map[string]any),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", s.authorize)
+	mux.HandleFunc("/token", s.token)
+	mux.HandleFunc("/userinfo", s.userinfo)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// SetUser задаёт поля userinfo пользователя, который войдёт при следующем /authorize.
+func (s *Server) SetUser(claims map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.user = claims
+}
+
+// Config возвращает настройки клиента для этого провайдера.
+func (s *Server) Config(name string, redirectURL string) oauthclient.Config {
+	return oauthclient.Config{
+		Name:         name,
+		ClientID:     ClientID,
+		ClientSecret: ClientSecret,
+		AuthURL:      s.URL + "/authorize",
+		TokenURL:     s.URL + "/token",
+		UserInfoURL:  s.URL + "/userinfo",
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != ClientID || query.Get("response_type") != "code" ||
+		query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.user == nil {
+		http.Error(w, "no user", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+	s.codes[code] = grant{
+		claims:      s.user,
+		redirectURI: query.Get("redirect_uri"),
+		challenge:   query.Get("code_challenge"),
+	}
+
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", query.Get("state"))
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		id, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+	if id != ClientID || secret != ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code := r.PostFormValue("code")
+	g, ok := s.codes[code]
+	delete(s.codes, code)
+	if !ok || g.redirectURI != r.PostFormValue("redirect_uri") ||
+		oauthclient.CodeChallenge(r.PostFormValue("code_verifier")) != g.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	token := randomString()
+	s.tokens[token] = g.claims
+	writeJSON(w, http.StatusOK, map[string]string{"access_token": token, "token_type": "Bearer"})
+}
+
+func (s *Server) userinfo(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	s.mu.Lock()
+	claims, known := s.tokens[token]
+	s.mu.Unlock()
+	if !ok || !known {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, claims)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,120 @@
+// Package fakes3 - S3-совместимое хранилище в памяти для тестов.
+// Подпись запросов не пересчитывается: проверяются ключ доступа, формат
+// Authorization и хэш тела. Чтение объектов (GET) доступно без подписи.
+package fakes3
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+const (
+	AccessKey = "fake-access-key"
+	SecretKey = "fake-secret-key"
+	Region    = "us-east-1"
+	Bucket    = "avatars"
+)
+
+type Object struct {
+	ContentType  string
+	CacheControl string
+	Data         []byte
+}
+
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	objects map[string]Object
+}
+
+func New() *Server {
+	s := &Server{
+		objects: make(map[string]Object),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Object возвращает объект по ключу внутри бакета.
+func (s *Server) Object(key string) (Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	object, ok := s.objects[key]
+	return object, ok
+}
+
+func (s *Server) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.objects)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	bucket, key, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if !ok || bucket != Bucket || key == "" {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		s.get(w, key)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !authorized(r, body) {
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		s.objects[key] = Object{
+			ContentType:  r.Header.Get("Content-Type"),
+			CacheControl: r.Header.Get("Cache-Control"),
+			Data:         body,
+		}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) get(w http.ResponseWriter, key string) {
+	object, ok := s.Object(key)
+	if !ok {
+		http.Error(w, "NoSuchKey", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", object.ContentType)
+	w.Header().Set("Cache-Control", object.CacheControl)
+	w.Write(object.Data)
+}
+
+func authorized(r *http.Request, body []byte) bool {
+	auth := r.Header.Get("Authorization")
+	prefix := "AWS4-HMAC-SHA256 Credential=" + AccessKey + "/"
+	if !strings.HasPrefix(auth, prefix) ||
+		!strings.Contains(auth, "/"+Region+"/s3/aws4_request, SignedHeaders=") ||
+		!strings.Contains(auth, ", Signature=") {
+		return false
+	}
+	sum := sha256.Sum256(body)
+	return r.Header.Get("X-Amz-Content-Sha256") == hex.EncodeToString(sum[:]) &&
+		r.Header.Get("X-Amz-Date") != ""
+}
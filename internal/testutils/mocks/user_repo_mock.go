@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: internal/repository/user_repo.go
+// Source: internal/repository/interface.go
 
 // Package mocks is a generated GoMock package.
 package mocks
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
@@ -51,17 +52,32 @@ func (mr *MockUserProviderMockRecorder) AddUser(arg0, arg1 interface{}) *gomock.
 }
 
 // DeleteUser mocks base method.
-func (m *MockUserProvider) DeleteUser(arg0 context.Context, arg1 uuid.UUID) error {
+func (m *MockUserProvider) DeleteUser(arg0 context.Context, arg1 uuid.UUID, arg2 int64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUser", arg0, arg1)
+	ret := m.ctrl.Call(m, "DeleteUser", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteUser indicates an expected call of DeleteUser.
-func (mr *MockUserProviderMockRecorder) DeleteUser(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockUserProviderMockRecorder) DeleteUser(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserProvider)(nil).DeleteUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserProvider)(nil).DeleteUser), arg0, arg1, arg2)
+}
+
+// EmailInOtherOrganization mocks base method.
+func (m *MockUserProvider) EmailInOtherOrganization(arg0 context.Context, arg1 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EmailInOtherOrganization", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EmailInOtherOrganization indicates an expected call of EmailInOtherOrganization.
+func (mr *MockUserProviderMockRecorder) EmailInOtherOrganization(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EmailInOtherOrganization", reflect.TypeOf((*MockUserProvider)(nil).EmailInOtherOrganization), arg0, arg1)
 }
 
 // GetUser mocks base method.
@@ -79,6 +95,21 @@ func (mr *MockUserProviderMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockUserProvider)(nil).GetUser), arg0, arg1)
 }
 
+// GetUserIDByEmail mocks base method.
+func (m *MockUserProvider) GetUserIDByEmail(arg0 context.Context, arg1 string) (*uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserIDByEmail", arg0, arg1)
+	ret0, _ := ret[0].(*uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserIDByEmail indicates an expected call of GetUserIDByEmail.
+func (mr *MockUserProviderMockRecorder) GetUserIDByEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIDByEmail", reflect.TypeOf((*MockUserProvider)(nil).GetUserIDByEmail), arg0, arg1)
+}
+
 // GetUserIDByLogin mocks base method.
 func (m *MockUserProvider) GetUserIDByLogin(arg0 context.Context, arg1 string) (*uuid.UUID, error) {
 	m.ctrl.T.Helper()
@@ -94,6 +125,124 @@ func (mr *MockUserProviderMockRecorder) GetUserIDByLogin(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIDByLogin", reflect.TypeOf((*MockUserProvider)(nil).GetUserIDByLogin), arg0, arg1)
 }
 
+// GetUserOrganizationID mocks base method.
+func (m *MockUserProvider) GetUserOrganizationID(arg0 context.Context, arg1 uuid.UUID) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserOrganizationID", arg0, arg1)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserOrganizationID indicates an expected call of GetUserOrganizationID.
+func (mr *MockUserProviderMockRecorder) GetUserOrganizationID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserOrganizationID", reflect.TypeOf((*MockUserProvider)(nil).GetUserOrganizationID), arg0, arg1)
+}
+
+// ListUsers mocks base method.
+func (m *MockUserProvider) ListUsers(arg0 context.Context, arg1 model.UserFilter, arg2 model.UserSort, arg3 *model.UserPosition, arg4 int) ([]model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].([]model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockUserProviderMockRecorder) ListUsers(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockUserProvider)(nil).ListUsers), arg0, arg1, arg2, arg3, arg4)
+}
+
+// LoginExists mocks base method.
+func (m *MockUserProvider) LoginExists(arg0 context.Context, arg1 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginExists", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginExists indicates an expected call of LoginExists.
+func (mr *MockUserProviderMockRecorder) LoginExists(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginExists", reflect.TypeOf((*MockUserProvider)(nil).LoginExists), arg0, arg1)
+}
+
+// MarkEmailVerified mocks base method.
+func (m *MockUserProvider) MarkEmailVerified(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEmailVerified", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEmailVerified indicates an expected call of MarkEmailVerified.
+func (mr *MockUserProviderMockRecorder) MarkEmailVerified(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEmailVerified", reflect.TypeOf((*MockUserProvider)(nil).MarkEmailVerified), arg0, arg1)
+}
+
+// PurgeDeletedUsers mocks base method.
+func (m *MockUserProvider) PurgeDeletedUsers(arg0 context.Context, arg1 time.Time, arg2 int) ([]model.PurgedUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedUsers", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]model.PurgedUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeletedUsers indicates an expected call of PurgeDeletedUsers.
+func (mr *MockUserProviderMockRecorder) PurgeDeletedUsers(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedUsers", reflect.TypeOf((*MockUserProvider)(nil).PurgeDeletedUsers), arg0, arg1, arg2)
+}
+
+// RestoreUser mocks base method.
+func (m *MockUserProvider) RestoreUser(arg0 context.Context, arg1 uuid.UUID, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUser", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreUser indicates an expected call of RestoreUser.
+func (mr *MockUserProviderMockRecorder) RestoreUser(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*MockUserProvider)(nil).RestoreUser), arg0, arg1, arg2)
+}
+
+// SearchUsers mocks base method.
+func (m *MockUserProvider) SearchUsers(arg0 context.Context, arg1 string, arg2 int) ([]model.UserSearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchUsers", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]model.UserSearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchUsers indicates an expected call of SearchUsers.
+func (mr *MockUserProviderMockRecorder) SearchUsers(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchUsers", reflect.TypeOf((*MockUserProvider)(nil).SearchUsers), arg0, arg1, arg2)
+}
+
+// SetUserAvatar mocks base method.
+func (m *MockUserProvider) SetUserAvatar(arg0 context.Context, arg1 uuid.UUID, arg2 *uuid.UUID) (*uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserAvatar", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserAvatar indicates an expected call of SetUserAvatar.
+func (mr *MockUserProviderMockRecorder) SetUserAvatar(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserAvatar", reflect.TypeOf((*MockUserProvider)(nil).SetUserAvatar), arg0, arg1, arg2)
+}
+
 // UpdateUser mocks base method.
 func (m *MockUserProvider) UpdateUser(arg0 context.Context, arg1 model.UpdateUserRequest) (*uuid.UUID, error) {
 	m.ctrl.T.Helper()
@@ -108,3 +257,1564 @@ func (mr *MockUserProviderMockRecorder) UpdateUser(arg0, arg1 interface{}) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*MockUserProvider)(nil).UpdateUser), arg0, arg1)
 }
+
+// MockOrganizationProvider is a mock of OrganizationProvider interface.
+type MockOrganizationProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrganizationProviderMockRecorder
+}
+
+// MockOrganizationProviderMockRecorder is the mock recorder for MockOrganizationProvider.
+type MockOrganizationProviderMockRecorder struct {
+	mock *MockOrganizationProvider
+}
+
+// NewMockOrganizationProvider creates a new mock instance.
+func NewMockOrganizationProvider(ctrl *gomock.Controller) *MockOrganizationProvider {
+	mock := &MockOrganizationProvider{ctrl: ctrl}
+	mock.recorder = &MockOrganizationProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrganizationProvider) EXPECT() *MockOrganizationProviderMockRecorder {
+	return m.recorder
+}
+
+// AddOrganization mocks base method.
+func (m *MockOrganizationProvider) AddOrganization(arg0 context.Context, arg1 model.Organization) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOrganization", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddOrganization indicates an expected call of AddOrganization.
+func (mr *MockOrganizationProviderMockRecorder) AddOrganization(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOrganization", reflect.TypeOf((*MockOrganizationProvider)(nil).AddOrganization), arg0, arg1)
+}
+
+// GetOrganizationBySlug mocks base method.
+func (m *MockOrganizationProvider) GetOrganizationBySlug(arg0 context.Context, arg1 string) (*model.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganizationBySlug", arg0, arg1)
+	ret0, _ := ret[0].(*model.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganizationBySlug indicates an expected call of GetOrganizationBySlug.
+func (mr *MockOrganizationProviderMockRecorder) GetOrganizationBySlug(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganizationBySlug", reflect.TypeOf((*MockOrganizationProvider)(nil).GetOrganizationBySlug), arg0, arg1)
+}
+
+// ListOrganizations mocks base method.
+func (m *MockOrganizationProvider) ListOrganizations(arg0 context.Context) ([]model.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrganizations", arg0)
+	ret0, _ := ret[0].([]model.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrganizations indicates an expected call of ListOrganizations.
+func (mr *MockOrganizationProviderMockRecorder) ListOrganizations(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrganizations", reflect.TypeOf((*MockOrganizationProvider)(nil).ListOrganizations), arg0)
+}
+
+// MockMembershipProvider is a mock of MembershipProvider interface.
+type MockMembershipProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockMembershipProviderMockRecorder
+}
+
+// MockMembershipProviderMockRecorder is the mock recorder for MockMembershipProvider.
+type MockMembershipProviderMockRecorder struct {
+	mock *MockMembershipProvider
+}
+
+// NewMockMembershipProvider creates a new mock instance.
+func NewMockMembershipProvider(ctrl *gomock.Controller) *MockMembershipProvider {
+	mock := &MockMembershipProvider{ctrl: ctrl}
+	mock.recorder = &MockMembershipProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMembershipProvider) EXPECT() *MockMembershipProviderMockRecorder {
+	return m.recorder
+}
+
+// GetMemberRole mocks base method.
+func (m *MockMembershipProvider) GetMemberRole(arg0 context.Context, arg1 uuid.UUID) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMemberRole", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMemberRole indicates an expected call of GetMemberRole.
+func (mr *MockMembershipProviderMockRecorder) GetMemberRole(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMemberRole", reflect.TypeOf((*MockMembershipProvider)(nil).GetMemberRole), arg0, arg1)
+}
+
+// ListMembers mocks base method.
+func (m *MockMembershipProvider) ListMembers(arg0 context.Context) ([]model.Member, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMembers", arg0)
+	ret0, _ := ret[0].([]model.Member)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMembers indicates an expected call of ListMembers.
+func (mr *MockMembershipProviderMockRecorder) ListMembers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMembers", reflect.TypeOf((*MockMembershipProvider)(nil).ListMembers), arg0)
+}
+
+// SetMemberRole mocks base method.
+func (m *MockMembershipProvider) SetMemberRole(arg0 context.Context, arg1 uuid.UUID, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMemberRole", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMemberRole indicates an expected call of SetMemberRole.
+func (mr *MockMembershipProviderMockRecorder) SetMemberRole(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMemberRole", reflect.TypeOf((*MockMembershipProvider)(nil).SetMemberRole), arg0, arg1, arg2)
+}
+
+// TransferOwnership mocks base method.
+func (m *MockMembershipProvider) TransferOwnership(arg0 context.Context, arg1, arg2 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferOwnership", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TransferOwnership indicates an expected call of TransferOwnership.
+func (mr *MockMembershipProviderMockRecorder) TransferOwnership(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferOwnership", reflect.TypeOf((*MockMembershipProvider)(nil).TransferOwnership), arg0, arg1, arg2)
+}
+
+// MockInvitationProvider is a mock of InvitationProvider interface.
+type MockInvitationProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockInvitationProviderMockRecorder
+}
+
+// MockInvitationProviderMockRecorder is the mock recorder for MockInvitationProvider.
+type MockInvitationProviderMockRecorder struct {
+	mock *MockInvitationProvider
+}
+
+// NewMockInvitationProvider creates a new mock instance.
+func NewMockInvitationProvider(ctrl *gomock.Controller) *MockInvitationProvider {
+	mock := &MockInvitationProvider{ctrl: ctrl}
+	mock.recorder = &MockInvitationProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInvitationProvider) EXPECT() *MockInvitationProviderMockRecorder {
+	return m.recorder
+}
+
+// AcceptInvitation mocks base method.
+func (m *MockInvitationProvider) AcceptInvitation(arg0 context.Context, arg1 string, arg2 model.Invitee) (*model.AcceptInvitationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptInvitation", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.AcceptInvitationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AcceptInvitation indicates an expected call of AcceptInvitation.
+func (mr *MockInvitationProviderMockRecorder) AcceptInvitation(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptInvitation", reflect.TypeOf((*MockInvitationProvider)(nil).AcceptInvitation), arg0, arg1, arg2)
+}
+
+// AddInvitation mocks base method.
+func (m *MockInvitationProvider) AddInvitation(arg0 context.Context, arg1 model.Invitation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddInvitation", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddInvitation indicates an expected call of AddInvitation.
+func (mr *MockInvitationProviderMockRecorder) AddInvitation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddInvitation", reflect.TypeOf((*MockInvitationProvider)(nil).AddInvitation), arg0, arg1)
+}
+
+// DeclineInvitation mocks base method.
+func (m *MockInvitationProvider) DeclineInvitation(arg0 context.Context, arg1 string) (*model.Invitation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeclineInvitation", arg0, arg1)
+	ret0, _ := ret[0].(*model.Invitation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeclineInvitation indicates an expected call of DeclineInvitation.
+func (mr *MockInvitationProviderMockRecorder) DeclineInvitation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeclineInvitation", reflect.TypeOf((*MockInvitationProvider)(nil).DeclineInvitation), arg0, arg1)
+}
+
+// FindInvitation mocks base method.
+func (m *MockInvitationProvider) FindInvitation(arg0 context.Context, arg1 string) (*model.Invitation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindInvitation", arg0, arg1)
+	ret0, _ := ret[0].(*model.Invitation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindInvitation indicates an expected call of FindInvitation.
+func (mr *MockInvitationProviderMockRecorder) FindInvitation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindInvitation", reflect.TypeOf((*MockInvitationProvider)(nil).FindInvitation), arg0, arg1)
+}
+
+// ListPendingInvitations mocks base method.
+func (m *MockInvitationProvider) ListPendingInvitations(arg0 context.Context) ([]model.Invitation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingInvitations", arg0)
+	ret0, _ := ret[0].([]model.Invitation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingInvitations indicates an expected call of ListPendingInvitations.
+func (mr *MockInvitationProviderMockRecorder) ListPendingInvitations(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingInvitations", reflect.TypeOf((*MockInvitationProvider)(nil).ListPendingInvitations), arg0)
+}
+
+// RevokeInvitation mocks base method.
+func (m *MockInvitationProvider) RevokeInvitation(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeInvitation", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeInvitation indicates an expected call of RevokeInvitation.
+func (mr *MockInvitationProviderMockRecorder) RevokeInvitation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeInvitation", reflect.TypeOf((*MockInvitationProvider)(nil).RevokeInvitation), arg0, arg1)
+}
+
+// MockRefreshTokenProvider is a mock of RefreshTokenProvider interface.
+type MockRefreshTokenProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockRefreshTokenProviderMockRecorder
+}
+
+// MockRefreshTokenProviderMockRecorder is the mock recorder for MockRefreshTokenProvider.
+type MockRefreshTokenProviderMockRecorder struct {
+	mock *MockRefreshTokenProvider
+}
+
+// NewMockRefreshTokenProvider creates a new mock instance.
+func NewMockRefreshTokenProvider(ctrl *gomock.Controller) *MockRefreshTokenProvider {
+	mock := &MockRefreshTokenProvider{ctrl: ctrl}
+	mock.recorder = &MockRefreshTokenProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRefreshTokenProvider) EXPECT() *MockRefreshTokenProviderMockRecorder {
+	return m.recorder
+}
+
+// AddRefreshToken mocks base method.
+func (m *MockRefreshTokenProvider) AddRefreshToken(arg0 context.Context, arg1 model.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddRefreshToken indicates an expected call of AddRefreshToken.
+func (mr *MockRefreshTokenProviderMockRecorder) AddRefreshToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRefreshToken", reflect.TypeOf((*MockRefreshTokenProvider)(nil).AddRefreshToken), arg0, arg1)
+}
+
+// GetRefreshTokenByHash mocks base method.
+func (m *MockRefreshTokenProvider) GetRefreshTokenByHash(arg0 context.Context, arg1 string) (*model.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefreshTokenByHash", arg0, arg1)
+	ret0, _ := ret[0].(*model.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefreshTokenByHash indicates an expected call of GetRefreshTokenByHash.
+func (mr *MockRefreshTokenProviderMockRecorder) GetRefreshTokenByHash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefreshTokenByHash", reflect.TypeOf((*MockRefreshTokenProvider)(nil).GetRefreshTokenByHash), arg0, arg1)
+}
+
+// RevokeRefreshToken mocks base method.
+func (m *MockRefreshTokenProvider) RevokeRefreshToken(arg0 context.Context, arg1 uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeRefreshToken indicates an expected call of RevokeRefreshToken.
+func (mr *MockRefreshTokenProviderMockRecorder) RevokeRefreshToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshToken", reflect.TypeOf((*MockRefreshTokenProvider)(nil).RevokeRefreshToken), arg0, arg1)
+}
+
+// MockSessionProvider is a mock of SessionProvider interface.
+type MockSessionProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionProviderMockRecorder
+}
+
+// MockSessionProviderMockRecorder is the mock recorder for MockSessionProvider.
+type MockSessionProviderMockRecorder struct {
+	mock *MockSessionProvider
+}
+
+// NewMockSessionProvider creates a new mock instance.
+func NewMockSessionProvider(ctrl *gomock.Controller) *MockSessionProvider {
+	mock := &MockSessionProvider{ctrl: ctrl}
+	mock.recorder = &MockSessionProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionProvider) EXPECT() *MockSessionProviderMockRecorder {
+	return m.recorder
+}
+
+// AddSession mocks base method.
+func (m *MockSessionProvider) AddSession(arg0 context.Context, arg1 model.Session) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddSession", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddSession indicates an expected call of AddSession.
+func (mr *MockSessionProviderMockRecorder) AddSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSession", reflect.TypeOf((*MockSessionProvider)(nil).AddSession), arg0, arg1)
+}
+
+// GetSession mocks base method.
+func (m *MockSessionProvider) GetSession(arg0 context.Context, arg1 uuid.UUID) (*model.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", arg0, arg1)
+	ret0, _ := ret[0].(*model.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockSessionProviderMockRecorder) GetSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockSessionProvider)(nil).GetSession), arg0, arg1)
+}
+
+// ListUserSessions mocks base method.
+func (m *MockSessionProvider) ListUserSessions(arg0 context.Context, arg1 uuid.UUID) ([]model.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserSessions", arg0, arg1)
+	ret0, _ := ret[0].([]model.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserSessions indicates an expected call of ListUserSessions.
+func (mr *MockSessionProviderMockRecorder) ListUserSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserSessions", reflect.TypeOf((*MockSessionProvider)(nil).ListUserSessions), arg0, arg1)
+}
+
+// RevokeSession mocks base method.
+func (m *MockSessionProvider) RevokeSession(arg0 context.Context, arg1, arg2 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeSession", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeSession indicates an expected call of RevokeSession.
+func (mr *MockSessionProviderMockRecorder) RevokeSession(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeSession", reflect.TypeOf((*MockSessionProvider)(nil).RevokeSession), arg0, arg1, arg2)
+}
+
+// RevokeUserSessions mocks base method.
+func (m *MockSessionProvider) RevokeUserSessions(arg0 context.Context, arg1, arg2 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserSessions", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserSessions indicates an expected call of RevokeUserSessions.
+func (mr *MockSessionProviderMockRecorder) RevokeUserSessions(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserSessions", reflect.TypeOf((*MockSessionProvider)(nil).RevokeUserSessions), arg0, arg1, arg2)
+}
+
+// TouchSession mocks base method.
+func (m *MockSessionProvider) TouchSession(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchSession", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchSession indicates an expected call of TouchSession.
+func (mr *MockSessionProviderMockRecorder) TouchSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchSession", reflect.TypeOf((*MockSessionProvider)(nil).TouchSession), arg0, arg1)
+}
+
+// MockOneTimeTokenProvider is a mock of OneTimeTokenProvider interface.
+type MockOneTimeTokenProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockOneTimeTokenProviderMockRecorder
+}
+
+// MockOneTimeTokenProviderMockRecorder is the mock recorder for MockOneTimeTokenProvider.
+type MockOneTimeTokenProviderMockRecorder struct {
+	mock *MockOneTimeTokenProvider
+}
+
+// NewMockOneTimeTokenProvider creates a new mock instance.
+func NewMockOneTimeTokenProvider(ctrl *gomock.Controller) *MockOneTimeTokenProvider {
+	mock := &MockOneTimeTokenProvider{ctrl: ctrl}
+	mock.recorder = &MockOneTimeTokenProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOneTimeTokenProvider) EXPECT() *MockOneTimeTokenProviderMockRecorder {
+	return m.recorder
+}
+
+// AddToken mocks base method.
+func (m *MockOneTimeTokenProvider) AddToken(arg0 context.Context, arg1 model.OneTimeToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddToken indicates an expected call of AddToken.
+func (mr *MockOneTimeTokenProviderMockRecorder) AddToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToken", reflect.TypeOf((*MockOneTimeTokenProvider)(nil).AddToken), arg0, arg1)
+}
+
+// ConsumeToken mocks base method.
+func (m *MockOneTimeTokenProvider) ConsumeToken(arg0 context.Context, arg1, arg2 string) (*model.OneTimeToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeToken", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.OneTimeToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeToken indicates an expected call of ConsumeToken.
+func (mr *MockOneTimeTokenProviderMockRecorder) ConsumeToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeToken", reflect.TypeOf((*MockOneTimeTokenProvider)(nil).ConsumeToken), arg0, arg1, arg2)
+}
+
+// FindToken mocks base method.
+func (m *MockOneTimeTokenProvider) FindToken(arg0 context.Context, arg1, arg2 string) (*model.OneTimeToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindToken", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.OneTimeToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindToken indicates an expected call of FindToken.
+func (mr *MockOneTimeTokenProviderMockRecorder) FindToken(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindToken", reflect.TypeOf((*MockOneTimeTokenProvider)(nil).FindToken), arg0, arg1, arg2)
+}
+
+// InvalidateUserTokens mocks base method.
+func (m *MockOneTimeTokenProvider) InvalidateUserTokens(arg0 context.Context, arg1 uuid.UUID, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InvalidateUserTokens", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InvalidateUserTokens indicates an expected call of InvalidateUserTokens.
+func (mr *MockOneTimeTokenProviderMockRecorder) InvalidateUserTokens(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateUserTokens", reflect.TypeOf((*MockOneTimeTokenProvider)(nil).InvalidateUserTokens), arg0, arg1, arg2)
+}
+
+// MockTOTPProvider is a mock of TOTPProvider interface.
+type MockTOTPProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockTOTPProviderMockRecorder
+}
+
+// MockTOTPProviderMockRecorder is the mock recorder for MockTOTPProvider.
+type MockTOTPProviderMockRecorder struct {
+	mock *MockTOTPProvider
+}
+
+// NewMockTOTPProvider creates a new mock instance.
+func NewMockTOTPProvider(ctrl *gomock.Controller) *MockTOTPProvider {
+	mock := &MockTOTPProvider{ctrl: ctrl}
+	mock.recorder = &MockTOTPProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTOTPProvider) EXPECT() *MockTOTPProviderMockRecorder {
+	return m.recorder
+}
+
+// ConfirmTOTP mocks base method.
+func (m *MockTOTPProvider) ConfirmTOTP(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTOTP", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmTOTP indicates an expected call of ConfirmTOTP.
+func (mr *MockTOTPProviderMockRecorder) ConfirmTOTP(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTOTP", reflect.TypeOf((*MockTOTPProvider)(nil).ConfirmTOTP), arg0, arg1)
+}
+
+// DeleteTOTP mocks base method.
+func (m *MockTOTPProvider) DeleteTOTP(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTOTP", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTOTP indicates an expected call of DeleteTOTP.
+func (mr *MockTOTPProviderMockRecorder) DeleteTOTP(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTOTP", reflect.TypeOf((*MockTOTPProvider)(nil).DeleteTOTP), arg0, arg1)
+}
+
+// GetTOTP mocks base method.
+func (m *MockTOTPProvider) GetTOTP(arg0 context.Context, arg1 uuid.UUID) (*model.TOTP, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTOTP", arg0, arg1)
+	ret0, _ := ret[0].(*model.TOTP)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTOTP indicates an expected call of GetTOTP.
+func (mr *MockTOTPProviderMockRecorder) GetTOTP(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTOTP", reflect.TypeOf((*MockTOTPProvider)(nil).GetTOTP), arg0, arg1)
+}
+
+// ReplaceRecoveryCodes mocks base method.
+func (m *MockTOTPProvider) ReplaceRecoveryCodes(arg0 context.Context, arg1 uuid.UUID, arg2 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceRecoveryCodes", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceRecoveryCodes indicates an expected call of ReplaceRecoveryCodes.
+func (mr *MockTOTPProviderMockRecorder) ReplaceRecoveryCodes(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceRecoveryCodes", reflect.TypeOf((*MockTOTPProvider)(nil).ReplaceRecoveryCodes), arg0, arg1, arg2)
+}
+
+// SaveTOTP mocks base method.
+func (m *MockTOTPProvider) SaveTOTP(arg0 context.Context, arg1 model.TOTP) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveTOTP", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveTOTP indicates an expected call of SaveTOTP.
+func (mr *MockTOTPProviderMockRecorder) SaveTOTP(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTOTP", reflect.TypeOf((*MockTOTPProvider)(nil).SaveTOTP), arg0, arg1)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockTOTPProvider) UseRecoveryCode(arg0 context.Context, arg1 uuid.UUID, arg2 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockTOTPProviderMockRecorder) UseRecoveryCode(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockTOTPProvider)(nil).UseRecoveryCode), arg0, arg1, arg2)
+}
+
+// UseTOTPStep mocks base method.
+func (m *MockTOTPProvider) UseTOTPStep(arg0 context.Context, arg1 uuid.UUID, arg2 int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTOTPStep", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTOTPStep indicates an expected call of UseTOTPStep.
+func (mr *MockTOTPProviderMockRecorder) UseTOTPStep(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTOTPStep", reflect.TypeOf((*MockTOTPProvider)(nil).UseTOTPStep), arg0, arg1, arg2)
+}
+
+// MockAPIKeyProvider is a mock of APIKeyProvider interface.
+type MockAPIKeyProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyProviderMockRecorder
+}
+
+// MockAPIKeyProviderMockRecorder is the mock recorder for MockAPIKeyProvider.
+type MockAPIKeyProviderMockRecorder struct {
+	mock *MockAPIKeyProvider
+}
+
+// NewMockAPIKeyProvider creates a new mock instance.
+func NewMockAPIKeyProvider(ctrl *gomock.Controller) *MockAPIKeyProvider {
+	mock := &MockAPIKeyProvider{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyProvider) EXPECT() *MockAPIKeyProviderMockRecorder {
+	return m.recorder
+}
+
+// AddAPIKey mocks base method.
+func (m *MockAPIKeyProvider) AddAPIKey(arg0 context.Context, arg1 model.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAPIKey indicates an expected call of AddAPIKey.
+func (mr *MockAPIKeyProviderMockRecorder) AddAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAPIKey", reflect.TypeOf((*MockAPIKeyProvider)(nil).AddAPIKey), arg0, arg1)
+}
+
+// GetAPIKeyByPrefix mocks base method.
+func (m *MockAPIKeyProvider) GetAPIKeyByPrefix(arg0 context.Context, arg1 string) (*model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByPrefix", arg0, arg1)
+	ret0, _ := ret[0].(*model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByPrefix indicates an expected call of GetAPIKeyByPrefix.
+func (mr *MockAPIKeyProviderMockRecorder) GetAPIKeyByPrefix(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByPrefix", reflect.TypeOf((*MockAPIKeyProvider)(nil).GetAPIKeyByPrefix), arg0, arg1)
+}
+
+// ListUserAPIKeys mocks base method.
+func (m *MockAPIKeyProvider) ListUserAPIKeys(arg0 context.Context, arg1 uuid.UUID) ([]model.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserAPIKeys", arg0, arg1)
+	ret0, _ := ret[0].([]model.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserAPIKeys indicates an expected call of ListUserAPIKeys.
+func (mr *MockAPIKeyProviderMockRecorder) ListUserAPIKeys(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserAPIKeys", reflect.TypeOf((*MockAPIKeyProvider)(nil).ListUserAPIKeys), arg0, arg1)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockAPIKeyProvider) RevokeAPIKey(arg0 context.Context, arg1, arg2 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockAPIKeyProviderMockRecorder) RevokeAPIKey(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockAPIKeyProvider)(nil).RevokeAPIKey), arg0, arg1, arg2)
+}
+
+// TouchAPIKey mocks base method.
+func (m *MockAPIKeyProvider) TouchAPIKey(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchAPIKey indicates an expected call of TouchAPIKey.
+func (mr *MockAPIKeyProviderMockRecorder) TouchAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchAPIKey", reflect.TypeOf((*MockAPIKeyProvider)(nil).TouchAPIKey), arg0, arg1)
+}
+
+// MockOIDCProvider is a mock of OIDCProvider interface.
+type MockOIDCProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockOIDCProviderMockRecorder
+}
+
+// MockOIDCProviderMockRecorder is the mock recorder for MockOIDCProvider.
+type MockOIDCProviderMockRecorder struct {
+	mock *MockOIDCProvider
+}
+
+// NewMockOIDCProvider creates a new mock instance.
+func NewMockOIDCProvider(ctrl *gomock.Controller) *MockOIDCProvider {
+	mock := &MockOIDCProvider{ctrl: ctrl}
+	mock.recorder = &MockOIDCProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOIDCProvider) EXPECT() *MockOIDCProviderMockRecorder {
+	return m.recorder
+}
+
+// AddAuthorizationCode mocks base method.
+func (m *MockOIDCProvider) AddAuthorizationCode(arg0 context.Context, arg1 model.AuthorizationCode) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAuthorizationCode", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAuthorizationCode indicates an expected call of AddAuthorizationCode.
+func (mr *MockOIDCProviderMockRecorder) AddAuthorizationCode(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAuthorizationCode", reflect.TypeOf((*MockOIDCProvider)(nil).AddAuthorizationCode), arg0, arg1)
+}
+
+// AddClient mocks base method.
+func (m *MockOIDCProvider) AddClient(arg0 context.Context, arg1 model.OAuthClient) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddClient", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddClient indicates an expected call of AddClient.
+func (mr *MockOIDCProviderMockRecorder) AddClient(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddClient", reflect.TypeOf((*MockOIDCProvider)(nil).AddClient), arg0, arg1)
+}
+
+// ConsumeAuthorizationCode mocks base method.
+func (m *MockOIDCProvider) ConsumeAuthorizationCode(arg0 context.Context, arg1 string) (*model.AuthorizationCode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeAuthorizationCode", arg0, arg1)
+	ret0, _ := ret[0].(*model.AuthorizationCode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeAuthorizationCode indicates an expected call of ConsumeAuthorizationCode.
+func (mr *MockOIDCProviderMockRecorder) ConsumeAuthorizationCode(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeAuthorizationCode", reflect.TypeOf((*MockOIDCProvider)(nil).ConsumeAuthorizationCode), arg0, arg1)
+}
+
+// DeleteConsent mocks base method.
+func (m *MockOIDCProvider) DeleteConsent(arg0 context.Context, arg1 uuid.UUID, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteConsent", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteConsent indicates an expected call of DeleteConsent.
+func (mr *MockOIDCProviderMockRecorder) DeleteConsent(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteConsent", reflect.TypeOf((*MockOIDCProvider)(nil).DeleteConsent), arg0, arg1, arg2)
+}
+
+// GetClient mocks base method.
+func (m *MockOIDCProvider) GetClient(arg0 context.Context, arg1 string) (*model.OAuthClient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClient", arg0, arg1)
+	ret0, _ := ret[0].(*model.OAuthClient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetClient indicates an expected call of GetClient.
+func (mr *MockOIDCProviderMockRecorder) GetClient(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClient", reflect.TypeOf((*MockOIDCProvider)(nil).GetClient), arg0, arg1)
+}
+
+// GetConsent mocks base method.
+func (m *MockOIDCProvider) GetConsent(arg0 context.Context, arg1 uuid.UUID, arg2 string) (*model.OAuthConsent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConsent", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.OAuthConsent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConsent indicates an expected call of GetConsent.
+func (mr *MockOIDCProviderMockRecorder) GetConsent(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConsent", reflect.TypeOf((*MockOIDCProvider)(nil).GetConsent), arg0, arg1, arg2)
+}
+
+// ListUserConsents mocks base method.
+func (m *MockOIDCProvider) ListUserConsents(arg0 context.Context, arg1 uuid.UUID) ([]model.OAuthConsent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserConsents", arg0, arg1)
+	ret0, _ := ret[0].([]model.OAuthConsent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserConsents indicates an expected call of ListUserConsents.
+func (mr *MockOIDCProviderMockRecorder) ListUserConsents(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserConsents", reflect.TypeOf((*MockOIDCProvider)(nil).ListUserConsents), arg0, arg1)
+}
+
+// SaveConsent mocks base method.
+func (m *MockOIDCProvider) SaveConsent(arg0 context.Context, arg1 model.OAuthConsent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveConsent", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveConsent indicates an expected call of SaveConsent.
+func (mr *MockOIDCProviderMockRecorder) SaveConsent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveConsent", reflect.TypeOf((*MockOIDCProvider)(nil).SaveConsent), arg0, arg1)
+}
+
+// MockIdentityProvider is a mock of IdentityProvider interface.
+type MockIdentityProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockIdentityProviderMockRecorder
+}
+
+// MockIdentityProviderMockRecorder is the mock recorder for MockIdentityProvider.
+type MockIdentityProviderMockRecorder struct {
+	mock *MockIdentityProvider
+}
+
+// NewMockIdentityProvider creates a new mock instance.
+func NewMockIdentityProvider(ctrl *gomock.Controller) *MockIdentityProvider {
+	mock := &MockIdentityProvider{ctrl: ctrl}
+	mock.recorder = &MockIdentityProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIdentityProvider) EXPECT() *MockIdentityProviderMockRecorder {
+	return m.recorder
+}
+
+// AddIdentity mocks base method.
+func (m *MockIdentityProvider) AddIdentity(arg0 context.Context, arg1 model.Identity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddIdentity", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddIdentity indicates an expected call of AddIdentity.
+func (mr *MockIdentityProviderMockRecorder) AddIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddIdentity", reflect.TypeOf((*MockIdentityProvider)(nil).AddIdentity), arg0, arg1)
+}
+
+// DeleteIdentity mocks base method.
+func (m *MockIdentityProvider) DeleteIdentity(arg0 context.Context, arg1 uuid.UUID, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIdentity", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIdentity indicates an expected call of DeleteIdentity.
+func (mr *MockIdentityProviderMockRecorder) DeleteIdentity(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIdentity", reflect.TypeOf((*MockIdentityProvider)(nil).DeleteIdentity), arg0, arg1, arg2)
+}
+
+// GetIdentity mocks base method.
+func (m *MockIdentityProvider) GetIdentity(arg0 context.Context, arg1, arg2 string) (*model.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdentity", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdentity indicates an expected call of GetIdentity.
+func (mr *MockIdentityProviderMockRecorder) GetIdentity(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdentity", reflect.TypeOf((*MockIdentityProvider)(nil).GetIdentity), arg0, arg1, arg2)
+}
+
+// ListUserIdentities mocks base method.
+func (m *MockIdentityProvider) ListUserIdentities(arg0 context.Context, arg1 uuid.UUID) ([]model.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserIdentities", arg0, arg1)
+	ret0, _ := ret[0].([]model.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserIdentities indicates an expected call of ListUserIdentities.
+func (mr *MockIdentityProviderMockRecorder) ListUserIdentities(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserIdentities", reflect.TypeOf((*MockIdentityProvider)(nil).ListUserIdentities), arg0, arg1)
+}
+
+// TouchIdentity mocks base method.
+func (m *MockIdentityProvider) TouchIdentity(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchIdentity", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchIdentity indicates an expected call of TouchIdentity.
+func (mr *MockIdentityProviderMockRecorder) TouchIdentity(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchIdentity", reflect.TypeOf((*MockIdentityProvider)(nil).TouchIdentity), arg0, arg1)
+}
+
+// MockWebAuthnProvider is a mock of WebAuthnProvider interface.
+type MockWebAuthnProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebAuthnProviderMockRecorder
+}
+
+// MockWebAuthnProviderMockRecorder is the mock recorder for MockWebAuthnProvider.
+type MockWebAuthnProviderMockRecorder struct {
+	mock *MockWebAuthnProvider
+}
+
+// NewMockWebAuthnProvider creates a new mock instance.
+func NewMockWebAuthnProvider(ctrl *gomock.Controller) *MockWebAuthnProvider {
+	mock := &MockWebAuthnProvider{ctrl: ctrl}
+	mock.recorder = &MockWebAuthnProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWebAuthnProvider) EXPECT() *MockWebAuthnProviderMockRecorder {
+	return m.recorder
+}
+
+// AddCredential mocks base method.
+func (m *MockWebAuthnProvider) AddCredential(arg0 context.Context, arg1 model.WebAuthnCredential) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCredential", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddCredential indicates an expected call of AddCredential.
+func (mr *MockWebAuthnProviderMockRecorder) AddCredential(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCredential", reflect.TypeOf((*MockWebAuthnProvider)(nil).AddCredential), arg0, arg1)
+}
+
+// DeleteCredential mocks base method.
+func (m *MockWebAuthnProvider) DeleteCredential(arg0 context.Context, arg1, arg2 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCredential", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCredential indicates an expected call of DeleteCredential.
+func (mr *MockWebAuthnProviderMockRecorder) DeleteCredential(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCredential", reflect.TypeOf((*MockWebAuthnProvider)(nil).DeleteCredential), arg0, arg1, arg2)
+}
+
+// GetCredential mocks base method.
+func (m *MockWebAuthnProvider) GetCredential(arg0 context.Context, arg1 []byte) (*model.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCredential", arg0, arg1)
+	ret0, _ := ret[0].(*model.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCredential indicates an expected call of GetCredential.
+func (mr *MockWebAuthnProviderMockRecorder) GetCredential(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCredential", reflect.TypeOf((*MockWebAuthnProvider)(nil).GetCredential), arg0, arg1)
+}
+
+// ListUserCredentials mocks base method.
+func (m *MockWebAuthnProvider) ListUserCredentials(arg0 context.Context, arg1 uuid.UUID) ([]model.WebAuthnCredential, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUserCredentials", arg0, arg1)
+	ret0, _ := ret[0].([]model.WebAuthnCredential)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUserCredentials indicates an expected call of ListUserCredentials.
+func (mr *MockWebAuthnProviderMockRecorder) ListUserCredentials(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUserCredentials", reflect.TypeOf((*MockWebAuthnProvider)(nil).ListUserCredentials), arg0, arg1)
+}
+
+// UpdateSignCount mocks base method.
+func (m *MockWebAuthnProvider) UpdateSignCount(arg0 context.Context, arg1 uuid.UUID, arg2, arg3 uint32) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSignCount", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateSignCount indicates an expected call of UpdateSignCount.
+func (mr *MockWebAuthnProviderMockRecorder) UpdateSignCount(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSignCount", reflect.TypeOf((*MockWebAuthnProvider)(nil).UpdateSignCount), arg0, arg1, arg2, arg3)
+}
+
+// MockRBACProvider is a mock of RBACProvider interface.
+type MockRBACProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockRBACProviderMockRecorder
+}
+
+// MockRBACProviderMockRecorder is the mock recorder for MockRBACProvider.
+type MockRBACProviderMockRecorder struct {
+	mock *MockRBACProvider
+}
+
+// NewMockRBACProvider creates a new mock instance.
+func NewMockRBACProvider(ctrl *gomock.Controller) *MockRBACProvider {
+	mock := &MockRBACProvider{ctrl: ctrl}
+	mock.recorder = &MockRBACProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRBACProvider) EXPECT() *MockRBACProviderMockRecorder {
+	return m.recorder
+}
+
+// AddGroup mocks base method.
+func (m *MockRBACProvider) AddGroup(arg0 context.Context, arg1 model.Group) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddGroup", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddGroup indicates an expected call of AddGroup.
+func (mr *MockRBACProviderMockRecorder) AddGroup(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGroup", reflect.TypeOf((*MockRBACProvider)(nil).AddGroup), arg0, arg1)
+}
+
+// AddGroupMember mocks base method.
+func (m *MockRBACProvider) AddGroupMember(arg0 context.Context, arg1, arg2 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddGroupMember", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddGroupMember indicates an expected call of AddGroupMember.
+func (mr *MockRBACProviderMockRecorder) AddGroupMember(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGroupMember", reflect.TypeOf((*MockRBACProvider)(nil).AddGroupMember), arg0, arg1, arg2)
+}
+
+// AddRole mocks base method.
+func (m *MockRBACProvider) AddRole(arg0 context.Context, arg1 model.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRole", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddRole indicates an expected call of AddRole.
+func (mr *MockRBACProviderMockRecorder) AddRole(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRole", reflect.TypeOf((*MockRBACProvider)(nil).AddRole), arg0, arg1)
+}
+
+// AssignGroupRole mocks base method.
+func (m *MockRBACProvider) AssignGroupRole(arg0 context.Context, arg1 uuid.UUID, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignGroupRole", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignGroupRole indicates an expected call of AssignGroupRole.
+func (mr *MockRBACProviderMockRecorder) AssignGroupRole(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignGroupRole", reflect.TypeOf((*MockRBACProvider)(nil).AssignGroupRole), arg0, arg1, arg2)
+}
+
+// AssignUserRole mocks base method.
+func (m *MockRBACProvider) AssignUserRole(arg0 context.Context, arg1 uuid.UUID, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignUserRole", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignUserRole indicates an expected call of AssignUserRole.
+func (mr *MockRBACProviderMockRecorder) AssignUserRole(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignUserRole", reflect.TypeOf((*MockRBACProvider)(nil).AssignUserRole), arg0, arg1, arg2)
+}
+
+// DeleteGroup mocks base method.
+func (m *MockRBACProvider) DeleteGroup(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGroup", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGroup indicates an expected call of DeleteGroup.
+func (mr *MockRBACProviderMockRecorder) DeleteGroup(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroup", reflect.TypeOf((*MockRBACProvider)(nil).DeleteGroup), arg0, arg1)
+}
+
+// DeleteRole mocks base method.
+func (m *MockRBACProvider) DeleteRole(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRole", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRole indicates an expected call of DeleteRole.
+func (mr *MockRBACProviderMockRecorder) DeleteRole(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRole", reflect.TypeOf((*MockRBACProvider)(nil).DeleteRole), arg0, arg1)
+}
+
+// GetGroup mocks base method.
+func (m *MockRBACProvider) GetGroup(arg0 context.Context, arg1 uuid.UUID) (*model.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroup", arg0, arg1)
+	ret0, _ := ret[0].(*model.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroup indicates an expected call of GetGroup.
+func (mr *MockRBACProviderMockRecorder) GetGroup(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroup", reflect.TypeOf((*MockRBACProvider)(nil).GetGroup), arg0, arg1)
+}
+
+// GetRole mocks base method.
+func (m *MockRBACProvider) GetRole(arg0 context.Context, arg1 string) (*model.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRole", arg0, arg1)
+	ret0, _ := ret[0].(*model.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRole indicates an expected call of GetRole.
+func (mr *MockRBACProviderMockRecorder) GetRole(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRole", reflect.TypeOf((*MockRBACProvider)(nil).GetRole), arg0, arg1)
+}
+
+// GetUserPermissions mocks base method.
+func (m *MockRBACProvider) GetUserPermissions(arg0 context.Context, arg1 uuid.UUID) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserPermissions", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserPermissions indicates an expected call of GetUserPermissions.
+func (mr *MockRBACProviderMockRecorder) GetUserPermissions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserPermissions", reflect.TypeOf((*MockRBACProvider)(nil).GetUserPermissions), arg0, arg1)
+}
+
+// ListGroupMembers mocks base method.
+func (m *MockRBACProvider) ListGroupMembers(arg0 context.Context, arg1 uuid.UUID) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGroupMembers", arg0, arg1)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGroupMembers indicates an expected call of ListGroupMembers.
+func (mr *MockRBACProviderMockRecorder) ListGroupMembers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroupMembers", reflect.TypeOf((*MockRBACProvider)(nil).ListGroupMembers), arg0, arg1)
+}
+
+// ListGroups mocks base method.
+func (m *MockRBACProvider) ListGroups(arg0 context.Context) ([]model.Group, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGroups", arg0)
+	ret0, _ := ret[0].([]model.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGroups indicates an expected call of ListGroups.
+func (mr *MockRBACProviderMockRecorder) ListGroups(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGroups", reflect.TypeOf((*MockRBACProvider)(nil).ListGroups), arg0)
+}
+
+// ListRoles mocks base method.
+func (m *MockRBACProvider) ListRoles(arg0 context.Context) ([]model.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRoles", arg0)
+	ret0, _ := ret[0].([]model.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRoles indicates an expected call of ListRoles.
+func (mr *MockRBACProviderMockRecorder) ListRoles(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRoles", reflect.TypeOf((*MockRBACProvider)(nil).ListRoles), arg0)
+}
+
+// RemoveGroupMember mocks base method.
+func (m *MockRBACProvider) RemoveGroupMember(arg0 context.Context, arg1, arg2 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveGroupMember", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveGroupMember indicates an expected call of RemoveGroupMember.
+func (mr *MockRBACProviderMockRecorder) RemoveGroupMember(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveGroupMember", reflect.TypeOf((*MockRBACProvider)(nil).RemoveGroupMember), arg0, arg1, arg2)
+}
+
+// RevokeGroupRole mocks base method.
+func (m *MockRBACProvider) RevokeGroupRole(arg0 context.Context, arg1 uuid.UUID, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeGroupRole", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeGroupRole indicates an expected call of RevokeGroupRole.
+func (mr *MockRBACProviderMockRecorder) RevokeGroupRole(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeGroupRole", reflect.TypeOf((*MockRBACProvider)(nil).RevokeGroupRole), arg0, arg1, arg2)
+}
+
+// RevokeUserRole mocks base method.
+func (m *MockRBACProvider) RevokeUserRole(arg0 context.Context, arg1 uuid.UUID, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserRole", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserRole indicates an expected call of RevokeUserRole.
+func (mr *MockRBACProviderMockRecorder) RevokeUserRole(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserRole", reflect.TypeOf((*MockRBACProvider)(nil).RevokeUserRole), arg0, arg1, arg2)
+}
+
+// UpdateRole mocks base method.
+func (m *MockRBACProvider) UpdateRole(arg0 context.Context, arg1 string, arg2 model.UpdateRoleRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRole", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRole indicates an expected call of UpdateRole.
+func (mr *MockRBACProviderMockRecorder) UpdateRole(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRole", reflect.TypeOf((*MockRBACProvider)(nil).UpdateRole), arg0, arg1, arg2)
+}
+
+// MockAttributeProvider is a mock of AttributeProvider interface.
+type MockAttributeProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockAttributeProviderMockRecorder
+}
+
+// MockAttributeProviderMockRecorder is the mock recorder for MockAttributeProvider.
+type MockAttributeProviderMockRecorder struct {
+	mock *MockAttributeProvider
+}
+
+// NewMockAttributeProvider creates a new mock instance.
+func NewMockAttributeProvider(ctrl *gomock.Controller) *MockAttributeProvider {
+	mock := &MockAttributeProvider{ctrl: ctrl}
+	mock.recorder = &MockAttributeProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAttributeProvider) EXPECT() *MockAttributeProviderMockRecorder {
+	return m.recorder
+}
+
+// AddAttributeDefinition mocks base method.
+func (m *MockAttributeProvider) AddAttributeDefinition(arg0 context.Context, arg1 model.AttributeDefinition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAttributeDefinition", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAttributeDefinition indicates an expected call of AddAttributeDefinition.
+func (mr *MockAttributeProviderMockRecorder) AddAttributeDefinition(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAttributeDefinition", reflect.TypeOf((*MockAttributeProvider)(nil).AddAttributeDefinition), arg0, arg1)
+}
+
+// DeleteAttributeDefinition mocks base method.
+func (m *MockAttributeProvider) DeleteAttributeDefinition(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAttributeDefinition", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAttributeDefinition indicates an expected call of DeleteAttributeDefinition.
+func (mr *MockAttributeProviderMockRecorder) DeleteAttributeDefinition(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAttributeDefinition", reflect.TypeOf((*MockAttributeProvider)(nil).DeleteAttributeDefinition), arg0, arg1)
+}
+
+// GetAttributeDefinition mocks base method.
+func (m *MockAttributeProvider) GetAttributeDefinition(arg0 context.Context, arg1 string) (*model.AttributeDefinition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttributeDefinition", arg0, arg1)
+	ret0, _ := ret[0].(*model.AttributeDefinition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttributeDefinition indicates an expected call of GetAttributeDefinition.
+func (mr *MockAttributeProviderMockRecorder) GetAttributeDefinition(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttributeDefinition", reflect.TypeOf((*MockAttributeProvider)(nil).GetAttributeDefinition), arg0, arg1)
+}
+
+// ListAttributeDefinitions mocks base method.
+func (m *MockAttributeProvider) ListAttributeDefinitions(arg0 context.Context) ([]model.AttributeDefinition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAttributeDefinitions", arg0)
+	ret0, _ := ret[0].([]model.AttributeDefinition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAttributeDefinitions indicates an expected call of ListAttributeDefinitions.
+func (mr *MockAttributeProviderMockRecorder) ListAttributeDefinitions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAttributeDefinitions", reflect.TypeOf((*MockAttributeProvider)(nil).ListAttributeDefinitions), arg0)
+}
+
+// UpdateAttributeDefinition mocks base method.
+func (m *MockAttributeProvider) UpdateAttributeDefinition(arg0 context.Context, arg1 string, arg2 model.UpdateAttributeDefinitionRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAttributeDefinition", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAttributeDefinition indicates an expected call of UpdateAttributeDefinition.
+func (mr *MockAttributeProviderMockRecorder) UpdateAttributeDefinition(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAttributeDefinition", reflect.TypeOf((*MockAttributeProvider)(nil).UpdateAttributeDefinition), arg0, arg1, arg2)
+}
+
+// MockImpersonationProvider is a mock of ImpersonationProvider interface.
+type MockImpersonationProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockImpersonationProviderMockRecorder
+}
+
+// MockImpersonationProviderMockRecorder is the mock recorder for MockImpersonationProvider.
+type MockImpersonationProviderMockRecorder struct {
+	mock *MockImpersonationProvider
+}
+
+// NewMockImpersonationProvider creates a new mock instance.
+func NewMockImpersonationProvider(ctrl *gomock.Controller) *MockImpersonationProvider {
+	mock := &MockImpersonationProvider{ctrl: ctrl}
+	mock.recorder = &MockImpersonationProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockImpersonationProvider) EXPECT() *MockImpersonationProviderMockRecorder {
+	return m.recorder
+}
+
+// AddImpersonation mocks base method.
+func (m *MockImpersonationProvider) AddImpersonation(arg0 context.Context, arg1 model.Impersonation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddImpersonation", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddImpersonation indicates an expected call of AddImpersonation.
+func (mr *MockImpersonationProviderMockRecorder) AddImpersonation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddImpersonation", reflect.TypeOf((*MockImpersonationProvider)(nil).AddImpersonation), arg0, arg1)
+}
+
+// EndImpersonation mocks base method.
+func (m *MockImpersonationProvider) EndImpersonation(arg0 context.Context, arg1 uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EndImpersonation", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EndImpersonation indicates an expected call of EndImpersonation.
+func (mr *MockImpersonationProviderMockRecorder) EndImpersonation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndImpersonation", reflect.TypeOf((*MockImpersonationProvider)(nil).EndImpersonation), arg0, arg1)
+}
+
+// GetImpersonation mocks base method.
+func (m *MockImpersonationProvider) GetImpersonation(arg0 context.Context, arg1 uuid.UUID) (*model.Impersonation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetImpersonation", arg0, arg1)
+	ret0, _ := ret[0].(*model.Impersonation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetImpersonation indicates an expected call of GetImpersonation.
+func (mr *MockImpersonationProviderMockRecorder) GetImpersonation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetImpersonation", reflect.TypeOf((*MockImpersonationProvider)(nil).GetImpersonation), arg0, arg1)
+}
+
+// ListImpersonations mocks base method.
+func (m *MockImpersonationProvider) ListImpersonations(arg0 context.Context, arg1 model.ImpersonationFilter) ([]model.Impersonation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListImpersonations", arg0, arg1)
+	ret0, _ := ret[0].([]model.Impersonation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListImpersonations indicates an expected call of ListImpersonations.
+func (mr *MockImpersonationProviderMockRecorder) ListImpersonations(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListImpersonations", reflect.TypeOf((*MockImpersonationProvider)(nil).ListImpersonations), arg0, arg1)
+}
+
+// MockAuditProvider is a mock of AuditProvider interface.
+type MockAuditProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditProviderMockRecorder
+}
+
+// MockAuditProviderMockRecorder is the mock recorder for MockAuditProvider.
+type MockAuditProviderMockRecorder struct {
+	mock *MockAuditProvider
+}
+
+// NewMockAuditProvider creates a new mock instance.
+func NewMockAuditProvider(ctrl *gomock.Controller) *MockAuditProvider {
+	mock := &MockAuditProvider{ctrl: ctrl}
+	mock.recorder = &MockAuditProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditProvider) EXPECT() *MockAuditProviderMockRecorder {
+	return m.recorder
+}
+
+// AddAuditEvent mocks base method.
+func (m *MockAuditProvider) AddAuditEvent(arg0 context.Context, arg1 model.AuditEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAuditEvent", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddAuditEvent indicates an expected call of AddAuditEvent.
+func (mr *MockAuditProviderMockRecorder) AddAuditEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAuditEvent", reflect.TypeOf((*MockAuditProvider)(nil).AddAuditEvent), arg0, arg1)
+}
+
+// ListAuditEvents mocks base method.
+func (m *MockAuditProvider) ListAuditEvents(arg0 context.Context, arg1 model.AuditFilter, arg2 *model.AuditPosition, arg3 int) ([]model.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]model.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockAuditProviderMockRecorder) ListAuditEvents(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockAuditProvider)(nil).ListAuditEvents), arg0, arg1, arg2, arg3)
+}
@@ -0,0 +1,42 @@
+package softauthn
+
+import "encoding/binary"
+
+// Кодирование CBOR ровно в том объёме, что нужен аутентификатору.
+
+func cborHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n <= 0xff:
+		return []byte{major<<5 | 24, byte(n)}
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+	}
+}
+
+func cborInt(v int) []byte {
+	if v < 0 {
+		return cborHead(1, uint64(-1-v))
+	}
+	return cborHead(0, uint64(v))
+}
+
+func cborBytes(b []byte) []byte {
+	return append(cborHead(2, uint64(len(b))), b...)
+}
+
+func cborText(s string) []byte {
+	return append(cborHead(3, uint64(len(s))), s...)
+}
+
+// cborMap принимает уже закодированные ключи и значения по очереди.
+func cborMap(items ...[]byte) []byte {
+	out := cborHead(5, uint64(len(items)/2))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
@@ -0,0 +1,184 @@
+// Package softauthn - программный аутентификатор WebAuthn для тестов.
+// Создаёт ключи ES256 в памяти и отвечает на церемонии так же, как браузер
+// с подключённым ключом доступа.
+package softauthn
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+
+	"github.com/lemavisaitov/lk-api/internal/webauthn"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrExcluded     = errors.New("authenticator already registered")
+	ErrNoCredential = errors.New("no matching credential")
+)
+
+type credential struct {
+	id         []byte
+	key        *ecdsa.PrivateKey
+	userHandle []byte
+	signCount  uint32
+}
+
+type Authenticator struct {
+	rpID   string
+	origin string
+
+	mu          sync.Mutex
+	credentials []*credential
+}
+
+func New(rpID string, origin string) *Authenticator {
+	return &Authenticator{rpID: rpID, origin: origin}
+}
+
+// Create отвечает на navigator.credentials.create.
+func (a *Authenticator) Create(options webauthn.CreationOptions) (webauthn.RegistrationCredential, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, excluded := range options.ExcludeCredentials {
+		if a.find(excluded.ID) != nil {
+			return webauthn.RegistrationCredential{}, ErrExcluded
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return webauthn.RegistrationCredential{}, err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return webauthn.RegistrationCredential{}, err
+	}
+	cred := &credential{id: id, key: key, userHandle: options.User.ID}
+	a.credentials = append(a.credentials, cred)
+
+	rpID := options.RP.ID
+	if rpID == "" {
+		rpID = a.rpID
+	}
+	authData := a.authData(rpID, webauthn.FlagUserPresent|webauthn.FlagUserVerified|webauthn.FlagAttestedData, 0)
+	authData = append(authData, make([]byte, 16)...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(id)))
+	authData = append(authData, id...)
+	authData = append(authData, coseKey(&key.PublicKey)...)
+
+	attestation := cborMap(
+		cborText("fmt"), cborText("none"),
+		cborText("attStmt"), cborMap(),
+		cborText("authData"), cborBytes(authData),
+	)
+
+	return webauthn.RegistrationCredential{
+		ID:    base64.RawURLEncoding.EncodeToString(id),
+		RawID: id,
+		Type:  "public-key",
+		Response: webauthn.AttestationResponse{
+			ClientDataJSON:    a.clientData("webauthn.create", options.Challenge),
+			AttestationObject: attestation,
+			Transports:        []string{"internal"},
+		},
+	}, nil
+}
+
+// Get отвечает на navigator.credentials.get. Без allowCredentials выбирается
+// первый сохранённый ключ, как при входе без логина.
+func (a *Authenticator) Get(options webauthn.RequestOptions) (webauthn.AssertionCredential, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var cred *credential
+	if len(options.AllowCredentials) == 0 && len(a.credentials) > 0 {
+		cred = a.credentials[0]
+	}
+	for _, allowed := range options.AllowCredentials {
+		if cred = a.find(allowed.ID); cred != nil {
+			break
+		}
+	}
+	if cred == nil {
+		return webauthn.AssertionCredential{}, ErrNoCredential
+	}
+
+	cred.signCount++
+	authData := a.authData(options.RPID, webauthn.FlagUserPresent|webauthn.FlagUserVerified, cred.signCount)
+	clientData := a.clientData("webauthn.get", options.Challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, cred.key, digest[:])
+	if err != nil {
+		return webauthn.AssertionCredential{}, err
+	}
+
+	return webauthn.AssertionCredential{
+		ID:    base64.RawURLEncoding.EncodeToString(cred.id),
+		RawID: cred.id,
+		Type:  "public-key",
+		Response: webauthn.AssertionResponse{
+			ClientDataJSON:    clientData,
+			AuthenticatorData: authData,
+			Signature:         signature,
+			UserHandle:        cred.userHandle,
+		},
+	}, nil
+}
+
+// SetSignCount переводит счётчик ключа, например чтобы изобразить клон.
+func (a *Authenticator) SetSignCount(id []byte, count uint32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if cred := a.find(id); cred != nil {
+		cred.signCount = count
+	}
+}
+
+func (a *Authenticator) find(id []byte) *credential {
+	for _, cred := range a.credentials {
+		if bytes.Equal(cred.id, id) {
+			return cred
+		}
+	}
+	return nil
+}
+
+func (a *Authenticator) authData(rpID string, flags byte, signCount uint32) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append(rpIDHash[:], flags)
+	return binary.BigEndian.AppendUint32(data, signCount)
+}
+
+func (a *Authenticator) clientData(ceremony string, challenge []byte) []byte {
+	data, _ := json.Marshal(map[string]any{
+		"type":        ceremony,
+		"challenge":   base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":      a.origin,
+		"crossOrigin": false,
+	})
+	return data
+}
+
+func coseKey(key *ecdsa.PublicKey) []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+	return cborMap(
+		cborInt(1), cborInt(2),
+		cborInt(3), cborInt(webauthn.AlgES256),
+		cborInt(-1), cborInt(1),
+		cborInt(-2), cborBytes(x),
+		cborInt(-3), cborBytes(y),
+	)
+}
@@ -0,0 +1,129 @@
+// Package thumbnail делает квадратные JPEG-миниатюры из загруженных изображений.
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+const jpegQuality = 85
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrInvalidImage      = errors.New("invalid image")
+	// ErrTooLarge - слишком много пикселей: маленький файл может распаковаться в гигабайты
+	ErrTooLarge = errors.New("image dimensions too large")
+)
+
+var decoders = map[string]func([]byte) (image.Image, error){
+	"image/jpeg": func(data []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(data)) },
+	"image/png":  func(data []byte) (image.Image, error) { return png.Decode(bytes.NewReader(data)) },
+	"image/gif":  func(data []byte) (image.Image, error) { return gif.Decode(bytes.NewReader(data)) },
+}
+
+var configDecoders = map[string]func([]byte) (image.Config, error){
+	"image/jpeg": func(data []byte) (image.Config, error) { return jpeg.DecodeConfig(bytes.NewReader(data)) },
+	"image/png":  func(data []byte) (image.Config, error) { return png.DecodeConfig(bytes.NewReader(data)) },
+	"image/gif":  func(data []byte) (image.Config, error) { return gif.DecodeConfig(bytes.NewReader(data)) },
+}
+
+// Generate определяет формат по содержимому, а не по имени файла или заголовкам клиента,
+// обрезает изображение до квадрата по центру и возвращает JPEG для каждого размера.
+// Прозрачные области заливаются белым, у GIF берётся первый кадр.
+func Generate(data []byte, sizes []int, maxPixels int) (map[int][]byte, error) {
+	contentType := http.DetectContentType(data)
+	decode, ok := decoders[contentType]
+	if !ok {
+		return nil, errors.Wrap(ErrUnsupportedFormat, contentType)
+	}
+
+	config, err := configDecoders[contentType](data)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidImage, err.Error())
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, errors.Wrap(ErrInvalidImage, "empty image")
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, errors.Wrapf(ErrTooLarge, "%dx%d", config.Width, config.Height)
+	}
+
+	img, err := decode(data)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidImage, err.Error())
+	}
+	square := cropSquare(img)
+
+	thumbnails := make(map[int][]byte, len(sizes))
+	for _, size := range sizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resize(square, size), &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, errors.Wrap(err, "encode thumbnail")
+		}
+		thumbnails[size] = buf.Bytes()
+	}
+	return thumbnails, nil
+}
+
+// cropSquare вырезает центральный квадрат и накладывает его на белый фон.
+func cropSquare(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	offset := image.Pt(bounds.Min.X+(bounds.Dx()-side)/2, bounds.Min.Y+(bounds.Dy()-side)/2)
+
+	square := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(square, square.Bounds(), img, offset, draw.Over)
+	return square
+}
+
+// resize усредняет пиксели исходного квадрата, попадающие в каждый пиксель результата.
+// При увеличении в ячейку попадает один пиксель, то есть это ближайший сосед.
+func resize(src *image.RGBA, size int) *image.RGBA {
+	side := src.Bounds().Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+
+	for dy := 0; dy < size; dy++ {
+		y0, y1 := cell(dy, side, size)
+		for dx := 0; dx < size; dx++ {
+			x0, x1 := cell(dx, side, size)
+
+			var r, g, b, a, n int
+			for y := y0; y < y1; y++ {
+				row := src.Pix[y*src.Stride:]
+				for x := x0; x < x1; x++ {
+					pixel := row[x*4 : x*4+4]
+					r += int(pixel[0])
+					g += int(pixel[1])
+					b += int(pixel[2])
+					a += int(pixel[3])
+					n++
+				}
+			}
+
+			i := dy*dst.Stride + dx*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// cell возвращает диапазон исходных пикселей [from, to) для пикселя i результата.
+func cell(i int, side int, size int) (int, int) {
+	from := i * side / size
+	to := (i + 1) * side / size
+	if to <= from {
+		to = from + 1
+	}
+	return from, to
+}
@@ -0,0 +1,57 @@
+package thumbnail
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stripes возвращает PNG: левая половина красная, правая прозрачная.
+func stripes(t *testing.T, width int, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width/2; x++ {
+			img.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestGenerate(t *testing.T) {
+	// Кейс 1: квадрат по центру каждого размера, прозрачность становится белой
+	thumbnails, err := Generate(stripes(t, 300, 200), []int{64, 256}, 1_000_000)
+	require.NoError(t, err)
+	require.Len(t, thumbnails, 2)
+	for size, data := range thumbnails {
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, size, size), img.Bounds())
+
+		r, g, b, _ := img.At(size/8, size/2).RGBA()
+		assert.Greater(t, r>>8, uint32(200))
+		assert.Less(t, g>>8, uint32(60))
+		assert.Less(t, b>>8, uint32(60))
+		r, g, b, _ = img.At(size-size/8, size/2).RGBA()
+		assert.Greater(t, r>>8, uint32(200))
+		assert.Greater(t, g>>8, uint32(200))
+		assert.Greater(t, b>>8, uint32(200))
+	}
+
+	// Кейс 2: формат определяется по содержимому
+	_, err = Generate([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"), []int{64}, 1_000_000)
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+	_, err = Generate(append([]byte("\x89PNG\r\n\x1a\n"), "broken"...), []int{64}, 1_000_000)
+	require.ErrorIs(t, err, ErrInvalidImage)
+
+	// Кейс 3: размеры проверяются до распаковки
+	_, err = Generate(stripes(t, 300, 200), []int{64}, 300*200-1)
+	require.ErrorIs(t, err, ErrTooLarge)
+}
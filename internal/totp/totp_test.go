@@ -0,0 +1,70 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Векторы из приложения B RFC 6238 (SHA1), последние 6 цифр
+func TestCode_RFC6238(t *testing.T) {
+	secret := []byte("12345678901234567890")
+
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.code, Code(secret, Step(time.Unix(tc.unix, 0))))
+	}
+}
+
+func TestVerify(t *testing.T) {
+	secret, err := NewSecret()
+	require.NoError(t, err)
+
+	now := time.Unix(1_700_000_000, 0)
+	code := Code(secret, Step(now))
+
+	// Кейс 1: код текущего шага
+	step, ok := Verify(secret, code, now, 1)
+	require.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	// Кейс 2: часы клиента отстают на один шаг
+	step, ok = Verify(secret, code, now.Add(Period), 1)
+	require.True(t, ok)
+	assert.Equal(t, Step(now), step)
+
+	// Кейс 3: за пределами допуска
+	_, ok = Verify(secret, code, now.Add(2*Period), 1)
+	assert.False(t, ok)
+
+	// Кейс 4: код неверной длины
+	_, ok = Verify(secret, code+"0", now, 1)
+	assert.False(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	secret := []byte("12345678901234567890")
+
+	uri := URI("lk-api", "john@example.com", secret)
+	require.True(t, strings.HasPrefix(uri, "otpauth://totp/"))
+
+	parsed, err := url.Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "/lk-api:john@example.com", parsed.Path)
+	assert.Equal(t, EncodeSecret(secret), parsed.Query().Get("secret"))
+	assert.Equal(t, "lk-api", parsed.Query().Get("issuer"))
+}
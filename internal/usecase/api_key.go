@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+	"github.com/lemavisaitov/lk-api/internal/metrics"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type APIKeyProvider interface {
+	CreateAPIKey(*gin.Context, uuid.UUID, model.CreateAPIKeyRequest) (*model.CreatedAPIKey, error)
+	ListAPIKeys(*gin.Context, uuid.UUID) ([]model.APIKey, error)
+	RevokeAPIKey(*gin.Context, uuid.UUID, uuid.UUID) error
+	AuthenticateAPIKey(context.Context, string) (*auth.Claims, error)
+}
+
+type APIKeyCase struct {
+	keyRepo  repository.APIKeyProvider
+	userRepo repository.UserProvider
+	now      func() time.Time
+}
+
+func NewAPIKeyProvider(keyRepo repository.APIKeyProvider, userRepo repository.UserProvider) *APIKeyCase {
+	return &APIKeyCase{
+		keyRepo:  keyRepo,
+		userRepo: userRepo,
+		now:      time.Now,
+	}
+}
+
+// CreateAPIKey выпускает ключ. Сам ключ возвращается только здесь,
+// в БД остаются префикс и хэш секрета.
+func (a *APIKeyCase) CreateAPIKey(c *gin.Context, userID uuid.UUID, req model.CreateAPIKeyRequest) (*model.CreatedAPIKey, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(a.now()) {
+		return nil, &apperr.ValidationError{Fields: []apperr.FieldError{{
+			Field:   "expires_at",
+			Code:    "future",
+			Message: "must be in the future",
+		}}}
+	}
+
+	key, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase CreateAPIKey")
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase CreateAPIKey")
+	}
+
+	apiKey := model.APIKey{
+		ID:         id,
+		UserID:     userID,
+		Name:       req.Name,
+		Prefix:     prefix,
+		SecretHash: hash,
+		Scopes:     req.Scopes,
+		ExpiresAt:  req.ExpiresAt,
+		CreatedAt:  a.now(),
+	}
+	if err := a.keyRepo.AddAPIKey(c, apiKey); err != nil {
+		return nil, errors.Wrap(err, "usecase CreateAPIKey")
+	}
+
+	return &model.CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+func (a *APIKeyCase) ListAPIKeys(c *gin.Context, userID uuid.UUID) ([]model.APIKey, error) {
+	keys, err := a.keyRepo.ListUserAPIKeys(c, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase ListAPIKeys")
+	}
+	return keys, nil
+}
+
+func (a *APIKeyCase) RevokeAPIKey(c *gin.Context, userID uuid.UUID, keyID uuid.UUID) error {
+	if err := a.keyRepo.RevokeAPIKey(c, userID, keyID); err != nil {
+		return errors.Wrap(err, "usecase RevokeAPIKey")
+	}
+	return nil
+}
+
+// AuthenticateAPIKey проверяет ключ и возвращает claims владельца,
+// ограниченные правами ключа.
+func (a *APIKeyCase) AuthenticateAPIKey(ctx context.Context, key string) (*auth.Claims, error) {
+	prefix, hash, err := auth.ParseAPIKey(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase AuthenticateAPIKey")
+	}
+
+	stored, err := a.keyRepo.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, errors.Wrap(apperr.ErrInvalidToken, "api key not found")
+		}
+		return nil, errors.Wrap(err, "usecase AuthenticateAPIKey")
+	}
+	if subtle.ConstantTimeCompare([]byte(stored.SecretHash), []byte(hash)) != 1 {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "api key secret mismatch")
+	}
+	if !stored.Active(a.now()) {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "api key revoked or expired")
+	}
+
+	// Ключ действует в организации владельца, а не в указанной в заголовке
+	ctx, err = userTenant(ctx, a.userRepo, stored.UserID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, errors.Wrap(apperr.ErrInvalidToken, "api key owner not found")
+		}
+		return nil, errors.Wrap(err, "usecase AuthenticateAPIKey")
+	}
+	user, err := a.userRepo.GetUser(ctx, stored.UserID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, errors.Wrap(apperr.ErrInvalidToken, "api key owner not found")
+		}
+		return nil, errors.Wrap(err, "usecase AuthenticateAPIKey")
+	}
+
+	if stored.LastUsedAt == nil || a.now().Sub(*stored.LastUsedAt) > sessionTouchInterval {
+		if err := a.keyRepo.TouchAPIKey(ctx, stored.ID); err != nil {
+			logger.Error("failed to touch api key",
+				zap.String("prefix", stored.Prefix),
+				zap.Error(err),
+			)
+		}
+	}
+	metrics.APIKeyRequestInc(stored.Prefix)
+
+	claims := auth.NewClaims(user.ID)
+	claims.Roles = user.Roles
+	claims.Org = user.OrganizationID.String()
+	claims.APIKeyPrefix = stored.Prefix
+	claims.Scopes = stored.Scopes
+	return claims, nil
+}
@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyCase_CreateAPIKey(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	keyRepo := mocks.NewMockAPIKeyProvider(ctrl)
+	uc := NewAPIKeyProvider(keyRepo, mocks.NewMockUserProvider(ctrl))
+	userID := uuid.New()
+
+	// Кейс 1: срок действия уже истёк
+	past := time.Now().Add(-time.Hour)
+	_, err := uc.CreateAPIKey(newTestContext(), userID, model.CreateAPIKeyRequest{
+		Name:      "ci",
+		Scopes:    []string{model.ScopeUsersRead},
+		ExpiresAt: &past,
+	})
+	require.ErrorIs(t, err, apperr.ErrValidation)
+
+	// Кейс 2: ключ отдаётся клиенту, в БД только префикс и хэш секрета
+	var stored model.APIKey
+	keyRepo.EXPECT().
+		AddAPIKey(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, key model.APIKey) {
+			stored = key
+		}).
+		Return(nil)
+
+	created, err := uc.CreateAPIKey(newTestContext(), userID, model.CreateAPIKeyRequest{
+		Name:   "ci",
+		Scopes: []string{model.ScopeUsersRead},
+	})
+	require.NoError(t, err)
+
+	prefix, hash, err := auth.ParseAPIKey(created.Key)
+	require.NoError(t, err)
+	assert.Equal(t, userID, stored.UserID)
+	assert.Equal(t, prefix, stored.Prefix)
+	assert.Equal(t, hash, stored.SecretHash)
+	assert.NotContains(t, created.Key, stored.SecretHash)
+}
+
+func TestAPIKeyCase_AuthenticateAPIKey(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	keyRepo := mocks.NewMockAPIKeyProvider(ctrl)
+	userRepo := mocks.NewMockUserProvider(ctrl)
+
+	now := time.Date(2025, 4, 11, 12, 0, 0, 0, time.UTC)
+	uc := NewAPIKeyProvider(keyRepo, userRepo)
+	uc.now = func() time.Time { return now }
+
+	key, prefix, hash, err := auth.NewAPIKey()
+	require.NoError(t, err)
+
+	user := &model.User{ID: uuid.New(), Login: "svc", Roles: []string{model.RoleAdmin}, OrganizationID: uuid.New()}
+	stored := func() *model.APIKey {
+		return &model.APIKey{
+			ID:         uuid.New(),
+			UserID:     user.ID,
+			Prefix:     prefix,
+			SecretHash: hash,
+			Scopes:     []string{model.ScopeUsersRead},
+		}
+	}
+
+	// Кейс 1: ключа с таким префиксом нет
+	keyRepo.EXPECT().
+		GetAPIKeyByPrefix(gomock.Any(), prefix).
+		Return(nil, errors.Wrap(apperr.ErrNotFound, "api key not found"))
+
+	_, err = uc.AuthenticateAPIKey(context.Background(), key)
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+
+	// Кейс 2: секрет не совпадает
+	keyRepo.EXPECT().GetAPIKeyByPrefix(gomock.Any(), prefix).Return(stored(), nil)
+
+	_, err = uc.AuthenticateAPIKey(context.Background(), key[:strings.LastIndex(key, "_")+1]+"wrong")
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+
+	// Кейс 3: ключ отозван
+	revoked := stored()
+	revoked.RevokedAt = &now
+	keyRepo.EXPECT().GetAPIKeyByPrefix(gomock.Any(), prefix).Return(revoked, nil)
+
+	_, err = uc.AuthenticateAPIKey(context.Background(), key)
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+
+	// Кейс 4: срок действия ключа истёк
+	expired := stored()
+	expiredAt := now.Add(-time.Second)
+	expired.ExpiresAt = &expiredAt
+	keyRepo.EXPECT().GetAPIKeyByPrefix(gomock.Any(), prefix).Return(expired, nil)
+
+	_, err = uc.AuthenticateAPIKey(context.Background(), key)
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+
+	// Кейс 5: ключ принят, время использования обновляется
+	active := stored()
+	keyRepo.EXPECT().GetAPIKeyByPrefix(gomock.Any(), prefix).Return(active, nil)
+	userRepo.EXPECT().GetUserOrganizationID(gomock.Any(), user.ID).Return(user.OrganizationID, nil)
+	userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+	keyRepo.EXPECT().TouchAPIKey(gomock.Any(), active.ID).Return(nil)
+
+	claims, err := uc.AuthenticateAPIKey(context.Background(), key)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.String(), claims.Subject)
+	assert.Equal(t, user.Roles, claims.Roles)
+	assert.Equal(t, prefix, claims.APIKeyPrefix)
+	assert.Equal(t, user.OrganizationID.String(), claims.Org)
+	assert.True(t, claims.HasScope(model.ScopeUsersRead))
+	assert.False(t, claims.HasScope(model.ScopeUsersWrite))
+
+	// Кейс 6: ключ использовался недавно, лишней записи в БД нет
+	recent := stored()
+	lastUsed := now.Add(-10 * time.Second)
+	recent.LastUsedAt = &lastUsed
+	keyRepo.EXPECT().GetAPIKeyByPrefix(gomock.Any(), prefix).Return(recent, nil)
+	userRepo.EXPECT().GetUserOrganizationID(gomock.Any(), user.ID).Return(user.OrganizationID, nil)
+	userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+
+	_, err = uc.AuthenticateAPIKey(context.Background(), key)
+	require.NoError(t, err)
+
+	// Кейс 7: владелец ключа удалён - организацию не найти
+	keyRepo.EXPECT().GetAPIKeyByPrefix(gomock.Any(), prefix).Return(recent, nil)
+	userRepo.EXPECT().GetUserOrganizationID(gomock.Any(), user.ID).Return(uuid.Nil, apperr.ErrNotFound)
+
+	_, err = uc.AuthenticateAPIKey(context.Background(), key)
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+}
@@ -0,0 +1,250 @@
+package usecase
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// Имя атрибута становится ключом JSON и параметром фильтра attr[name]
+var attributeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// AttributeValidator проверяет атрибуты пользователя по справочнику.
+// Без required отсутствие обязательных атрибутов не считается ошибкой.
+type AttributeValidator interface {
+	ValidateAttributes(c *gin.Context, attributes map[string]any, required bool) error
+}
+
+type AttributeProvider interface {
+	AttributeValidator
+	ParseAttributeFilter(*gin.Context, map[string]string) (map[string]any, error)
+	ListAttributeDefinitions(*gin.Context) ([]model.AttributeDefinition, error)
+	GetAttributeDefinition(*gin.Context, string) (*model.AttributeDefinition, error)
+	CreateAttributeDefinition(*gin.Context, model.AttributeDefinition) (*model.AttributeDefinition, error)
+	UpdateAttributeDefinition(*gin.Context, string, model.UpdateAttributeDefinitionRequest) (*model.AttributeDefinition, error)
+	DeleteAttributeDefinition(*gin.Context, string) error
+}
+
+type AttributeCase struct {
+	attributeRepo repository.AttributeProvider
+}
+
+func NewAttributeProvider(attributeRepo repository.AttributeProvider) *AttributeCase {
+	return &AttributeCase{
+		attributeRepo: attributeRepo,
+	}
+}
+
+func (a *AttributeCase) ListAttributeDefinitions(c *gin.Context) ([]model.AttributeDefinition, error) {
+	definitions, err := a.attributeRepo.ListAttributeDefinitions(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase ListAttributeDefinitions")
+	}
+	return definitions, nil
+}
+
+func (a *AttributeCase) GetAttributeDefinition(c *gin.Context, name string) (*model.AttributeDefinition, error) {
+	definition, err := a.attributeRepo.GetAttributeDefinition(c, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase GetAttributeDefinition")
+	}
+	return definition, nil
+}
+
+func (a *AttributeCase) CreateAttributeDefinition(c *gin.Context,
+	definition model.AttributeDefinition) (*model.AttributeDefinition, error) {
+	if !attributeNamePattern.MatchString(definition.Name) {
+		return nil, &apperr.ValidationError{Fields: []apperr.FieldError{{
+			Field:   "name",
+			Code:    "format",
+			Message: "must start with a lowercase letter and contain only a-z, 0-9 and _",
+		}}}
+	}
+	if err := validateAttributeConstraints(definition.Type, definition.MaxLength, definition.Enum); err != nil {
+		return nil, err
+	}
+
+	if err := a.attributeRepo.AddAttributeDefinition(c, definition); err != nil {
+		return nil, errors.Wrap(err, "usecase CreateAttributeDefinition")
+	}
+	return a.GetAttributeDefinition(c, definition.Name)
+}
+
+// UpdateAttributeDefinition не перепроверяет сохранённые значения: новые
+// ограничения применяются при следующем изменении атрибутов пользователя.
+func (a *AttributeCase) UpdateAttributeDefinition(c *gin.Context, name string,
+	req model.UpdateAttributeDefinitionRequest) (*model.AttributeDefinition, error) {
+	current, err := a.GetAttributeDefinition(c, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateAttributeConstraints(current.Type, req.MaxLength, req.Enum); err != nil {
+		return nil, err
+	}
+
+	if err := a.attributeRepo.UpdateAttributeDefinition(c, name, req); err != nil {
+		return nil, errors.Wrap(err, "usecase UpdateAttributeDefinition")
+	}
+	return a.GetAttributeDefinition(c, name)
+}
+
+func (a *AttributeCase) DeleteAttributeDefinition(c *gin.Context, name string) error {
+	if err := a.attributeRepo.DeleteAttributeDefinition(c, name); err != nil {
+		return errors.Wrap(err, "usecase DeleteAttributeDefinition")
+	}
+	return nil
+}
+
+// ValidateAttributes проверяет полный набор атрибутов: неизвестные запрещены,
+// значения должны соответствовать типу и ограничениям.
+func (a *AttributeCase) ValidateAttributes(c *gin.Context, attributes map[string]any, required bool) error {
+	definitions, err := a.attributeRepo.ListAttributeDefinitions(c)
+	if err != nil {
+		return errors.Wrap(err, "usecase ValidateAttributes")
+	}
+
+	var fields []apperr.FieldError
+	for name := range attributes {
+		if !slices.ContainsFunc(definitions, func(d model.AttributeDefinition) bool { return d.Name == name }) {
+			fields = append(fields, attributeError(name, "unknown", "is not a known attribute"))
+		}
+	}
+	for _, definition := range definitions {
+		value, ok := attributes[definition.Name]
+		if !ok {
+			if required && definition.Required {
+				fields = append(fields, attributeError(definition.Name, "required", "is required"))
+			}
+			continue
+		}
+		if field := checkAttributeValue(definition, value); field != nil {
+			fields = append(fields, *field)
+		}
+	}
+
+	if len(fields) > 0 {
+		// Порядок обхода map случаен, а клиенту удобнее стабильный ответ
+		slices.SortFunc(fields, func(x, y apperr.FieldError) int { return strings.Compare(x.Field, y.Field) })
+		return &apperr.ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// ParseAttributeFilter приводит значения из query-параметров attr[name] к типам атрибутов.
+func (a *AttributeCase) ParseAttributeFilter(c *gin.Context, raw map[string]string) (map[string]any, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	definitions, err := a.attributeRepo.ListAttributeDefinitions(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase ParseAttributeFilter")
+	}
+
+	filter := make(map[string]any, len(raw))
+	for name, value := range raw {
+		index := slices.IndexFunc(definitions, func(d model.AttributeDefinition) bool { return d.Name == name })
+		if index < 0 {
+			return nil, &apperr.ValidationError{Fields: []apperr.FieldError{
+				filterError(name, "unknown", "is not a known attribute"),
+			}}
+		}
+
+		switch definitions[index].Type {
+		case model.AttributeTypeNumber:
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, &apperr.ValidationError{Fields: []apperr.FieldError{
+					filterError(name, "type", "must be a number"),
+				}}
+			}
+			filter[name] = number
+		case model.AttributeTypeBoolean:
+			boolean, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, &apperr.ValidationError{Fields: []apperr.FieldError{
+					filterError(name, "type", "must be a boolean"),
+				}}
+			}
+			filter[name] = boolean
+		default:
+			filter[name] = value
+		}
+	}
+	return filter, nil
+}
+
+// validateAttributeConstraints запрещает длину и перечисление у нестроковых атрибутов.
+func validateAttributeConstraints(attributeType string, maxLength int, enum []string) error {
+	if attributeType == model.AttributeTypeString {
+		return nil
+	}
+	var fields []apperr.FieldError
+	if maxLength != 0 {
+		fields = append(fields, apperr.FieldError{
+			Field:   "max_length",
+			Code:    "string_only",
+			Message: "applies only to string attributes",
+		})
+	}
+	if len(enum) > 0 {
+		fields = append(fields, apperr.FieldError{
+			Field:   "enum",
+			Code:    "string_only",
+			Message: "applies only to string attributes",
+		})
+	}
+	if len(fields) > 0 {
+		return &apperr.ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// checkAttributeValue работает со значениями после json.Unmarshal: числа - float64.
+func checkAttributeValue(definition model.AttributeDefinition, value any) *apperr.FieldError {
+	switch definition.Type {
+	case model.AttributeTypeNumber:
+		if _, ok := value.(float64); !ok {
+			field := attributeError(definition.Name, "type", "must be a number")
+			return &field
+		}
+	case model.AttributeTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			field := attributeError(definition.Name, "type", "must be a boolean")
+			return &field
+		}
+	default:
+		text, ok := value.(string)
+		if !ok {
+			field := attributeError(definition.Name, "type", "must be a string")
+			return &field
+		}
+		if definition.MaxLength > 0 && utf8.RuneCountInString(text) > definition.MaxLength {
+			field := attributeError(definition.Name, "max",
+				fmt.Sprintf("must be at most %d characters long", definition.MaxLength))
+			return &field
+		}
+		if len(definition.Enum) > 0 && !slices.Contains(definition.Enum, text) {
+			field := attributeError(definition.Name, "oneof", "must be one of the allowed values")
+			return &field
+		}
+	}
+	return nil
+}
+
+func attributeError(name string, code string, message string) apperr.FieldError {
+	return apperr.FieldError{Field: "attributes." + name, Code: code, Message: message}
+}
+
+func filterError(name string, code string, message string) apperr.FieldError {
+	return apperr.FieldError{Field: "attr[" + name + "]", Code: code, Message: message}
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestAttributes возвращает справочник с заданными атрибутами.
+func newTestAttributes(t *testing.T, definitions ...model.AttributeDefinition) *AttributeCase {
+	attributeRepo := mocks.NewMockAttributeProvider(gomock.NewController(t))
+	attributeRepo.EXPECT().ListAttributeDefinitions(gomock.Any()).Return(definitions, nil).AnyTimes()
+	return NewAttributeProvider(attributeRepo)
+}
+
+var testAttributeDefinitions = []model.AttributeDefinition{
+	{Name: "department", Type: model.AttributeTypeString, Required: true, Enum: []string{"sales", "support"}},
+	{Name: "nickname", Type: model.AttributeTypeString, MaxLength: 5},
+	{Name: "floor", Type: model.AttributeTypeNumber},
+	{Name: "remote", Type: model.AttributeTypeBoolean},
+}
+
+func TestAttributeCase_ValidateAttributes(t *testing.T) {
+	uc := newTestAttributes(t, testAttributeDefinitions...)
+	c := newTestContext()
+
+	testCases := []struct {
+		caseName   string
+		attributes map[string]any
+		required   bool
+		fields     []string
+	}{
+		{"valid", map[string]any{"department": "sales", "nickname": "ivan", "floor": 3.0, "remote": true},
+			true, nil},
+		{"missing required", map[string]any{"floor": 3.0}, true, []string{"attributes.department"}},
+		{"missing required allowed", map[string]any{"floor": 3.0}, false, nil},
+		{"unknown attribute", map[string]any{"department": "sales", "shoe_size": 42.0}, true,
+			[]string{"attributes.shoe_size"}},
+		{"wrong types", map[string]any{"department": "sales", "floor": "3", "remote": nil}, true,
+			[]string{"attributes.floor", "attributes.remote"}},
+		{"enum and length", map[string]any{"department": "hr", "nickname": "ivan_the_great"}, true,
+			[]string{"attributes.department", "attributes.nickname"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.caseName, func(t *testing.T) {
+			err := uc.ValidateAttributes(c, tc.attributes, tc.required)
+			if tc.fields == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			var validationErr *apperr.ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			fields := make([]string, 0, len(validationErr.Fields))
+			for _, field := range validationErr.Fields {
+				fields = append(fields, field.Field)
+			}
+			assert.Equal(t, tc.fields, fields)
+		})
+	}
+}
+
+func TestAttributeCase_ParseAttributeFilter(t *testing.T) {
+	uc := newTestAttributes(t, testAttributeDefinitions...)
+	c := newTestContext()
+
+	// Кейс 1: значения приводятся к типам атрибутов
+	filter, err := uc.ParseAttributeFilter(c, map[string]string{"department": "sales", "floor": "3", "remote": "true"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"department": "sales", "floor": 3.0, "remote": true}, filter)
+
+	// Кейс 2: неизвестный атрибут и значение не того типа
+	_, err = uc.ParseAttributeFilter(c, map[string]string{"shoe_size": "42"})
+	require.ErrorIs(t, err, apperr.ErrValidation)
+	_, err = uc.ParseAttributeFilter(c, map[string]string{"floor": "third"})
+	require.ErrorIs(t, err, apperr.ErrValidation)
+}
+
+func TestAttributeCase_CreateAttributeDefinition(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	attributeRepo := mocks.NewMockAttributeProvider(ctrl)
+	uc := NewAttributeProvider(attributeRepo)
+	c := newTestContext()
+
+	// Кейс 1: имя должно годиться для ключа JSON и параметра фильтра
+	_, err := uc.CreateAttributeDefinition(c, model.AttributeDefinition{Name: "Cost Center", Type: model.AttributeTypeString})
+	require.ErrorIs(t, err, apperr.ErrValidation)
+
+	// Кейс 2: длина и перечисление только у строк
+	_, err = uc.CreateAttributeDefinition(c, model.AttributeDefinition{
+		Name: "floor", Type: model.AttributeTypeNumber, Enum: []string{"1", "2"},
+	})
+	require.ErrorIs(t, err, apperr.ErrValidation)
+
+	// Кейс 3: успешное создание
+	definition := model.AttributeDefinition{Name: "cost_center", Type: model.AttributeTypeString, MaxLength: 10}
+	attributeRepo.EXPECT().AddAttributeDefinition(gomock.Any(), definition).Return(nil)
+	attributeRepo.EXPECT().GetAttributeDefinition(gomock.Any(), "cost_center").Return(&definition, nil)
+	created, err := uc.CreateAttributeDefinition(c, definition)
+	require.NoError(t, err)
+	assert.Equal(t, &definition, created)
+}
@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/cursor"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+	"github.com/lemavisaitov/lk-api/internal/requestid"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Значение этого поля не попадает в журнал
+const auditPasswordField = "password"
+
+// AuditRecorder пишет события в журнал аудита. Ошибка записи логируется
+// и не отменяет уже выполненное действие.
+type AuditRecorder interface {
+	Record(*gin.Context, model.AuditEvent)
+}
+
+type AuditProvider interface {
+	AuditRecorder
+	ListEvents(*gin.Context, model.AuditFilter, string, int) (*model.AuditPage, error)
+}
+
+type AuditCase struct {
+	auditRepo repository.AuditProvider
+	cursors   *cursor.Codec
+}
+
+func NewAuditProvider(auditRepo repository.AuditProvider, cursors *cursor.Codec) *AuditCase {
+	return &AuditCase{
+		auditRepo: auditRepo,
+		cursors:   cursors,
+	}
+}
+
+// Record дополняет событие автором из токена, IP и идентификатором запроса.
+func (a *AuditCase) Record(c *gin.Context, event model.AuditEvent) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		logger.Error("failed to write audit event",
+			zap.String("action", event.Action),
+			zap.Error(err),
+		)
+		return
+	}
+	event.ID = id
+
+	if claims, ok := auth.ClaimsFromContext(c); ok {
+		if event.ActorID == nil {
+			if actorID, err := claims.UserID(); err == nil {
+				event.ActorID = &actorID
+			}
+		}
+		if impersonatorID, err := claims.ActorID(); err == nil {
+			event.ImpersonatorID = &impersonatorID
+		}
+	}
+	// Вызовы вне HTTP-запроса (тесты, фоновые задачи) приходят без Request
+	if c.Request != nil {
+		event.IP = c.ClientIP()
+		event.RequestID = requestid.FromContext(c)
+	}
+
+	if err := a.auditRepo.AddAuditEvent(c, event); err != nil {
+		logger.Error("failed to write audit event",
+			zap.String("action", event.Action),
+			zap.String("requestID", event.RequestID),
+			zap.Error(err),
+		)
+	}
+}
+
+// ListEvents возвращает страницу событий от новых к старым. Пустой
+// pageCursor означает первую страницу.
+func (a *AuditCase) ListEvents(c *gin.Context, filter model.AuditFilter, pageCursor string,
+	limit int) (*model.AuditPage, error) {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+
+	var after *model.AuditPosition
+	if pageCursor != "" {
+		position, err := decodeCursor[model.AuditPosition](a.cursors, pageCursor, filter)
+		if err != nil {
+			return nil, err
+		}
+		after = position
+	}
+
+	// Лишняя запись показывает, есть ли следующая страница
+	events, err := a.auditRepo.ListAuditEvents(c, filter, after, limit+1)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase ListEvents")
+	}
+
+	page := &model.AuditPage{Events: events}
+	if len(events) > limit {
+		page.Events = events[:limit]
+		last := page.Events[limit-1]
+		page.NextCursor, err = encodeCursor(a.cursors, model.AuditPosition{CreatedAt: last.CreatedAt, ID: last.ID}, filter)
+		if err != nil {
+			return nil, errors.Wrap(err, "usecase ListEvents")
+		}
+	}
+	return page, nil
+}
+
+// auditDiff сравнивает поля до и после действия. Значения секретных
+// полей заменяются на model.AuditRedacted, отмечается только факт изменения.
+func auditDiff(before map[string]any, after map[string]any) map[string]model.AuditChange {
+	fields := make(map[string]struct{}, len(before)+len(after))
+	for field := range before {
+		fields[field] = struct{}{}
+	}
+	for field := range after {
+		fields[field] = struct{}{}
+	}
+
+	changes := make(map[string]model.AuditChange)
+	for field := range fields {
+		oldValue, hadOld := before[field]
+		newValue, hasNew := after[field]
+		if hadOld && hasNew && oldValue == newValue {
+			continue
+		}
+
+		var change model.AuditChange
+		if hadOld {
+			change.Old = redactAudit(field, oldValue)
+		}
+		if hasNew {
+			change.New = redactAudit(field, newValue)
+		}
+		changes[field] = change
+	}
+	return changes
+}
+
+func redactAudit(field string, value any) any {
+	if field == auditPasswordField {
+		return model.AuditRedacted
+	}
+	return value
+}
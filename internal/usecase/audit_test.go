@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/cursor"
+	"github.com/lemavisaitov/lk-api/internal/lockout"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/requestid"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAuditRecorder запоминает события вместо записи в БД
+type fakeAuditRecorder struct {
+	events []model.AuditEvent
+}
+
+func (f *fakeAuditRecorder) Record(_ *gin.Context, event model.AuditEvent) {
+	f.events = append(f.events, event)
+}
+
+func (f *fakeAuditRecorder) last(t *testing.T) model.AuditEvent {
+	require.NotEmpty(t, f.events)
+	return f.events[len(f.events)-1]
+}
+
+func newTestCursorCodec(t *testing.T) *cursor.Codec {
+	codec, err := cursor.New(bytes.Repeat([]byte{3}, cursor.MinKeySize))
+	require.NoError(t, err)
+	return codec
+}
+
+func TestAuditDiff(t *testing.T) {
+	before := map[string]any{"name": "old", "age": 20, "password": "hash-1"}
+
+	// Кейс 1: неизменённые поля пропускаются, пароль скрыт
+	changes := auditDiff(before, map[string]any{"name": "new", "age": 20, "password": "hash-2"})
+	assert.Equal(t, map[string]model.AuditChange{
+		"name":     {Old: "old", New: "new"},
+		"password": {Old: model.AuditRedacted, New: model.AuditRedacted},
+	}, changes)
+
+	// Кейс 2: удаление сохраняет прежние значения
+	changes = auditDiff(before, nil)
+	assert.Equal(t, model.AuditChange{Old: 20}, changes["age"])
+	assert.Equal(t, model.AuditChange{Old: model.AuditRedacted}, changes["password"])
+}
+
+func TestAuditCase_Record(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	auditRepo := mocks.NewMockAuditProvider(ctrl)
+	uc := NewAuditProvider(auditRepo, newTestCursorCodec(t))
+
+	subjectID := uuid.New()
+	actorID := uuid.New()
+	claims := auth.NewClaims(subjectID)
+	claims.Act = &auth.Actor{Subject: actorID.String()}
+	c := newClaimsContext(claims)
+	c.Request = c.Request.WithContext(requestid.WithID(c.Request.Context(), "req-1"))
+
+	// Кейс 1: автор, сотрудник и запрос берутся из контекста
+	auditRepo.EXPECT().AddAuditEvent(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ any, event model.AuditEvent) error {
+			assert.NotEqual(t, uuid.Nil, event.ID)
+			assert.Equal(t, &subjectID, event.ActorID)
+			assert.Equal(t, &actorID, event.ImpersonatorID)
+			assert.Equal(t, "req-1", event.RequestID)
+			assert.Equal(t, "192.0.2.1", event.IP)
+			return nil
+		})
+	uc.Record(c, model.AuditEvent{Action: model.AuditUserUpdate, TargetID: &subjectID})
+
+	// Кейс 2: ошибка записи не паникует и не возвращается
+	auditRepo.EXPECT().AddAuditEvent(gomock.Any(), gomock.Any()).Return(assert.AnError)
+	uc.Record(&gin.Context{}, model.AuditEvent{Action: model.AuditUserCreate})
+}
+
+func TestAuditCase_ListEvents(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	auditRepo := mocks.NewMockAuditProvider(ctrl)
+	uc := NewAuditProvider(auditRepo, newTestCursorCodec(t))
+	c := newTestContext()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	events := []model.AuditEvent{
+		{ID: uuid.New(), CreatedAt: now},
+		{ID: uuid.New(), CreatedAt: now.Add(-time.Second)},
+		{ID: uuid.New(), CreatedAt: now.Add(-2 * time.Second)},
+	}
+	filter := model.AuditFilter{Action: model.AuditUserLogin}
+
+	// Кейс 1: первая страница запрашивает на одну запись больше
+	auditRepo.EXPECT().ListAuditEvents(gomock.Any(), filter, nil, 3).Return(events, nil)
+	page, err := uc.ListEvents(c, filter, "", 2)
+	require.NoError(t, err)
+	assert.Len(t, page.Events, 2)
+	require.NotEmpty(t, page.NextCursor)
+
+	// Кейс 2: курсор продолжает с последней записи страницы
+	auditRepo.EXPECT().ListAuditEvents(gomock.Any(), filter, gomock.Any(), 3).
+		DoAndReturn(func(_ any, _ model.AuditFilter, after *model.AuditPosition, _ int) ([]model.AuditEvent, error) {
+			require.NotNil(t, after)
+			assert.Equal(t, events[1].ID, after.ID)
+			assert.True(t, events[1].CreatedAt.Equal(after.CreatedAt))
+			return events[2:], nil
+		})
+	page, err = uc.ListEvents(c, filter, page.NextCursor, 2)
+	require.NoError(t, err)
+	assert.Len(t, page.Events, 1)
+	assert.Empty(t, page.NextCursor)
+
+	// Кейс 3: подделанный курсор
+	_, err = uc.ListEvents(c, filter, "bogus", 2)
+	var validationErr *apperr.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "cursor", validationErr.Fields[0].Field)
+}
+
+func TestUserCase_Audit(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	tokenRepo := mocks.NewMockOneTimeTokenProvider(ctrl)
+	sessionRepo := mocks.NewMockSessionProvider(ctrl)
+	store := lockout.NewMemoryStore(time.Minute, time.Hour)
+	t.Cleanup(store.Close)
+	recorder := &fakeAuditRecorder{}
+	passwordHasher := newTestHasher(t)
+	uc := NewUserProvider(userRepo, sessionRepo, tokenRepo, passwordHasher, newTestPolicy(),
+		lockout.NewLimiter(store, lockout.Policy{}, lockout.Policy{}), recorder,
+		newTestAttributes(t), nil, 0, false)
+	c := newTestContext()
+
+	hash, err := passwordHasher.Hash("correct-horse")
+	require.NoError(t, err)
+	user := &model.User{ID: uuid.New(), Login: "ivan", Name: "Ivan", Email: "ivan@example.com", Password: hash}
+
+	// Кейс 1: изменение почты записывается с прежним и новым значением
+	userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+	userRepo.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Return(&user.ID, nil)
+	tokenRepo.EXPECT().InvalidateUserTokens(gomock.Any(), user.ID, model.TokenPurposeEmailVerification).Return(nil)
+	email, name := "new@example.com", "Ivan"
+	_, err = uc.UpdateUser(c, model.UpdateUserRequest{ID: user.ID, Email: &email, Name: &name})
+	require.NoError(t, err)
+	event := recorder.last(t)
+	assert.Equal(t, model.AuditUserUpdate, event.Action)
+	assert.Equal(t, map[string]model.AuditChange{
+		"email": {Old: "ivan@example.com", New: "new@example.com"},
+	}, event.Changes)
+
+	// Кейс 2: неверный пароль записывается как неудачный вход с целью
+	userRepo.EXPECT().GetUserIDByLogin(gomock.Any(), "ivan").Return(&user.ID, nil)
+	userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+	_, err = uc.Authenticate(c, model.LoginRequest{Login: "ivan", Password: "wrong-password"})
+	require.ErrorIs(t, err, apperr.ErrWrongPassword)
+	event = recorder.last(t)
+	assert.Equal(t, model.AuditUserLoginFailure, event.Action)
+	assert.Equal(t, &user.ID, event.TargetID)
+	assert.Equal(t, "wrong_password", event.Details["reason"])
+
+	// Кейс 3: успешный вход
+	userRepo.EXPECT().GetUserIDByLogin(gomock.Any(), "ivan").Return(&user.ID, nil)
+	userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+	_, err = uc.Authenticate(c, model.LoginRequest{Login: "ivan", Password: "correct-horse"})
+	require.NoError(t, err)
+	event = recorder.last(t)
+	assert.Equal(t, model.AuditUserLogin, event.Action)
+	assert.Equal(t, &user.ID, event.ActorID)
+
+	// Кейс 4: удаление сохраняет данные пользователя без пароля
+	userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+	sessionRepo.EXPECT().RevokeUserSessions(gomock.Any(), user.ID, uuid.Nil).Return(nil)
+	userRepo.EXPECT().DeleteUser(gomock.Any(), user.ID, int64(0)).Return(nil)
+	require.NoError(t, uc.DeleteUser(c, user.ID, 0))
+	event = recorder.last(t)
+	assert.Equal(t, model.AuditUserDelete, event.Action)
+	assert.Equal(t, model.AuditChange{Old: "ivan"}, event.Changes["login"])
+	assert.Equal(t, model.AuditChange{Old: model.AuditRedacted}, event.Changes["password"])
+}
@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	tokenTypeBearer = "Bearer"
+	// Не чаще раза в минуту обновляем last_seen_at, чтобы не писать в БД на каждый запрос
+	sessionTouchInterval = time.Minute
+)
+
+type AuthProvider interface {
+	IssueTokens(*gin.Context, *model.User, string) (*model.TokenPair, error)
+	RefreshTokens(*gin.Context, string) (*model.TokenPair, error)
+	AuthenticateAccessToken(context.Context, string) (*auth.Claims, error)
+}
+
+type AuthCase struct {
+	userRepo    repository.UserProvider
+	tokenRepo   repository.RefreshTokenProvider
+	sessionRepo repository.SessionProvider
+	issuer      *auth.Issuer
+	refreshTTL  time.Duration
+}
+
+func NewAuthProvider(userRepo repository.UserProvider,
+	tokenRepo repository.RefreshTokenProvider,
+	sessionRepo repository.SessionProvider,
+	issuer *auth.Issuer,
+	refreshTTL time.Duration) *AuthCase {
+	return &AuthCase{
+		userRepo:    userRepo,
+		tokenRepo:   tokenRepo,
+		sessionRepo: sessionRepo,
+		issuer:      issuer,
+		refreshTTL:  refreshTTL,
+	}
+}
+
+// IssueTokens открывает новую сессию и выдаёт для неё пару токенов.
+func (a *AuthCase) IssueTokens(c *gin.Context, user *model.User, device string) (*model.TokenPair, error) {
+	sessionID, err := uuid.NewV7()
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase IssueTokens")
+	}
+
+	err = a.sessionRepo.AddSession(c, model.Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		Device:    device,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase IssueTokens")
+	}
+
+	pair, err := a.issuePair(c, user, sessionID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase IssueTokens")
+	}
+	return pair, nil
+}
+
+// RefreshTokens меняет refresh-токен на новую пару. Повторное предъявление
+// уже использованного токена считается утечкой, и вся сессия отзывается.
+func (a *AuthCase) RefreshTokens(c *gin.Context, refreshToken string) (*model.TokenPair, error) {
+	stored, err := a.tokenRepo.GetRefreshTokenByHash(c, auth.HashOpaqueToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, errors.Wrap(apperr.ErrInvalidToken, "refresh token not found")
+		}
+		return nil, errors.Wrap(err, "usecase RefreshTokens")
+	}
+
+	if stored.RevokedAt != nil {
+		return nil, a.revokeReusedSession(c, stored)
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "refresh token expired")
+	}
+
+	session, err := a.sessionRepo.GetSession(c, stored.SessionID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase RefreshTokens")
+	}
+	if !session.Active() {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "session revoked")
+	}
+
+	revoked, err := a.tokenRepo.RevokeRefreshToken(c, stored.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase RefreshTokens")
+	}
+	if !revoked {
+		return nil, a.revokeReusedSession(c, stored)
+	}
+
+	if err := a.sessionRepo.TouchSession(c, session.ID); err != nil {
+		return nil, errors.Wrap(err, "usecase RefreshTokens")
+	}
+
+	if err := enterUserTenant(c, a.userRepo, stored.UserID); err != nil {
+		return nil, errors.Wrap(err, "usecase RefreshTokens")
+	}
+	user, err := a.userRepo.GetUser(c, stored.UserID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase RefreshTokens")
+	}
+
+	pair, err := a.issuePair(c, user, session.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase RefreshTokens")
+	}
+	return pair, nil
+}
+
+// AuthenticateAccessToken проверяет подпись токена и то, что его сессия не отозвана.
+func (a *AuthCase) AuthenticateAccessToken(ctx context.Context, token string) (*auth.Claims, error) {
+	claims, err := a.issuer.Parse(token)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase AuthenticateAccessToken")
+	}
+	if claims.Purpose != "" {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "not an access token")
+	}
+	if _, err := claims.UserID(); err != nil {
+		return nil, errors.Wrap(err, "usecase AuthenticateAccessToken")
+	}
+
+	sessionID, err := claims.SessionID()
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase AuthenticateAccessToken")
+	}
+
+	session, err := a.sessionRepo.GetSession(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, errors.Wrap(apperr.ErrInvalidToken, "session not found")
+		}
+		return nil, errors.Wrap(err, "usecase AuthenticateAccessToken")
+	}
+	if !session.Active() || session.UserID.String() != claims.Subject {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "session revoked")
+	}
+
+	if time.Since(session.LastSeenAt) > sessionTouchInterval {
+		if err := a.sessionRepo.TouchSession(ctx, session.ID); err != nil {
+			logger.Error("failed to touch session",
+				zap.String("sessionID", session.ID.String()),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return claims, nil
+}
+
+func (a *AuthCase) revokeReusedSession(c *gin.Context, stored *model.RefreshToken) error {
+	// Сессия отзывается в организации владельца токена, а не той, что указана в запросе.
+	// Удалённого пользователя искать не нужно: его сессии отозваны при удалении
+	if err := enterUserTenant(c, a.userRepo, stored.UserID); err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return errors.Wrap(apperr.ErrInvalidToken, "refresh token reused")
+		}
+		return errors.Wrap(err, "usecase RefreshTokens")
+	}
+	if err := a.sessionRepo.RevokeSession(c, stored.UserID, stored.SessionID); err != nil &&
+		!errors.Is(err, apperr.ErrNotFound) {
+		return errors.Wrap(err, "usecase RefreshTokens")
+	}
+	return errors.Wrap(apperr.ErrInvalidToken, "refresh token reused")
+}
+
+func (a *AuthCase) issuePair(c *gin.Context, user *model.User, sessionID uuid.UUID) (*model.TokenPair, error) {
+	claims := auth.NewClaims(user.ID)
+	claims.Roles = user.Roles
+	claims.SID = sessionID.String()
+	claims.Org = user.OrganizationID.String()
+
+	accessToken, err := a.issuer.Issue(claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "issue access token")
+	}
+
+	refreshToken, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "issue refresh token")
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, errors.Wrap(err, "issue refresh token")
+	}
+
+	err = a.tokenRepo.AddRefreshToken(c, model.RefreshToken{
+		ID:        id,
+		UserID:    user.ID,
+		SessionID: sessionID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(a.refreshTTL),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "store refresh token")
+	}
+
+	return &model.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    tokenTypeBearer,
+		ExpiresIn:    int(a.issuer.TTL().Seconds()),
+	}, nil
+}
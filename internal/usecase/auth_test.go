@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthCase_RefreshTokensReused(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	tokenRepo := mocks.NewMockRefreshTokenProvider(ctrl)
+	sessionRepo := mocks.NewMockSessionProvider(ctrl)
+	uc := NewAuthProvider(userRepo, tokenRepo, sessionRepo, nil, time.Hour)
+
+	revokedAt := time.Now().Add(-time.Minute)
+	stored := &model.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		SessionID: uuid.New(),
+		ExpiresAt: time.Now().Add(time.Hour),
+		RevokedAt: &revokedAt,
+	}
+	organizationID := uuid.New()
+
+	// Кейс 1: сессия отзывается в организации владельца токена, а не из заголовка запроса
+	tokenRepo.EXPECT().GetRefreshTokenByHash(gomock.Any(), gomock.Any()).Return(stored, nil)
+	userRepo.EXPECT().GetUserOrganizationID(gomock.Any(), stored.UserID).Return(organizationID, nil)
+	sessionRepo.EXPECT().RevokeSession(gomock.Any(), stored.UserID, stored.SessionID).
+		DoAndReturn(func(ctx context.Context, _ uuid.UUID, _ uuid.UUID) error {
+			id, ok := tenant.FromContext(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, organizationID, id)
+			return nil
+		})
+	_, err := uc.RefreshTokens(newTestContext(), "reused")
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+
+	// Кейс 2: владелец удалён - отзывать нечего, токен просто отклоняется
+	tokenRepo.EXPECT().GetRefreshTokenByHash(gomock.Any(), gomock.Any()).Return(stored, nil)
+	userRepo.EXPECT().GetUserOrganizationID(gomock.Any(), stored.UserID).
+		Return(uuid.Nil, apperr.ErrNotFound)
+	_, err = uc.RefreshTokens(newTestContext(), "reused")
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+}
@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/blobstore"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+	"github.com/lemavisaitov/lk-api/internal/thumbnail"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Размеры квадратных миниатюр аватара в пикселях
+var avatarSizes = []int{64, 128, 256}
+
+type AvatarProvider interface {
+	SetAvatar(c *gin.Context, userID uuid.UUID, data []byte) (map[string]string, error)
+	DeleteAvatar(c *gin.Context, userID uuid.UUID) error
+	AvatarURLs(user *model.User) map[string]string
+	MaxAvatarSize() int64
+}
+
+// AvatarCleaner удаляет файлы аватара стёртого пользователя.
+type AvatarCleaner interface {
+	RemoveAvatarFiles(ctx context.Context, userID uuid.UUID, avatarID uuid.UUID)
+}
+
+// AvatarCase хранит миниатюры под ключами с идентификатором аватара: новый аватар
+// получает новые адреса, и закэшированные клиентами файлы не устаревают.
+type AvatarCase struct {
+	userRepo  repository.UserProvider
+	blobs     blobstore.BlobStore
+	audit     AuditRecorder
+	maxSize   int64
+	maxPixels int
+}
+
+func NewAvatarProvider(userRepo repository.UserProvider,
+	blobs blobstore.BlobStore,
+	audit AuditRecorder,
+	maxSize int64,
+	maxPixels int) *AvatarCase {
+	return &AvatarCase{
+		userRepo:  userRepo,
+		blobs:     blobs,
+		audit:     audit,
+		maxSize:   maxSize,
+		maxPixels: maxPixels,
+	}
+}
+
+// SetAvatar сохраняет миниатюры, затем переключает на них пользователя и удаляет прежние.
+// Ошибки удаления файлов только логируются: пользователь уже видит новый аватар.
+func (a *AvatarCase) SetAvatar(c *gin.Context, userID uuid.UUID, data []byte) (map[string]string, error) {
+	if int64(len(data)) > a.maxSize {
+		return nil, errors.Wrapf(apperr.ErrTooLarge, "usecase SetAvatar %d bytes", len(data))
+	}
+	thumbnails, err := thumbnail.Generate(data, avatarSizes, a.maxPixels)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase SetAvatar")
+	}
+
+	avatarID, err := uuid.NewV7()
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase SetAvatar generate id")
+	}
+	for _, size := range avatarSizes {
+		if err := a.blobs.Put(c, avatarKey(userID, avatarID, size), "image/jpeg", thumbnails[size]); err != nil {
+			a.RemoveAvatarFiles(c, userID, avatarID)
+			return nil, errors.Wrap(err, "usecase SetAvatar store")
+		}
+	}
+
+	previous, err := a.userRepo.SetUserAvatar(c, userID, &avatarID)
+	if err != nil {
+		a.RemoveAvatarFiles(c, userID, avatarID)
+		return nil, errors.Wrap(err, "usecase SetAvatar")
+	}
+	if previous != nil {
+		a.RemoveAvatarFiles(c, userID, *previous)
+	}
+
+	a.audit.Record(c, model.AuditEvent{
+		Action:   model.AuditUserUpdate,
+		TargetID: &userID,
+		Changes:  map[string]model.AuditChange{"avatar": {Old: avatarAuditValue(previous), New: avatarID.String()}},
+	})
+	return a.urls(userID, avatarID), nil
+}
+
+// DeleteAvatar убирает аватар; если его не было, ничего не делает.
+func (a *AvatarCase) DeleteAvatar(c *gin.Context, userID uuid.UUID) error {
+	previous, err := a.userRepo.SetUserAvatar(c, userID, nil)
+	if err != nil {
+		return errors.Wrap(err, "usecase DeleteAvatar")
+	}
+	if previous == nil {
+		return nil
+	}
+	a.RemoveAvatarFiles(c, userID, *previous)
+
+	a.audit.Record(c, model.AuditEvent{
+		Action:   model.AuditUserUpdate,
+		TargetID: &userID,
+		Changes:  map[string]model.AuditChange{"avatar": {Old: previous.String()}},
+	})
+	return nil
+}
+
+// AvatarURLs возвращает адреса миниатюр по размерам или nil, если аватара нет.
+func (a *AvatarCase) AvatarURLs(user *model.User) map[string]string {
+	if user.AvatarID == nil {
+		return nil
+	}
+	return a.urls(user.ID, *user.AvatarID)
+}
+
+// MaxAvatarSize - наибольший размер загружаемого файла в байтах.
+func (a *AvatarCase) MaxAvatarSize() int64 {
+	return a.maxSize
+}
+
+func (a *AvatarCase) RemoveAvatarFiles(ctx context.Context, userID uuid.UUID, avatarID uuid.UUID) {
+	for _, size := range avatarSizes {
+		key := avatarKey(userID, avatarID, size)
+		if err := a.blobs.Delete(ctx, key); err != nil {
+			logger.Error("failed to delete avatar file",
+				zap.String("key", key),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+func (a *AvatarCase) urls(userID uuid.UUID, avatarID uuid.UUID) map[string]string {
+	urls := make(map[string]string, len(avatarSizes))
+	for _, size := range avatarSizes {
+		urls[strconv.Itoa(size)] = a.blobs.URL(avatarKey(userID, avatarID, size))
+	}
+	return urls
+}
+
+func avatarKey(userID uuid.UUID, avatarID uuid.UUID, size int) string {
+	return userID.String() + "/" + avatarID.String() + "/" + strconv.Itoa(size) + ".jpg"
+}
+
+func avatarAuditValue(avatarID *uuid.UUID) any {
+	if avatarID == nil {
+		return nil
+	}
+	return avatarID.String()
+}
@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/blobstore"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+	"github.com/lemavisaitov/lk-api/internal/thumbnail"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countFiles считает файлы в каталоге хранилища вместе с подкаталогами.
+func countFiles(t *testing.T, dir string) int {
+	count := 0
+	err := filepath.WalkDir(dir, func(_ string, entry os.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			count++
+		}
+		return err
+	})
+	require.NoError(t, err)
+	return count
+}
+
+func TestAvatarCase_SetAvatar(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	dir := t.TempDir()
+	recorder := &fakeAuditRecorder{}
+	uc := NewAvatarProvider(userRepo, blobstore.NewLocal(dir, "http://localhost/avatars"), recorder, 1<<20, 1_000_000)
+	c := newTestContext()
+	userID := uuid.New()
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 40, 30))))
+	picture := buf.Bytes()
+
+	// Кейс 1: первая загрузка сохраняет все размеры
+	var first uuid.UUID
+	userRepo.EXPECT().SetUserAvatar(gomock.Any(), userID, gomock.Any()).
+		DoAndReturn(func(_ any, _ uuid.UUID, avatarID *uuid.UUID) (*uuid.UUID, error) {
+			first = *avatarID
+			return nil, nil
+		})
+	urls, err := uc.SetAvatar(c, userID, picture)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost/avatars/"+userID.String()+"/"+first.String()+"/64.jpg", urls["64"])
+	assert.Len(t, urls, len(avatarSizes))
+	assert.Equal(t, len(avatarSizes), countFiles(t, dir))
+	assert.Equal(t, model.AuditChange{New: first.String()}, recorder.last(t).Changes["avatar"])
+	assert.Equal(t, urls, uc.AvatarURLs(&model.User{ID: userID, AvatarID: &first}))
+
+	// Кейс 2: после замены файлы прежнего аватара удаляются
+	userRepo.EXPECT().SetUserAvatar(gomock.Any(), userID, gomock.Any()).Return(&first, nil)
+	_, err = uc.SetAvatar(c, userID, picture)
+	require.NoError(t, err)
+	assert.Equal(t, len(avatarSizes), countFiles(t, dir))
+	_, err = os.Stat(filepath.Join(dir, userID.String(), first.String()))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// Кейс 3: не изображение - до хранилища и БД дело не доходит
+	_, err = uc.SetAvatar(c, userID, []byte("GIF89a"))
+	require.ErrorIs(t, err, thumbnail.ErrInvalidImage)
+	_, err = uc.SetAvatar(c, userID, []byte("plain text"))
+	require.ErrorIs(t, err, thumbnail.ErrUnsupportedFormat)
+
+	_, err = uc.SetAvatar(c, userID, make([]byte, uc.MaxAvatarSize()+1))
+	require.ErrorIs(t, err, apperr.ErrTooLarge)
+
+	// Кейс 4: пользователя нет - загруженные файлы убираются
+	missing := uuid.New()
+	userRepo.EXPECT().SetUserAvatar(gomock.Any(), missing, gomock.Any()).Return(nil, apperr.ErrNotFound)
+	_, err = uc.SetAvatar(c, missing, picture)
+	require.ErrorIs(t, err, apperr.ErrNotFound)
+	assert.Equal(t, len(avatarSizes), countFiles(t, dir))
+}
+
+func TestAvatarCase_DeleteAvatar(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	dir := t.TempDir()
+	blobs := blobstore.NewLocal(dir, "http://localhost/avatars")
+	recorder := &fakeAuditRecorder{}
+	uc := NewAvatarProvider(userRepo, blobs, recorder, 1<<20, 1_000_000)
+	c := newTestContext()
+	userID, avatarID := uuid.New(), uuid.New()
+
+	for _, size := range avatarSizes {
+		require.NoError(t, blobs.Put(c, avatarKey(userID, avatarID, size), "image/jpeg", []byte("jpeg")))
+	}
+
+	// Кейс 1: файлы удаляются вместе со ссылкой
+	userRepo.EXPECT().SetUserAvatar(gomock.Any(), userID, nil).Return(&avatarID, nil)
+	require.NoError(t, uc.DeleteAvatar(c, userID))
+	assert.Zero(t, countFiles(t, dir))
+	assert.Equal(t, model.AuditChange{Old: avatarID.String()}, recorder.last(t).Changes["avatar"])
+
+	// Кейс 2: аватара уже нет
+	userRepo.EXPECT().SetUserAvatar(gomock.Any(), userID, nil).Return(nil, nil)
+	require.NoError(t, uc.DeleteAvatar(c, userID))
+	assert.Len(t, recorder.events, 1)
+	assert.Nil(t, uc.AvatarURLs(&model.User{ID: userID}))
+}
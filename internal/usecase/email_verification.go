@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/notifier"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+type EmailVerificationProvider interface {
+	SendVerification(*gin.Context, uuid.UUID) error
+	ConfirmEmail(*gin.Context, string) error
+}
+
+type EmailVerificationCase struct {
+	userRepo   repository.UserProvider
+	tokenRepo  repository.OneTimeTokenProvider
+	notifier   notifier.Notifier
+	ttl        time.Duration
+	confirmURL string
+}
+
+func NewEmailVerificationProvider(userRepo repository.UserProvider,
+	tokenRepo repository.OneTimeTokenProvider,
+	notify notifier.Notifier,
+	ttl time.Duration,
+	confirmURL string) *EmailVerificationCase {
+	return &EmailVerificationCase{
+		userRepo:   userRepo,
+		tokenRepo:  tokenRepo,
+		notifier:   notify,
+		ttl:        ttl,
+		confirmURL: confirmURL,
+	}
+}
+
+// SendVerification отправляет на текущую почту пользователя ссылку для подтверждения.
+// Предыдущие ссылки перестают действовать.
+func (e *EmailVerificationCase) SendVerification(c *gin.Context, userID uuid.UUID) error {
+	user, err := e.userRepo.GetUser(c, userID)
+	if err != nil {
+		return errors.Wrap(err, "usecase SendVerification")
+	}
+	if user.Email == "" {
+		return errors.Wrap(apperr.ErrEmailNotSet, "usecase SendVerification")
+	}
+	if user.EmailVerified() {
+		return errors.Wrap(apperr.ErrEmailVerified, "usecase SendVerification")
+	}
+
+	token, err := issueOneTimeToken(c, e.tokenRepo, user.ID, model.TokenPurposeEmailVerification, e.ttl)
+	if err != nil {
+		return errors.Wrap(err, "usecase SendVerification")
+	}
+
+	sendInBackground(e.notifier, e.verificationMessage(user, token), user.ID)
+	return nil
+}
+
+// ConfirmEmail подтверждает почту по токену из письма.
+func (e *EmailVerificationCase) ConfirmEmail(c *gin.Context, token string) error {
+	stored, err := e.tokenRepo.ConsumeToken(c, model.TokenPurposeEmailVerification, auth.HashOpaqueToken(token))
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return errors.Wrap(apperr.ErrInvalidToken, "usecase ConfirmEmail")
+		}
+		return errors.Wrap(err, "usecase ConfirmEmail")
+	}
+
+	if err := enterUserTenant(c, e.userRepo, stored.UserID); err != nil {
+		return errors.Wrap(err, "usecase ConfirmEmail")
+	}
+	if err := e.userRepo.MarkEmailVerified(c, stored.UserID); err != nil {
+		return errors.Wrap(err, "usecase ConfirmEmail")
+	}
+
+	return nil
+}
+
+func (e *EmailVerificationCase) verificationMessage(user *model.User, token string) notifier.Message {
+	link := e.confirmURL + "?token=" + url.QueryEscape(token)
+	return notifier.Message{
+		To:      user.Email,
+		Subject: "Подтверждение почты",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\n"+
+			"Чтобы подтвердить адрес почты, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %s. Если вы не регистрировались, просто проигнорируйте это письмо.",
+			user.Name, link, e.ttl),
+	}
+}
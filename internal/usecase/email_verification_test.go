@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/notifier"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailVerificationCase_SendVerification(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	tokenRepo := mocks.NewMockOneTimeTokenProvider(ctrl)
+	notify := &fakeNotifier{sent: make(chan notifier.Message, 1)}
+
+	uc := NewEmailVerificationProvider(userRepo, tokenRepo, notify, time.Hour, "https://lk.example.com/confirm")
+
+	// Кейс 1: почта не указана
+	noEmail := &model.User{ID: uuid.New(), Login: "jane"}
+	userRepo.EXPECT().GetUser(gomock.Any(), noEmail.ID).Return(noEmail, nil)
+
+	require.ErrorIs(t, uc.SendVerification(newTestContext(), noEmail.ID), apperr.ErrEmailNotSet)
+
+	// Кейс 2: почта уже подтверждена
+	verifiedAt := time.Now()
+	verified := &model.User{ID: uuid.New(), Email: "jane@example.com", EmailVerifiedAt: &verifiedAt}
+	userRepo.EXPECT().GetUser(gomock.Any(), verified.ID).Return(verified, nil)
+
+	require.ErrorIs(t, uc.SendVerification(newTestContext(), verified.ID), apperr.ErrEmailVerified)
+
+	// Кейс 3: письмо уходит на почту, в БД только хэш токена
+	user := &model.User{ID: uuid.New(), Login: "john", Name: "John", Email: "John@Example.com"}
+	var stored model.OneTimeToken
+
+	userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+	tokenRepo.EXPECT().InvalidateUserTokens(gomock.Any(), user.ID, model.TokenPurposeEmailVerification).Return(nil)
+	tokenRepo.EXPECT().
+		AddToken(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, token model.OneTimeToken) {
+			stored = token
+		}).
+		Return(nil)
+
+	require.NoError(t, uc.SendVerification(newTestContext(), user.ID))
+
+	var msg notifier.Message
+	select {
+	case msg = <-notify.sent:
+	case <-time.After(time.Second):
+		t.Fatal("verification message was not sent")
+	}
+	assert.Equal(t, user.Email, msg.To)
+	assert.Contains(t, msg.Body, "https://lk.example.com/confirm?token=")
+	assert.Equal(t, model.TokenPurposeEmailVerification, stored.Purpose)
+	assert.NotContains(t, msg.Body, stored.TokenHash)
+}
+
+func TestEmailVerificationCase_ConfirmEmail(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	tokenRepo := mocks.NewMockOneTimeTokenProvider(ctrl)
+
+	uc := NewEmailVerificationProvider(userRepo, tokenRepo, &fakeNotifier{}, time.Hour, "https://lk.example.com/confirm")
+
+	// Кейс 1: неизвестный, истёкший или использованный токен
+	tokenRepo.EXPECT().
+		ConsumeToken(gomock.Any(), model.TokenPurposeEmailVerification, auth.HashOpaqueToken("bad")).
+		Return(nil, errors.Wrap(apperr.ErrNotFound, "token not found"))
+
+	require.ErrorIs(t, uc.ConfirmEmail(newTestContext(), "bad"), apperr.ErrInvalidToken)
+
+	// Кейс 2: почта подтверждается
+	userID := uuid.New()
+	tokenRepo.EXPECT().
+		ConsumeToken(gomock.Any(), model.TokenPurposeEmailVerification, auth.HashOpaqueToken("good")).
+		Return(&model.OneTimeToken{UserID: userID}, nil)
+	userRepo.EXPECT().GetUserOrganizationID(gomock.Any(), userID).Return(tenant.DefaultID, nil)
+	userRepo.EXPECT().MarkEmailVerified(gomock.Any(), userID).Return(nil)
+
+	require.NoError(t, uc.ConfirmEmail(newTestContext(), "good"))
+}
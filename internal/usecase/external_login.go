@@ -0,0 +1,363 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/oauthclient"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+	"github.com/lemavisaitov/lk-api/internal/sealer"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// Связанные данные при шифровании state, чтобы его нельзя было подменить другим зашифрованным значением
+	externalStateAD = "external-login"
+	// Сколько раз подбирать свободный логин или пароль под политику при регистрации
+	externalSignupAttempts = 5
+)
+
+type ExternalLoginProvider interface {
+	StartLogin(*gin.Context, string, *uuid.UUID) (string, string, error)
+	CompleteLogin(*gin.Context, string, string, string, string) (*model.ExternalLoginResult, error)
+	ListIdentities(*gin.Context, uuid.UUID) ([]model.Identity, error)
+	UnlinkIdentity(*gin.Context, uuid.UUID, string) error
+	Providers() []string
+}
+
+// externalState хранится в cookie браузера в зашифрованном виде между уходом к провайдеру и возвратом.
+type externalState struct {
+	State      string     `json:"state"`
+	Verifier   string     `json:"verifier"`
+	Provider   string     `json:"provider"`
+	LinkUserID *uuid.UUID `json:"link_user_id,omitempty"`
+	// Организация, в которой регистрируется или привязывает аккаунт пользователь
+	OrganizationID uuid.UUID `json:"organization_id"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+type ExternalLoginCase struct {
+	identityRepo repository.IdentityProvider
+	userRepo     repository.UserProvider
+	userUC       UserProvider
+	providers    map[string]*oauthclient.Client
+	sealer       *sealer.Sealer
+	stateTTL     time.Duration
+	now          func() time.Time
+}
+
+func NewExternalLoginProvider(identityRepo repository.IdentityProvider,
+	userRepo repository.UserProvider,
+	userUC UserProvider,
+	providers []*oauthclient.Client,
+	stateSealer *sealer.Sealer,
+	stateTTL time.Duration) *ExternalLoginCase {
+	byName := make(map[string]*oauthclient.Client, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+
+	return &ExternalLoginCase{
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		userUC:       userUC,
+		providers:    byName,
+		sealer:       stateSealer,
+		stateTTL:     stateTTL,
+		now:          time.Now,
+	}
+}
+
+func (e *ExternalLoginCase) Providers() []string {
+	names := make([]string, 0, len(e.providers))
+	for name := range e.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StartLogin возвращает адрес провайдера и зашифрованный state для cookie.
+// Если linkUserID задан, после возврата аккаунт привяжется к этому пользователю.
+func (e *ExternalLoginCase) StartLogin(c *gin.Context, provider string, linkUserID *uuid.UUID) (string, string, error) {
+	client, ok := e.providers[provider]
+	if !ok {
+		return "", "", errors.Wrapf(apperr.ErrNotFound, "provider %q", provider)
+	}
+
+	organizationID, ok := tenant.FromContext(c.Request.Context())
+	if !ok {
+		return "", "", errors.Wrap(apperr.ErrNoTenant, "usecase StartLogin")
+	}
+
+	state := externalState{
+		State:          randomToken(),
+		Verifier:       randomToken(),
+		Provider:       provider,
+		LinkUserID:     linkUserID,
+		OrganizationID: organizationID,
+		ExpiresAt:      e.now().Add(e.stateTTL),
+	}
+	plain, err := json.Marshal(state)
+	if err != nil {
+		return "", "", errors.Wrap(err, "usecase StartLogin")
+	}
+	sealed, err := e.sealer.Seal(plain, []byte(externalStateAD))
+	if err != nil {
+		return "", "", errors.Wrap(err, "usecase StartLogin")
+	}
+
+	return client.AuthCodeURL(state.State, state.Verifier), sealed, nil
+}
+
+// CompleteLogin обрабатывает возврат от провайдера: входит привязанным
+// пользователем, регистрирует нового или привязывает аккаунт к текущему.
+func (e *ExternalLoginCase) CompleteLogin(c *gin.Context, provider string, code string, stateParam string,
+	sealedState string) (*model.ExternalLoginResult, error) {
+	state, err := e.openState(sealedState)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase CompleteLogin")
+	}
+	if state.Provider != provider || subtle.ConstantTimeCompare([]byte(state.State), []byte(stateParam)) != 1 {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "state mismatch")
+	}
+
+	client, ok := e.providers[provider]
+	if !ok {
+		return nil, errors.Wrapf(apperr.ErrNotFound, "provider %q", provider)
+	}
+	accessToken, err := client.Exchange(c, code, state.Verifier)
+	if err != nil {
+		return nil, errors.Wrap(apperr.ErrExternalAuth, err.Error())
+	}
+	profile, err := client.Profile(c, accessToken)
+	if err != nil {
+		return nil, errors.Wrap(apperr.ErrExternalAuth, err.Error())
+	}
+
+	// Возврат от провайдера приходит без заголовка организации
+	if state.OrganizationID == uuid.Nil {
+		state.OrganizationID = tenant.DefaultID
+	}
+	enterTenant(c, state.OrganizationID)
+	if state.LinkUserID != nil {
+		return e.link(c, *state.LinkUserID, provider, profile)
+	}
+
+	identity, err := e.identityRepo.GetIdentity(c, provider, profile.Subject)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return e.signup(c, provider, profile)
+		}
+		return nil, errors.Wrap(err, "usecase CompleteLogin")
+	}
+
+	// Привязанный аккаунт входит в свою организацию, где бы ни начался вход
+	if err := enterUserTenant(c, e.userRepo, identity.UserID); err != nil {
+		return nil, errors.Wrap(err, "usecase CompleteLogin")
+	}
+	user, err := e.userRepo.GetUser(c, identity.UserID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase CompleteLogin")
+	}
+	if err := e.identityRepo.TouchIdentity(c, identity.ID); err != nil {
+		logger.Error("failed to touch identity",
+			zap.String("identityID", identity.ID.String()),
+			zap.Error(err),
+		)
+	}
+
+	return &model.ExternalLoginResult{User: user}, nil
+}
+
+func (e *ExternalLoginCase) ListIdentities(c *gin.Context, userID uuid.UUID) ([]model.Identity, error) {
+	identities, err := e.identityRepo.ListUserIdentities(c, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase ListIdentities")
+	}
+	return identities, nil
+}
+
+func (e *ExternalLoginCase) UnlinkIdentity(c *gin.Context, userID uuid.UUID, provider string) error {
+	if err := e.identityRepo.DeleteIdentity(c, userID, provider); err != nil {
+		return errors.Wrap(err, "usecase UnlinkIdentity")
+	}
+	return nil
+}
+
+func (e *ExternalLoginCase) openState(sealed string) (*externalState, error) {
+	if sealed == "" {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "state cookie is missing")
+	}
+	plain, err := e.sealer.Open(sealed, []byte(externalStateAD))
+	if err != nil {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "state cookie is corrupted")
+	}
+
+	var state externalState
+	if err := json.Unmarshal(plain, &state); err != nil {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "state cookie is corrupted")
+	}
+	if e.now().After(state.ExpiresAt) {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "state expired")
+	}
+	return &state, nil
+}
+
+func (e *ExternalLoginCase) link(c *gin.Context, userID uuid.UUID, provider string,
+	profile *oauthclient.Profile) (*model.ExternalLoginResult, error) {
+	user, err := e.userRepo.GetUser(c, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase link identity")
+	}
+	if err := e.addIdentity(c, user.ID, provider, profile); err != nil {
+		return nil, errors.Wrap(err, "usecase link identity")
+	}
+
+	return &model.ExternalLoginResult{User: user, Linked: true}, nil
+}
+
+// signup регистрирует пользователя по профилю провайдера. Почту, уже занятую
+// другим пользователем, автоматически не связываем: иначе аккаунт можно
+// захватить через провайдера, не проверяющего почту. Такой пользователь
+// должен войти паролем и привязать провайдера сам.
+func (e *ExternalLoginCase) signup(c *gin.Context, provider string,
+	profile *oauthclient.Profile) (*model.ExternalLoginResult, error) {
+	if err := openSignup(c); err != nil {
+		return nil, errors.Wrap(err, "usecase external signup")
+	}
+	if profile.Email == "" {
+		return nil, errors.Wrap(apperr.ErrEmailNotSet, "provider did not return an email")
+	}
+	exists, err := e.userUC.EmailExists(c, profile.Email)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase external signup")
+	}
+	if exists {
+		return nil, errors.Wrap(apperr.ErrAlreadyExists, "email is registered, log in and link the provider")
+	}
+
+	login, err := e.freeLogin(c, profile)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase external signup")
+	}
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase external signup")
+	}
+	user := model.User{
+		ID:    id,
+		Login: login,
+		Name:  profile.Name,
+		Email: profile.Email,
+	}
+	if user.Name == "" {
+		user.Name = login
+	}
+
+	// Пароль пользователю неизвестен, задать свой он может через сброс пароля.
+	// Случайный пароль изредка может не пройти политику (например, содержать логин)
+	for attempt := 1; ; attempt++ {
+		user.Password = randomPassword()
+		_, err = e.userUC.AddExternalUser(c, user)
+		if err == nil || !errors.Is(err, apperr.ErrValidation) || attempt == externalSignupAttempts {
+			break
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase external signup")
+	}
+
+	if err := e.addIdentity(c, user.ID, provider, profile); err != nil {
+		if deleteErr := e.userUC.DeleteUser(c, user.ID, 0); deleteErr != nil {
+			logger.Error("failed to remove user after identity link failure",
+				zap.String("userID", user.ID.String()),
+				zap.Error(deleteErr),
+			)
+		}
+		return nil, errors.Wrap(err, "usecase external signup")
+	}
+
+	if profile.EmailVerified {
+		if err := e.userRepo.MarkEmailVerified(c, user.ID); err != nil {
+			logger.Error("failed to mark email verified",
+				zap.String("userID", user.ID.String()),
+				zap.Error(err),
+			)
+		} else {
+			verifiedAt := e.now()
+			user.EmailVerifiedAt = &verifiedAt
+		}
+	}
+
+	user.Password = ""
+	return &model.ExternalLoginResult{User: &user, Created: true}, nil
+}
+
+func (e *ExternalLoginCase) addIdentity(c *gin.Context, userID uuid.UUID, provider string,
+	profile *oauthclient.Profile) error {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return err
+	}
+
+	return e.identityRepo.AddIdentity(c, model.Identity{
+		ID:       id,
+		UserID:   userID,
+		Provider: provider,
+		Subject:  profile.Subject,
+		Email:    profile.Email,
+	})
+}
+
+// freeLogin берёт логин у провайдера или из почты и добавляет суффикс, если он занят.
+func (e *ExternalLoginCase) freeLogin(c *gin.Context, profile *oauthclient.Profile) (string, error) {
+	base := strings.TrimSpace(profile.Login)
+	if base == "" {
+		base, _, _ = strings.Cut(profile.Email, "@")
+	}
+
+	login := base
+	for attempt := 0; attempt < externalSignupAttempts; attempt++ {
+		exists, err := e.userUC.LoginExists(c, login)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return login, nil
+		}
+
+		suffix := make([]byte, 3)
+		if _, err := rand.Read(suffix); err != nil {
+			return "", err
+		}
+		login = base + "-" + hex.EncodeToString(suffix)
+	}
+
+	return "", errors.Wrap(apperr.ErrAlreadyExists, "no free login")
+}
+
+func randomToken() string {
+	buf := make([]byte, 32)
+	_, _ = rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// randomPassword проходит любую разумную политику: длинный, со всеми классами символов.
+func randomPassword() string {
+	return randomToken() + "Aa1!"
+}
@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/oauthclient"
+	"github.com/lemavisaitov/lk-api/internal/sealer"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+	"github.com/lemavisaitov/lk-api/internal/testutils/fakeidp"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCallbackURL = "https://lk.example.com/auth/external/fake/callback"
+
+func newTestExternalCase(t *testing.T, identityRepo *mocks.MockIdentityProvider,
+	userRepo *mocks.MockUserProvider) (*ExternalLoginCase, *fakeidp.Server) {
+	idp := fakeidp.New()
+	t.Cleanup(idp.Close)
+
+	s, err := sealer.New(bytes.Repeat([]byte{7}, sealer.KeySize))
+	require.NoError(t, err)
+
+	userUC := NewUserProvider(userRepo, nil, nil, newTestHasher(t), newTestPolicy(), nil, &fakeAuditRecorder{},
+		newTestAttributes(t), nil, 0, false)
+	client := oauthclient.New(idp.Config("fake", testCallbackURL), idp.Client())
+	uc := NewExternalLoginProvider(identityRepo, userRepo, userUC, []*oauthclient.Client{client}, s, 10*time.Minute)
+
+	return uc, idp
+}
+
+// authorize проходит страницу провайдера и возвращает code и state из редиректа обратно.
+func authorize(t *testing.T, authURL string) (string, string) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestExternalLoginCase_CompleteLogin(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	identityRepo := mocks.NewMockIdentityProvider(ctrl)
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	uc, idp := newTestExternalCase(t, identityRepo, userRepo)
+	c := newTestContext()
+
+	idp.SetUser(map[string]any{
+		"sub":                "42",
+		"email":              "jane@example.com",
+		"email_verified":     true,
+		"name":               "Jane Doe",
+		"preferred_username": "jane",
+	})
+
+	// Кейс 1: неизвестный провайдер
+	_, _, err := uc.StartLogin(c, "unknown", nil)
+	require.ErrorIs(t, err, apperr.ErrNotFound)
+
+	// Кейс 2: первый вход регистрирует пользователя и привязывает аккаунт
+	authURL, state, err := uc.StartLogin(c, "fake", nil)
+	require.NoError(t, err)
+	code, stateParam := authorize(t, authURL)
+
+	var created model.User
+	identityRepo.EXPECT().GetIdentity(gomock.Any(), "fake", "42").Return(nil, apperr.ErrNotFound)
+	userRepo.EXPECT().GetUserIDByEmail(gomock.Any(), "jane@example.com").Return(nil, apperr.ErrNotFound)
+	userRepo.EXPECT().LoginExists(gomock.Any(), "jane").Return(false, nil)
+	userRepo.EXPECT().AddUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, user model.User) error {
+		created = user
+		return nil
+	})
+	identityRepo.EXPECT().AddIdentity(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, identity model.Identity) error {
+		assert.Equal(t, created.ID, identity.UserID)
+		assert.Equal(t, "42", identity.Subject)
+		return nil
+	})
+	userRepo.EXPECT().MarkEmailVerified(gomock.Any(), gomock.Any()).Return(nil)
+
+	result, err := uc.CompleteLogin(c, "fake", code, stateParam, state)
+	require.NoError(t, err)
+	assert.True(t, result.Created)
+	assert.Equal(t, "jane", result.User.Login)
+	assert.Equal(t, "Jane Doe", result.User.Name)
+	assert.True(t, result.User.EmailVerified())
+	assert.Empty(t, result.User.Password)
+	assert.NotEmpty(t, created.Password, "пароль сохраняется только хешем")
+
+	// Кейс 3: повторный вход находит привязанного пользователя
+	authURL, state, err = uc.StartLogin(c, "fake", nil)
+	require.NoError(t, err)
+	code, stateParam = authorize(t, authURL)
+
+	identity := &model.Identity{ID: uuid.New(), UserID: created.ID, Provider: "fake", Subject: "42"}
+	identityRepo.EXPECT().GetIdentity(gomock.Any(), "fake", "42").Return(identity, nil)
+	userRepo.EXPECT().GetUserOrganizationID(gomock.Any(), created.ID).Return(tenant.DefaultID, nil)
+	userRepo.EXPECT().GetUser(gomock.Any(), created.ID).Return(&model.User{ID: created.ID, Login: "jane"}, nil)
+	identityRepo.EXPECT().TouchIdentity(gomock.Any(), identity.ID).Return(nil)
+
+	result, err = uc.CompleteLogin(c, "fake", code, stateParam, state)
+	require.NoError(t, err)
+	assert.False(t, result.Created)
+	assert.Equal(t, created.ID, result.User.ID)
+
+	// Кейс 4: state из запроса не совпадает с cookie
+	authURL, state, err = uc.StartLogin(c, "fake", nil)
+	require.NoError(t, err)
+	code, _ = authorize(t, authURL)
+	_, err = uc.CompleteLogin(c, "fake", code, "forged", state)
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+
+	// Кейс 5: без cookie вход невозможен
+	_, err = uc.CompleteLogin(c, "fake", code, stateParam, "")
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+}
+
+func TestExternalLoginCase_EmailTaken(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	identityRepo := mocks.NewMockIdentityProvider(ctrl)
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	uc, idp := newTestExternalCase(t, identityRepo, userRepo)
+	c := newTestContext()
+
+	idp.SetUser(map[string]any{"sub": "7", "email": "john@example.com", "email_verified": true})
+
+	// Кейс 1: почта занята - аккаунт не привязывается к чужому пользователю автоматически
+	authURL, state, err := uc.StartLogin(c, "fake", nil)
+	require.NoError(t, err)
+	code, stateParam := authorize(t, authURL)
+
+	existing := uuid.New()
+	identityRepo.EXPECT().GetIdentity(gomock.Any(), "fake", "7").Return(nil, apperr.ErrNotFound)
+	userRepo.EXPECT().GetUserIDByEmail(gomock.Any(), "john@example.com").Return(&existing, nil)
+
+	_, err = uc.CompleteLogin(c, "fake", code, stateParam, state)
+	require.ErrorIs(t, err, apperr.ErrAlreadyExists)
+
+	// Кейс 2: вошедший пользователь привязывает аккаунт сам
+	authURL, state, err = uc.StartLogin(c, "fake", &existing)
+	require.NoError(t, err)
+	code, stateParam = authorize(t, authURL)
+
+	userRepo.EXPECT().GetUser(gomock.Any(), existing).Return(&model.User{ID: existing, Login: "john"}, nil)
+	identityRepo.EXPECT().AddIdentity(gomock.Any(), gomock.Any()).Return(nil)
+
+	result, err := uc.CompleteLogin(c, "fake", code, stateParam, state)
+	require.NoError(t, err)
+	assert.True(t, result.Linked)
+	assert.Equal(t, existing, result.User.ID)
+}
+
+func TestExternalLoginCase_InvitationOnlyOrganization(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	identityRepo := mocks.NewMockIdentityProvider(ctrl)
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	uc, idp := newTestExternalCase(t, identityRepo, userRepo)
+	c := newTestContext()
+	enterTenant(c, uuid.New())
+
+	idp.SetUser(map[string]any{"sub": "9", "email": "eve@example.com", "email_verified": true})
+
+	// Кейс 1: в организацию, кроме организации по умолчанию, через провайдера не зарегистрироваться
+	authURL, state, err := uc.StartLogin(c, "fake", nil)
+	require.NoError(t, err)
+	code, stateParam := authorize(t, authURL)
+
+	identityRepo.EXPECT().GetIdentity(gomock.Any(), "fake", "9").Return(nil, apperr.ErrNotFound)
+
+	_, err = uc.CompleteLogin(c, "fake", code, stateParam, state)
+	require.ErrorIs(t, err, apperr.ErrForbidden)
+}
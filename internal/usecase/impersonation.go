@@ -0,0 +1,205 @@
+package usecase
+
+import (
+	"slices"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Так сессия имперсонации выглядит в списке сессий пользователя
+const impersonationDevice = "impersonation"
+
+type ImpersonationProvider interface {
+	StartImpersonation(*gin.Context, uuid.UUID, string) (*model.ImpersonationToken, error)
+	EndImpersonation(*gin.Context) error
+	ListImpersonations(*gin.Context, model.ImpersonationFilter) ([]model.Impersonation, error)
+}
+
+type ImpersonationCase struct {
+	impersonationRepo repository.ImpersonationProvider
+	userRepo          repository.UserProvider
+	sessionRepo       repository.SessionProvider
+	rbacRepo          repository.RBACProvider
+	issuer            *auth.Issuer
+	ttl               time.Duration
+	now               func() time.Time
+}
+
+func NewImpersonationProvider(impersonationRepo repository.ImpersonationProvider,
+	userRepo repository.UserProvider,
+	sessionRepo repository.SessionProvider,
+	rbacRepo repository.RBACProvider,
+	issuer *auth.Issuer,
+	ttl time.Duration) *ImpersonationCase {
+	return &ImpersonationCase{
+		impersonationRepo: impersonationRepo,
+		userRepo:          userRepo,
+		sessionRepo:       sessionRepo,
+		rbacRepo:          rbacRepo,
+		issuer:            issuer,
+		ttl:               ttl,
+		now:               time.Now,
+	}
+}
+
+// StartImpersonation выдаёт сотруднику из токена запроса короткоживущий токен
+// от имени subjectID. Запись аудита создаётся до выдачи токена.
+func (i *ImpersonationCase) StartImpersonation(c *gin.Context, subjectID uuid.UUID,
+	reason string) (*model.ImpersonationToken, error) {
+	claims, ok := auth.ClaimsFromContext(c)
+	if !ok {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "claims are missing")
+	}
+	if claims.Impersonated() {
+		return nil, errors.Wrap(apperr.ErrForbidden, "nested impersonation")
+	}
+	actorID, err := claims.UserID()
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase StartImpersonation")
+	}
+	if actorID == subjectID {
+		return nil, errors.Wrap(apperr.ErrForbidden, "cannot impersonate yourself")
+	}
+
+	subject, err := i.userRepo.GetUser(c, subjectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase StartImpersonation")
+	}
+	if err := i.checkEscalation(c, actorID, subjectID); err != nil {
+		return nil, errors.Wrap(err, "usecase StartImpersonation")
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase StartImpersonation")
+	}
+	expiresAt := i.now().Add(i.ttl)
+
+	err = i.impersonationRepo.AddImpersonation(c, model.Impersonation{
+		ID:        id,
+		ActorID:   actorID,
+		SubjectID: subject.ID,
+		Reason:    reason,
+		IP:        c.ClientIP(),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase StartImpersonation")
+	}
+
+	// Отдельная сессия видна пользователю в списке сессий, и её можно отозвать
+	err = i.sessionRepo.AddSession(c, model.Session{
+		ID:        id,
+		UserID:    subject.ID,
+		Device:    impersonationDevice,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	})
+	if err != nil {
+		if _, endErr := i.impersonationRepo.EndImpersonation(c, id); endErr != nil {
+			logger.Error("failed to end impersonation after session failure",
+				zap.String("impersonationID", id.String()),
+				zap.Error(endErr),
+			)
+		}
+		return nil, errors.Wrap(err, "usecase StartImpersonation")
+	}
+
+	tokenClaims := auth.NewClaims(subject.ID)
+	tokenClaims.Roles = subject.Roles
+	tokenClaims.SID = id.String()
+	tokenClaims.Act = &auth.Actor{Subject: actorID.String()}
+	tokenClaims.Org = subject.OrganizationID.String()
+	tokenClaims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+
+	token, err := i.issuer.Issue(tokenClaims)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase StartImpersonation")
+	}
+
+	logger.Info("impersonation started",
+		zap.String("impersonationID", id.String()),
+		zap.String("actor", actorID.String()),
+		zap.String("subject", subject.ID.String()),
+		zap.String("reason", reason),
+	)
+
+	return &model.ImpersonationToken{
+		ImpersonationID: id,
+		AccessToken:     token,
+		TokenType:       tokenTypeBearer,
+		ExpiresIn:       int(i.ttl.Seconds()),
+	}, nil
+}
+
+// EndImpersonation завершает имперсонацию из токена запроса и отзывает её сессию.
+func (i *ImpersonationCase) EndImpersonation(c *gin.Context) error {
+	claims, ok := auth.ClaimsFromContext(c)
+	if !ok || !claims.Impersonated() {
+		return errors.Wrap(apperr.ErrNotImpersonating, "usecase EndImpersonation")
+	}
+	id, err := claims.SessionID()
+	if err != nil {
+		return errors.Wrap(err, "usecase EndImpersonation")
+	}
+	subjectID, err := claims.UserID()
+	if err != nil {
+		return errors.Wrap(err, "usecase EndImpersonation")
+	}
+
+	ended, err := i.impersonationRepo.EndImpersonation(c, id)
+	if err != nil {
+		return errors.Wrap(err, "usecase EndImpersonation")
+	}
+	if err := i.sessionRepo.RevokeSession(c, subjectID, id); err != nil && !errors.Is(err, apperr.ErrNotFound) {
+		return errors.Wrap(err, "usecase EndImpersonation")
+	}
+
+	if ended {
+		logger.Info("impersonation ended",
+			zap.String("impersonationID", id.String()),
+			zap.String("actor", claims.Act.Subject),
+			zap.String("subject", claims.Subject),
+		)
+	}
+	return nil
+}
+
+func (i *ImpersonationCase) ListImpersonations(c *gin.Context,
+	filter model.ImpersonationFilter) ([]model.Impersonation, error) {
+	impersonations, err := i.impersonationRepo.ListImpersonations(c, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase ListImpersonations")
+	}
+	return impersonations, nil
+}
+
+// checkEscalation не даёт получить через имперсонацию права, которых у сотрудника нет.
+func (i *ImpersonationCase) checkEscalation(c *gin.Context, actorID uuid.UUID, subjectID uuid.UUID) error {
+	actorPermissions, err := i.rbacRepo.GetUserPermissions(c, actorID)
+	if err != nil {
+		return err
+	}
+	subjectPermissions, err := i.rbacRepo.GetUserPermissions(c, subjectID)
+	if err != nil {
+		return err
+	}
+
+	for _, permission := range subjectPermissions {
+		if !slices.Contains(actorPermissions, permission) {
+			return errors.Wrapf(apperr.ErrForbidden, "user has permission %q", permission)
+		}
+	}
+	return nil
+}
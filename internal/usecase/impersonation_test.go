@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newClaimsContext возвращает контекст запроса, прошедшего Auth с claims.
+func newClaimsContext(claims *auth.Claims) *gin.Context {
+	c, engine := gin.CreateTestContext(httptest.NewRecorder())
+	engine.ContextWithFallback = true
+	c.Request = httptest.NewRequest("POST", "/", nil)
+	c.Request = c.Request.WithContext(auth.WithClaims(c.Request.Context(), claims))
+	return c
+}
+
+func newTestImpersonationIssuer(t *testing.T) *auth.Issuer {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := auth.NewKey("test", private)
+	require.NoError(t, err)
+	keys, err := auth.NewKeySet("test", key)
+	require.NoError(t, err)
+	return auth.NewIssuer(keys, "lk-api", time.Hour)
+}
+
+func TestImpersonationCase_StartImpersonation(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	impersonationRepo := mocks.NewMockImpersonationProvider(ctrl)
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	sessionRepo := mocks.NewMockSessionProvider(ctrl)
+	rbacRepo := mocks.NewMockRBACProvider(ctrl)
+	issuer := newTestImpersonationIssuer(t)
+	uc := NewImpersonationProvider(impersonationRepo, userRepo, sessionRepo, rbacRepo, issuer, 15*time.Minute)
+
+	actorID := uuid.New()
+	subjectID := uuid.New()
+	c := newClaimsContext(auth.NewClaims(actorID))
+	actorPermissions := []string{model.PermissionUsersRead, model.PermissionUsersImpersonate}
+
+	// Кейс 1: под собой войти нельзя
+	_, err := uc.StartImpersonation(c, actorID, "test")
+	require.ErrorIs(t, err, apperr.ErrForbidden)
+
+	// Кейс 2: пользователь с правами, которых нет у сотрудника
+	userRepo.EXPECT().GetUser(gomock.Any(), subjectID).Return(&model.User{ID: subjectID}, nil)
+	rbacRepo.EXPECT().GetUserPermissions(gomock.Any(), actorID).Return(actorPermissions, nil)
+	rbacRepo.EXPECT().GetUserPermissions(gomock.Any(), subjectID).
+		Return([]string{model.PermissionUsersDelete}, nil)
+	_, err = uc.StartImpersonation(c, subjectID, "test")
+	require.ErrorIs(t, err, apperr.ErrForbidden)
+
+	// Кейс 3: успешный вход, запись аудита появляется раньше сессии
+	var recorded model.Impersonation
+	userRepo.EXPECT().GetUser(gomock.Any(), subjectID).
+		Return(&model.User{ID: subjectID, Roles: []string{"support"}}, nil)
+	rbacRepo.EXPECT().GetUserPermissions(gomock.Any(), actorID).Return(actorPermissions, nil)
+	rbacRepo.EXPECT().GetUserPermissions(gomock.Any(), subjectID).Return([]string{}, nil)
+	gomock.InOrder(
+		impersonationRepo.EXPECT().AddImpersonation(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ any, impersonation model.Impersonation) error {
+				recorded = impersonation
+				return nil
+			}),
+		sessionRepo.EXPECT().AddSession(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ any, session model.Session) error {
+				assert.Equal(t, recorded.ID, session.ID)
+				assert.Equal(t, subjectID, session.UserID)
+				return nil
+			}),
+	)
+
+	token, err := uc.StartImpersonation(c, subjectID, "ticket 42")
+	require.NoError(t, err)
+	assert.Equal(t, recorded.ID, token.ImpersonationID)
+	assert.Equal(t, actorID, recorded.ActorID)
+	assert.Equal(t, "ticket 42", recorded.Reason)
+	assert.Equal(t, 15*60, token.ExpiresIn)
+
+	claims, err := issuer.Parse(token.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, subjectID.String(), claims.Subject)
+	assert.Equal(t, recorded.ID.String(), claims.SID)
+	assert.Equal(t, []string{"support"}, claims.Roles)
+	gotActor, err := claims.ActorID()
+	require.NoError(t, err)
+	assert.Equal(t, actorID, gotActor)
+	// Срок жизни токена - как у имперсонации, а не как у обычного access-токена
+	assert.WithinDuration(t, recorded.ExpiresAt, claims.ExpiresAt.Time, time.Second)
+
+	// Кейс 4: имперсонация изнутри имперсонации
+	nested := auth.NewClaims(subjectID)
+	nested.Act = &auth.Actor{Subject: actorID.String()}
+	_, err = uc.StartImpersonation(newClaimsContext(nested), uuid.New(), "test")
+	require.ErrorIs(t, err, apperr.ErrForbidden)
+}
+
+func TestImpersonationCase_EndImpersonation(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	impersonationRepo := mocks.NewMockImpersonationProvider(ctrl)
+	sessionRepo := mocks.NewMockSessionProvider(ctrl)
+	uc := NewImpersonationProvider(impersonationRepo, mocks.NewMockUserProvider(ctrl), sessionRepo,
+		mocks.NewMockRBACProvider(ctrl), newTestImpersonationIssuer(t), time.Minute)
+
+	subjectID := uuid.New()
+	id := uuid.New()
+
+	// Кейс 1: обычный токен пользователя
+	err := uc.EndImpersonation(newClaimsContext(auth.NewClaims(subjectID)))
+	require.ErrorIs(t, err, apperr.ErrNotImpersonating)
+
+	// Кейс 2: завершение записывается и отзывает сессию
+	claims := auth.NewClaims(subjectID)
+	claims.SID = id.String()
+	claims.Act = &auth.Actor{Subject: uuid.NewString()}
+	impersonationRepo.EXPECT().EndImpersonation(gomock.Any(), id).Return(true, nil)
+	sessionRepo.EXPECT().RevokeSession(gomock.Any(), subjectID, id).Return(nil)
+	require.NoError(t, uc.EndImpersonation(newClaimsContext(claims)))
+
+	// Кейс 3: сессию уже отозвал сам пользователь
+	impersonationRepo.EXPECT().EndImpersonation(gomock.Any(), id).Return(true, nil)
+	sessionRepo.EXPECT().RevokeSession(gomock.Any(), subjectID, id).Return(apperr.ErrNotFound)
+	require.NoError(t, uc.EndImpersonation(newClaimsContext(claims)))
+}
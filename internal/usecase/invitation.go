@@ -0,0 +1,269 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/notifier"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+type InvitationProvider interface {
+	CreateInvitation(*gin.Context, model.CreateInvitationRequest) (*model.Invitation, error)
+	InviteOwner(*gin.Context, uuid.UUID, string) (*model.Invitation, error)
+	ListInvitations(*gin.Context) ([]model.Invitation, error)
+	RevokeInvitation(*gin.Context, uuid.UUID) error
+	AcceptInvitation(*gin.Context, model.AcceptInvitationRequest) (*model.AcceptInvitationResult, error)
+	DeclineInvitation(*gin.Context, string) error
+}
+
+type InvitationCase struct {
+	invitationRepo repository.InvitationProvider
+	userRepo       repository.UserProvider
+	userUC         UserProvider
+	notifier       notifier.Notifier
+	ttl            time.Duration
+	acceptURL      string
+	now            func() time.Time
+}
+
+func NewInvitationProvider(invitationRepo repository.InvitationProvider,
+	userRepo repository.UserProvider,
+	userUC UserProvider,
+	notify notifier.Notifier,
+	ttl time.Duration,
+	acceptURL string) *InvitationCase {
+	return &InvitationCase{
+		invitationRepo: invitationRepo,
+		userRepo:       userRepo,
+		userUC:         userUC,
+		notifier:       notify,
+		ttl:            ttl,
+		acceptURL:      acceptURL,
+		now:            time.Now,
+	}
+}
+
+// CreateInvitation приглашает в организацию запроса. Прежние приглашения на тот же адрес отзываются.
+func (i *InvitationCase) CreateInvitation(c *gin.Context,
+	req model.CreateInvitationRequest) (*model.Invitation, error) {
+	organizationID, ok := tenant.FromContext(c)
+	if !ok {
+		return nil, errors.Wrap(apperr.ErrNoTenant, "usecase CreateInvitation")
+	}
+	invitation, err := i.invite(c, organizationID, req.Email, req.Role)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase CreateInvitation")
+	}
+	return invitation, nil
+}
+
+// InviteOwner приглашает владельца в новую организацию. Вызывает оператор из организации по умолчанию.
+func (i *InvitationCase) InviteOwner(c *gin.Context, organizationID uuid.UUID,
+	email string) (*model.Invitation, error) {
+	invitation, err := i.invite(c, organizationID, email, model.MemberRoleOwner)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase InviteOwner")
+	}
+	return invitation, nil
+}
+
+func (i *InvitationCase) ListInvitations(c *gin.Context) ([]model.Invitation, error) {
+	invitations, err := i.invitationRepo.ListPendingInvitations(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase ListInvitations")
+	}
+	return invitations, nil
+}
+
+func (i *InvitationCase) RevokeInvitation(c *gin.Context, id uuid.UUID) error {
+	if err := i.invitationRepo.RevokeInvitation(c, id); err != nil {
+		return errors.Wrap(err, "usecase RevokeInvitation")
+	}
+	return nil
+}
+
+// AcceptInvitation добавляет в организацию владельца почты из приглашения. Если в организации
+// на эту почту ещё никто не зарегистрирован, создаёт учётную запись из данных запроса.
+// Существующую учётную запись присоединяет, только если она подтвердила почту и запрос
+// сделан от её имени: иначе роль из приглашения достанется тому, кто заранее занял адрес.
+// Пользователь состоит ровно в одной организации, поэтому почту, занятую в другой
+// организации, приглашение не принимает (ErrOtherOrganization): переноса учётных
+// записей между организациями нет, а вторая учётная запись на тот же адрес не нужна.
+func (i *InvitationCase) AcceptInvitation(c *gin.Context,
+	req model.AcceptInvitationRequest) (*model.AcceptInvitationResult, error) {
+	hash := auth.HashOpaqueToken(req.Token)
+	invitation, err := i.invitationRepo.FindInvitation(c, hash)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, errors.Wrap(apperr.ErrInvalidToken, "usecase AcceptInvitation")
+		}
+		return nil, errors.Wrap(err, "usecase AcceptInvitation")
+	}
+	enterTenant(c, invitation.OrganizationID)
+
+	var result *model.AcceptInvitationResult
+	userID, err := i.userRepo.GetUserIDByEmail(c, invitation.Email)
+	switch {
+	case err == nil:
+		if err := i.checkInvitee(c, *userID); err != nil {
+			return nil, errors.Wrap(err, "usecase AcceptInvitation")
+		}
+		// Приглашение закрывается в той же транзакции: его могли принять или отозвать параллельно
+		result, err = i.invitationRepo.AcceptInvitation(c, hash, model.Invitee{UserID: *userID})
+	case errors.Is(err, apperr.ErrNotFound):
+		result, err = i.addInvitedUser(c, hash, invitation, req)
+	}
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, errors.Wrap(apperr.ErrInvalidToken, "usecase AcceptInvitation")
+		}
+		return nil, errors.Wrap(err, "usecase AcceptInvitation")
+	}
+
+	return result, nil
+}
+
+func (i *InvitationCase) DeclineInvitation(c *gin.Context, token string) error {
+	if _, err := i.invitationRepo.DeclineInvitation(c, auth.HashOpaqueToken(token)); err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return errors.Wrap(apperr.ErrInvalidToken, "usecase DeclineInvitation")
+		}
+		return errors.Wrap(err, "usecase DeclineInvitation")
+	}
+	return nil
+}
+
+func (i *InvitationCase) invite(c *gin.Context, organizationID uuid.UUID,
+	email string, role string) (*model.Invitation, error) {
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+
+	now := i.now()
+	invitation := model.Invitation{
+		ID:             id,
+		OrganizationID: organizationID,
+		Email:          strings.TrimSpace(email),
+		Role:           role,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(i.ttl),
+		TokenHash:      hash,
+	}
+	if claims, ok := auth.ClaimsFromContext(c); ok {
+		if inviterID, err := claims.UserID(); err == nil {
+			invitation.InvitedBy = &inviterID
+		}
+	}
+
+	if err := i.invitationRepo.AddInvitation(c, invitation); err != nil {
+		return nil, err
+	}
+
+	sendInBackground(i.notifier, i.invitationMessage(invitation, token), invitation.ID)
+	return &invitation, nil
+}
+
+// checkInvitee проверяет, что существующий пользователь с почтой из приглашения
+// подтвердил её и сам принимает приглашение.
+func (i *InvitationCase) checkInvitee(c *gin.Context, userID uuid.UUID) error {
+	user, err := i.userRepo.GetUser(c, userID)
+	if err != nil {
+		return err
+	}
+	if !user.EmailVerified() {
+		return errors.Wrap(apperr.ErrEmailNotVerified, "email is held by an unverified account")
+	}
+
+	claims, ok := auth.ClaimsFromContext(c)
+	if !ok {
+		return errors.Wrap(apperr.ErrForbidden, "log in as the invited user to accept")
+	}
+	if claimsUserID, err := claims.UserID(); err != nil || claimsUserID != userID {
+		return errors.Wrap(apperr.ErrForbidden, "log in as the invited user to accept")
+	}
+	return nil
+}
+
+// addInvitedUser регистрирует приглашённого и принимает приглашение одной транзакцией.
+// Почта подтверждена: токен пришёл на неё.
+func (i *InvitationCase) addInvitedUser(c *gin.Context, hash string, invitation *model.Invitation,
+	req model.AcceptInvitationRequest) (*model.AcceptInvitationResult, error) {
+	var fields []apperr.FieldError
+	for _, field := range []struct{ name, value string }{
+		{"login", req.Login}, {"password", req.Password}, {"name", req.Name},
+	} {
+		if field.value == "" {
+			fields = append(fields, apperr.FieldError{
+				Field:   field.name,
+				Code:    "required",
+				Message: "is required to create an account",
+			})
+		}
+	}
+	if len(fields) > 0 {
+		return nil, &apperr.ValidationError{Fields: fields}
+	}
+
+	elsewhere, err := i.userRepo.EmailInOtherOrganization(c, invitation.Email)
+	if err != nil {
+		return nil, err
+	}
+	if elsewhere {
+		return nil, errors.Wrap(apperr.ErrOtherOrganization, "invitee already has an account")
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+	user := model.User{
+		ID:         id,
+		Age:        req.Age,
+		Login:      req.Login,
+		Password:   req.Password,
+		Name:       req.Name,
+		Email:      invitation.Email,
+		Attributes: req.Attributes,
+	}
+
+	var result *model.AcceptInvitationResult
+	_, err = i.userUC.AddInvitedUser(c, user, func(ctx context.Context, user model.User) error {
+		var err error
+		result, err = i.invitationRepo.AcceptInvitation(ctx, hash, model.Invitee{NewUser: &user})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (i *InvitationCase) invitationMessage(invitation model.Invitation, token string) notifier.Message {
+	link := i.acceptURL + "?token=" + url.QueryEscape(token)
+	return notifier.Message{
+		To:      invitation.Email,
+		Subject: "Приглашение в организацию",
+		Body: fmt.Sprintf("Здравствуйте!\n\n"+
+			"Вас пригласили в организацию с ролью %s. Чтобы принять или отклонить приглашение, "+
+			"перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %s. Если вы не ждали приглашения, просто проигнорируйте это письмо.",
+			invitation.Role, link, i.ttl),
+	}
+}
@@ -0,0 +1,240 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/notifier"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testInvitationURL = "https://lk.example.com/invitations"
+
+func newTestInvitationCase(t *testing.T, invitationRepo *mocks.MockInvitationProvider,
+	userRepo *mocks.MockUserProvider, notify *fakeNotifier) *InvitationCase {
+	userUC := NewUserProvider(userRepo, nil, nil, newTestHasher(t), newTestPolicy(), nil, &fakeAuditRecorder{},
+		newTestAttributes(t), nil, 0, false)
+	return NewInvitationProvider(invitationRepo, userRepo, userUC, notify, 24*time.Hour,
+		testInvitationURL)
+}
+
+func TestInvitationCase_CreateInvitation(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	invitationRepo := mocks.NewMockInvitationProvider(ctrl)
+	notify := &fakeNotifier{sent: make(chan notifier.Message, 1)}
+
+	uc := newTestInvitationCase(t, invitationRepo, mocks.NewMockUserProvider(ctrl), notify)
+	organizationID := uuid.New()
+	inviterID := uuid.New()
+
+	// Кейс 1: приглашение в организацию запроса, в БД только хэш токена
+	var stored model.Invitation
+	invitationRepo.EXPECT().
+		AddInvitation(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, invitation model.Invitation) {
+			stored = invitation
+		}).
+		Return(nil)
+
+	c := newClaimsContext(auth.NewClaims(inviterID))
+	enterTenant(c, organizationID)
+	invitation, err := uc.CreateInvitation(c, model.CreateInvitationRequest{
+		Email: "jane@example.com",
+		Role:  model.MemberRoleAdmin,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, organizationID, stored.OrganizationID)
+	assert.Equal(t, model.MemberRoleAdmin, stored.Role)
+	assert.Equal(t, inviterID, *stored.InvitedBy)
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), stored.ExpiresAt, time.Minute)
+	assert.Equal(t, stored.ID, invitation.ID)
+
+	var msg notifier.Message
+	select {
+	case msg = <-notify.sent:
+	case <-time.After(time.Second):
+		t.Fatal("invitation message was not sent")
+	}
+	assert.Equal(t, "jane@example.com", msg.To)
+	assert.Contains(t, msg.Body, testInvitationURL+"?token=")
+	assert.NotContains(t, msg.Body, stored.TokenHash)
+
+	// Кейс 2: без организации в контексте приглашать некуда
+	_, err = uc.CreateInvitation(newClaimsContext(auth.NewClaims(inviterID)), model.CreateInvitationRequest{
+		Email: "jane@example.com",
+		Role:  model.MemberRoleMember,
+	})
+	require.ErrorIs(t, err, apperr.ErrNoTenant)
+}
+
+func TestInvitationCase_AcceptInvitation(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	invitationRepo := mocks.NewMockInvitationProvider(ctrl)
+	userRepo := mocks.NewMockUserProvider(ctrl)
+
+	uc := newTestInvitationCase(t, invitationRepo, userRepo, &fakeNotifier{})
+	organizationID := uuid.New()
+	hash := auth.HashOpaqueToken("token")
+	invitation := &model.Invitation{
+		ID:             uuid.New(),
+		OrganizationID: organizationID,
+		Email:          "jane@example.com",
+		Role:           model.MemberRoleAdmin,
+	}
+
+	// Кейс 1: неизвестный, истёкший или закрытый токен
+	invitationRepo.EXPECT().
+		FindInvitation(gomock.Any(), auth.HashOpaqueToken("bad")).
+		Return(nil, errors.Wrap(apperr.ErrNotFound, "invitation not found"))
+
+	_, err := uc.AcceptInvitation(newTestContext(), model.AcceptInvitationRequest{Token: "bad"})
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+
+	// Кейс 2: адрес занят учётной записью без подтверждённой почты, её не присоединяем
+	squatter := &model.User{ID: uuid.New(), Email: invitation.Email}
+	invitationRepo.EXPECT().FindInvitation(gomock.Any(), hash).Return(invitation, nil)
+	userRepo.EXPECT().GetUserIDByEmail(gomock.Any(), invitation.Email).Return(&squatter.ID, nil)
+	userRepo.EXPECT().GetUser(gomock.Any(), squatter.ID).Return(squatter, nil)
+
+	_, err = uc.AcceptInvitation(newClaimsContext(auth.NewClaims(squatter.ID)),
+		model.AcceptInvitationRequest{Token: "token"})
+	require.ErrorIs(t, err, apperr.ErrEmailNotVerified)
+
+	// Кейс 3: подтверждённую учётную запись присоединяет только она сама
+	verifiedAt := time.Now()
+	user := &model.User{ID: uuid.New(), Email: invitation.Email, EmailVerifiedAt: &verifiedAt}
+	for _, c := range []*gin.Context{newTestContext(), newClaimsContext(auth.NewClaims(uuid.New()))} {
+		invitationRepo.EXPECT().FindInvitation(gomock.Any(), hash).Return(invitation, nil)
+		userRepo.EXPECT().GetUserIDByEmail(gomock.Any(), invitation.Email).Return(&user.ID, nil)
+		userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+
+		_, err = uc.AcceptInvitation(c, model.AcceptInvitationRequest{Token: "token"})
+		require.ErrorIs(t, err, apperr.ErrForbidden)
+	}
+
+	// Кейс 4: вошедший участник получает роль из приглашения в организации приглашения
+	invitationRepo.EXPECT().FindInvitation(gomock.Any(), hash).Return(invitation, nil)
+	userRepo.EXPECT().
+		GetUserIDByEmail(gomock.Any(), invitation.Email).
+		DoAndReturn(func(ctx context.Context, _ string) (*uuid.UUID, error) {
+			id, _ := tenant.FromContext(ctx)
+			assert.Equal(t, organizationID, id)
+			return &user.ID, nil
+		})
+	userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+	invitationRepo.EXPECT().
+		AcceptInvitation(gomock.Any(), hash, model.Invitee{UserID: user.ID}).
+		Return(&model.AcceptInvitationResult{UserID: user.ID, Role: model.MemberRoleAdmin}, nil)
+
+	result, err := uc.AcceptInvitation(newClaimsContext(auth.NewClaims(user.ID)),
+		model.AcceptInvitationRequest{Token: "token"})
+	require.NoError(t, err)
+	assert.Equal(t, model.AcceptInvitationResult{UserID: user.ID, Role: model.MemberRoleAdmin}, *result)
+
+	// Кейс 5: приглашение успели принять или отозвать параллельно
+	invitationRepo.EXPECT().FindInvitation(gomock.Any(), hash).Return(invitation, nil)
+	userRepo.EXPECT().GetUserIDByEmail(gomock.Any(), invitation.Email).Return(&user.ID, nil)
+	userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+	invitationRepo.EXPECT().
+		AcceptInvitation(gomock.Any(), hash, model.Invitee{UserID: user.ID}).
+		Return(nil, errors.Wrap(apperr.ErrNotFound, "invitation not found"))
+
+	_, err = uc.AcceptInvitation(newClaimsContext(auth.NewClaims(user.ID)),
+		model.AcceptInvitationRequest{Token: "token"})
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+
+	// Кейс 6: для новой учётной записи нужны логин, пароль и имя, приглашение остаётся открытым
+	invitationRepo.EXPECT().FindInvitation(gomock.Any(), hash).Return(invitation, nil)
+	userRepo.EXPECT().
+		GetUserIDByEmail(gomock.Any(), invitation.Email).
+		Return(nil, errors.Wrap(apperr.ErrNotFound, "email not found"))
+
+	_, err = uc.AcceptInvitation(newTestContext(), model.AcceptInvitationRequest{Token: "token", Login: "jane"})
+	var validationErr *apperr.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Len(t, validationErr.Fields, 2)
+
+	// Кейс 7: учётная запись создаётся на почту из приглашения той же транзакцией, что закрывает его
+	var created model.User
+	invitationRepo.EXPECT().FindInvitation(gomock.Any(), hash).Return(invitation, nil)
+	userRepo.EXPECT().
+		GetUserIDByEmail(gomock.Any(), invitation.Email).
+		Return(nil, errors.Wrap(apperr.ErrNotFound, "email not found"))
+	userRepo.EXPECT().EmailInOtherOrganization(gomock.Any(), invitation.Email).Return(false, nil)
+	invitationRepo.EXPECT().
+		AcceptInvitation(gomock.Any(), hash, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, invitee model.Invitee) (*model.AcceptInvitationResult, error) {
+			require.NotNil(t, invitee.NewUser)
+			created = *invitee.NewUser
+			return &model.AcceptInvitationResult{UserID: created.ID, Role: model.MemberRoleAdmin, Created: true}, nil
+		})
+
+	result, err = uc.AcceptInvitation(newTestContext(), model.AcceptInvitationRequest{
+		Token:    "token",
+		Login:    "jane",
+		Password: "correct-horse-battery",
+		Name:     "Jane",
+	})
+	require.NoError(t, err)
+	assert.True(t, result.Created)
+	assert.Equal(t, created.ID, result.UserID)
+	assert.Equal(t, invitation.Email, created.Email)
+	assert.NotEqual(t, "correct-horse-battery", created.Password)
+
+	// Кейс 8: почта занята в другой организации - вторую учётную запись не создаём
+	invitationRepo.EXPECT().FindInvitation(gomock.Any(), hash).Return(invitation, nil)
+	userRepo.EXPECT().
+		GetUserIDByEmail(gomock.Any(), invitation.Email).
+		Return(nil, errors.Wrap(apperr.ErrNotFound, "email not found"))
+	userRepo.EXPECT().EmailInOtherOrganization(gomock.Any(), invitation.Email).Return(true, nil)
+
+	_, err = uc.AcceptInvitation(newTestContext(), model.AcceptInvitationRequest{
+		Token:    "token",
+		Login:    "jane",
+		Password: "correct-horse-battery",
+		Name:     "Jane",
+	})
+	require.ErrorIs(t, err, apperr.ErrOtherOrganization)
+}
+
+func TestInvitationCase_DeclineInvitation(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	invitationRepo := mocks.NewMockInvitationProvider(ctrl)
+
+	uc := newTestInvitationCase(t, invitationRepo, mocks.NewMockUserProvider(ctrl), &fakeNotifier{})
+
+	// Кейс 1: приглашение уже закрыто
+	invitationRepo.EXPECT().
+		DeclineInvitation(gomock.Any(), auth.HashOpaqueToken("bad")).
+		Return(nil, errors.Wrap(apperr.ErrNotFound, "invitation not found"))
+
+	require.ErrorIs(t, uc.DeclineInvitation(newTestContext(), "bad"), apperr.ErrInvalidToken)
+
+	// Кейс 2: приглашение отклонено
+	invitationRepo.EXPECT().
+		DeclineInvitation(gomock.Any(), auth.HashOpaqueToken("token")).
+		Return(&model.Invitation{ID: uuid.New()}, nil)
+
+	require.NoError(t, uc.DeclineInvitation(newTestContext(), "token"))
+}
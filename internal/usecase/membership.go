@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+type MembershipProvider interface {
+	ListMembers(*gin.Context) ([]model.Member, error)
+	UpdateMemberRole(*gin.Context, uuid.UUID, string) error
+	TransferOwnership(*gin.Context, uuid.UUID) error
+	MemberRole(context.Context, uuid.UUID) (string, error)
+}
+
+type MembershipCase struct {
+	memberRepo repository.MembershipProvider
+	audit      AuditRecorder
+}
+
+func NewMembershipProvider(memberRepo repository.MembershipProvider, audit AuditRecorder) *MembershipCase {
+	return &MembershipCase{
+		memberRepo: memberRepo,
+		audit:      audit,
+	}
+}
+
+func (m *MembershipCase) ListMembers(c *gin.Context) ([]model.Member, error) {
+	members, err := m.memberRepo.ListMembers(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase ListMembers")
+	}
+	return members, nil
+}
+
+// UpdateMemberRole меняет роль участника. Владельца меняет только передача владения.
+func (m *MembershipCase) UpdateMemberRole(c *gin.Context, userID uuid.UUID, role string) error {
+	current, err := m.memberRepo.GetMemberRole(c, userID)
+	if err != nil {
+		return errors.Wrap(err, "usecase UpdateMemberRole")
+	}
+	if current == model.MemberRoleOwner || role == model.MemberRoleOwner {
+		return errors.Wrap(apperr.ErrForbidden, "owner role is changed only by ownership transfer")
+	}
+	if current == role {
+		return nil
+	}
+
+	if err := m.memberRepo.SetMemberRole(c, userID, role); err != nil {
+		return errors.Wrap(err, "usecase UpdateMemberRole")
+	}
+
+	m.recordRole(c, userID, current, role)
+	return nil
+}
+
+// TransferOwnership передаёт владение организацией участнику, прежний владелец становится администратором.
+func (m *MembershipCase) TransferOwnership(c *gin.Context, newOwnerID uuid.UUID) error {
+	claims, ok := auth.ClaimsFromContext(c)
+	if !ok {
+		return errors.Wrap(apperr.ErrInvalidToken, "claims are missing")
+	}
+	ownerID, err := claims.UserID()
+	if err != nil {
+		return errors.Wrap(err, "usecase TransferOwnership")
+	}
+	if ownerID == newOwnerID {
+		return errors.Wrap(apperr.ErrForbidden, "already the owner")
+	}
+
+	current, err := m.memberRepo.GetMemberRole(c, newOwnerID)
+	if err != nil {
+		return errors.Wrap(err, "usecase TransferOwnership")
+	}
+
+	if err := m.memberRepo.TransferOwnership(c, ownerID, newOwnerID); err != nil {
+		return errors.Wrap(err, "usecase TransferOwnership")
+	}
+
+	m.recordRole(c, ownerID, model.MemberRoleOwner, model.MemberRoleAdmin)
+	m.recordRole(c, newOwnerID, current, model.MemberRoleOwner)
+	return nil
+}
+
+func (m *MembershipCase) MemberRole(ctx context.Context, userID uuid.UUID) (string, error) {
+	role, err := m.memberRepo.GetMemberRole(ctx, userID)
+	if err != nil {
+		return "", errors.Wrap(err, "usecase MemberRole")
+	}
+	return role, nil
+}
+
+func (m *MembershipCase) recordRole(c *gin.Context, userID uuid.UUID, old string, role string) {
+	m.audit.Record(c, model.AuditEvent{
+		Action:   model.AuditMemberRole,
+		TargetID: &userID,
+		Changes:  map[string]model.AuditChange{"role": {Old: old, New: role}},
+	})
+}
@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMembershipCase_UpdateMemberRole(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	memberRepo := mocks.NewMockMembershipProvider(ctrl)
+	audit := &fakeAuditRecorder{}
+
+	uc := NewMembershipProvider(memberRepo, audit)
+
+	// Кейс 1: роль владельца меняет только передача владения
+	ownerID := uuid.New()
+	memberRepo.EXPECT().GetMemberRole(gomock.Any(), ownerID).Return(model.MemberRoleOwner, nil)
+
+	err := uc.UpdateMemberRole(newTestContext(), ownerID, model.MemberRoleAdmin)
+	require.ErrorIs(t, err, apperr.ErrForbidden)
+
+	// Кейс 2: роль не меняется, запись в журнал не нужна
+	adminID := uuid.New()
+	memberRepo.EXPECT().GetMemberRole(gomock.Any(), adminID).Return(model.MemberRoleAdmin, nil)
+
+	require.NoError(t, uc.UpdateMemberRole(newTestContext(), adminID, model.MemberRoleAdmin))
+	assert.Empty(t, audit.events)
+
+	// Кейс 3: участник становится администратором
+	memberID := uuid.New()
+	memberRepo.EXPECT().GetMemberRole(gomock.Any(), memberID).Return(model.MemberRoleMember, nil)
+	memberRepo.EXPECT().SetMemberRole(gomock.Any(), memberID, model.MemberRoleAdmin).Return(nil)
+
+	require.NoError(t, uc.UpdateMemberRole(newTestContext(), memberID, model.MemberRoleAdmin))
+	event := audit.last(t)
+	assert.Equal(t, model.AuditMemberRole, event.Action)
+	assert.Equal(t, memberID, *event.TargetID)
+	assert.Equal(t, model.AuditChange{Old: model.MemberRoleMember, New: model.MemberRoleAdmin}, event.Changes["role"])
+
+	// Кейс 4: пользователь не состоит в организации
+	missingID := uuid.New()
+	memberRepo.EXPECT().
+		GetMemberRole(gomock.Any(), missingID).
+		Return("", errors.Wrap(apperr.ErrNotFound, "member not found"))
+
+	err = uc.UpdateMemberRole(newTestContext(), missingID, model.MemberRoleAdmin)
+	require.ErrorIs(t, err, apperr.ErrNotFound)
+}
+
+func TestMembershipCase_TransferOwnership(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	memberRepo := mocks.NewMockMembershipProvider(ctrl)
+	audit := &fakeAuditRecorder{}
+
+	uc := NewMembershipProvider(memberRepo, audit)
+	ownerID := uuid.New()
+	newOwnerID := uuid.New()
+
+	// Кейс 1: передать владение себе нельзя
+	err := uc.TransferOwnership(newClaimsContext(auth.NewClaims(ownerID)), ownerID)
+	require.ErrorIs(t, err, apperr.ErrForbidden)
+
+	// Кейс 2: вызывающий не владелец
+	memberRepo.EXPECT().GetMemberRole(gomock.Any(), newOwnerID).Return(model.MemberRoleMember, nil)
+	memberRepo.EXPECT().
+		TransferOwnership(gomock.Any(), ownerID, newOwnerID).
+		Return(errors.Wrap(apperr.ErrForbidden, "not the owner"))
+
+	err = uc.TransferOwnership(newClaimsContext(auth.NewClaims(ownerID)), newOwnerID)
+	require.ErrorIs(t, err, apperr.ErrForbidden)
+	assert.Empty(t, audit.events)
+
+	// Кейс 3: владелец становится администратором, участник - владельцем
+	memberRepo.EXPECT().GetMemberRole(gomock.Any(), newOwnerID).Return(model.MemberRoleMember, nil)
+	memberRepo.EXPECT().TransferOwnership(gomock.Any(), ownerID, newOwnerID).Return(nil)
+
+	require.NoError(t, uc.TransferOwnership(newClaimsContext(auth.NewClaims(ownerID)), newOwnerID))
+	require.Len(t, audit.events, 2)
+	assert.Equal(t, ownerID, *audit.events[0].TargetID)
+	assert.Equal(t, model.AuditChange{Old: model.MemberRoleOwner, New: model.MemberRoleAdmin},
+		audit.events[0].Changes["role"])
+	assert.Equal(t, newOwnerID, *audit.events[1].TargetID)
+	assert.Equal(t, model.AuditChange{Old: model.MemberRoleMember, New: model.MemberRoleOwner},
+		audit.events[1].Changes["role"])
+}
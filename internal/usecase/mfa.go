@@ -0,0 +1,361 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/lockout"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+	"github.com/lemavisaitov/lk-api/internal/sealer"
+	"github.com/lemavisaitov/lk-api/internal/totp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// Допуск в один шаг (30 секунд) на расхождение часов
+	totpSkew          = 1
+	recoveryCodeCount = 10
+	recoveryCodeBytes = 5
+	// Попытки ввода кода учитываются отдельно от пароля: успешный ввод
+	// пароля сбрасывает его счётчик и не должен обнулять перебор кодов
+	mfaAttemptsPrefix = "mfa:"
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type MFAProvider interface {
+	EnrollTOTP(*gin.Context, uuid.UUID) (*model.TOTPEnrollment, error)
+	ConfirmTOTP(*gin.Context, uuid.UUID, string) ([]string, error)
+	DisableTOTP(*gin.Context, uuid.UUID, string) error
+	RegenerateRecoveryCodes(*gin.Context, uuid.UUID, string) ([]string, error)
+	MFAEnabled(*gin.Context, uuid.UUID) (bool, error)
+	StartChallenge(*gin.Context, *model.User) (*model.MFAChallenge, error)
+	VerifyChallenge(*gin.Context, model.MFALoginRequest) (*model.User, error)
+}
+
+type MFACase struct {
+	userRepo     repository.UserProvider
+	totpRepo     repository.TOTPProvider
+	issuer       *auth.Issuer
+	sealer       *sealer.Sealer
+	attempts     lockout.Tracker
+	totpIssuer   string
+	challengeTTL time.Duration
+	now          func() time.Time
+}
+
+func NewMFAProvider(userRepo repository.UserProvider,
+	totpRepo repository.TOTPProvider,
+	issuer *auth.Issuer,
+	secretSealer *sealer.Sealer,
+	attempts lockout.Tracker,
+	totpIssuer string,
+	challengeTTL time.Duration) *MFACase {
+	return &MFACase{
+		userRepo:     userRepo,
+		totpRepo:     totpRepo,
+		issuer:       issuer,
+		sealer:       secretSealer,
+		attempts:     attempts,
+		totpIssuer:   totpIssuer,
+		challengeTTL: challengeTTL,
+		now:          time.Now,
+	}
+}
+
+// EnrollTOTP генерирует новый секрет. Фактор начнёт действовать только после
+// ConfirmTOTP, поэтому повторная регистрация до подтверждения просто заменяет секрет.
+func (m *MFACase) EnrollTOTP(c *gin.Context, userID uuid.UUID) (*model.TOTPEnrollment, error) {
+	current, err := m.totpRepo.GetTOTP(c, userID)
+	if err != nil && !errors.Is(err, apperr.ErrNotFound) {
+		return nil, errors.Wrap(err, "usecase EnrollTOTP")
+	}
+	if current != nil && current.Enabled() {
+		return nil, errors.Wrap(apperr.ErrMFAEnabled, "usecase EnrollTOTP")
+	}
+
+	user, err := m.userRepo.GetUser(c, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase EnrollTOTP")
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase EnrollTOTP")
+	}
+	sealed, err := m.sealer.Seal(secret, userID[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase EnrollTOTP")
+	}
+
+	if err := m.totpRepo.SaveTOTP(c, model.TOTP{UserID: userID, Secret: sealed}); err != nil {
+		return nil, errors.Wrap(err, "usecase EnrollTOTP")
+	}
+
+	account := user.Email
+	if account == "" {
+		account = user.Login
+	}
+	return &model.TOTPEnrollment{
+		Secret: totp.EncodeSecret(secret),
+		URI:    totp.URI(m.totpIssuer, account, secret),
+	}, nil
+}
+
+// ConfirmTOTP включает второй фактор по первому коду из приложения
+// и возвращает коды восстановления. Они показываются один раз.
+func (m *MFACase) ConfirmTOTP(c *gin.Context, userID uuid.UUID, code string) ([]string, error) {
+	current, err := m.getTOTP(c, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase ConfirmTOTP")
+	}
+	if current.Enabled() {
+		return nil, errors.Wrap(apperr.ErrMFAEnabled, "usecase ConfirmTOTP")
+	}
+
+	if err := m.checkCode(c, current, code); err != nil {
+		return nil, errors.Wrap(err, "usecase ConfirmTOTP")
+	}
+
+	if err := m.totpRepo.ConfirmTOTP(c, userID); err != nil {
+		return nil, errors.Wrap(err, "usecase ConfirmTOTP")
+	}
+
+	codes, err := m.replaceRecoveryCodes(c, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase ConfirmTOTP")
+	}
+	return codes, nil
+}
+
+// DisableTOTP отключает второй фактор. Для включённого фактора нужен
+// действующий код или код восстановления.
+func (m *MFACase) DisableTOTP(c *gin.Context, userID uuid.UUID, code string) error {
+	current, err := m.getTOTP(c, userID)
+	if err != nil {
+		return errors.Wrap(err, "usecase DisableTOTP")
+	}
+
+	if current.Enabled() {
+		if err := m.checkCode(c, current, code); err != nil {
+			return errors.Wrap(err, "usecase DisableTOTP")
+		}
+	}
+
+	if err := m.totpRepo.DeleteTOTP(c, userID); err != nil {
+		return errors.Wrap(err, "usecase DisableTOTP")
+	}
+	return nil
+}
+
+func (m *MFACase) RegenerateRecoveryCodes(c *gin.Context, userID uuid.UUID, code string) ([]string, error) {
+	current, err := m.getTOTP(c, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase RegenerateRecoveryCodes")
+	}
+	if !current.Enabled() {
+		return nil, errors.Wrap(apperr.ErrMFANotEnrolled, "usecase RegenerateRecoveryCodes")
+	}
+
+	if err := m.checkCode(c, current, code); err != nil {
+		return nil, errors.Wrap(err, "usecase RegenerateRecoveryCodes")
+	}
+
+	codes, err := m.replaceRecoveryCodes(c, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase RegenerateRecoveryCodes")
+	}
+	return codes, nil
+}
+
+func (m *MFACase) MFAEnabled(c *gin.Context, userID uuid.UUID) (bool, error) {
+	current, err := m.totpRepo.GetTOTP(c, userID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "usecase MFAEnabled")
+	}
+	return current.Enabled(), nil
+}
+
+// StartChallenge выдаёт короткоживущий токен, который вместе с кодом
+// обменивается на пару токенов в VerifyChallenge.
+func (m *MFACase) StartChallenge(_ *gin.Context, user *model.User) (*model.MFAChallenge, error) {
+	claims := auth.NewClaims(user.ID)
+	claims.Purpose = auth.PurposeMFA
+	claims.Org = user.OrganizationID.String()
+	claims.ExpiresAt = jwt.NewNumericDate(m.now().Add(m.challengeTTL))
+
+	token, err := m.issuer.Issue(claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase StartChallenge")
+	}
+
+	return &model.MFAChallenge{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresIn:   int(m.challengeTTL.Seconds()),
+	}, nil
+}
+
+// VerifyChallenge проверяет токен первого шага и код второго фактора.
+func (m *MFACase) VerifyChallenge(c *gin.Context, req model.MFALoginRequest) (*model.User, error) {
+	claims, err := m.issuer.Parse(req.MFAToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase VerifyChallenge")
+	}
+	if claims.Purpose != auth.PurposeMFA {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "not an mfa token")
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase VerifyChallenge")
+	}
+	organizationID, err := claims.OrganizationID()
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase VerifyChallenge")
+	}
+	enterTenant(c, organizationID)
+
+	current, err := m.getTOTP(c, userID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrMFANotEnrolled) {
+			return nil, errors.Wrap(apperr.ErrInvalidToken, "mfa disabled")
+		}
+		return nil, errors.Wrap(err, "usecase VerifyChallenge")
+	}
+	if !current.Enabled() {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "mfa disabled")
+	}
+
+	if err := m.checkCode(c, current, req.Code); err != nil {
+		return nil, errors.Wrap(err, "usecase VerifyChallenge")
+	}
+
+	user, err := m.userRepo.GetUser(c, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase VerifyChallenge")
+	}
+	return user, nil
+}
+
+func (m *MFACase) getTOTP(c *gin.Context, userID uuid.UUID) (*model.TOTP, error) {
+	current, err := m.totpRepo.GetTOTP(c, userID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, apperr.ErrMFANotEnrolled
+		}
+		return nil, err
+	}
+	return current, nil
+}
+
+// checkCode принимает код из приложения, а для включённого фактора ещё
+// и код восстановления. Неудачи ограничиваются так же, как вход по паролю.
+func (m *MFACase) checkCode(c *gin.Context, current *model.TOTP, code string) error {
+	key := mfaAttemptsPrefix + current.UserID.String()
+
+	decision, err := m.attempts.Check(c, key, c.ClientIP())
+	if err != nil {
+		return errors.Wrap(err, "check attempts")
+	}
+	if !decision.Allowed() {
+		return rejectAttempt(decision)
+	}
+
+	ok, err := m.verifyCode(c, current, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if _, err := m.attempts.Fail(c, key, c.ClientIP()); err != nil {
+			logger.Error("failed to record mfa attempt",
+				zap.String("userID", current.UserID.String()),
+				zap.Error(err),
+			)
+		}
+		return apperr.ErrInvalidMFACode
+	}
+
+	if err := m.attempts.Reset(c, key); err != nil {
+		logger.Error("failed to reset mfa attempts",
+			zap.String("userID", current.UserID.String()),
+			zap.Error(err),
+		)
+	}
+	return nil
+}
+
+func (m *MFACase) verifyCode(c *gin.Context, current *model.TOTP, code string) (bool, error) {
+	code = normalizeCode(code)
+
+	if isTOTPCode(code) {
+		secret, err := m.sealer.Open(current.Secret, current.UserID[:])
+		if err != nil {
+			return false, errors.Wrap(err, "open totp secret")
+		}
+
+		step, ok := totp.Verify(secret, code, m.now(), totpSkew)
+		if !ok {
+			return false, nil
+		}
+		// Каждый код принимается один раз
+		return m.totpRepo.UseTOTPStep(c, current.UserID, step)
+	}
+
+	if !current.Enabled() {
+		return false, nil
+	}
+	return m.totpRepo.UseRecoveryCode(c, current.UserID, auth.HashOpaqueToken(code))
+}
+
+func (m *MFACase) replaceRecoveryCodes(c *gin.Context, userID uuid.UUID) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for range recoveryCodeCount {
+		buf := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, errors.Wrap(err, "generate recovery code")
+		}
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(buf))
+
+		codes = append(codes, code[:4]+"-"+code[4:])
+		hashes = append(hashes, auth.HashOpaqueToken(code))
+	}
+
+	if err := m.totpRepo.ReplaceRecoveryCodes(c, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeCode убирает пробелы и дефисы, которые пользователи копируют вместе с кодом.
+func normalizeCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
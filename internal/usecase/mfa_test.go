@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/lockout"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/sealer"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+	"github.com/lemavisaitov/lk-api/internal/totp"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTOTPRepo хранит состояние в памяти, чтобы пройти весь сценарий целиком
+type fakeTOTPRepo struct {
+	totp  *model.TOTP
+	codes map[string]bool
+}
+
+func (f *fakeTOTPRepo) SaveTOTP(_ context.Context, t model.TOTP) error {
+	f.totp = &t
+	return nil
+}
+
+func (f *fakeTOTPRepo) GetTOTP(_ context.Context, _ uuid.UUID) (*model.TOTP, error) {
+	if f.totp == nil {
+		return nil, apperr.ErrNotFound
+	}
+	t := *f.totp
+	return &t, nil
+}
+
+func (f *fakeTOTPRepo) ConfirmTOTP(_ context.Context, _ uuid.UUID) error {
+	now := time.Now()
+	f.totp.ConfirmedAt = &now
+	return nil
+}
+
+func (f *fakeTOTPRepo) UseTOTPStep(_ context.Context, _ uuid.UUID, step int64) (bool, error) {
+	if step <= f.totp.LastUsedStep {
+		return false, nil
+	}
+	f.totp.LastUsedStep = step
+	return true, nil
+}
+
+func (f *fakeTOTPRepo) DeleteTOTP(_ context.Context, _ uuid.UUID) error {
+	f.totp = nil
+	f.codes = nil
+	return nil
+}
+
+func (f *fakeTOTPRepo) ReplaceRecoveryCodes(_ context.Context, _ uuid.UUID, hashes []string) error {
+	f.codes = make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		f.codes[hash] = true
+	}
+	return nil
+}
+
+func (f *fakeTOTPRepo) UseRecoveryCode(_ context.Context, _ uuid.UUID, hash string) (bool, error) {
+	if !f.codes[hash] {
+		return false, nil
+	}
+	delete(f.codes, hash)
+	return true, nil
+}
+
+func newTestMFACase(t *testing.T, userRepo *mocks.MockUserProvider, now *time.Time) (*MFACase, *fakeTOTPRepo) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := auth.NewKey("test", private)
+	require.NoError(t, err)
+	keys, err := auth.NewKeySet("test", key)
+	require.NoError(t, err)
+
+	s, err := sealer.New(bytes.Repeat([]byte{7}, sealer.KeySize))
+	require.NoError(t, err)
+
+	store := lockout.NewMemoryStore(time.Minute, time.Hour)
+	t.Cleanup(store.Close)
+
+	clock := func() time.Time { return *now }
+	repo := &fakeTOTPRepo{}
+	uc := NewMFAProvider(userRepo, repo, auth.NewIssuer(keys, "lk-api", time.Minute).WithClock(clock), s,
+		lockout.NewLimiter(store, lockout.Policy{}, lockout.Policy{}), "lk-api", 5*time.Minute)
+	uc.now = clock
+
+	return uc, repo
+}
+
+func TestMFACase_Flow(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	now := time.Unix(1_700_000_000, 0)
+	uc, repo := newTestMFACase(t, userRepo, &now)
+
+	user := &model.User{ID: uuid.New(), Login: "admin", Email: "admin@example.com"}
+	userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil).AnyTimes()
+
+	// Кейс 1: регистрация - секрет в БД зашифрован
+	enrollment, err := uc.EnrollTOTP(newTestContext(), user.ID)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/")
+	assert.NotContains(t, repo.totp.Secret, enrollment.Secret)
+
+	secret, err := uc.sealer.Open(repo.totp.Secret, user.ID[:])
+	require.NoError(t, err)
+	assert.Equal(t, totp.EncodeSecret(secret), enrollment.Secret)
+
+	enabled, err := uc.MFAEnabled(newTestContext(), user.ID)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	// Кейс 2: подтверждение неверным кодом
+	_, err = uc.ConfirmTOTP(newTestContext(), user.ID, "000000")
+	require.ErrorIs(t, err, apperr.ErrInvalidMFACode)
+
+	// Кейс 3: подтверждение верным кодом выдаёт коды восстановления
+	codes, err := uc.ConfirmTOTP(newTestContext(), user.ID, totp.Code(secret, totp.Step(now)))
+	require.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+
+	enabled, err = uc.MFAEnabled(newTestContext(), user.ID)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	// Кейс 4: тот же код второй раз не принимается
+	challenge, err := uc.StartChallenge(newTestContext(), user)
+	require.NoError(t, err)
+	_, err = uc.VerifyChallenge(newTestContext(), model.MFALoginRequest{
+		MFAToken: challenge.MFAToken,
+		Code:     totp.Code(secret, totp.Step(now)),
+	})
+	require.ErrorIs(t, err, apperr.ErrInvalidMFACode)
+
+	// Кейс 5: код следующего шага
+	now = now.Add(totp.Period)
+	got, err := uc.VerifyChallenge(newTestContext(), model.MFALoginRequest{
+		MFAToken: challenge.MFAToken,
+		Code:     totp.Code(secret, totp.Step(now)),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, got.ID)
+
+	// Кейс 6: код восстановления действует один раз
+	_, err = uc.VerifyChallenge(newTestContext(), model.MFALoginRequest{MFAToken: challenge.MFAToken, Code: codes[0]})
+	require.NoError(t, err)
+	_, err = uc.VerifyChallenge(newTestContext(), model.MFALoginRequest{MFAToken: challenge.MFAToken, Code: codes[0]})
+	require.ErrorIs(t, err, apperr.ErrInvalidMFACode)
+
+	// Кейс 7: токен первого шага истёк
+	now = now.Add(10 * time.Minute)
+	_, err = uc.VerifyChallenge(newTestContext(), model.MFALoginRequest{MFAToken: challenge.MFAToken, Code: codes[1]})
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+
+	// Кейс 8: отключение требует кода
+	err = uc.DisableTOTP(newTestContext(), user.ID, "000000")
+	require.ErrorIs(t, err, apperr.ErrInvalidMFACode)
+	require.NoError(t, uc.DisableTOTP(newTestContext(), user.ID, codes[1]))
+
+	enabled, err = uc.MFAEnabled(newTestContext(), user.ID)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+}
+
+func TestMFACase_ChallengeIsNotAccessToken(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Now()
+	uc, _ := newTestMFACase(t, mocks.NewMockUserProvider(ctrl), &now)
+
+	challenge, err := uc.StartChallenge(newTestContext(), &model.User{ID: uuid.New()})
+	require.NoError(t, err)
+
+	authUC := NewAuthProvider(nil, nil, mocks.NewMockSessionProvider(ctrl), uc.issuer, time.Hour)
+	_, err = authUC.AuthenticateAccessToken(context.Background(), challenge.MFAToken)
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+}
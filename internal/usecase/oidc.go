@@ -0,0 +1,447 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	responseTypeCode        = "code"
+	grantTypeAuthCode       = "authorization_code"
+	codeChallengeMethodS256 = "S256"
+	consentGranted          = "granted"
+	consentDenied           = "denied"
+	// Длина code_verifier и code_challenge по RFC 7636
+	pkceMinLength = 43
+	pkceMaxLength = 128
+)
+
+var oidcScopes = []string{model.OIDCScopeOpenID, model.OIDCScopeProfile, model.OIDCScopeEmail}
+
+type OIDCProvider interface {
+	RegisterClient(*gin.Context, model.RegisterClientRequest) (*model.RegisteredClient, error)
+	Authorize(*gin.Context, uuid.UUID, model.AuthorizeRequest) (*model.AuthorizeResult, error)
+	Exchange(*gin.Context, model.TokenRequest) (*model.OIDCTokenResponse, error)
+	UserInfo(*gin.Context, string) (*model.UserInfo, error)
+	ListConsents(*gin.Context, uuid.UUID) ([]model.OAuthConsent, error)
+	RevokeConsent(*gin.Context, uuid.UUID, string) error
+	Discovery() model.OIDCDiscovery
+	JWKS() auth.JWKS
+}
+
+type OIDCCase struct {
+	oidcRepo repository.OIDCProvider
+	userRepo repository.UserProvider
+	// Выпускает токены с iss, равным адресу провайдера
+	issuer  *auth.Issuer
+	codeTTL time.Duration
+	now     func() time.Time
+}
+
+func NewOIDCProvider(oidcRepo repository.OIDCProvider,
+	userRepo repository.UserProvider,
+	issuer *auth.Issuer,
+	codeTTL time.Duration) *OIDCCase {
+	return &OIDCCase{
+		oidcRepo: oidcRepo,
+		userRepo: userRepo,
+		issuer:   issuer,
+		codeTTL:  codeTTL,
+		now:      time.Now,
+	}
+}
+
+func (o *OIDCCase) RegisterClient(c *gin.Context, req model.RegisterClientRequest) (*model.RegisteredClient, error) {
+	for _, redirectURI := range req.RedirectURIs {
+		// Фрагмент в redirect_uri запрещён RFC 6749, 3.1.2
+		if u, err := url.Parse(redirectURI); err != nil || u.Fragment != "" || !u.IsAbs() {
+			return nil, &apperr.ValidationError{Fields: []apperr.FieldError{{
+				Field:   "redirect_uris",
+				Code:    "absolute_url",
+				Message: "must be an absolute URL without fragment",
+			}}}
+		}
+	}
+
+	client := model.RegisteredClient{OAuthClient: model.OAuthClient{
+		ID:           uuid.NewString(),
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		Public:       req.Public,
+		CreatedAt:    o.now(),
+	}}
+	if !req.Public {
+		secret, hash, err := auth.NewOpaqueToken()
+		if err != nil {
+			return nil, errors.Wrap(err, "usecase RegisterClient")
+		}
+		client.Secret = secret
+		client.SecretHash = hash
+	}
+
+	if err := o.oidcRepo.AddClient(c, client.OAuthClient); err != nil {
+		return nil, errors.Wrap(err, "usecase RegisterClient")
+	}
+	return &client, nil
+}
+
+// Authorize выдаёт код авторизации пользователю userID. Пока клиент и
+// redirect_uri не проверены, ошибки возвращаются вызывающему, после - уходят
+// в приложение через redirect_uri, как требует RFC 6749.
+func (o *OIDCCase) Authorize(c *gin.Context, userID uuid.UUID, req model.AuthorizeRequest) (*model.AuthorizeResult, error) {
+	client, err := o.oidcRepo.GetClient(c, req.ClientID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, &apperr.OAuthError{Code: apperr.OAuthInvalidClient, Description: "unknown client_id"}
+		}
+		return nil, errors.Wrap(err, "usecase Authorize")
+	}
+
+	// OpenID Connect требует redirect_uri всегда, сравнение точное
+	redirectURI := req.RedirectURI
+	if !slices.Contains(client.RedirectURIs, redirectURI) {
+		return nil, &apperr.OAuthError{Code: apperr.OAuthInvalidRequest, Description: "redirect_uri is not registered"}
+	}
+
+	reject := func(code string, description string) (*model.AuthorizeResult, error) {
+		return &model.AuthorizeResult{RedirectTo: withQuery(redirectURI, map[string]string{
+			"error":             code,
+			"error_description": description,
+			"state":             req.State,
+		})}, nil
+	}
+
+	if req.ResponseType != responseTypeCode {
+		return reject(apperr.OAuthUnsupportedResponseType, "only response_type=code is supported")
+	}
+	scopes, ok := parseOIDCScope(req.Scope)
+	if !ok {
+		return reject(apperr.OAuthInvalidScope, "scope must include openid and only supported scopes")
+	}
+	// PKCE обязателен для всех клиентов, plain не принимаем
+	if req.CodeChallengeMethod != codeChallengeMethodS256 || !validPKCE(req.CodeChallenge) {
+		return reject(apperr.OAuthInvalidRequest, "code_challenge with code_challenge_method=S256 is required")
+	}
+	if req.Consent == consentDenied {
+		return reject(apperr.OAuthAccessDenied, "user denied access")
+	}
+
+	consent, err := o.oidcRepo.GetConsent(c, userID, client.ID)
+	if err != nil && !errors.Is(err, apperr.ErrNotFound) {
+		return nil, errors.Wrap(err, "usecase Authorize")
+	}
+	if consent == nil || !containsAll(consent.Scopes, scopes) {
+		if req.Consent != consentGranted {
+			return &model.AuthorizeResult{Consent: &model.ConsentPrompt{
+				ConsentRequired: true,
+				ClientID:        client.ID,
+				ClientName:      client.Name,
+				Scopes:          scopes,
+			}}, nil
+		}
+
+		granted := scopes
+		if consent != nil {
+			granted = mergeScopes(consent.Scopes, scopes)
+		}
+		err = o.oidcRepo.SaveConsent(c, model.OAuthConsent{UserID: userID, ClientID: client.ID, Scopes: granted})
+		if err != nil {
+			return nil, errors.Wrap(err, "usecase Authorize")
+		}
+	}
+
+	code, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase Authorize")
+	}
+	err = o.oidcRepo.AddAuthorizationCode(c, model.AuthorizationCode{
+		CodeHash:      hash,
+		ClientID:      client.ID,
+		UserID:        userID,
+		RedirectURI:   redirectURI,
+		Scopes:        scopes,
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     o.now().Add(o.codeTTL),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase Authorize")
+	}
+
+	return &model.AuthorizeResult{RedirectTo: withQuery(redirectURI, map[string]string{
+		"code":  code,
+		"state": req.State,
+	})}, nil
+}
+
+// Exchange меняет код авторизации на access- и ID-токен.
+func (o *OIDCCase) Exchange(c *gin.Context, req model.TokenRequest) (*model.OIDCTokenResponse, error) {
+	if req.GrantType != grantTypeAuthCode {
+		return nil, &apperr.OAuthError{Code: apperr.OAuthUnsupportedGrantType, Description: "only authorization_code is supported"}
+	}
+
+	client, err := o.authenticateClient(c, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	// Код гасится до остальных проверок: повторно его не использовать даже при ошибке
+	code, err := o.oidcRepo.ConsumeAuthorizationCode(c, auth.HashOpaqueToken(req.Code))
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, &apperr.OAuthError{Code: apperr.OAuthInvalidGrant, Description: "code is invalid, expired or already used"}
+		}
+		return nil, errors.Wrap(err, "usecase Exchange")
+	}
+	if code.ClientID != client.ID || code.RedirectURI != req.RedirectURI {
+		return nil, &apperr.OAuthError{Code: apperr.OAuthInvalidGrant, Description: "code was issued to another client or redirect_uri"}
+	}
+	if !validPKCE(req.CodeVerifier) || subtle.ConstantTimeCompare([]byte(pkceChallenge(req.CodeVerifier)), []byte(code.CodeChallenge)) != 1 {
+		return nil, &apperr.OAuthError{Code: apperr.OAuthInvalidGrant, Description: "code_verifier does not match code_challenge"}
+	}
+
+	err = enterUserTenant(c, o.userRepo, code.UserID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, &apperr.OAuthError{Code: apperr.OAuthInvalidGrant, Description: "user not found"}
+		}
+		return nil, errors.Wrap(err, "usecase Exchange")
+	}
+	user, err := o.userRepo.GetUser(c, code.UserID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, &apperr.OAuthError{Code: apperr.OAuthInvalidGrant, Description: "user not found"}
+		}
+		return nil, errors.Wrap(err, "usecase Exchange")
+	}
+
+	scope := strings.Join(code.Scopes, " ")
+	claims := auth.NewClaims(user.ID)
+	claims.Purpose = auth.PurposeOIDC
+	claims.Audience = jwt.ClaimStrings{client.ID}
+	claims.OAuthScope = scope
+	claims.Org = user.OrganizationID.String()
+	accessToken, err := o.issuer.Issue(claims)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase Exchange")
+	}
+
+	info := newUserInfo(user, code.Scopes)
+	idClaims := &auth.IDClaims{
+		Nonce:             code.Nonce,
+		Name:              info.Name,
+		PreferredUsername: info.PreferredUsername,
+		Email:             info.Email,
+		EmailVerified:     info.EmailVerified,
+	}
+	idClaims.Subject = info.Subject
+	idClaims.Audience = jwt.ClaimStrings{client.ID}
+	idToken, err := o.issuer.IssueIDToken(idClaims)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase Exchange")
+	}
+
+	return &model.OIDCTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   tokenTypeBearer,
+		ExpiresIn:   int(o.issuer.TTL().Seconds()),
+		IDToken:     idToken,
+		Scope:       scope,
+	}, nil
+}
+
+// UserInfo отдаёт данные пользователя по access-токену приложения.
+// После отзыва согласия токен перестаёт работать сразу, не дожидаясь exp.
+func (o *OIDCCase) UserInfo(c *gin.Context, accessToken string) (*model.UserInfo, error) {
+	claims, err := o.issuer.Parse(accessToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase UserInfo")
+	}
+	if claims.Purpose != auth.PurposeOIDC || len(claims.Audience) != 1 {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "not an oidc access token")
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase UserInfo")
+	}
+	organizationID, err := claims.OrganizationID()
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase UserInfo")
+	}
+	enterTenant(c, organizationID)
+
+	if _, err := o.oidcRepo.GetConsent(c, userID, claims.Audience[0]); err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, errors.Wrap(apperr.ErrInvalidToken, "consent revoked")
+		}
+		return nil, errors.Wrap(err, "usecase UserInfo")
+	}
+
+	user, err := o.userRepo.GetUser(c, userID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, errors.Wrap(apperr.ErrInvalidToken, "user not found")
+		}
+		return nil, errors.Wrap(err, "usecase UserInfo")
+	}
+
+	return newUserInfo(user, strings.Fields(claims.OAuthScope)), nil
+}
+
+func (o *OIDCCase) ListConsents(c *gin.Context, userID uuid.UUID) ([]model.OAuthConsent, error) {
+	consents, err := o.oidcRepo.ListUserConsents(c, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase ListConsents")
+	}
+	return consents, nil
+}
+
+func (o *OIDCCase) RevokeConsent(c *gin.Context, userID uuid.UUID, clientID string) error {
+	if err := o.oidcRepo.DeleteConsent(c, userID, clientID); err != nil {
+		return errors.Wrap(err, "usecase RevokeConsent")
+	}
+	return nil
+}
+
+func (o *OIDCCase) Discovery() model.OIDCDiscovery {
+	base := strings.TrimSuffix(o.issuer.Name(), "/")
+
+	algs := make([]string, 0)
+	for _, key := range o.issuer.Keys().Keys() {
+		if !slices.Contains(algs, key.Method.Alg()) {
+			algs = append(algs, key.Method.Alg())
+		}
+	}
+
+	return model.OIDCDiscovery{
+		Issuer:                            o.issuer.Name(),
+		AuthorizationEndpoint:             base + model.OIDCAuthorizePath,
+		TokenEndpoint:                     base + model.OIDCTokenPath,
+		UserinfoEndpoint:                  base + model.OIDCUserInfoPath,
+		JWKSURI:                           base + model.OIDCJWKSPath,
+		ResponseTypesSupported:            []string{responseTypeCode},
+		GrantTypesSupported:               []string{grantTypeAuthCode},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  algs,
+		ScopesSupported:                   oidcScopes,
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{codeChallengeMethodS256},
+		ClaimsSupported:                   []string{"sub", "name", "preferred_username", "email", "email_verified"},
+	}
+}
+
+func (o *OIDCCase) JWKS() auth.JWKS {
+	return o.issuer.Keys().JWKS()
+}
+
+func (o *OIDCCase) authenticateClient(c *gin.Context, clientID string, secret string) (*model.OAuthClient, error) {
+	client, err := o.oidcRepo.GetClient(c, clientID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, &apperr.OAuthError{Code: apperr.OAuthInvalidClient, Description: "unknown client"}
+		}
+		return nil, errors.Wrap(err, "usecase authenticate client")
+	}
+
+	if !client.Public {
+		hash := auth.HashOpaqueToken(secret)
+		if secret == "" || subtle.ConstantTimeCompare([]byte(hash), []byte(client.SecretHash)) != 1 {
+			return nil, &apperr.OAuthError{Code: apperr.OAuthInvalidClient, Description: "client authentication failed"}
+		}
+	}
+	return client, nil
+}
+
+func newUserInfo(user *model.User, scopes []string) *model.UserInfo {
+	info := &model.UserInfo{Subject: user.ID.String()}
+	if slices.Contains(scopes, model.OIDCScopeProfile) {
+		info.Name = user.Name
+		info.PreferredUsername = user.Login
+	}
+	if slices.Contains(scopes, model.OIDCScopeEmail) && user.Email != "" {
+		verified := user.EmailVerified()
+		info.Email = user.Email
+		info.EmailVerified = &verified
+	}
+	return info
+}
+
+func parseOIDCScope(scope string) ([]string, bool) {
+	scopes := make([]string, 0, len(oidcScopes))
+	for _, s := range strings.Fields(scope) {
+		if !slices.Contains(oidcScopes, s) {
+			return nil, false
+		}
+		if !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes, slices.Contains(scopes, model.OIDCScopeOpenID)
+}
+
+func containsAll(granted []string, requested []string) bool {
+	for _, s := range requested {
+		if !slices.Contains(granted, s) {
+			return false
+		}
+	}
+	return true
+}
+
+func mergeScopes(granted []string, requested []string) []string {
+	merged := slices.Clone(granted)
+	for _, s := range requested {
+		if !slices.Contains(merged, s) {
+			merged = append(merged, s)
+		}
+	}
+	return merged
+}
+
+// validPKCE проверяет длину и алфавит code_verifier и code_challenge (RFC 7636, 4.1).
+func validPKCE(value string) bool {
+	if len(value) < pkceMinLength || len(value) > pkceMaxLength {
+		return false
+	}
+	return strings.IndexFunc(value, func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' ||
+			r == '-' || r == '.' || r == '_' || r == '~')
+	}) == -1
+}
+
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// withQuery добавляет к адресу параметры, пустые значения пропускаются.
+func withQuery(rawURL string, params map[string]string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	query := u.Query()
+	for key, value := range params {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
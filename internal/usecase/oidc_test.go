@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOIDCCase(t *testing.T, oidcRepo *mocks.MockOIDCProvider, userRepo *mocks.MockUserProvider) *OIDCCase {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := auth.NewKey("test", private)
+	require.NoError(t, err)
+	keys, err := auth.NewKeySet("test", key)
+	require.NoError(t, err)
+
+	return NewOIDCProvider(oidcRepo, userRepo, auth.NewIssuer(keys, "https://lk.example.com", time.Minute), time.Minute)
+}
+
+func TestOIDCCase_Authorize(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	oidcRepo := mocks.NewMockOIDCProvider(ctrl)
+	uc := newTestOIDCCase(t, oidcRepo, mocks.NewMockUserProvider(ctrl))
+
+	client := &model.OAuthClient{ID: "wiki", Name: "Wiki", RedirectURIs: []string{"https://wiki.example.com/cb"}}
+	userID := uuid.New()
+	valid := model.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            client.ID,
+		RedirectURI:         "https://wiki.example.com/cb",
+		Scope:               "openid email",
+		State:               "xyz",
+		CodeChallenge:       pkceChallenge(strings.Repeat("v", pkceMinLength)),
+		CodeChallengeMethod: "S256",
+	}
+
+	// Кейс 1: неизвестный клиент - ошибка вызывающему, без редиректа
+	oidcRepo.EXPECT().GetClient(gomock.Any(), "unknown").Return(nil, errors.Wrap(apperr.ErrNotFound, "client not found"))
+
+	req := valid
+	req.ClientID = "unknown"
+	_, err := uc.Authorize(newTestContext(), userID, req)
+	var oauthErr *apperr.OAuthError
+	require.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, apperr.OAuthInvalidClient, oauthErr.Code)
+
+	// Кейс 2: незарегистрированный redirect_uri - тоже без редиректа
+	oidcRepo.EXPECT().GetClient(gomock.Any(), client.ID).Return(client, nil)
+
+	req = valid
+	req.RedirectURI = "https://evil.example.com/cb"
+	_, err = uc.Authorize(newTestContext(), userID, req)
+	require.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, apperr.OAuthInvalidRequest, oauthErr.Code)
+
+	// Кейс 3: без PKCE ошибка уходит в приложение вместе со state
+	oidcRepo.EXPECT().GetClient(gomock.Any(), client.ID).Return(client, nil)
+
+	req = valid
+	req.CodeChallengeMethod = "plain"
+	result, err := uc.Authorize(newTestContext(), userID, req)
+	require.NoError(t, err)
+	redirect, err := url.Parse(result.RedirectTo)
+	require.NoError(t, err)
+	assert.Equal(t, apperr.OAuthInvalidRequest, redirect.Query().Get("error"))
+	assert.Equal(t, "xyz", redirect.Query().Get("state"))
+
+	// Кейс 4: scope без openid
+	oidcRepo.EXPECT().GetClient(gomock.Any(), client.ID).Return(client, nil)
+
+	req = valid
+	req.Scope = "email"
+	result, err = uc.Authorize(newTestContext(), userID, req)
+	require.NoError(t, err)
+	assert.Contains(t, result.RedirectTo, "error="+apperr.OAuthInvalidScope)
+
+	// Кейс 5: пользователь отказал
+	oidcRepo.EXPECT().GetClient(gomock.Any(), client.ID).Return(client, nil)
+
+	req = valid
+	req.Consent = "denied"
+	result, err = uc.Authorize(newTestContext(), userID, req)
+	require.NoError(t, err)
+	assert.Contains(t, result.RedirectTo, "error="+apperr.OAuthAccessDenied)
+
+	// Кейс 6: согласие дано на меньший набор прав - спрашиваем снова
+	oidcRepo.EXPECT().GetClient(gomock.Any(), client.ID).Return(client, nil)
+	oidcRepo.EXPECT().GetConsent(gomock.Any(), userID, client.ID).
+		Return(&model.OAuthConsent{Scopes: []string{"openid"}}, nil)
+
+	result, err = uc.Authorize(newTestContext(), userID, valid)
+	require.NoError(t, err)
+	require.NotNil(t, result.Consent)
+	assert.Equal(t, []string{"openid", "email"}, result.Consent.Scopes)
+
+	// Кейс 7: расширенное согласие сохраняется вместе с прежними правами
+	oidcRepo.EXPECT().GetClient(gomock.Any(), client.ID).Return(client, nil)
+	oidcRepo.EXPECT().GetConsent(gomock.Any(), userID, client.ID).
+		Return(&model.OAuthConsent{Scopes: []string{"openid", "profile"}}, nil)
+	oidcRepo.EXPECT().SaveConsent(gomock.Any(), model.OAuthConsent{
+		UserID: userID, ClientID: client.ID, Scopes: []string{"openid", "profile", "email"},
+	}).Return(nil)
+	oidcRepo.EXPECT().AddAuthorizationCode(gomock.Any(), gomock.Any()).Return(nil)
+
+	req = valid
+	req.Consent = "granted"
+	result, err = uc.Authorize(newTestContext(), userID, req)
+	require.NoError(t, err)
+	redirect, err = url.Parse(result.RedirectTo)
+	require.NoError(t, err)
+	assert.NotEmpty(t, redirect.Query().Get("code"))
+	assert.Equal(t, "xyz", redirect.Query().Get("state"))
+}
+
+func TestOIDCCase_Exchange(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	oidcRepo := mocks.NewMockOIDCProvider(ctrl)
+	uc := newTestOIDCCase(t, oidcRepo, mocks.NewMockUserProvider(ctrl))
+
+	client := &model.OAuthClient{ID: "wiki", SecretHash: auth.HashOpaqueToken("secret")}
+	verifier := strings.Repeat("v", pkceMinLength)
+	req := model.TokenRequest{
+		GrantType:    "authorization_code",
+		Code:         "code",
+		RedirectURI:  "https://wiki.example.com/cb",
+		ClientID:     client.ID,
+		ClientSecret: "secret",
+		CodeVerifier: verifier,
+	}
+
+	// Кейс 1: неверный секрет клиента
+	oidcRepo.EXPECT().GetClient(gomock.Any(), client.ID).Return(client, nil)
+
+	wrongSecret := req
+	wrongSecret.ClientSecret = "wrong"
+	_, err := uc.Exchange(newTestContext(), wrongSecret)
+	var oauthErr *apperr.OAuthError
+	require.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, apperr.OAuthInvalidClient, oauthErr.Code)
+
+	// Кейс 2: code_verifier не подходит к code_challenge
+	oidcRepo.EXPECT().GetClient(gomock.Any(), client.ID).Return(client, nil)
+	oidcRepo.EXPECT().ConsumeAuthorizationCode(gomock.Any(), auth.HashOpaqueToken("code")).
+		Return(&model.AuthorizationCode{
+			ClientID:      client.ID,
+			RedirectURI:   req.RedirectURI,
+			CodeChallenge: pkceChallenge(strings.Repeat("x", pkceMinLength)),
+		}, nil)
+
+	_, err = uc.Exchange(newTestContext(), req)
+	require.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, apperr.OAuthInvalidGrant, oauthErr.Code)
+
+	// Кейс 3: код выдан на другой redirect_uri
+	oidcRepo.EXPECT().GetClient(gomock.Any(), client.ID).Return(client, nil)
+	oidcRepo.EXPECT().ConsumeAuthorizationCode(gomock.Any(), auth.HashOpaqueToken("code")).
+		Return(&model.AuthorizationCode{
+			ClientID:      client.ID,
+			RedirectURI:   "https://wiki.example.com/other",
+			CodeChallenge: pkceChallenge(verifier),
+		}, nil)
+
+	_, err = uc.Exchange(newTestContext(), req)
+	require.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, apperr.OAuthInvalidGrant, oauthErr.Code)
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/notifier"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const notificationTimeout = 30 * time.Second
+
+// issueOneTimeToken гасит прежние токены пользователя с той же целью и выдаёт новый.
+// В БД сохраняется только хэш, сам токен уходит в письме.
+func issueOneTimeToken(ctx context.Context,
+	tokenRepo repository.OneTimeTokenProvider,
+	userID uuid.UUID,
+	purpose string,
+	ttl time.Duration) (string, error) {
+	if err := tokenRepo.InvalidateUserTokens(ctx, userID, purpose); err != nil {
+		return "", err
+	}
+
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", errors.Wrap(err, "generate token id")
+	}
+
+	err = tokenRepo.AddToken(ctx, model.OneTimeToken{
+		ID:        id,
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// sendInBackground отправляет письмо, не задерживая ответ на запрос.
+func sendInBackground(n notifier.Notifier, msg notifier.Message, userID uuid.UUID) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), notificationTimeout)
+		defer cancel()
+		if err := n.Send(ctx, msg); err != nil {
+			logger.Error("failed to send notification",
+				zap.String("userID", userID.String()),
+				zap.String("subject", msg.Subject),
+				zap.Error(err),
+			)
+		}
+	}()
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// Slug передаётся в заголовке X-Organization и в ссылках
+var organizationSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+type OrganizationProvider interface {
+	ListOrganizations(*gin.Context) ([]model.Organization, error)
+	CreateOrganization(*gin.Context, model.Organization) (*model.Organization, error)
+	OrganizationIDBySlug(context.Context, string) (uuid.UUID, error)
+}
+
+type OrganizationCase struct {
+	organizationRepo repository.OrganizationProvider
+}
+
+func NewOrganizationProvider(organizationRepo repository.OrganizationProvider) *OrganizationCase {
+	return &OrganizationCase{
+		organizationRepo: organizationRepo,
+	}
+}
+
+func (o *OrganizationCase) ListOrganizations(c *gin.Context) ([]model.Organization, error) {
+	organizations, err := o.organizationRepo.ListOrganizations(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase ListOrganizations")
+	}
+	return organizations, nil
+}
+
+func (o *OrganizationCase) CreateOrganization(c *gin.Context,
+	organization model.Organization) (*model.Organization, error) {
+	if !organizationSlugPattern.MatchString(organization.Slug) {
+		return nil, &apperr.ValidationError{Fields: []apperr.FieldError{{
+			Field:   "slug",
+			Code:    "format",
+			Message: "must start with a lowercase letter or digit and contain only a-z, 0-9 and -",
+		}}}
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase CreateOrganization")
+	}
+	organization.ID = id
+	if err := o.organizationRepo.AddOrganization(c, organization); err != nil {
+		return nil, errors.Wrap(err, "usecase CreateOrganization")
+	}
+
+	created, err := o.organizationRepo.GetOrganizationBySlug(c, organization.Slug)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase CreateOrganization")
+	}
+	return created, nil
+}
+
+func (o *OrganizationCase) OrganizationIDBySlug(ctx context.Context, slug string) (uuid.UUID, error) {
+	organization, err := o.organizationRepo.GetOrganizationBySlug(ctx, slug)
+	if err != nil {
+		return uuid.Nil, errors.Wrap(err, "usecase OrganizationIDBySlug")
+	}
+	return organization.ID, nil
+}
+
+// openSignup разрешает самостоятельную регистрацию только в организации по умолчанию.
+// В остальные организации попадают по приглашению, иначе любой стал бы их участником.
+func openSignup(ctx context.Context) error {
+	organizationID, ok := tenant.FromContext(ctx)
+	if !ok || organizationID != tenant.DefaultID {
+		return errors.Wrap(apperr.ErrForbidden, "organization accepts members by invitation only")
+	}
+	return nil
+}
+
+// userTenant возвращает контекст организации пользователя. Нужен входам по секрету
+// (refresh-токен, ссылка из письма): организацию запроса задаёт пользователь, на
+// которого указывает проверенный секрет, а не заголовок.
+func userTenant(ctx context.Context, userRepo repository.UserProvider, userID uuid.UUID) (context.Context, error) {
+	organizationID, err := userRepo.GetUserOrganizationID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return tenant.WithID(ctx, organizationID), nil
+}
+
+// enterUserTenant переключает запрос в организацию пользователя, см. userTenant.
+func enterUserTenant(c *gin.Context, userRepo repository.UserProvider, userID uuid.UUID) error {
+	organizationID, err := userRepo.GetUserOrganizationID(c, userID)
+	if err != nil {
+		return err
+	}
+	enterTenant(c, organizationID)
+	return nil
+}
+
+// enterTenant переключает запрос в организацию из подписанного токена.
+func enterTenant(c *gin.Context, organizationID uuid.UUID) {
+	c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), organizationID))
+}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrganizationCase_CreateOrganization(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	organizationRepo := mocks.NewMockOrganizationProvider(ctrl)
+	uc := NewOrganizationProvider(organizationRepo)
+	c := newTestContext()
+
+	// Кейс 1: slug должен годиться для заголовка и ссылки
+	_, err := uc.CreateOrganization(c, model.Organization{Slug: "Acme Corp", Name: "Acme"})
+	require.ErrorIs(t, err, apperr.ErrValidation)
+
+	// Кейс 2: успешное создание, идентификатор назначает сервис
+	var added model.Organization
+	organizationRepo.EXPECT().AddOrganization(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ any, organization model.Organization) error {
+			added = organization
+			return nil
+		})
+	organizationRepo.EXPECT().GetOrganizationBySlug(gomock.Any(), "acme").
+		DoAndReturn(func(_ any, _ string) (*model.Organization, error) { return &added, nil })
+	created, err := uc.CreateOrganization(c, model.Organization{ID: tenant.DefaultID, Slug: "acme", Name: "Acme"})
+	require.NoError(t, err)
+	assert.NotEqual(t, tenant.DefaultID, created.ID)
+	assert.Equal(t, "acme", created.Slug)
+
+	// Кейс 3: slug занят
+	organizationRepo.EXPECT().AddOrganization(gomock.Any(), gomock.Any()).Return(apperr.ErrAlreadyExists)
+	_, err = uc.CreateOrganization(c, model.Organization{Slug: "acme", Name: "Acme"})
+	require.ErrorIs(t, err, apperr.ErrAlreadyExists)
+}
+
+func TestUserTenant(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	userID, organizationID := uuid.New(), uuid.New()
+
+	// Кейс 1: организация берётся у пользователя, а не из запроса
+	userRepo.EXPECT().GetUserOrganizationID(gomock.Any(), userID).Return(organizationID, nil)
+	c := newTestContext()
+	require.NoError(t, enterUserTenant(c, userRepo, userID))
+	got, ok := tenant.FromContext(c)
+	require.True(t, ok)
+	assert.Equal(t, organizationID, got)
+
+	// Кейс 2: пользователь удалён - организации нет
+	userRepo.EXPECT().GetUserOrganizationID(gomock.Any(), userID).Return(uuid.Nil, apperr.ErrNotFound)
+	_, err := userTenant(context.Background(), userRepo, userID)
+	require.ErrorIs(t, err, apperr.ErrNotFound)
+}
@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/cursor"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultPageSize = 50
+	// Поиск отдаёт только лучшие совпадения, без продолжения
+	defaultSearchSize = 20
+)
+
+// pageCursor - содержимое курсора: позиция на странице и отпечаток
+// фильтров, с которыми она получена. С другими фильтрами курсор не принимается.
+type pageCursor[P any] struct {
+	Position P      `json:"p"`
+	Query    string `json:"q"`
+}
+
+func encodeCursor[P any](codec *cursor.Codec, position P, query any) (string, error) {
+	fingerprint, err := queryFingerprint(query)
+	if err != nil {
+		return "", err
+	}
+	return codec.Encode(pageCursor[P]{Position: position, Query: fingerprint})
+}
+
+func decodeCursor[P any](codec *cursor.Codec, value string, query any) (*P, error) {
+	var decoded pageCursor[P]
+	if err := codec.Decode(value, &decoded); err != nil {
+		return nil, invalidCursorError("cursor is invalid or was issued by another server")
+	}
+
+	fingerprint, err := queryFingerprint(query)
+	if err != nil {
+		return nil, err
+	}
+	if decoded.Query != fingerprint {
+		return nil, invalidCursorError("cursor was issued for other filters or sorting")
+	}
+	return &decoded.Position, nil
+}
+
+func queryFingerprint(query any) (string, error) {
+	data, err := json.Marshal(query)
+	if err != nil {
+		return "", errors.Wrap(err, "cursor query fingerprint")
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
+
+func invalidCursorError(message string) error {
+	return &apperr.ValidationError{Fields: []apperr.FieldError{{
+		Field:   "cursor",
+		Code:    "invalid",
+		Message: message,
+	}}}
+}
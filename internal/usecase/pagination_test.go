@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserCase_ListUsers(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	uc := NewUserProvider(userRepo, nil, nil, newTestHasher(t), newTestPolicy(), nil, &fakeAuditRecorder{}, nil,
+		newTestCursorCodec(t), 0, false)
+	c := newTestContext()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	users := []model.User{
+		{ID: uuid.New(), Login: "anna", Name: "Anna", Age: 30, Password: "hash", CreatedAt: now},
+		{ID: uuid.New(), Login: "andrey", Name: "Andrey", Age: 25, Password: "hash", CreatedAt: now},
+		{ID: uuid.New(), Login: "arseny", Name: "Arseny", Age: 40, Password: "hash", CreatedAt: now},
+	}
+	filter := model.UserFilter{NamePrefix: "A", MinAge: 18}
+	sort := model.ParseUserSort("name")
+
+	// Кейс 1: первая страница запрашивает на одну запись больше
+	userRepo.EXPECT().ListUsers(gomock.Any(), filter, sort, nil, 3).Return(users, nil)
+	page, err := uc.ListUsers(c, filter, sort, "", 2)
+	require.NoError(t, err)
+	require.Len(t, page.Users, 2)
+	assert.Equal(t, users[0].ID, page.Users[0].ID)
+	require.NotEmpty(t, page.NextCursor)
+
+	// Кейс 2: курсор продолжает с последнего пользователя страницы
+	userRepo.EXPECT().ListUsers(gomock.Any(), filter, sort, gomock.Any(), 3).
+		DoAndReturn(func(_ any, _ model.UserFilter, _ model.UserSort, after *model.UserPosition,
+			_ int) ([]model.User, error) {
+			require.NotNil(t, after)
+			assert.Equal(t, users[1].ID, after.ID)
+			assert.Equal(t, users[1].Name, after.Name)
+			return users[2:], nil
+		})
+	next, err := uc.ListUsers(c, filter, sort, page.NextCursor, 2)
+	require.NoError(t, err)
+	assert.Len(t, next.Users, 1)
+	assert.Empty(t, next.NextCursor)
+
+	// Кейс 3: курсор не подходит к другой сортировке
+	_, err = uc.ListUsers(c, filter, model.ParseUserSort("-age"), page.NextCursor, 2)
+	var validationErr *apperr.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "cursor", validationErr.Fields[0].Field)
+
+	// Кейс 4: подделанный курсор
+	_, err = uc.ListUsers(c, filter, sort, page.NextCursor+"x", 2)
+	require.ErrorAs(t, err, &validationErr)
+
+	// Кейс 5: размер страницы по умолчанию
+	userRepo.EXPECT().ListUsers(gomock.Any(), model.UserFilter{}, sort, nil, defaultPageSize+1).Return(nil, nil)
+	page, err = uc.ListUsers(c, model.UserFilter{}, sort, "", 0)
+	require.NoError(t, err)
+	assert.Empty(t, page.Users)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestUserCase_SearchUsers(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	uc := NewUserProvider(userRepo, nil, nil, newTestHasher(t), newTestPolicy(), nil, &fakeAuditRecorder{}, nil,
+		newTestCursorCodec(t), 0, false)
+	c := newTestContext()
+
+	results := []model.UserSearchResult{
+		{UserSummary: model.UserSummary{ID: uuid.New(), Login: "ivanov"}, Score: 1},
+		{UserSummary: model.UserSummary{ID: uuid.New(), Login: "ivanova"}, Score: 0.8},
+	}
+
+	// Кейс 1: без лимита используется размер по умолчанию
+	userRepo.EXPECT().SearchUsers(gomock.Any(), "ivanov", defaultSearchSize).Return(results, nil)
+	found, err := uc.SearchUsers(c, "ivanov", 0)
+	require.NoError(t, err)
+	assert.Equal(t, results, found)
+
+	// Кейс 2: ошибка репозитория
+	userRepo.EXPECT().SearchUsers(gomock.Any(), "ivna", 5).Return(nil, assert.AnError)
+	_, err = uc.SearchUsers(c, "ivna", 5)
+	require.ErrorIs(t, err, assert.AnError)
+}
@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/hasher"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/notifier"
+	"github.com/lemavisaitov/lk-api/internal/passpolicy"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type PasswordResetProvider interface {
+	RequestPasswordReset(*gin.Context, string) error
+	ResetPassword(*gin.Context, model.ResetPasswordRequest) error
+}
+
+type PasswordResetCase struct {
+	userRepo    repository.UserProvider
+	tokenRepo   repository.OneTimeTokenProvider
+	sessionRepo repository.SessionProvider
+	hasher      hasher.PasswordHasher
+	policy      *passpolicy.Checker
+	notifier    notifier.Notifier
+	ttl         time.Duration
+	resetURL    string
+}
+
+func NewPasswordResetProvider(userRepo repository.UserProvider,
+	tokenRepo repository.OneTimeTokenProvider,
+	sessionRepo repository.SessionProvider,
+	passwordHasher hasher.PasswordHasher,
+	policy *passpolicy.Checker,
+	notify notifier.Notifier,
+	ttl time.Duration,
+	resetURL string) *PasswordResetCase {
+	return &PasswordResetCase{
+		userRepo:    userRepo,
+		tokenRepo:   tokenRepo,
+		sessionRepo: sessionRepo,
+		hasher:      passwordHasher,
+		policy:      policy,
+		notifier:    notify,
+		ttl:         ttl,
+		resetURL:    resetURL,
+	}
+}
+
+// RequestPasswordReset выдаёт новый токен сброса и отправляет ссылку.
+// Для несуществующего логина молча ничего не делает, а письмо уходит
+// в фоне, чтобы ни ответ, ни время ответа не выдавали наличие аккаунта.
+func (p *PasswordResetCase) RequestPasswordReset(c *gin.Context, login string) error {
+	id, err := p.userRepo.GetUserIDByLogin(c, login)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil
+		}
+		return errors.Wrap(err, "usecase RequestPasswordReset")
+	}
+
+	user, err := p.userRepo.GetUser(c, *id)
+	if err != nil {
+		return errors.Wrap(err, "usecase RequestPasswordReset")
+	}
+
+	// Без почты ссылку отправить некуда, но ответ остаётся тем же
+	if user.Email == "" {
+		logger.Info("password reset requested for user without email",
+			zap.String("userID", user.ID.String()),
+		)
+		return nil
+	}
+
+	token, err := issueOneTimeToken(c, p.tokenRepo, user.ID, model.TokenPurposePasswordReset, p.ttl)
+	if err != nil {
+		return errors.Wrap(err, "usecase RequestPasswordReset")
+	}
+
+	sendInBackground(p.notifier, p.resetMessage(user, token), user.ID)
+	return nil
+}
+
+// ResetPassword меняет пароль по токену из письма и завершает все сессии.
+// Пароль проверяется до того, как токен будет потрачен, чтобы слабый пароль
+// не требовал запрашивать новое письмо.
+func (p *PasswordResetCase) ResetPassword(c *gin.Context, req model.ResetPasswordRequest) error {
+	tokenHash := auth.HashOpaqueToken(req.Token)
+
+	found, err := p.tokenRepo.FindToken(c, model.TokenPurposePasswordReset, tokenHash)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return errors.Wrap(apperr.ErrInvalidToken, "usecase ResetPassword")
+		}
+		return errors.Wrap(err, "usecase ResetPassword")
+	}
+
+	if err := enterUserTenant(c, p.userRepo, found.UserID); err != nil {
+		return errors.Wrap(err, "usecase ResetPassword")
+	}
+	user, err := p.userRepo.GetUser(c, found.UserID)
+	if err != nil {
+		return errors.Wrap(err, "usecase ResetPassword")
+	}
+	if err := p.policy.Validate(c, req.Password, user.Login, user.Name, user.Email); err != nil {
+		return errors.Wrap(err, "usecase ResetPassword")
+	}
+
+	token, err := p.tokenRepo.ConsumeToken(c, model.TokenPurposePasswordReset, tokenHash)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return errors.Wrap(apperr.ErrInvalidToken, "usecase ResetPassword")
+		}
+		return errors.Wrap(err, "usecase ResetPassword")
+	}
+
+	hash, err := p.hasher.Hash(req.Password)
+	if err != nil {
+		return errors.Wrap(err, "usecase ResetPassword hash password")
+	}
+
+	_, err = p.userRepo.UpdateUser(c, model.UpdateUserRequest{
+		ID:       token.UserID,
+		Password: &hash,
+	})
+	if err != nil {
+		return errors.Wrap(err, "usecase ResetPassword")
+	}
+
+	if err := p.sessionRepo.RevokeUserSessions(c, token.UserID, uuid.Nil); err != nil {
+		return errors.Wrap(err, "usecase ResetPassword revoke sessions")
+	}
+
+	return nil
+}
+
+func (p *PasswordResetCase) resetMessage(user *model.User, token string) notifier.Message {
+	link := p.resetURL + "?token=" + url.QueryEscape(token)
+	return notifier.Message{
+		To:      user.Email,
+		Subject: "Сброс пароля",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\n"+
+			"Чтобы задать новый пароль, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %s. Если вы не запрашивали сброс, просто проигнорируйте это письмо.",
+			user.Name, link, p.ttl),
+	}
+}
@@ -0,0 +1,178 @@
+package usecase
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/hasher"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/notifier"
+	"github.com/lemavisaitov/lk-api/internal/passpolicy"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeNotifier struct {
+	sent chan notifier.Message
+}
+
+func (f *fakeNotifier) Send(_ context.Context, msg notifier.Message) error {
+	f.sent <- msg
+	return nil
+}
+
+// newTestContext возвращает контекст запроса в организации по умолчанию, как после middleware.Tenant
+func newTestContext() *gin.Context {
+	c, engine := gin.CreateTestContext(httptest.NewRecorder())
+	engine.ContextWithFallback = true
+	c.Request = httptest.NewRequest("POST", "/", nil)
+	c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), tenant.DefaultID))
+	return c
+}
+
+func newTestHasher(t *testing.T) hasher.PasswordHasher {
+	h, err := hasher.New(hasher.AlgoArgon2id, hasher.Argon2Params{
+		Memory:      1024,
+		Iterations:  1,
+		Parallelism: 1,
+	}, 4)
+	require.NoError(t, err)
+	return h
+}
+
+func newTestPolicy() *passpolicy.Checker {
+	return passpolicy.NewChecker(passpolicy.Policy{MinLength: 8, DisallowUserInfo: true}, nil)
+}
+
+func TestPasswordResetCase_RequestPasswordReset(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	tokenRepo := mocks.NewMockOneTimeTokenProvider(ctrl)
+	sessionRepo := mocks.NewMockSessionProvider(ctrl)
+	notify := &fakeNotifier{sent: make(chan notifier.Message, 1)}
+
+	uc := NewPasswordResetProvider(userRepo, tokenRepo, sessionRepo, newTestHasher(t), newTestPolicy(), notify,
+		time.Hour, "https://lk.example.com/reset")
+
+	// Кейс 1: логина нет - ошибки нет и письмо не отправляется
+	userRepo.EXPECT().
+		GetUserIDByLogin(gomock.Any(), "nobody").
+		Return(nil, errors.Wrap(apperr.ErrNotFound, "login not found"))
+
+	require.NoError(t, uc.RequestPasswordReset(newTestContext(), "nobody"))
+
+	// Кейс 2: логин есть - старые токены гасятся, в БД попадает только хэш
+	user := &model.User{ID: uuid.New(), Login: "john", Name: "John", Email: "john@example.com"}
+	var stored model.OneTimeToken
+
+	userRepo.EXPECT().GetUserIDByLogin(gomock.Any(), user.Login).Return(&user.ID, nil)
+	userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+	tokenRepo.EXPECT().InvalidateUserTokens(gomock.Any(), user.ID, model.TokenPurposePasswordReset).Return(nil)
+	tokenRepo.EXPECT().
+		AddToken(gomock.Any(), gomock.Any()).
+		Do(func(_ context.Context, token model.OneTimeToken) {
+			stored = token
+		}).
+		Return(nil)
+
+	require.NoError(t, uc.RequestPasswordReset(newTestContext(), user.Login))
+
+	var msg notifier.Message
+	select {
+	case msg = <-notify.sent:
+	case <-time.After(time.Second):
+		t.Fatal("reset message was not sent")
+	}
+	assert.Equal(t, user.Email, msg.To)
+
+	link := msg.Body[strings.Index(msg.Body, "https://"):]
+	link = link[:strings.Index(link, "\n")]
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	token := parsed.Query().Get("token")
+
+	assert.Equal(t, auth.HashOpaqueToken(token), stored.TokenHash)
+	assert.NotContains(t, stored.TokenHash, token)
+	assert.Equal(t, model.TokenPurposePasswordReset, stored.Purpose)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, time.Minute)
+
+	// Кейс 3: почта не указана - токен не выдаётся, ответ тот же
+	noEmail := &model.User{ID: uuid.New(), Login: "jane", Name: "Jane"}
+	userRepo.EXPECT().GetUserIDByLogin(gomock.Any(), noEmail.Login).Return(&noEmail.ID, nil)
+	userRepo.EXPECT().GetUser(gomock.Any(), noEmail.ID).Return(noEmail, nil)
+
+	require.NoError(t, uc.RequestPasswordReset(newTestContext(), noEmail.Login))
+	assert.Empty(t, notify.sent)
+}
+
+func TestPasswordResetCase_ResetPassword(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	tokenRepo := mocks.NewMockOneTimeTokenProvider(ctrl)
+	sessionRepo := mocks.NewMockSessionProvider(ctrl)
+	passwordHasher := newTestHasher(t)
+
+	uc := NewPasswordResetProvider(userRepo, tokenRepo, sessionRepo, passwordHasher, newTestPolicy(), &fakeNotifier{},
+		time.Hour, "https://lk.example.com/reset")
+
+	// Кейс 1: неизвестный, истёкший или использованный токен
+	tokenRepo.EXPECT().
+		FindToken(gomock.Any(), model.TokenPurposePasswordReset, auth.HashOpaqueToken("bad")).
+		Return(nil, errors.Wrap(apperr.ErrNotFound, "token not found"))
+
+	err := uc.ResetPassword(newTestContext(), model.ResetPasswordRequest{Token: "bad", Password: "new-password"})
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+
+	userID := uuid.New()
+	user := &model.User{ID: userID, Login: "johndoe", Name: "John"}
+	userRepo.EXPECT().GetUserOrganizationID(gomock.Any(), userID).Return(tenant.DefaultID, nil).Times(2)
+	userRepo.EXPECT().GetUser(gomock.Any(), userID).Return(user, nil).Times(2)
+	tokenRepo.EXPECT().
+		FindToken(gomock.Any(), model.TokenPurposePasswordReset, auth.HashOpaqueToken("good")).
+		Return(&model.OneTimeToken{UserID: userID}, nil).
+		Times(2)
+
+	// Кейс 2: пароль не проходит политику - токен не тратится
+	err = uc.ResetPassword(newTestContext(), model.ResetPasswordRequest{Token: "good", Password: "johndoe1"})
+	var validationErr *apperr.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, passpolicy.CodeUserInfo, validationErr.Fields[0].Code)
+
+	// Кейс 3: пароль сохраняется хэшем, все сессии завершаются
+	tokenRepo.EXPECT().
+		ConsumeToken(gomock.Any(), model.TokenPurposePasswordReset, auth.HashOpaqueToken("good")).
+		Return(&model.OneTimeToken{UserID: userID}, nil)
+	userRepo.EXPECT().
+		UpdateUser(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, req model.UpdateUserRequest) (*uuid.UUID, error) {
+			assert.Equal(t, userID, req.ID)
+			require.NotNil(t, req.Password)
+			ok, err := passwordHasher.Verify("new-password", *req.Password)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			return &userID, nil
+		})
+	sessionRepo.EXPECT().RevokeUserSessions(gomock.Any(), userID, uuid.Nil).Return(nil)
+
+	err = uc.ResetPassword(newTestContext(), model.ResetPasswordRequest{Token: "good", Password: "new-password"})
+	require.NoError(t, err)
+}
@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+type RBACProvider interface {
+	ListRoles(*gin.Context) ([]model.Role, error)
+	GetRole(*gin.Context, string) (*model.Role, error)
+	CreateRole(*gin.Context, model.Role) (*model.Role, error)
+	UpdateRole(*gin.Context, string, model.UpdateRoleRequest) (*model.Role, error)
+	DeleteRole(*gin.Context, string) error
+	AssignUserRole(*gin.Context, uuid.UUID, string) error
+	RevokeUserRole(*gin.Context, uuid.UUID, string) error
+	ListGroups(*gin.Context) ([]model.Group, error)
+	GetGroup(*gin.Context, uuid.UUID) (*model.Group, error)
+	CreateGroup(*gin.Context, model.CreateGroupRequest) (*model.Group, error)
+	DeleteGroup(*gin.Context, uuid.UUID) error
+	ListGroupMembers(*gin.Context, uuid.UUID) ([]uuid.UUID, error)
+	AddGroupMember(*gin.Context, uuid.UUID, uuid.UUID) error
+	RemoveGroupMember(*gin.Context, uuid.UUID, uuid.UUID) error
+	AssignGroupRole(*gin.Context, uuid.UUID, string) error
+	RevokeGroupRole(*gin.Context, uuid.UUID, string) error
+	UserPermissions(context.Context, uuid.UUID) ([]string, error)
+}
+
+// UserInvalidator сбрасывает закэшированного пользователя. Роли из него попадают
+// в токены при обновлении, поэтому после изменения ролей запись должна уйти из кэша.
+type UserInvalidator interface {
+	InvalidateUser(uuid.UUID)
+}
+
+type RBACCase struct {
+	rbacRepo repository.RBACProvider
+	users    UserInvalidator
+}
+
+func NewRBACProvider(rbacRepo repository.RBACProvider, users UserInvalidator) *RBACCase {
+	return &RBACCase{
+		rbacRepo: rbacRepo,
+		users:    users,
+	}
+}
+
+func (r *RBACCase) ListRoles(c *gin.Context) ([]model.Role, error) {
+	roles, err := r.rbacRepo.ListRoles(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase ListRoles")
+	}
+	return roles, nil
+}
+
+func (r *RBACCase) GetRole(c *gin.Context, name string) (*model.Role, error) {
+	role, err := r.rbacRepo.GetRole(c, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase GetRole")
+	}
+	return role, nil
+}
+
+func (r *RBACCase) CreateRole(c *gin.Context, role model.Role) (*model.Role, error) {
+	role.Permissions = normalizePermissions(role.Permissions)
+	if err := r.rbacRepo.AddRole(c, role); err != nil {
+		return nil, errors.Wrap(err, "usecase CreateRole")
+	}
+	return r.GetRole(c, role.Name)
+}
+
+func (r *RBACCase) UpdateRole(c *gin.Context, name string, req model.UpdateRoleRequest) (*model.Role, error) {
+	req.Permissions = normalizePermissions(req.Permissions)
+	if err := r.rbacRepo.UpdateRole(c, name, req); err != nil {
+		return nil, errors.Wrap(err, "usecase UpdateRole")
+	}
+	return r.GetRole(c, name)
+}
+
+// DeleteRole не даёт удалить admin: без неё управлять ролями будет некому.
+func (r *RBACCase) DeleteRole(c *gin.Context, name string) error {
+	if name == model.RoleAdmin {
+		return &apperr.ValidationError{Fields: []apperr.FieldError{{
+			Field:   "name",
+			Code:    "protected",
+			Message: "the admin role cannot be deleted",
+		}}}
+	}
+
+	if err := r.rbacRepo.DeleteRole(c, name); err != nil {
+		return errors.Wrap(err, "usecase DeleteRole")
+	}
+	return nil
+}
+
+func (r *RBACCase) AssignUserRole(c *gin.Context, userID uuid.UUID, role string) error {
+	defer r.users.InvalidateUser(userID)
+	if err := r.rbacRepo.AssignUserRole(c, userID, role); err != nil {
+		return errors.Wrap(err, "usecase AssignUserRole")
+	}
+	return nil
+}
+
+func (r *RBACCase) RevokeUserRole(c *gin.Context, userID uuid.UUID, role string) error {
+	defer r.users.InvalidateUser(userID)
+	if err := r.rbacRepo.RevokeUserRole(c, userID, role); err != nil {
+		return errors.Wrap(err, "usecase RevokeUserRole")
+	}
+	return nil
+}
+
+func (r *RBACCase) ListGroups(c *gin.Context) ([]model.Group, error) {
+	groups, err := r.rbacRepo.ListGroups(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase ListGroups")
+	}
+	return groups, nil
+}
+
+func (r *RBACCase) GetGroup(c *gin.Context, id uuid.UUID) (*model.Group, error) {
+	group, err := r.rbacRepo.GetGroup(c, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase GetGroup")
+	}
+	return group, nil
+}
+
+func (r *RBACCase) CreateGroup(c *gin.Context, req model.CreateGroupRequest) (*model.Group, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase CreateGroup")
+	}
+
+	group := model.Group{
+		ID:          id,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if err := r.rbacRepo.AddGroup(c, group); err != nil {
+		return nil, errors.Wrap(err, "usecase CreateGroup")
+	}
+	return r.GetGroup(c, id)
+}
+
+func (r *RBACCase) DeleteGroup(c *gin.Context, id uuid.UUID) error {
+	if err := r.rbacRepo.DeleteGroup(c, id); err != nil {
+		return errors.Wrap(err, "usecase DeleteGroup")
+	}
+	return nil
+}
+
+func (r *RBACCase) ListGroupMembers(c *gin.Context, groupID uuid.UUID) ([]uuid.UUID, error) {
+	// Пустой список и несуществующая группа должны различаться
+	if _, err := r.rbacRepo.GetGroup(c, groupID); err != nil {
+		return nil, errors.Wrap(err, "usecase ListGroupMembers")
+	}
+
+	members, err := r.rbacRepo.ListGroupMembers(c, groupID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase ListGroupMembers")
+	}
+	return members, nil
+}
+
+func (r *RBACCase) AddGroupMember(c *gin.Context, groupID uuid.UUID, userID uuid.UUID) error {
+	if err := r.rbacRepo.AddGroupMember(c, groupID, userID); err != nil {
+		return errors.Wrap(err, "usecase AddGroupMember")
+	}
+	return nil
+}
+
+func (r *RBACCase) RemoveGroupMember(c *gin.Context, groupID uuid.UUID, userID uuid.UUID) error {
+	if err := r.rbacRepo.RemoveGroupMember(c, groupID, userID); err != nil {
+		return errors.Wrap(err, "usecase RemoveGroupMember")
+	}
+	return nil
+}
+
+func (r *RBACCase) AssignGroupRole(c *gin.Context, groupID uuid.UUID, role string) error {
+	if err := r.rbacRepo.AssignGroupRole(c, groupID, role); err != nil {
+		return errors.Wrap(err, "usecase AssignGroupRole")
+	}
+	return nil
+}
+
+func (r *RBACCase) RevokeGroupRole(c *gin.Context, groupID uuid.UUID, role string) error {
+	if err := r.rbacRepo.RevokeGroupRole(c, groupID, role); err != nil {
+		return errors.Wrap(err, "usecase RevokeGroupRole")
+	}
+	return nil
+}
+
+// UserPermissions возвращает итоговые права пользователя, используется в middleware.
+func (r *RBACCase) UserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	permissions, err := r.rbacRepo.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase UserPermissions")
+	}
+	return permissions, nil
+}
+
+func normalizePermissions(permissions []string) []string {
+	normalized := slices.Clone(permissions)
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUserInvalidator запоминает пользователей, сброшенных из кэша
+type fakeUserInvalidator struct {
+	invalidated []uuid.UUID
+}
+
+func (f *fakeUserInvalidator) InvalidateUser(id uuid.UUID) {
+	f.invalidated = append(f.invalidated, id)
+}
+
+func TestRBACCase_Roles(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rbacRepo := mocks.NewMockRBACProvider(ctrl)
+	uc := NewRBACProvider(rbacRepo, &fakeUserInvalidator{})
+	c := newTestContext()
+
+	// Кейс 1: права сохраняются без повторов
+	rbacRepo.EXPECT().AddRole(gomock.Any(), gomock.Any()).DoAndReturn(func(_ any, role model.Role) error {
+		assert.Equal(t, []string{model.PermissionUsersRead, model.PermissionUsersWrite}, role.Permissions)
+		return nil
+	})
+	rbacRepo.EXPECT().GetRole(gomock.Any(), "editor").Return(&model.Role{Name: "editor"}, nil)
+
+	role, err := uc.CreateRole(c, model.Role{
+		Name:        "editor",
+		Permissions: []string{model.PermissionUsersWrite, model.PermissionUsersRead, model.PermissionUsersWrite},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "editor", role.Name)
+
+	// Кейс 2: роль admin удалить нельзя
+	err = uc.DeleteRole(c, model.RoleAdmin)
+	var validationErr *apperr.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "protected", validationErr.Fields[0].Code)
+
+	// Кейс 3: обычная роль удаляется
+	rbacRepo.EXPECT().DeleteRole(gomock.Any(), "editor").Return(nil)
+	require.NoError(t, uc.DeleteRole(c, "editor"))
+}
+
+func TestRBACCase_ListGroupMembers(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rbacRepo := mocks.NewMockRBACProvider(ctrl)
+	uc := NewRBACProvider(rbacRepo, &fakeUserInvalidator{})
+	c := newTestContext()
+
+	// Кейс 1: несуществующая группа
+	missing := uuid.New()
+	rbacRepo.EXPECT().GetGroup(gomock.Any(), missing).Return(nil, apperr.ErrNotFound)
+	_, err := uc.ListGroupMembers(c, missing)
+	require.ErrorIs(t, err, apperr.ErrNotFound)
+
+	// Кейс 2: пустая группа
+	group := uuid.New()
+	rbacRepo.EXPECT().GetGroup(gomock.Any(), group).Return(&model.Group{ID: group}, nil)
+	rbacRepo.EXPECT().ListGroupMembers(gomock.Any(), group).Return([]uuid.UUID{}, nil)
+	members, err := uc.ListGroupMembers(c, group)
+	require.NoError(t, err)
+	assert.Empty(t, members)
+}
+
+func TestRBACCase_UserRoles(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rbacRepo := mocks.NewMockRBACProvider(ctrl)
+	users := &fakeUserInvalidator{}
+	uc := NewRBACProvider(rbacRepo, users)
+	c := newTestContext()
+	userID := uuid.New()
+
+	// Кейс 1: после назначения роли пользователь уходит из кэша, новый токен получит роль
+	rbacRepo.EXPECT().AssignUserRole(gomock.Any(), userID, model.RoleAdmin).Return(nil)
+	require.NoError(t, uc.AssignUserRole(c, userID, model.RoleAdmin))
+	assert.Equal(t, []uuid.UUID{userID}, users.invalidated)
+
+	// Кейс 2: после отзыва тоже, иначе обновление токена вернёт отозванную роль
+	rbacRepo.EXPECT().RevokeUserRole(gomock.Any(), userID, model.RoleAdmin).Return(nil)
+	require.NoError(t, uc.RevokeUserRole(c, userID, model.RoleAdmin))
+	assert.Equal(t, []uuid.UUID{userID, userID}, users.invalidated)
+}
@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+type SessionProvider interface {
+	ListSessions(*gin.Context, uuid.UUID) ([]model.Session, error)
+	RevokeSession(*gin.Context, uuid.UUID, uuid.UUID) error
+	RevokeAllSessions(*gin.Context, uuid.UUID) error
+}
+
+type SessionCase struct {
+	sessionRepo repository.SessionProvider
+}
+
+func NewSessionProvider(sessionRepo repository.SessionProvider) *SessionCase {
+	return &SessionCase{sessionRepo: sessionRepo}
+}
+
+func (s *SessionCase) ListSessions(c *gin.Context, userID uuid.UUID) ([]model.Session, error) {
+	sessions, err := s.sessionRepo.ListUserSessions(c, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase ListSessions")
+	}
+
+	current := currentSessionID(c, userID)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+	return sessions, nil
+}
+
+func (s *SessionCase) RevokeSession(c *gin.Context, userID uuid.UUID, sessionID uuid.UUID) error {
+	if err := s.sessionRepo.RevokeSession(c, userID, sessionID); err != nil {
+		return errors.Wrap(err, "usecase RevokeSession")
+	}
+	return nil
+}
+
+func (s *SessionCase) RevokeAllSessions(c *gin.Context, userID uuid.UUID) error {
+	if err := s.sessionRepo.RevokeUserSessions(c, userID, uuid.Nil); err != nil {
+		return errors.Wrap(err, "usecase RevokeAllSessions")
+	}
+	return nil
+}
+
+// currentSessionID возвращает сессию из токена запроса, если запрос сделан
+// от имени userID, иначе uuid.Nil.
+func currentSessionID(ctx context.Context, userID uuid.UUID) uuid.UUID {
+	claims, ok := auth.ClaimsFromContext(ctx)
+	if !ok || claims.Subject != userID.String() {
+		return uuid.Nil
+	}
+
+	sessionID, err := claims.SessionID()
+	if err != nil {
+		return uuid.Nil
+	}
+	return sessionID
+}
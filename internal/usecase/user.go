@@ -1,35 +1,134 @@
 package usecase
 
 import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/cursor"
+	"github.com/lemavisaitov/lk-api/internal/hasher"
+	"github.com/lemavisaitov/lk-api/internal/lockout"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+	"github.com/lemavisaitov/lk-api/internal/metrics"
 	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/passpolicy"
 	"github.com/lemavisaitov/lk-api/internal/repository"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 )
 
 type UserProvider interface {
 	AddUser(*gin.Context, model.User) (*uuid.UUID, error)
+	AddExternalUser(*gin.Context, model.User) (*uuid.UUID, error)
+	AddInvitedUser(*gin.Context, model.User, func(context.Context, model.User) error) (*uuid.UUID, error)
 	GetUser(*gin.Context, uuid.UUID) (*model.User, error)
 	GetUserIDByLogin(*gin.Context, string) (*uuid.UUID, error)
 	UpdateUser(*gin.Context, model.UpdateUserRequest) (*uuid.UUID, error)
-	DeleteUser(*gin.Context, uuid.UUID) error
+	DeleteUser(*gin.Context, uuid.UUID, int64) error
+	RestoreUser(*gin.Context, uuid.UUID) error
 	LoginExists(*gin.Context, string) (bool, error)
+	EmailExists(*gin.Context, string) (bool, error)
+	Authenticate(*gin.Context, model.LoginRequest) (*model.User, error)
+	ListUsers(*gin.Context, model.UserFilter, model.UserSort, string, int) (*model.UserPage, error)
+	SearchUsers(*gin.Context, string, int) ([]model.UserSearchResult, error)
 }
 
 type UserCase struct {
-	userRepo repository.UserProvider
+	userRepo    repository.UserProvider
+	sessionRepo repository.SessionProvider
+	tokenRepo   repository.OneTimeTokenProvider
+	hasher      hasher.PasswordHasher
+	policy      *passpolicy.Checker
+	attempts    lockout.Tracker
+	audit       AuditRecorder
+	attributes  AttributeValidator
+	cursors     *cursor.Codec
+	// Сколько после удаления пользователя можно восстановить
+	restorePeriod time.Duration
+	now           func() time.Time
+	// Запрещать вход, пока почта не подтверждена
+	requireVerifiedEmail bool
 }
 
-func NewUserProvider(userRepo repository.UserProvider) *UserCase {
-	return &UserCase{userRepo: userRepo}
+func NewUserProvider(userRepo repository.UserProvider,
+	sessionRepo repository.SessionProvider,
+	tokenRepo repository.OneTimeTokenProvider,
+	passwordHasher hasher.PasswordHasher,
+	policy *passpolicy.Checker,
+	attempts lockout.Tracker,
+	audit AuditRecorder,
+	attributes AttributeValidator,
+	cursors *cursor.Codec,
+	restorePeriod time.Duration,
+	requireVerifiedEmail bool) *UserCase {
+	return &UserCase{
+		userRepo:             userRepo,
+		sessionRepo:          sessionRepo,
+		tokenRepo:            tokenRepo,
+		hasher:               passwordHasher,
+		policy:               policy,
+		attempts:             attempts,
+		audit:                audit,
+		attributes:           attributes,
+		cursors:              cursors,
+		restorePeriod:        restorePeriod,
+		now:                  time.Now,
+		requireVerifiedEmail: requireVerifiedEmail,
+	}
 }
 
 func (u *UserCase) AddUser(c *gin.Context, user model.User) (*uuid.UUID, error) {
-	if err := u.userRepo.AddUser(c, user); err != nil {
+	if err := u.attributes.ValidateAttributes(c, user.Attributes, true); err != nil {
+		return nil, errors.Wrap(err, "usecase AddUser")
+	}
+	return u.addUser(c, user, u.userRepo.AddUser)
+}
+
+// AddExternalUser регистрирует пользователя внешнего провайдера. Провайдер не
+// знает дополнительных атрибутов, поэтому обязательные пользователь заполнит позже.
+func (u *UserCase) AddExternalUser(c *gin.Context, user model.User) (*uuid.UUID, error) {
+	if err := u.attributes.ValidateAttributes(c, user.Attributes, false); err != nil {
+		return nil, errors.Wrap(err, "usecase AddExternalUser")
+	}
+	return u.addUser(c, user, u.userRepo.AddUser)
+}
+
+// AddInvitedUser проверяет и хэширует пароль так же, как AddUser, но сохраняет
+// пользователя через store: приглашение записывает его вместе с принятием.
+func (u *UserCase) AddInvitedUser(c *gin.Context, user model.User,
+	store func(context.Context, model.User) error) (*uuid.UUID, error) {
+	if err := u.attributes.ValidateAttributes(c, user.Attributes, true); err != nil {
+		return nil, errors.Wrap(err, "usecase AddInvitedUser")
+	}
+	return u.addUser(c, user, store)
+}
+
+func (u *UserCase) addUser(c *gin.Context, user model.User,
+	store func(context.Context, model.User) error) (*uuid.UUID, error) {
+	if err := u.policy.Validate(c, user.Password, user.Login, user.Name, user.Email); err != nil {
 		return nil, errors.Wrap(err, "usecase AddUser")
 	}
+
+	hash, err := u.hasher.Hash(user.Password)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase AddUser hash password")
+	}
+	user.Password = hash
+
+	if err := store(c, user); err != nil {
+		return nil, errors.Wrap(err, "usecase AddUser")
+	}
+
+	u.audit.Record(c, model.AuditEvent{
+		Action:   model.AuditUserCreate,
+		TargetID: &user.ID,
+		Changes:  auditDiff(nil, userAuditFields(&user)),
+	})
 	return &user.ID, nil
 }
 
@@ -42,11 +141,76 @@ func (u *UserCase) GetUser(c *gin.Context, userID uuid.UUID) (*model.User, error
 	return user, nil
 }
 
+// UpdateUser меняет только заданные поля запроса. Версия 0 изменяет без проверки версии.
 func (u *UserCase) UpdateUser(c *gin.Context, req model.UpdateUserRequest) (*uuid.UUID, error) {
+	// Прежние значения нужны для журнала аудита и проверки пароля
+	current, err := u.userRepo.GetUser(c, req.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase UpdateUser")
+	}
+
+	emailChanged := req.Email != nil && !strings.EqualFold(*req.Email, current.Email)
+	// Учётные данные за пользователя сотрудник не меняет
+	if claims, ok := auth.ClaimsFromContext(c); ok && claims.Impersonated() && (req.Password != nil || emailChanged) {
+		return nil, errors.Wrap(apperr.ErrForbidden, "usecase UpdateUser credentials while impersonating")
+	}
+
+	updated := *current
+	if req.Name != nil {
+		updated.Name = *req.Name
+	}
+	if req.Age != nil {
+		updated.Age = *req.Age
+	}
+	if req.Email != nil {
+		updated.Email = *req.Email
+	}
+	if req.Attributes != nil {
+		if err := u.attributes.ValidateAttributes(c, req.Attributes, true); err != nil {
+			return nil, errors.Wrap(err, "usecase UpdateUser")
+		}
+		updated.Attributes = req.Attributes
+	}
+
+	if req.Password != nil {
+		// Новые имя и почта из того же запроса тоже не должны попадать в пароль
+		err := u.policy.Validate(c, *req.Password, current.Login, current.Name, current.Email, updated.Name, updated.Email)
+		if err != nil {
+			return nil, errors.Wrap(err, "usecase UpdateUser")
+		}
+
+		hash, err := u.hasher.Hash(*req.Password)
+		if err != nil {
+			return nil, errors.Wrap(err, "usecase UpdateUser hash password")
+		}
+		req.Password = &hash
+		updated.Password = hash
+	}
+
 	id, err := u.userRepo.UpdateUser(c, req)
 	if err != nil {
 		return nil, errors.Wrap(err, "usecase UpdateUser")
 	}
+
+	// После смены пароля остаётся только сессия, из которой его сменили
+	if req.Password != nil {
+		if err := u.sessionRepo.RevokeUserSessions(c, req.ID, currentSessionID(c, req.ID)); err != nil {
+			return nil, errors.Wrap(err, "usecase UpdateUser revoke sessions")
+		}
+	}
+
+	// Ссылки, отправленные на прежнюю почту, больше не должны её подтверждать
+	if emailChanged {
+		if err := u.tokenRepo.InvalidateUserTokens(c, req.ID, model.TokenPurposeEmailVerification); err != nil {
+			return nil, errors.Wrap(err, "usecase UpdateUser invalidate tokens")
+		}
+	}
+
+	u.audit.Record(c, model.AuditEvent{
+		Action:   model.AuditUserUpdate,
+		TargetID: &req.ID,
+		Changes:  auditDiff(userAuditFields(current), userAuditFields(&updated)),
+	})
 	return id, nil
 }
 
@@ -59,18 +223,277 @@ func (u *UserCase) GetUserIDByLogin(c *gin.Context, login string) (*uuid.UUID, e
 	return id, nil
 }
 
-func (u *UserCase) DeleteUser(c *gin.Context, userID uuid.UUID) error {
-	if err := u.userRepo.DeleteUser(c, userID); err != nil {
+// DeleteUser помечает пользователя удалённым и завершает его сессии.
+// Окончательно данные стирает фоновая очистка. Версия 0 удаляет без проверки версии.
+func (u *UserCase) DeleteUser(c *gin.Context, userID uuid.UUID, version int64) error {
+	current, err := u.userRepo.GetUser(c, userID)
+	if err != nil {
 		return errors.Wrap(err, "usecase DeleteUser")
 	}
+	if err := u.userRepo.DeleteUser(c, userID, version); err != nil {
+		return errors.Wrap(err, "usecase DeleteUser")
+	}
+	// Сессии удалённого пользователя не исчезают каскадом, поэтому отзываются явно
+	if err := u.sessionRepo.RevokeUserSessions(c, userID, uuid.Nil); err != nil {
+		return errors.Wrap(err, "usecase DeleteUser")
+	}
+
+	u.audit.Record(c, model.AuditEvent{
+		Action:   model.AuditUserDelete,
+		TargetID: &userID,
+		Changes:  auditDiff(userAuditFields(current), nil),
+	})
+	return nil
+}
+
+// RestoreUser возвращает пользователя, удалённого не раньше restorePeriod назад.
+// Сессии не восстанавливаются, войти нужно заново.
+func (u *UserCase) RestoreUser(c *gin.Context, userID uuid.UUID) error {
+	if err := u.userRepo.RestoreUser(c, userID, u.now().Add(-u.restorePeriod)); err != nil {
+		return errors.Wrap(err, "usecase RestoreUser")
+	}
+
+	u.audit.Record(c, model.AuditEvent{
+		Action:   model.AuditUserRestore,
+		TargetID: &userID,
+	})
 	return nil
 }
 
+// ListUsers возвращает страницу пользователей. Пустой pageCursor означает
+// первую страницу, курсор действителен только с теми же фильтрами и сортировкой.
+func (u *UserCase) ListUsers(c *gin.Context, filter model.UserFilter, sort model.UserSort, pageCursor string,
+	limit int) (*model.UserPage, error) {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	query := struct {
+		Filter model.UserFilter `json:"filter"`
+		Sort   model.UserSort   `json:"sort"`
+	}{filter, sort}
+
+	var after *model.UserPosition
+	if pageCursor != "" {
+		position, err := decodeCursor[model.UserPosition](u.cursors, pageCursor, query)
+		if err != nil {
+			return nil, err
+		}
+		after = position
+	}
+
+	// Лишняя запись показывает, есть ли следующая страница
+	users, err := u.userRepo.ListUsers(c, filter, sort, after, limit+1)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase ListUsers")
+	}
+
+	page := &model.UserPage{Users: make([]model.UserSummary, 0, min(len(users), limit))}
+	for _, user := range users[:min(len(users), limit)] {
+		page.Users = append(page.Users, model.UserSummary{
+			ID:              user.ID,
+			Login:           user.Login,
+			Name:            user.Name,
+			Age:             user.Age,
+			Email:           user.Email,
+			EmailVerifiedAt: user.EmailVerifiedAt,
+			CreatedAt:       user.CreatedAt,
+			Attributes:      user.Attributes,
+		})
+	}
+	if len(users) > limit {
+		last := users[limit-1]
+		page.NextCursor, err = encodeCursor(u.cursors, model.UserPosition{
+			ID:        last.ID,
+			Login:     last.Login,
+			Name:      last.Name,
+			Age:       last.Age,
+			CreatedAt: last.CreatedAt,
+		}, query)
+		if err != nil {
+			return nil, errors.Wrap(err, "usecase ListUsers")
+		}
+	}
+	return page, nil
+}
+
+func (u *UserCase) SearchUsers(c *gin.Context, text string, limit int) ([]model.UserSearchResult, error) {
+	if limit <= 0 {
+		limit = defaultSearchSize
+	}
+
+	results, err := u.userRepo.SearchUsers(c, text, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase SearchUsers")
+	}
+	return results, nil
+}
+
+// LoginExists учитывает и удалённых пользователей: их логины заняты до очистки.
 func (u *UserCase) LoginExists(c *gin.Context, login string) (bool, error) {
-	_, err := u.userRepo.GetUserIDByLogin(c, login)
+	exists, err := u.userRepo.LoginExists(c, login)
 	if err != nil {
 		return false, errors.Wrap(err, "usecase LoginExists")
 	}
 
+	return exists, nil
+}
+
+func (u *UserCase) EmailExists(c *gin.Context, email string) (bool, error) {
+	_, err := u.userRepo.GetUserIDByEmail(c, email)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "usecase EmailExists")
+	}
+
 	return true, nil
 }
+
+// Authenticate проверяет пароль и, если хэш устарел (другой алгоритм,
+// другие параметры или пароль ещё хранится открытым текстом), перехэширует его.
+// Неудачные попытки учитываются по логину и IP, при переборе вход блокируется.
+func (u *UserCase) Authenticate(c *gin.Context, req model.LoginRequest) (*model.User, error) {
+	decision, err := u.attempts.Check(c, req.Login, c.ClientIP())
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase Authenticate check attempts")
+	}
+	if !decision.Allowed() {
+		reason := "throttled"
+		if decision.Locked {
+			reason = "locked"
+		}
+		u.auditLoginFailure(c, req.Login, nil, reason)
+		return nil, rejectAttempt(decision)
+	}
+
+	user, err := u.verifyPassword(c, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, apperr.ErrNotFound):
+			u.recordFailure(c, req.Login)
+			u.auditLoginFailure(c, req.Login, nil, "unknown_login")
+		case errors.Is(err, apperr.ErrWrongPassword):
+			u.recordFailure(c, req.Login)
+			u.auditLoginFailure(c, req.Login, &user.ID, "wrong_password")
+		}
+		return nil, errors.Wrap(err, "usecase Authenticate")
+	}
+
+	if err := u.attempts.Reset(c, req.Login); err != nil {
+		logger.Error("failed to reset login attempts",
+			zap.String("login", req.Login),
+			zap.Error(err),
+		)
+	}
+
+	if u.hasher.NeedsRehash(user.Password) {
+		u.rehashPassword(c, user.ID, req.Password)
+	}
+
+	if u.requireVerifiedEmail && !user.EmailVerified() {
+		u.auditLoginFailure(c, req.Login, &user.ID, "email_not_verified")
+		return nil, errors.Wrap(apperr.ErrEmailNotVerified, "usecase Authenticate")
+	}
+
+	u.audit.Record(c, model.AuditEvent{
+		Action:   model.AuditUserLogin,
+		ActorID:  &user.ID,
+		TargetID: &user.ID,
+		Details:  map[string]string{"login": req.Login, "method": "password"},
+	})
+	return user, nil
+}
+
+func (u *UserCase) verifyPassword(c *gin.Context, req model.LoginRequest) (*model.User, error) {
+	id, err := u.userRepo.GetUserIDByLogin(c, req.Login)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := u.userRepo.GetUser(c, *id)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := u.hasher.Verify(req.Password, user.Password)
+	if err != nil {
+		return nil, errors.Wrap(err, "verify password")
+	}
+	if !ok {
+		// Пользователь нужен вызывающему, чтобы записать неудачу в аудит
+		return user, apperr.ErrWrongPassword
+	}
+
+	return user, nil
+}
+
+func (u *UserCase) recordFailure(c *gin.Context, login string) {
+	metrics.LoginFailureInc()
+
+	locked, err := u.attempts.Fail(c, login, c.ClientIP())
+	if err != nil {
+		logger.Error("failed to record login attempt",
+			zap.String("login", login),
+			zap.Error(err),
+		)
+		return
+	}
+	if locked {
+		logger.Info("login locked out",
+			zap.String("login", login),
+			zap.String("ip", c.ClientIP()),
+		)
+		metrics.LoginLockoutInc()
+	}
+}
+
+func (u *UserCase) auditLoginFailure(c *gin.Context, login string, target *uuid.UUID, reason string) {
+	u.audit.Record(c, model.AuditEvent{
+		Action:   model.AuditUserLoginFailure,
+		TargetID: target,
+		Details:  map[string]string{"login": login, "reason": reason},
+	})
+}
+
+// userAuditFields - поля пользователя, изменения которых попадают в журнал.
+func userAuditFields(user *model.User) map[string]any {
+	fields := map[string]any{
+		"login":            user.Login,
+		"name":             user.Name,
+		"email":            user.Email,
+		"age":              user.Age,
+		auditPasswordField: user.Password,
+	}
+	// Атрибуты пишутся по одному, чтобы в журнал попали только изменённые
+	for name, value := range user.Attributes {
+		fields["attributes."+name] = value
+	}
+	return fields
+}
+
+func rejectAttempt(decision lockout.Decision) error {
+	if decision.Locked {
+		metrics.LoginRejectedInc("locked")
+		return &apperr.RetryError{Err: apperr.ErrAccountLocked, RetryAfter: decision.RetryAfter}
+	}
+
+	metrics.LoginRejectedInc("throttled")
+	return &apperr.RetryError{Err: apperr.ErrTooManyAttempts, RetryAfter: decision.RetryAfter}
+}
+
+func (u *UserCase) rehashPassword(c *gin.Context, id uuid.UUID, password string) {
+	hash, err := u.hasher.Hash(password)
+	if err == nil {
+		_, err = u.userRepo.UpdateUser(c, model.UpdateUserRequest{
+			ID:       id,
+			Password: &hash,
+		})
+	}
+	if err != nil {
+		logger.Error("failed to rehash password",
+			zap.String("userID", id.String()),
+			zap.Error(err),
+		)
+	}
+}
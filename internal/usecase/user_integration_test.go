@@ -5,13 +5,17 @@ package usecase
 
 import (
 	"context"
-	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/lockout"
 	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/passpolicy"
 	"github.com/lemavisaitov/lk-api/internal/repository"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/require"
@@ -61,8 +65,7 @@ func TestUserCase_AddUser(t *testing.T) {
 	pool, cleanup := setupTestDB(t)
 	defer cleanup()
 
-	userRepo := repository.NewUserProvider(pool)
-	userUC := NewUserProvider(userRepo)
+	userUC := newTestUserCase(t, pool)
 	for _, tc := range testCases {
 		id := uuid.New()
 		user := model.User{
@@ -73,7 +76,7 @@ func TestUserCase_AddUser(t *testing.T) {
 			Age:      tc.age,
 		}
 		t.Run(tc.caseName, func(t *testing.T) {
-			storedID, err := userUC.AddUser(&gin.Context{}, user)
+			storedID, err := userUC.AddUser(newTestContext(), user)
 			if tc.valid {
 				require.NoError(t, err)
 				assert.Equal(t, id.String(), storedID.String())
@@ -83,12 +86,107 @@ func TestUserCase_AddUser(t *testing.T) {
 	}
 }
 
-func TestUserCase_DeleteUser(t *testing.T) {
+func TestUserCase_AddExternalUser(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	userUC := newTestUserCase(t, pool)
+
+	// Внешний провайдер не сообщает возраст, поэтому пользователь сохраняется с нулевым
+	user := model.User{
+		ID:       uuid.New(),
+		Login:    "external",
+		Password: "Xk9#mQ2$vL7!pR4z",
+		Name:     "external",
+		Email:    "external@example.com",
+	}
+
+	storedID, err := userUC.AddExternalUser(newTestContext(), user)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.String(), storedID.String())
+
+	stored, err := userUC.GetUser(newTestContext(), user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.Age)
+}
+
+func TestInvitationCase_AcceptInvitationAtomic(t *testing.T) {
 	pool, cleanup := setupTestDB(t)
 	defer cleanup()
 
+	_, err := pool.Exec(context.Background(), "DELETE FROM organization_invitations")
+	require.NoError(t, err)
+
 	userRepo := repository.NewUserProvider(pool)
-	userUC := NewUserProvider(userRepo)
+	invitationRepo := repository.NewInvitationProvider(pool)
+	userUC := newTestUserCase(t, pool)
+	uc := NewInvitationProvider(invitationRepo, userRepo, userUC, &fakeNotifier{}, time.Hour,
+		"https://lk.example.com/invitations")
+
+	_, err = userUC.AddUser(newTestContext(), model.User{
+		ID:       uuid.New(),
+		Login:    "taken",
+		Password: "password",
+		Name:     "taken",
+		Age:      18,
+	})
+	require.NoError(t, err)
+
+	token, hash, err := auth.NewOpaqueToken()
+	require.NoError(t, err)
+	err = invitationRepo.AddInvitation(newTestContext(), model.Invitation{
+		ID:             uuid.New(),
+		OrganizationID: tenant.DefaultID,
+		Email:          "invitee@example.com",
+		Role:           model.MemberRoleAdmin,
+		ExpiresAt:      time.Now().Add(time.Hour),
+		TokenHash:      hash,
+	})
+	require.NoError(t, err)
+
+	t.Run("invalid test: login taken, invitation stays open", func(t *testing.T) {
+		_, err := uc.AcceptInvitation(newTestContext(), model.AcceptInvitationRequest{
+			Token:    token,
+			Login:    "taken",
+			Password: "password",
+			Name:     "invitee",
+		})
+		require.ErrorIs(t, err, apperr.ErrAlreadyExists)
+
+		_, err = invitationRepo.FindInvitation(newTestContext(), hash)
+		require.NoError(t, err)
+		_, err = userRepo.GetUserIDByEmail(newTestContext(), "invitee@example.com")
+		require.ErrorIs(t, err, apperr.ErrNotFound)
+	})
+	// Возраст не передан: приглашённый сохраняется с нулевым, это допускает users_age_check
+	t.Run("valid test: user, membership and invitation change together", func(t *testing.T) {
+		result, err := uc.AcceptInvitation(newTestContext(), model.AcceptInvitationRequest{
+			Token:    token,
+			Login:    "invitee",
+			Password: "password",
+			Name:     "invitee",
+		})
+		require.NoError(t, err)
+		assert.True(t, result.Created)
+		assert.Equal(t, model.MemberRoleAdmin, result.Role)
+
+		user, err := userRepo.GetUser(newTestContext(), result.UserID)
+		require.NoError(t, err)
+		assert.True(t, user.EmailVerified())
+		assert.Equal(t, 0, user.Age)
+		role, err := repository.NewMembershipProvider(pool).GetMemberRole(newTestContext(), result.UserID)
+		require.NoError(t, err)
+		assert.Equal(t, model.MemberRoleAdmin, role)
+		_, err = invitationRepo.FindInvitation(newTestContext(), hash)
+		require.ErrorIs(t, err, apperr.ErrNotFound)
+	})
+}
+
+func TestUserCase_DeleteUser(t *testing.T) {
+	pool, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	userUC := newTestUserCase(t, pool)
 
 	user := model.User{
 		ID:       uuid.New(),
@@ -99,16 +197,16 @@ func TestUserCase_DeleteUser(t *testing.T) {
 	}
 
 	t.Run("add user", func(t *testing.T) {
-		storedID, err := userUC.AddUser(&gin.Context{}, user)
+		storedID, err := userUC.AddUser(newTestContext(), user)
 		require.NoError(t, err)
 		assert.Equal(t, user.ID.String(), storedID.String())
 	})
 	t.Run("delete user", func(t *testing.T) {
-		err := userUC.DeleteUser(&gin.Context{}, user.ID)
+		err := userUC.DeleteUser(newTestContext(), user.ID, 0)
 		require.NoError(t, err)
 	})
 	t.Run("get user", func(t *testing.T) {
-		_, err := userUC.GetUser(&gin.Context{}, user.ID)
+		_, err := userUC.GetUser(newTestContext(), user.ID)
 		require.ErrorIs(t, err, apperr.ErrNotFound)
 	})
 }
@@ -116,8 +214,7 @@ func TestUserCase_DeleteUser(t *testing.T) {
 func TestUserCase_GetUser(t *testing.T) {
 	pool, cleanup := setupTestDB(t)
 	defer cleanup()
-	userRepo := repository.NewUserProvider(pool)
-	userUC := NewUserProvider(userRepo)
+	userUC := newTestUserCase(t, pool)
 
 	user := model.User{
 		ID:       uuid.New(),
@@ -128,7 +225,7 @@ func TestUserCase_GetUser(t *testing.T) {
 	}
 
 	t.Run("add user", func(t *testing.T) {
-		storedID, err := userUC.AddUser(&gin.Context{}, user)
+		storedID, err := userUC.AddUser(newTestContext(), user)
 		require.NoError(t, err)
 		assert.Equal(t, user.ID.String(), storedID.String())
 	})
@@ -156,7 +253,7 @@ func TestUserCase_GetUser(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		t.Run(tc.caseName, func(t *testing.T) {
-			user, err := userUC.GetUser(&gin.Context{}, user.ID)
+			user, err := userUC.GetUser(newTestContext(), user.ID)
 			if tc.valid {
 				require.NoError(t, err)
 				assert.Equal(t, user.ID.String(), tc.id)
@@ -169,15 +266,14 @@ func TestUserCase_GetUser(t *testing.T) {
 func TestUserCase_GetUserIDByLogin(t *testing.T) {
 	pool, cleanup := setupTestDB(t)
 	defer cleanup()
-	userRepo := repository.NewUserProvider(pool)
-	userUC := NewUserProvider(userRepo)
+	userUC := newTestUserCase(t, pool)
 	user := model.User{
 		ID:       uuid.New(),
 		Login:    "login",
 		Password: "password",
 	}
 	t.Run("add user", func(t *testing.T) {
-		storedID, err := userUC.AddUser(&gin.Context{}, user)
+		storedID, err := userUC.AddUser(newTestContext(), user)
 		require.NoError(t, err)
 		assert.Equal(t, user.ID.String(), storedID.String())
 	})
@@ -200,7 +296,7 @@ func TestUserCase_GetUserIDByLogin(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		t.Run(tc.caseName, func(t *testing.T) {
-			id, err := userUC.GetUserIDByLogin(&gin.Context{}, user.Login)
+			id, err := userUC.GetUserIDByLogin(newTestContext(), user.Login)
 			if tc.valid {
 				require.NoError(t, err)
 				assert.Equal(t, id.String(), user.ID.String())
@@ -213,19 +309,19 @@ func TestUserCase_GetUserIDByLogin(t *testing.T) {
 func TestUserCase_UpdateUser(t *testing.T) {
 	pool, cleanup := setupTestDB(t)
 	defer cleanup()
-	userRepo := repository.NewUserProvider(pool)
-	userUC := NewUserProvider(userRepo)
+	userUC := newTestUserCase(t, pool)
 	user := model.User{
 		ID:       uuid.New(),
 		Login:    "login1",
 		Password: "password",
 	}
 	t.Run("add user", func(t *testing.T) {
-		storedID, err := userUC.AddUser(&gin.Context{}, user)
+		storedID, err := userUC.AddUser(newTestContext(), user)
 		require.NoError(t, err)
 		assert.Equal(t, user.ID.String(), storedID.String())
 	})
 
+	password, age := "password2", -1
 	testCases := []struct {
 		valid    bool
 		caseName string
@@ -236,7 +332,7 @@ func TestUserCase_UpdateUser(t *testing.T) {
 			caseName: "valid test",
 			req: model.UpdateUserRequest{
 				ID:       user.ID,
-				Password: "password2",
+				Password: &password,
 			},
 		},
 		{
@@ -244,7 +340,7 @@ func TestUserCase_UpdateUser(t *testing.T) {
 			caseName: "invalid test: age less then 0",
 			req: model.UpdateUserRequest{
 				ID:  user.ID,
-				Age: -1,
+				Age: &age,
 			},
 		},
 		{
@@ -258,7 +354,7 @@ func TestUserCase_UpdateUser(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.caseName, func(t *testing.T) {
-			storedID, err := userUC.UpdateUser(&gin.Context{}, tc.req)
+			storedID, err := userUC.UpdateUser(newTestContext(), tc.req)
 			if tc.valid {
 				require.NoError(t, err)
 				assert.Equal(t, tc.req.ID.String(), storedID.String())
@@ -268,6 +364,22 @@ func TestUserCase_UpdateUser(t *testing.T) {
 	}
 }
 
+func newTestUserCase(t *testing.T, pool *pgxpool.Pool) *UserCase {
+	return NewUserProvider(
+		repository.NewUserProvider(pool),
+		repository.NewSessionProvider(pool),
+		repository.NewOneTimeTokenProvider(pool),
+		newTestHasher(t),
+		passpolicy.NewChecker(passpolicy.Policy{}, nil),
+		lockout.NewLimiter(lockout.NewPostgresStore(pool, time.Hour, time.Hour), lockout.Policy{}, lockout.Policy{}),
+		NewAuditProvider(repository.NewAuditProvider(pool), nil),
+		NewAttributeProvider(repository.NewAttributeProvider(pool)),
+		nil,
+		0,
+		false,
+	)
+}
+
 func setupTestDB(t *testing.T) (*pgxpool.Pool, func()) {
 	connStr := "host=postgres user=postgres password=postgres dbname=testdb sslmode=disable"
 	pool, err := pgxpool.New(context.Background(), connStr)
@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/logger"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// Пользователи стираются пачками, чтобы не держать долгую транзакцию
+	purgeBatchSize = 100
+	purgeTimeout   = time.Minute
+)
+
+// UserPurger периодически стирает пользователей, удалённых раньше, чем retention назад.
+// Журнал аудита не связан с users внешними ключами и сохраняется.
+type UserPurger struct {
+	userRepo         repository.UserProvider
+	organizationRepo repository.OrganizationProvider
+	auditRepo        repository.AuditProvider
+	avatars          AvatarCleaner
+	retention        time.Duration
+	now              func() time.Time
+	done             chan struct{}
+}
+
+func NewUserPurger(userRepo repository.UserProvider,
+	organizationRepo repository.OrganizationProvider,
+	auditRepo repository.AuditProvider,
+	avatars AvatarCleaner,
+	retention time.Duration,
+	interval time.Duration) *UserPurger {
+	purger := &UserPurger{
+		userRepo:         userRepo,
+		organizationRepo: organizationRepo,
+		auditRepo:        auditRepo,
+		avatars:          avatars,
+		retention:        retention,
+		now:              time.Now,
+		done:             make(chan struct{}),
+	}
+
+	purger.runPurger(interval)
+
+	return purger
+}
+
+func (p *UserPurger) Close() {
+	close(p.done)
+}
+
+func (p *UserPurger) runPurger(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), purgeTimeout)
+				p.purge(ctx)
+				cancel()
+			case <-p.done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+// purge обходит организации по очереди: репозиторий пользователей работает
+// только в рамках одной организации.
+func (p *UserPurger) purge(ctx context.Context) {
+	organizations, err := p.organizationRepo.ListOrganizations(ctx)
+	if err != nil {
+		logger.Error("failed to list organizations",
+			zap.Error(err),
+		)
+		return
+	}
+
+	deletedBefore := p.now().Add(-p.retention)
+	for _, organization := range organizations {
+		p.purgeOrganization(tenant.WithID(ctx, organization.ID), deletedBefore)
+	}
+}
+
+func (p *UserPurger) purgeOrganization(ctx context.Context, deletedBefore time.Time) {
+	for {
+		users, err := p.userRepo.PurgeDeletedUsers(ctx, deletedBefore, purgeBatchSize)
+		if err != nil {
+			organizationID, _ := tenant.FromContext(ctx)
+			logger.Error("failed to purge deleted users",
+				zap.String("organizationID", organizationID.String()),
+				zap.Error(err),
+			)
+			return
+		}
+		for _, user := range users {
+			// Файлы удаляются после строки в БД: оставшийся файл лучше ссылки на пустоту
+			if user.AvatarID != nil {
+				p.avatars.RemoveAvatarFiles(ctx, user.ID, *user.AvatarID)
+			}
+			p.recordPurge(ctx, user.ID)
+		}
+		if len(users) > 0 {
+			logger.Info("purged deleted users",
+				zap.Int("count", len(users)),
+			)
+		}
+		if len(users) < purgeBatchSize {
+			return
+		}
+	}
+}
+
+func (p *UserPurger) recordPurge(ctx context.Context, userID uuid.UUID) {
+	id, err := uuid.NewV7()
+	if err == nil {
+		err = p.auditRepo.AddAuditEvent(ctx, model.AuditEvent{
+			ID:       id,
+			Action:   model.AuditUserPurge,
+			TargetID: &userID,
+		})
+	}
+	if err != nil {
+		logger.Error("failed to write audit event",
+			zap.String("action", model.AuditUserPurge),
+			zap.String("userID", userID.String()),
+			zap.Error(err),
+		)
+	}
+}
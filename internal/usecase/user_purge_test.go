@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAvatarCleaner struct {
+	removed []uuid.UUID
+}
+
+func (f *fakeAvatarCleaner) RemoveAvatarFiles(_ context.Context, _ uuid.UUID, avatarID uuid.UUID) {
+	f.removed = append(f.removed, avatarID)
+}
+
+func TestUserPurger_Purge(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	organizationRepo := mocks.NewMockOrganizationProvider(ctrl)
+	auditRepo := mocks.NewMockAuditProvider(ctrl)
+	avatars := &fakeAvatarCleaner{}
+	purger := NewUserPurger(userRepo, organizationRepo, auditRepo, avatars, 30*24*time.Hour, time.Hour)
+	defer purger.Close()
+
+	now := time.Now()
+	purger.now = func() time.Time { return now }
+	deletedBefore := now.Add(-30 * 24 * time.Hour)
+
+	fullBatch := make([]model.PurgedUser, purgeBatchSize)
+	for i := range fullBatch {
+		fullBatch[i] = model.PurgedUser{ID: uuid.New()}
+	}
+	last, avatarID := uuid.New(), uuid.New()
+	defaultOrganization := []model.Organization{{ID: tenant.DefaultID, Slug: "default"}}
+
+	// Кейс 1: полная пачка означает, что удалённые ещё остались
+	organizationRepo.EXPECT().ListOrganizations(gomock.Any()).Return(defaultOrganization, nil)
+	gomock.InOrder(
+		userRepo.EXPECT().PurgeDeletedUsers(gomock.Any(), deletedBefore, purgeBatchSize).Return(fullBatch, nil),
+		userRepo.EXPECT().PurgeDeletedUsers(gomock.Any(), deletedBefore, purgeBatchSize).
+			Return([]model.PurgedUser{{ID: last, AvatarID: &avatarID}}, nil),
+	)
+	purged := make(map[uuid.UUID]bool)
+	auditRepo.EXPECT().AddAuditEvent(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ any, event model.AuditEvent) error {
+			assert.Equal(t, model.AuditUserPurge, event.Action)
+			assert.Nil(t, event.ActorID)
+			purged[*event.TargetID] = true
+			return nil
+		}).Times(purgeBatchSize + 1)
+	purger.purge(context.Background())
+	assert.Len(t, purged, purgeBatchSize+1)
+	assert.True(t, purged[last])
+	// Файлы аватара удаляются только у тех, у кого он был
+	assert.Equal(t, []uuid.UUID{avatarID}, avatars.removed)
+
+	// Кейс 2: ошибка БД прерывает проход по организации, но не по остальным
+	acme := model.Organization{ID: uuid.New(), Slug: "acme"}
+	organizationRepo.EXPECT().ListOrganizations(gomock.Any()).
+		Return(append(defaultOrganization, acme), nil)
+	var visited []uuid.UUID
+	userRepo.EXPECT().PurgeDeletedUsers(gomock.Any(), deletedBefore, purgeBatchSize).
+		DoAndReturn(func(ctx context.Context, _ time.Time, _ int) ([]model.PurgedUser, error) {
+			organizationID, ok := tenant.FromContext(ctx)
+			require.True(t, ok)
+			visited = append(visited, organizationID)
+			return nil, assert.AnError
+		}).Times(2)
+	purger.purge(context.Background())
+	assert.Equal(t, []uuid.UUID{tenant.DefaultID, acme.ID}, visited)
+
+	// Кейс 3: без списка организаций чистить нечего
+	organizationRepo.EXPECT().ListOrganizations(gomock.Any()).Return(nil, assert.AnError)
+	purger.purge(context.Background())
+}
+
+func TestUserCase_RestoreUser(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	recorder := &fakeAuditRecorder{}
+	uc := NewUserProvider(userRepo, nil, nil, newTestHasher(t), newTestPolicy(), nil, recorder, nil, nil,
+		7*24*time.Hour, false)
+	now := time.Now()
+	uc.now = func() time.Time { return now }
+	c := newTestContext()
+	userID := uuid.New()
+
+	// Кейс 1: восстановить можно только удалённого в пределах срока
+	userRepo.EXPECT().RestoreUser(gomock.Any(), userID, now.Add(-7*24*time.Hour)).Return(nil)
+	require.NoError(t, uc.RestoreUser(c, userID))
+	event := recorder.last(t)
+	assert.Equal(t, model.AuditUserRestore, event.Action)
+	assert.Equal(t, &userID, event.TargetID)
+
+	// Кейс 2: срок истёк или пользователь не удалён
+	userRepo.EXPECT().RestoreUser(gomock.Any(), userID, gomock.Any()).Return(apperr.ErrNotFound)
+	require.ErrorIs(t, uc.RestoreUser(c, userID), apperr.ErrNotFound)
+	assert.Len(t, recorder.events, 1)
+}
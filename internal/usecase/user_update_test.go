@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/auth"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserCase_UpdateUserFields(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	tokenRepo := mocks.NewMockOneTimeTokenProvider(ctrl)
+	recorder := &fakeAuditRecorder{}
+	uc := NewUserProvider(userRepo, nil, tokenRepo, newTestHasher(t), newTestPolicy(), nil, recorder,
+		newTestAttributes(t, testAttributeDefinitions...), nil, 7*24*time.Hour, false)
+	c := newTestContext()
+	user := &model.User{ID: uuid.New(), Login: "ivan", Name: "Ivan", Age: 30, Email: "ivan@example.com", Version: 4}
+
+	// Кейс 1: нулевой возраст и пустая почта - это значения, а не "не задано"
+	age, email := 0, ""
+	req := model.UpdateUserRequest{ID: user.ID, Age: &age, Email: &email, Version: 4}
+	userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+	userRepo.EXPECT().UpdateUser(gomock.Any(), req).Return(&user.ID, nil)
+	tokenRepo.EXPECT().InvalidateUserTokens(gomock.Any(), user.ID, model.TokenPurposeEmailVerification).Return(nil)
+	_, err := uc.UpdateUser(c, req)
+	require.NoError(t, err)
+	event := recorder.last(t)
+	assert.Contains(t, event.Changes, "age")
+	assert.Contains(t, event.Changes, "email")
+	assert.NotContains(t, event.Changes, "name")
+
+	// Кейс 2: та же почта в другом регистре не сбрасывает ссылки подтверждения
+	sameEmail := "Ivan@Example.com"
+	req = model.UpdateUserRequest{ID: user.ID, Email: &sameEmail}
+	userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+	userRepo.EXPECT().UpdateUser(gomock.Any(), req).Return(&user.ID, nil)
+	_, err = uc.UpdateUser(c, req)
+	require.NoError(t, err)
+
+	// Кейс 3: атрибуты проверяются по справочнику до записи
+	attributes := map[string]any{"department": "marketing"}
+	userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+	_, err = uc.UpdateUser(c, model.UpdateUserRequest{ID: user.ID, Attributes: attributes})
+	require.ErrorIs(t, err, apperr.ErrValidation)
+
+	attributes = map[string]any{"department": "sales", "floor": 2.0}
+	req = model.UpdateUserRequest{ID: user.ID, Attributes: attributes}
+	userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+	userRepo.EXPECT().UpdateUser(gomock.Any(), req).Return(&user.ID, nil)
+	_, err = uc.UpdateUser(c, req)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]model.AuditChange{
+		"attributes.department": {New: "sales"},
+		"attributes.floor":      {New: 2.0},
+	}, recorder.last(t).Changes)
+
+	// Кейс 4: при имперсонации нельзя сменить пароль или почту, имя - можно
+	claims := auth.NewClaims(user.ID)
+	claims.Act = &auth.Actor{Subject: uuid.NewString()}
+	impersonated := newClaimsContext(claims)
+
+	password, newEmail, name := "new-password", "other@example.com", "Ivan Petrov"
+	for _, forbidden := range []model.UpdateUserRequest{
+		{ID: user.ID, Password: &password},
+		{ID: user.ID, Email: &newEmail},
+	} {
+		userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+		_, err = uc.UpdateUser(impersonated, forbidden)
+		require.ErrorIs(t, err, apperr.ErrForbidden)
+	}
+
+	req = model.UpdateUserRequest{ID: user.ID, Name: &name, Email: &sameEmail}
+	userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil)
+	userRepo.EXPECT().UpdateUser(gomock.Any(), req).Return(&user.ID, nil)
+	_, err = uc.UpdateUser(impersonated, req)
+	require.NoError(t, err)
+}
@@ -0,0 +1,302 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/logger"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/repository"
+	"github.com/lemavisaitov/lk-api/internal/sealer"
+	"github.com/lemavisaitov/lk-api/internal/webauthn"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	webAuthnSessionAD      = "webauthn"
+	webAuthnCeremonyCreate = "create"
+	webAuthnCeremonyGet    = "get"
+	defaultPasskeyName     = "Passkey"
+	webAuthnCredentialType = "public-key"
+)
+
+type WebAuthnProvider interface {
+	BeginRegistration(*gin.Context, uuid.UUID) (*model.WebAuthnRegistrationOptions, error)
+	FinishRegistration(*gin.Context, uuid.UUID, model.WebAuthnRegisterRequest) (*model.WebAuthnCredential, error)
+	BeginLogin(*gin.Context, string) (*model.WebAuthnLoginOptions, error)
+	FinishLogin(*gin.Context, model.WebAuthnLoginRequest) (*model.User, bool, error)
+	ListCredentials(*gin.Context, uuid.UUID) ([]model.WebAuthnCredential, error)
+	DeleteCredential(*gin.Context, uuid.UUID, uuid.UUID) error
+}
+
+// webAuthnSession хранит challenge между выдачей параметров и ответом
+// аутентификатора. Клиент получает его в зашифрованном виде.
+type webAuthnSession struct {
+	Ceremony  string     `json:"ceremony"`
+	Challenge []byte     `json:"challenge"`
+	UserID    *uuid.UUID `json:"user_id,omitempty"`
+	ExpiresAt time.Time  `json:"expires_at"`
+}
+
+type WebAuthnCase struct {
+	credRepo   repository.WebAuthnProvider
+	userRepo   repository.UserProvider
+	rp         *webauthn.RelyingParty
+	sealer     *sealer.Sealer
+	sessionTTL time.Duration
+	now        func() time.Time
+}
+
+func NewWebAuthnProvider(credRepo repository.WebAuthnProvider,
+	userRepo repository.UserProvider,
+	rp *webauthn.RelyingParty,
+	sessionSealer *sealer.Sealer,
+	sessionTTL time.Duration) *WebAuthnCase {
+	return &WebAuthnCase{
+		credRepo:   credRepo,
+		userRepo:   userRepo,
+		rp:         rp,
+		sealer:     sessionSealer,
+		sessionTTL: sessionTTL,
+		now:        time.Now,
+	}
+}
+
+func (w *WebAuthnCase) BeginRegistration(c *gin.Context, userID uuid.UUID) (*model.WebAuthnRegistrationOptions, error) {
+	user, err := w.userRepo.GetUser(c, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase BeginRegistration")
+	}
+	// Повторно регистрировать тот же аутентификатор не нужно
+	exclude, err := w.descriptors(c, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase BeginRegistration")
+	}
+
+	challenge, session, err := w.newSession(webAuthnCeremonyCreate, &userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase BeginRegistration")
+	}
+
+	return &model.WebAuthnRegistrationOptions{
+		Session: session,
+		PublicKey: w.rp.CreationOptions(challenge, webauthn.UserEntity{
+			ID:          user.ID[:],
+			Name:        user.Login,
+			DisplayName: user.Name,
+		}, exclude),
+	}, nil
+}
+
+func (w *WebAuthnCase) FinishRegistration(c *gin.Context, userID uuid.UUID,
+	req model.WebAuthnRegisterRequest) (*model.WebAuthnCredential, error) {
+	session, err := w.openSession(req.Session, webAuthnCeremonyCreate)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase FinishRegistration")
+	}
+	if session.UserID == nil || *session.UserID != userID {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "session belongs to another user")
+	}
+
+	verified, err := w.rp.VerifyRegistration(session.Challenge, req.Credential)
+	if err != nil {
+		return nil, errors.Wrap(apperr.ErrInvalidPasskey, err.Error())
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase FinishRegistration")
+	}
+	name := req.Name
+	if name == "" {
+		name = defaultPasskeyName
+	}
+	transports := req.Credential.Response.Transports
+	if transports == nil {
+		transports = []string{}
+	}
+	cred := model.WebAuthnCredential{
+		ID:             id,
+		UserID:         userID,
+		CredentialID:   verified.ID,
+		PublicKey:      verified.PublicKey,
+		SignCount:      verified.SignCount,
+		Name:           name,
+		Transports:     transports,
+		BackupEligible: verified.Flags&webauthn.FlagBackupEligible != 0,
+		CreatedAt:      w.now(),
+	}
+	if err := w.credRepo.AddCredential(c, cred); err != nil {
+		return nil, errors.Wrap(err, "usecase FinishRegistration")
+	}
+
+	return &cred, nil
+}
+
+// BeginLogin с логином ограничивает вход ключами этого пользователя. Для
+// неизвестного логина ответ такой же, как без него, чтобы не раскрывать учётные записи.
+func (w *WebAuthnCase) BeginLogin(c *gin.Context, login string) (*model.WebAuthnLoginOptions, error) {
+	var (
+		userID *uuid.UUID
+		allow  []webauthn.CredentialDescriptor
+	)
+	if login != "" {
+		id, err := w.userRepo.GetUserIDByLogin(c, login)
+		if err != nil && !errors.Is(err, apperr.ErrNotFound) {
+			return nil, errors.Wrap(err, "usecase BeginLogin")
+		}
+		if id != nil {
+			allow, err = w.descriptors(c, *id)
+			if err != nil {
+				return nil, errors.Wrap(err, "usecase BeginLogin")
+			}
+			userID = id
+		}
+	}
+
+	challenge, session, err := w.newSession(webAuthnCeremonyGet, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase BeginLogin")
+	}
+
+	return &model.WebAuthnLoginOptions{
+		Session:   session,
+		PublicKey: w.rp.RequestOptions(challenge, allow),
+	}, nil
+}
+
+// FinishLogin проверяет подпись и возвращает пользователя. Второй результат
+// сообщает, подтвердил ли аутентификатор личность (PIN, биометрия): такой
+// вход уже двухфакторный.
+func (w *WebAuthnCase) FinishLogin(c *gin.Context, req model.WebAuthnLoginRequest) (*model.User, bool, error) {
+	session, err := w.openSession(req.Session, webAuthnCeremonyGet)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "usecase FinishLogin")
+	}
+
+	stored, err := w.credRepo.GetCredential(c, req.Credential.RawID)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, false, errors.Wrap(apperr.ErrInvalidPasskey, "unknown credential")
+		}
+		return nil, false, errors.Wrap(err, "usecase FinishLogin")
+	}
+	if session.UserID != nil && *session.UserID != stored.UserID {
+		return nil, false, errors.Wrap(apperr.ErrInvalidPasskey, "credential belongs to another user")
+	}
+	userHandle := req.Credential.Response.UserHandle
+	if len(userHandle) > 0 && !bytes.Equal(userHandle, stored.UserID[:]) {
+		return nil, false, errors.Wrap(apperr.ErrInvalidPasskey, "user handle mismatch")
+	}
+
+	assertion, err := w.rp.VerifyAssertion(session.Challenge, stored.PublicKey, req.Credential)
+	if err != nil {
+		return nil, false, errors.Wrap(apperr.ErrInvalidPasskey, err.Error())
+	}
+	if webauthn.CounterRegressed(stored.SignCount, assertion.SignCount) {
+		logger.Error("webauthn sign count regressed, authenticator may be cloned",
+			zap.String("userID", stored.UserID.String()),
+			zap.String("credentialID", stored.ID.String()),
+			zap.Uint32("stored", stored.SignCount),
+			zap.Uint32("got", assertion.SignCount),
+		)
+		return nil, false, errors.Wrap(apperr.ErrInvalidPasskey, "sign count regressed")
+	}
+	updated, err := w.credRepo.UpdateSignCount(c, stored.ID, stored.SignCount, assertion.SignCount)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "usecase FinishLogin")
+	}
+	if !updated {
+		return nil, false, errors.Wrap(apperr.ErrInvalidPasskey, "concurrent login with the same credential")
+	}
+
+	if err := enterUserTenant(c, w.userRepo, stored.UserID); err != nil {
+		return nil, false, errors.Wrap(err, "usecase FinishLogin")
+	}
+	user, err := w.userRepo.GetUser(c, stored.UserID)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "usecase FinishLogin")
+	}
+
+	return user, assertion.Flags&webauthn.FlagUserVerified != 0, nil
+}
+
+func (w *WebAuthnCase) ListCredentials(c *gin.Context, userID uuid.UUID) ([]model.WebAuthnCredential, error) {
+	creds, err := w.credRepo.ListUserCredentials(c, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "usecase ListCredentials")
+	}
+	return creds, nil
+}
+
+func (w *WebAuthnCase) DeleteCredential(c *gin.Context, userID uuid.UUID, id uuid.UUID) error {
+	if err := w.credRepo.DeleteCredential(c, userID, id); err != nil {
+		return errors.Wrap(err, "usecase DeleteCredential")
+	}
+	return nil
+}
+
+func (w *WebAuthnCase) descriptors(c *gin.Context, userID uuid.UUID) ([]webauthn.CredentialDescriptor, error) {
+	creds, err := w.credRepo.ListUserCredentials(c, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptors := make([]webauthn.CredentialDescriptor, 0, len(creds))
+	for _, cred := range creds {
+		descriptors = append(descriptors, webauthn.CredentialDescriptor{
+			Type:       webAuthnCredentialType,
+			ID:         cred.CredentialID,
+			Transports: cred.Transports,
+		})
+	}
+	return descriptors, nil
+}
+
+func (w *WebAuthnCase) newSession(ceremony string, userID *uuid.UUID) ([]byte, string, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, "", err
+	}
+
+	plain, err := json.Marshal(webAuthnSession{
+		Ceremony:  ceremony,
+		Challenge: challenge,
+		UserID:    userID,
+		ExpiresAt: w.now().Add(w.sessionTTL),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	sealed, err := w.sealer.Seal(plain, []byte(webAuthnSessionAD))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return challenge, sealed, nil
+}
+
+func (w *WebAuthnCase) openSession(sealed string, ceremony string) (*webAuthnSession, error) {
+	plain, err := w.sealer.Open(sealed, []byte(webAuthnSessionAD))
+	if err != nil {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "webauthn session is corrupted")
+	}
+
+	var session webAuthnSession
+	if err := json.Unmarshal(plain, &session); err != nil {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "webauthn session is corrupted")
+	}
+	if session.Ceremony != ceremony {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "webauthn session is for another ceremony")
+	}
+	if w.now().After(session.ExpiresAt) {
+		return nil, errors.Wrap(apperr.ErrInvalidToken, "webauthn session expired")
+	}
+	return &session, nil
+}
@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/apperr"
+	"github.com/lemavisaitov/lk-api/internal/model"
+	"github.com/lemavisaitov/lk-api/internal/sealer"
+	"github.com/lemavisaitov/lk-api/internal/tenant"
+	"github.com/lemavisaitov/lk-api/internal/testutils/mocks"
+	"github.com/lemavisaitov/lk-api/internal/testutils/softauthn"
+	"github.com/lemavisaitov/lk-api/internal/webauthn"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRPID   = "lk.example.com"
+	testOrigin = "https://lk.example.com"
+)
+
+// fakeWebAuthnRepo хранит ключи в памяти
+type fakeWebAuthnRepo struct {
+	creds []model.WebAuthnCredential
+}
+
+func (f *fakeWebAuthnRepo) AddCredential(_ context.Context, cred model.WebAuthnCredential) error {
+	for _, existing := range f.creds {
+		if bytes.Equal(existing.CredentialID, cred.CredentialID) {
+			return apperr.ErrAlreadyExists
+		}
+	}
+	f.creds = append(f.creds, cred)
+	return nil
+}
+
+func (f *fakeWebAuthnRepo) GetCredential(_ context.Context, credentialID []byte) (*model.WebAuthnCredential, error) {
+	for _, cred := range f.creds {
+		if bytes.Equal(cred.CredentialID, credentialID) {
+			return &cred, nil
+		}
+	}
+	return nil, apperr.ErrNotFound
+}
+
+func (f *fakeWebAuthnRepo) ListUserCredentials(_ context.Context, userID uuid.UUID) ([]model.WebAuthnCredential, error) {
+	creds := make([]model.WebAuthnCredential, 0)
+	for _, cred := range f.creds {
+		if cred.UserID == userID {
+			creds = append(creds, cred)
+		}
+	}
+	return creds, nil
+}
+
+func (f *fakeWebAuthnRepo) UpdateSignCount(_ context.Context, id uuid.UUID, prev uint32, next uint32) (bool, error) {
+	for i := range f.creds {
+		if f.creds[i].ID == id && f.creds[i].SignCount == prev {
+			f.creds[i].SignCount = next
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeWebAuthnRepo) DeleteCredential(_ context.Context, userID uuid.UUID, id uuid.UUID) error {
+	for i, cred := range f.creds {
+		if cred.ID == id && cred.UserID == userID {
+			f.creds = append(f.creds[:i], f.creds[i+1:]...)
+			return nil
+		}
+	}
+	return apperr.ErrNotFound
+}
+
+func newTestWebAuthnCase(t *testing.T, userRepo *mocks.MockUserProvider) (*WebAuthnCase, *fakeWebAuthnRepo) {
+	s, err := sealer.New(bytes.Repeat([]byte{7}, sealer.KeySize))
+	require.NoError(t, err)
+
+	rp := webauthn.New(webauthn.Config{
+		RPID:    testRPID,
+		RPName:  "lk-api",
+		Origins: []string{testOrigin},
+		Timeout: time.Minute,
+	})
+	repo := &fakeWebAuthnRepo{}
+	return NewWebAuthnProvider(repo, userRepo, rp, s, time.Minute), repo
+}
+
+func TestWebAuthnCase_Flow(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	uc, repo := newTestWebAuthnCase(t, userRepo)
+	c := newTestContext()
+	authenticator := softauthn.New(testRPID, testOrigin)
+
+	user := &model.User{ID: uuid.New(), Login: "jane", Name: "Jane"}
+	userRepo.EXPECT().GetUser(gomock.Any(), user.ID).Return(user, nil).AnyTimes()
+	userRepo.EXPECT().GetUserOrganizationID(gomock.Any(), user.ID).Return(tenant.DefaultID, nil).AnyTimes()
+	userRepo.EXPECT().GetUserIDByLogin(gomock.Any(), "jane").Return(&user.ID, nil).AnyTimes()
+	userRepo.EXPECT().GetUserIDByLogin(gomock.Any(), "ghost").Return(nil, apperr.ErrNotFound).AnyTimes()
+
+	// Кейс 1: регистрация ключа
+	options, err := uc.BeginRegistration(c, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID[:], []byte(options.PublicKey.User.ID))
+	response, err := authenticator.Create(options.PublicKey)
+	require.NoError(t, err)
+
+	cred, err := uc.FinishRegistration(c, user.ID, model.WebAuthnRegisterRequest{
+		Session:    options.Session,
+		Name:       "Laptop",
+		Credential: response,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Laptop", cred.Name)
+	require.Len(t, repo.creds, 1)
+
+	// Кейс 2: повторно тот же аутентификатор не регистрируется
+	options, err = uc.BeginRegistration(c, user.ID)
+	require.NoError(t, err)
+	require.Len(t, options.PublicKey.ExcludeCredentials, 1)
+	_, err = authenticator.Create(options.PublicKey)
+	require.ErrorIs(t, err, softauthn.ErrExcluded)
+
+	// Кейс 3: сессия регистрации другого пользователя
+	_, err = uc.FinishRegistration(c, uuid.New(), model.WebAuthnRegisterRequest{Session: options.Session})
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+
+	// Кейс 4: вход без логина
+	loginOptions, err := uc.BeginLogin(c, "")
+	require.NoError(t, err)
+	assert.Empty(t, loginOptions.PublicKey.AllowCredentials)
+	assertion, err := authenticator.Get(loginOptions.PublicKey)
+	require.NoError(t, err)
+
+	loggedIn, verified, err := uc.FinishLogin(c, model.WebAuthnLoginRequest{Session: loginOptions.Session, Credential: assertion})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, loggedIn.ID)
+	assert.True(t, verified)
+	assert.Equal(t, uint32(1), repo.creds[0].SignCount)
+
+	// Кейс 5: тот же ответ повторно не принимается
+	_, _, err = uc.FinishLogin(c, model.WebAuthnLoginRequest{Session: loginOptions.Session, Credential: assertion})
+	require.ErrorIs(t, err, apperr.ErrInvalidPasskey)
+
+	// Кейс 6: вход по логину ограничен ключами пользователя
+	loginOptions, err = uc.BeginLogin(c, "jane")
+	require.NoError(t, err)
+	require.Len(t, loginOptions.PublicKey.AllowCredentials, 1)
+	assertion, err = authenticator.Get(loginOptions.PublicKey)
+	require.NoError(t, err)
+	_, _, err = uc.FinishLogin(c, model.WebAuthnLoginRequest{Session: loginOptions.Session, Credential: assertion})
+	require.NoError(t, err)
+
+	// Кейс 7: неизвестный логин неотличим от входа без логина
+	loginOptions, err = uc.BeginLogin(c, "ghost")
+	require.NoError(t, err)
+	assert.Empty(t, loginOptions.PublicKey.AllowCredentials)
+
+	// Кейс 8: сессия регистрации не подходит для входа
+	assertion, err = authenticator.Get(loginOptions.PublicKey)
+	require.NoError(t, err)
+	_, _, err = uc.FinishLogin(c, model.WebAuthnLoginRequest{Session: options.Session, Credential: assertion})
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+
+	// Кейс 9: счётчик откатился - похоже на клон ключа
+	authenticator.SetSignCount(cred.CredentialID, 0)
+	loginOptions, err = uc.BeginLogin(c, "")
+	require.NoError(t, err)
+	assertion, err = authenticator.Get(loginOptions.PublicKey)
+	require.NoError(t, err)
+	_, _, err = uc.FinishLogin(c, model.WebAuthnLoginRequest{Session: loginOptions.Session, Credential: assertion})
+	require.ErrorIs(t, err, apperr.ErrInvalidPasskey)
+
+	// Кейс 10: после удаления ключом войти нельзя
+	require.NoError(t, uc.DeleteCredential(c, user.ID, cred.ID))
+	loginOptions, err = uc.BeginLogin(c, "")
+	require.NoError(t, err)
+	assertion, err = authenticator.Get(loginOptions.PublicKey)
+	require.NoError(t, err)
+	_, _, err = uc.FinishLogin(c, model.WebAuthnLoginRequest{Session: loginOptions.Session, Credential: assertion})
+	require.ErrorIs(t, err, apperr.ErrInvalidPasskey)
+}
+
+func TestWebAuthnCase_ExpiredSession(t *testing.T) {
+	// Создаем контроллер мока
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserProvider(ctrl)
+	uc, _ := newTestWebAuthnCase(t, userRepo)
+	c := newTestContext()
+
+	now := time.Now()
+	uc.now = func() time.Time { return now }
+	options, err := uc.BeginLogin(c, "")
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+	_, _, err = uc.FinishLogin(c, model.WebAuthnLoginRequest{Session: options.Session})
+	require.ErrorIs(t, err, apperr.ErrInvalidToken)
+}
@@ -0,0 +1,184 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// Минимальный декодер CBOR (RFC 8949) для attestationObject и COSE-ключей.
+// Аутентификаторы обязаны кодировать их в каноническом виде CTAP2, поэтому
+// неопределённые длины не поддерживаются.
+
+const cborMaxDepth = 16
+
+var errCBOR = errors.New("malformed cbor")
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+// decodeCBOR читает одно значение из начала data и возвращает число прочитанных байт.
+// Числа декодируются в int64, байтовые строки в []byte, словари в map[any]any.
+func decodeCBOR(data []byte) (any, int, error) {
+	d := &cborDecoder{data: data}
+	value, err := d.value(0)
+	if err != nil {
+		return nil, 0, err
+	}
+	return value, d.pos, nil
+}
+
+func (d *cborDecoder) value(depth int) (any, error) {
+	if depth > cborMaxDepth {
+		return nil, errors.Wrap(errCBOR, "nesting too deep")
+	}
+	if d.pos >= len(d.data) {
+		return nil, errors.Wrap(errCBOR, "unexpected end of data")
+	}
+
+	initial := d.data[d.pos]
+	d.pos++
+	major, info := initial>>5, initial&0x1f
+
+	// Простые значения и числа с плавающей точкой
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22, 23:
+			return nil, nil
+		case 25:
+			_, err := d.take(2)
+			return nil, err
+		case 26:
+			b, err := d.take(4)
+			if err != nil {
+				return nil, err
+			}
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+		case 27:
+			b, err := d.take(8)
+			if err != nil {
+				return nil, err
+			}
+			return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+		default:
+			return nil, errors.Wrapf(errCBOR, "unsupported simple value %d", info)
+		}
+	}
+
+	arg, err := d.argument(info)
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case 0:
+		if arg > math.MaxInt64 {
+			return nil, errors.Wrap(errCBOR, "integer overflow")
+		}
+		return int64(arg), nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return nil, errors.Wrap(errCBOR, "integer overflow")
+		}
+		return -1 - int64(arg), nil
+	case 2:
+		b, err := d.take(arg)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case 3:
+		b, err := d.take(arg)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	case 4:
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, errors.Wrap(errCBOR, "array too long")
+		}
+		items := make([]any, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			item, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case 5:
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, errors.Wrap(errCBOR, "map too long")
+		}
+		m := make(map[any]any, arg)
+		for i := uint64(0); i < arg; i++ {
+			key, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, errors.Wrap(errCBOR, "unsupported map key")
+			}
+			item, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = item
+		}
+		return m, nil
+	default:
+		// Теги (major 6) в WebAuthn не используются
+		return nil, errors.Wrapf(errCBOR, "unsupported major type %d", major)
+	}
+}
+
+func (d *cborDecoder) argument(info byte) (uint64, error) {
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info == 24:
+		b, err := d.take(1)
+		if err != nil {
+			return 0, err
+		}
+		return uint64(b[0]), nil
+	case info == 25:
+		b, err := d.take(2)
+		if err != nil {
+			return 0, err
+		}
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case info == 26:
+		b, err := d.take(4)
+		if err != nil {
+			return 0, err
+		}
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	case info == 27:
+		b, err := d.take(8)
+		if err != nil {
+			return 0, err
+		}
+		return binary.BigEndian.Uint64(b), nil
+	default:
+		return 0, errors.Wrap(errCBOR, "indefinite length is not supported")
+	}
+}
+
+func (d *cborDecoder) take(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, errors.Wrap(errCBOR, "unexpected end of data")
+	}
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
@@ -0,0 +1,364 @@
+// Package webauthn - проверка церемоний регистрации и входа по ключам доступа
+// (WebAuthn Level 2). Аттестация не проверяется: сервер запрашивает "none"
+// и доверяет ключу, привязанному к уже вошедшему пользователю.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ChallengeSize = 32
+
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+
+	UserVerificationPreferred = "preferred"
+	UserVerificationRequired  = "required"
+
+	minAuthDataSize     = 37
+	credentialIDMaxSize = 1023
+)
+
+// Флаги authenticatorData
+const (
+	FlagUserPresent    = 0x01
+	FlagUserVerified   = 0x04
+	FlagBackupEligible = 0x08
+	FlagBackedUp       = 0x10
+	FlagAttestedData   = 0x40
+	FlagExtensionData  = 0x80
+)
+
+var ErrVerification = errors.New("webauthn verification failed")
+
+// Base64URL - бинарное поле, которое в JSON передаётся как base64url без выравнивания.
+type Base64URL []byte
+
+func (b Base64URL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func (b *Base64URL) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+type Config struct {
+	RPID    string
+	RPName  string
+	Origins []string
+	Timeout time.Duration
+	// Требовать ли от аутентификатора проверку пользователя (PIN, биометрия)
+	UserVerification string
+}
+
+type RelyingParty struct {
+	cfg Config
+}
+
+func New(cfg Config) *RelyingParty {
+	if cfg.UserVerification == "" {
+		cfg.UserVerification = UserVerificationPreferred
+	}
+	return &RelyingParty{cfg: cfg}
+}
+
+func NewChallenge() ([]byte, error) {
+	challenge := make([]byte, ChallengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, errors.Wrap(err, "generate webauthn challenge")
+	}
+	return challenge, nil
+}
+
+type RPEntity struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          Base64URL `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type       string    `json:"type"`
+	ID         Base64URL `json:"id"`
+	Transports []string  `json:"transports,omitempty"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions - PublicKeyCredentialCreationOptions для navigator.credentials.create.
+type CreationOptions struct {
+	RP                     RPEntity               `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	Challenge              Base64URL              `json:"challenge"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout,omitempty"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions - PublicKeyCredentialRequestOptions для navigator.credentials.get.
+type RequestOptions struct {
+	Challenge        Base64URL              `json:"challenge"`
+	Timeout          int64                  `json:"timeout,omitempty"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials,omitempty"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+type AttestationResponse struct {
+	ClientDataJSON    Base64URL `json:"clientDataJSON" validate:"required"`
+	AttestationObject Base64URL `json:"attestationObject" validate:"required"`
+	Transports        []string  `json:"transports"`
+}
+
+// RegistrationCredential - результат navigator.credentials.create в JSON-виде.
+type RegistrationCredential struct {
+	ID       string              `json:"id"`
+	RawID    Base64URL           `json:"rawId" validate:"required"`
+	Type     string              `json:"type" validate:"required"`
+	Response AttestationResponse `json:"response"`
+}
+
+type AssertionResponse struct {
+	ClientDataJSON    Base64URL `json:"clientDataJSON" validate:"required"`
+	AuthenticatorData Base64URL `json:"authenticatorData" validate:"required"`
+	Signature         Base64URL `json:"signature" validate:"required"`
+	UserHandle        Base64URL `json:"userHandle"`
+}
+
+// AssertionCredential - результат navigator.credentials.get в JSON-виде.
+type AssertionCredential struct {
+	ID       string            `json:"id"`
+	RawID    Base64URL         `json:"rawId" validate:"required"`
+	Type     string            `json:"type" validate:"required"`
+	Response AssertionResponse `json:"response"`
+}
+
+// Credential - проверенные при регистрации учётные данные.
+type Credential struct {
+	ID        []byte
+	PublicKey []byte
+	SignCount uint32
+	AAGUID    []byte
+	Flags     byte
+}
+
+// Assertion - проверенный результат входа.
+type Assertion struct {
+	SignCount uint32
+	Flags     byte
+}
+
+func (rp *RelyingParty) CreationOptions(challenge []byte, user UserEntity,
+	exclude []CredentialDescriptor) CreationOptions {
+	return CreationOptions{
+		RP:        RPEntity{ID: rp.cfg.RPID, Name: rp.cfg.RPName},
+		User:      user,
+		Challenge: challenge,
+		PubKeyCredParams: []CredentialParameter{
+			{Type: "public-key", Alg: AlgES256},
+			{Type: "public-key", Alg: AlgEdDSA},
+			{Type: "public-key", Alg: AlgRS256},
+		},
+		Timeout:            rp.cfg.Timeout.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: rp.cfg.UserVerification,
+		},
+		Attestation: "none",
+	}
+}
+
+// RequestOptions с пустым allow позволяет войти любым ключом, сохранённым на устройстве.
+func (rp *RelyingParty) RequestOptions(challenge []byte, allow []CredentialDescriptor) RequestOptions {
+	return RequestOptions{
+		Challenge:        challenge,
+		Timeout:          rp.cfg.Timeout.Milliseconds(),
+		RPID:             rp.cfg.RPID,
+		AllowCredentials: allow,
+		UserVerification: rp.cfg.UserVerification,
+	}
+}
+
+// VerifyRegistration проверяет ответ аутентификатора на CreationOptions с данным challenge.
+func (rp *RelyingParty) VerifyRegistration(challenge []byte, cred RegistrationCredential) (*Credential, error) {
+	if cred.Type != "public-key" {
+		return nil, errors.Wrap(ErrVerification, "unexpected credential type")
+	}
+	if err := rp.verifyClientData(cred.Response.ClientDataJSON, ceremonyCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	value, n, err := decodeCBOR(cred.Response.AttestationObject)
+	if err != nil || n != len(cred.Response.AttestationObject) {
+		return nil, errors.Wrap(ErrVerification, "malformed attestation object")
+	}
+	attestation, ok := value.(map[any]any)
+	if !ok {
+		return nil, errors.Wrap(ErrVerification, "malformed attestation object")
+	}
+	authData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, errors.Wrap(ErrVerification, "attestation object without authData")
+	}
+
+	flags, signCount, err := rp.verifyAuthData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if flags&FlagAttestedData == 0 {
+		return nil, errors.Wrap(ErrVerification, "no attested credential data")
+	}
+
+	// aaguid(16) | длина id(2) | id | COSE_Key | расширения
+	rest := authData[minAuthDataSize:]
+	if len(rest) < 18 {
+		return nil, errors.Wrap(ErrVerification, "attested credential data is truncated")
+	}
+	aaguid := rest[:16]
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLen > credentialIDMaxSize || len(rest) < idLen {
+		return nil, errors.Wrap(ErrVerification, "invalid credential id")
+	}
+	credentialID := rest[:idLen]
+	rest = rest[idLen:]
+	if !bytes.Equal(credentialID, cred.RawID) {
+		return nil, errors.Wrap(ErrVerification, "credential id mismatch")
+	}
+
+	_, keyLen, err := decodeCBOR(rest)
+	if err != nil {
+		return nil, errors.Wrap(ErrVerification, "malformed credential public key")
+	}
+	coseKey := rest[:keyLen]
+	if flags&FlagExtensionData == 0 && keyLen != len(rest) {
+		return nil, errors.Wrap(ErrVerification, "trailing data in authData")
+	}
+	if _, err := parsePublicKey(coseKey); err != nil {
+		return nil, errors.Wrap(ErrVerification, err.Error())
+	}
+
+	return &Credential{
+		ID:        append([]byte(nil), credentialID...),
+		PublicKey: append([]byte(nil), coseKey...),
+		SignCount: signCount,
+		AAGUID:    append([]byte(nil), aaguid...),
+		Flags:     flags,
+	}, nil
+}
+
+// VerifyAssertion проверяет подпись входа открытым ключом, сохранённым при регистрации.
+func (rp *RelyingParty) VerifyAssertion(challenge []byte, coseKey []byte, cred AssertionCredential) (*Assertion, error) {
+	if cred.Type != "public-key" {
+		return nil, errors.Wrap(ErrVerification, "unexpected credential type")
+	}
+	if err := rp.verifyClientData(cred.Response.ClientDataJSON, ceremonyGet, challenge); err != nil {
+		return nil, err
+	}
+
+	authData := cred.Response.AuthenticatorData
+	flags, signCount, err := rp.verifyAuthData(authData)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := parsePublicKey(coseKey)
+	if err != nil {
+		return nil, errors.Wrap(ErrVerification, err.Error())
+	}
+	clientDataHash := sha256.Sum256(cred.Response.ClientDataJSON)
+	signed := append(append([]byte(nil), authData...), clientDataHash[:]...)
+	if !key.verify(signed, cred.Response.Signature) {
+		return nil, errors.Wrap(ErrVerification, "invalid signature")
+	}
+
+	return &Assertion{SignCount: signCount, Flags: flags}, nil
+}
+
+// CounterRegressed сообщает, что счётчик подписей не вырос. Это признак
+// клонированного аутентификатора; нулевой счётчик означает, что он не ведётся.
+func CounterRegressed(stored uint32, got uint32) bool {
+	return (stored != 0 || got != 0) && got <= stored
+}
+
+func (rp *RelyingParty) verifyClientData(raw []byte, ceremony string, challenge []byte) error {
+	var clientData struct {
+		Type        string `json:"type"`
+		Challenge   string `json:"challenge"`
+		Origin      string `json:"origin"`
+		CrossOrigin bool   `json:"crossOrigin"`
+	}
+	if err := json.Unmarshal(raw, &clientData); err != nil {
+		return errors.Wrap(ErrVerification, "malformed client data")
+	}
+	if clientData.Type != ceremony {
+		return errors.Wrapf(ErrVerification, "unexpected client data type %q", clientData.Type)
+	}
+	got, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(clientData.Challenge, "="))
+	if err != nil || subtle.ConstantTimeCompare(got, challenge) != 1 {
+		return errors.Wrap(ErrVerification, "challenge mismatch")
+	}
+	if !slices.Contains(rp.cfg.Origins, clientData.Origin) {
+		return errors.Wrapf(ErrVerification, "origin %q is not allowed", clientData.Origin)
+	}
+	if clientData.CrossOrigin {
+		return errors.Wrap(ErrVerification, "cross-origin request")
+	}
+	return nil
+}
+
+func (rp *RelyingParty) verifyAuthData(authData []byte) (byte, uint32, error) {
+	if len(authData) < minAuthDataSize {
+		return 0, 0, errors.Wrap(ErrVerification, "authenticator data is truncated")
+	}
+	rpIDHash := sha256.Sum256([]byte(rp.cfg.RPID))
+	if subtle.ConstantTimeCompare(authData[:32], rpIDHash[:]) != 1 {
+		return 0, 0, errors.Wrap(ErrVerification, "rp id mismatch")
+	}
+
+	flags := authData[32]
+	if flags&FlagUserPresent == 0 {
+		return 0, 0, errors.Wrap(ErrVerification, "user not present")
+	}
+	if rp.cfg.UserVerification == UserVerificationRequired && flags&FlagUserVerified == 0 {
+		return 0, 0, errors.Wrap(ErrVerification, "user not verified")
+	}
+
+	return flags, binary.BigEndian.Uint32(authData[33:37]), nil
+}
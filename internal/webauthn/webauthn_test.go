@@ -0,0 +1,130 @@
+package webauthn_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lemavisaitov/lk-api/internal/testutils/softauthn"
+	"github.com/lemavisaitov/lk-api/internal/webauthn"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRPID   = "lk.example.com"
+	testOrigin = "https://lk.example.com"
+)
+
+func newTestRP() *webauthn.RelyingParty {
+	return webauthn.New(webauthn.Config{
+		RPID:    testRPID,
+		RPName:  "lk-api",
+		Origins: []string{testOrigin},
+		Timeout: time.Minute,
+	})
+}
+
+func register(t *testing.T, rp *webauthn.RelyingParty, authenticator *softauthn.Authenticator) *webauthn.Credential {
+	challenge, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+	options := rp.CreationOptions(challenge, webauthn.UserEntity{ID: []byte("user"), Name: "jane"}, nil)
+
+	response, err := authenticator.Create(options)
+	require.NoError(t, err)
+	cred, err := rp.VerifyRegistration(challenge, response)
+	require.NoError(t, err)
+	return cred
+}
+
+func TestRelyingParty_Registration(t *testing.T) {
+	rp := newTestRP()
+	authenticator := softauthn.New(testRPID, testOrigin)
+
+	// Кейс 1: успешная регистрация
+	cred := register(t, rp, authenticator)
+	assert.Len(t, cred.ID, 16)
+	assert.NotEmpty(t, cred.PublicKey)
+	assert.Zero(t, cred.SignCount)
+
+	// Кейс 2: ответ на другой challenge
+	challenge, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+	options := rp.CreationOptions(challenge, webauthn.UserEntity{ID: []byte("user"), Name: "jane"}, nil)
+	response, err := authenticator.Create(options)
+	require.NoError(t, err)
+	other, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+	_, err = rp.VerifyRegistration(other, response)
+	require.ErrorIs(t, err, webauthn.ErrVerification)
+
+	// Кейс 3: чужой origin
+	phishing := softauthn.New(testRPID, "https://lk-example.evil")
+	response, err = phishing.Create(options)
+	require.NoError(t, err)
+	_, err = rp.VerifyRegistration(challenge, response)
+	require.ErrorIs(t, err, webauthn.ErrVerification)
+
+	// Кейс 4: ключ создан для другого rp id
+	options.RP.ID = "evil.example.com"
+	response, err = authenticator.Create(options)
+	require.NoError(t, err)
+	_, err = rp.VerifyRegistration(challenge, response)
+	require.ErrorIs(t, err, webauthn.ErrVerification)
+
+	// Кейс 5: повреждённый attestationObject
+	options.RP.ID = testRPID
+	response, err = authenticator.Create(options)
+	require.NoError(t, err)
+	response.Response.AttestationObject = response.Response.AttestationObject[:40]
+	_, err = rp.VerifyRegistration(challenge, response)
+	require.ErrorIs(t, err, webauthn.ErrVerification)
+}
+
+func TestRelyingParty_Assertion(t *testing.T) {
+	rp := newTestRP()
+	authenticator := softauthn.New(testRPID, testOrigin)
+	cred := register(t, rp, authenticator)
+
+	allow := []webauthn.CredentialDescriptor{{Type: "public-key", ID: cred.ID}}
+
+	// Кейс 1: успешный вход, счётчик растёт
+	challenge, err := webauthn.NewChallenge()
+	require.NoError(t, err)
+	response, err := authenticator.Get(rp.RequestOptions(challenge, allow))
+	require.NoError(t, err)
+	assertion, err := rp.VerifyAssertion(challenge, cred.PublicKey, response)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), assertion.SignCount)
+	assert.Equal(t, []byte("user"), []byte(response.Response.UserHandle))
+
+	// Кейс 2: подменённая подпись
+	response, err = authenticator.Get(rp.RequestOptions(challenge, allow))
+	require.NoError(t, err)
+	response.Response.Signature[len(response.Response.Signature)-1] ^= 0xff
+	_, err = rp.VerifyAssertion(challenge, cred.PublicKey, response)
+	require.ErrorIs(t, err, webauthn.ErrVerification)
+
+	// Кейс 3: подпись другим ключом
+	otherCred := register(t, rp, softauthn.New(testRPID, testOrigin))
+	response, err = authenticator.Get(rp.RequestOptions(challenge, allow))
+	require.NoError(t, err)
+	_, err = rp.VerifyAssertion(challenge, otherCred.PublicKey, response)
+	require.ErrorIs(t, err, webauthn.ErrVerification)
+
+	// Кейс 4: ответ регистрации не годится для входа
+	options := rp.CreationOptions(challenge, webauthn.UserEntity{ID: []byte("user"), Name: "jane"}, nil)
+	created, err := softauthn.New(testRPID, testOrigin).Create(options)
+	require.NoError(t, err)
+	response.Response.ClientDataJSON = created.Response.ClientDataJSON
+	_, err = rp.VerifyAssertion(challenge, cred.PublicKey, response)
+	require.ErrorIs(t, err, webauthn.ErrVerification)
+}
+
+func TestCounterRegressed(t *testing.T) {
+	assert.False(t, webauthn.CounterRegressed(0, 0))
+	assert.False(t, webauthn.CounterRegressed(5, 6))
+	assert.True(t, webauthn.CounterRegressed(5, 5))
+	assert.True(t, webauthn.CounterRegressed(5, 1))
+	assert.True(t, webauthn.CounterRegressed(5, 0))
+}
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS user_roles
+(
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role VARCHAR(64) NOT NULL,
+    PRIMARY KEY (user_id, role)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_roles;
+-- +goose StatementEnd
@@ -0,0 +1,65 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS roles
+(
+    name VARCHAR(64) PRIMARY KEY,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions
+(
+    role VARCHAR(64) NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    permission VARCHAR(64) NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+
+CREATE TABLE IF NOT EXISTS groups
+(
+    id UUID PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS group_members
+(
+    group_id UUID NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS group_members_user_id_idx ON group_members (user_id);
+
+CREATE TABLE IF NOT EXISTS group_roles
+(
+    group_id UUID NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    role VARCHAR(64) NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    PRIMARY KEY (group_id, role)
+);
+
+-- Роль admin получает все права, чтобы прежние проверки "admin или сам пользователь" работали как раньше
+INSERT INTO roles (name, description) VALUES ('admin', 'Full access to user management')
+ON CONFLICT DO NOTHING;
+INSERT INTO role_permissions (role, permission)
+VALUES ('admin', 'users:read'), ('admin', 'users:write'), ('admin', 'users:delete'), ('admin', 'users:impersonate')
+ON CONFLICT DO NOTHING;
+
+-- Уже выданные роли становятся записями справочника без прав
+INSERT INTO roles (name)
+SELECT DISTINCT role FROM user_roles
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE user_roles
+    ADD CONSTRAINT user_roles_role_fkey FOREIGN KEY (role) REFERENCES roles (name) ON DELETE CASCADE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE user_roles DROP CONSTRAINT user_roles_role_fkey;
+DROP TABLE group_roles;
+DROP TABLE group_members;
+DROP TABLE groups;
+DROP TABLE role_permissions;
+DROP TABLE roles;
+-- +goose StatementEnd
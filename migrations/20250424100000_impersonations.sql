@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- id совпадает с id сессии, открытой для токена имперсонации.
+-- У actor_id нет внешнего ключа: запись должна пережить удаление сотрудника.
+CREATE TABLE IF NOT EXISTS impersonations
+(
+    id UUID PRIMARY KEY,
+    actor_id UUID NOT NULL,
+    subject_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonations_subject_id ON impersonations (subject_id);
+CREATE INDEX IF NOT EXISTS idx_impersonations_actor_id ON impersonations (actor_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS impersonations;
+-- +goose StatementEnd
@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Внешних ключей нет: журнал должен пережить удаление пользователей
+CREATE TABLE IF NOT EXISTS audit_events
+(
+    id UUID PRIMARY KEY,
+    action VARCHAR(64) NOT NULL,
+    actor_id UUID,
+    impersonator_id UUID,
+    target_id UUID,
+    changes JSONB NOT NULL DEFAULT '{}',
+    details JSONB NOT NULL DEFAULT '{}',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON audit_events (actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target_id ON audit_events (target_id, created_at DESC);
+
+-- Записи только добавляются: изменить или удалить их нельзя даже напрямую в БД
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS TRIGGER AS
+$$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
+
+INSERT INTO role_permissions (role, permission) VALUES ('admin', 'audit:read')
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM role_permissions WHERE permission = 'audit:read';
+DROP TABLE IF EXISTS audit_events;
+DROP FUNCTION IF EXISTS audit_events_append_only();
+-- +goose StatementEnd
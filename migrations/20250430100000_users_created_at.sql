@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- У существующих пользователей дата регистрации неизвестна, им достаётся время миграции
+ALTER TABLE users ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- Индексы под сортировки списка пользователей: колонка + id для keyset-пагинации
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_users_name_id ON users (name, id);
+CREATE INDEX IF NOT EXISTS idx_users_age_id ON users (age, id);
+CREATE INDEX IF NOT EXISTS idx_users_login_id ON users (login, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_login_id;
+DROP INDEX IF EXISTS idx_users_age_id;
+DROP INDEX IF EXISTS idx_users_name_id;
+DROP INDEX IF EXISTS idx_users_created_at_id;
+ALTER TABLE users DROP COLUMN IF EXISTS created_at;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- GIN-индексы обслуживают операторы похожести (%, <%) и ILIKE '%...%'
+CREATE INDEX IF NOT EXISTS idx_users_login_trgm ON users USING GIN (login gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_users_name_trgm ON users USING GIN (name gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_name_trgm;
+DROP INDEX IF EXISTS idx_users_login_trgm;
+-- Расширение не удаляется: им могут пользоваться другие объекты базы
+-- +goose StatementEnd
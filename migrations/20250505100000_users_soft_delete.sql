@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Удалённый пользователь остаётся в таблице до очистки, уникальность логина и почты сохраняется
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Очистка выбирает только удалённых, их немного по сравнению со всеми
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_deleted_at;
+DELETE FROM users WHERE deleted_at IS NOT NULL;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Версия растёт при каждом изменении строки и отдаётся клиенту как ETag
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS version;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Дополнительные поля профиля, набор и типы задаются справочником attribute_definitions
+ALTER TABLE users ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+
+-- jsonb_path_ops обслуживает только @>, зато индекс меньше и быстрее
+CREATE INDEX IF NOT EXISTS idx_users_attributes ON users USING GIN (attributes jsonb_path_ops);
+
+CREATE TABLE IF NOT EXISTS attribute_definitions
+(
+    name VARCHAR(64) PRIMARY KEY,
+    type VARCHAR(16) NOT NULL,
+    required BOOLEAN NOT NULL DEFAULT false,
+    max_length INT NOT NULL DEFAULT 0,
+    enum TEXT[] NOT NULL DEFAULT '{}',
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS attribute_definitions;
+DROP INDEX IF EXISTS idx_users_attributes;
+ALTER TABLE users DROP COLUMN IF EXISTS attributes;
+-- +goose StatementEnd
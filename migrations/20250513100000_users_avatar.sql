@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Файлы аватара лежат в хранилище по ключам {user_id}/{avatar_id}/{size}.jpg,
+-- новый avatar_id при каждой загрузке даёт новые URL и не требует сброса кэшей
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_id UUID;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS avatar_id;
+-- +goose StatementEnd
@@ -0,0 +1,83 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS organizations
+(
+    id UUID PRIMARY KEY,
+    slug VARCHAR(63) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Существующие пользователи попадают в организацию по умолчанию (tenant.DefaultID)
+INSERT INTO organizations (id, slug, name)
+VALUES ('00000000-0000-0000-0000-000000000001', 'default', 'Default')
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS organization_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations (id);
+-- Без значения по умолчанию вставка без организации падает, а не попадает в чужую
+ALTER TABLE users ALTER COLUMN organization_id DROP DEFAULT;
+
+-- Логин и почта уникальны в пределах организации
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_login_key;
+DROP INDEX IF EXISTS idx_login;
+DROP INDEX IF EXISTS idx_users_email_lower;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_organization_login ON users (organization_id, login);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_organization_email_lower ON users (organization_id, lower(email));
+
+-- Постраничная выдача всегда идёт внутри организации, по логину сортирует
+-- уникальный индекс выше
+DROP INDEX IF EXISTS idx_users_created_at_id;
+DROP INDEX IF EXISTS idx_users_name_id;
+DROP INDEX IF EXISTS idx_users_age_id;
+DROP INDEX IF EXISTS idx_users_login_id;
+CREATE INDEX IF NOT EXISTS idx_users_organization_created_at_id ON users (organization_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_users_organization_name_id ON users (organization_id, name, id);
+CREATE INDEX IF NOT EXISTS idx_users_organization_age_id ON users (organization_id, age, id);
+
+-- Row-level security действует только для роли, которая не владеет таблицей, и только
+-- если сервис запущен с DB_ROW_LEVEL_SECURITY=true: тогда он передаёт организацию запроса
+-- в app.organization_id. Без организации строки не видны.
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+CREATE POLICY users_organization_isolation ON users
+    USING (organization_id = NULLIF(current_setting('app.organization_id', true), '')::UUID);
+
+-- Организацию пользователя по его id узнают входы по секрету (refresh-токен, ссылка
+-- из письма), когда организация запроса ещё неизвестна. Функция выполняется с правами
+-- владельца и видит всех пользователей, но отдаёт только организацию.
+CREATE OR REPLACE FUNCTION user_organization_id(user_id UUID) RETURNS UUID
+    LANGUAGE sql STABLE SECURITY DEFINER
+    SET search_path = pg_catalog, public
+AS 'SELECT organization_id FROM users WHERE id = user_id AND deleted_at IS NULL';
+
+-- Справочник атрибутов общий, поэтому удалённый атрибут убирается у всех организаций
+CREATE OR REPLACE FUNCTION strip_user_attribute(attribute_name TEXT) RETURNS VOID
+    LANGUAGE sql SECURITY DEFINER
+    SET search_path = pg_catalog, public
+AS 'UPDATE users SET attributes = attributes - attribute_name, version = version + 1 WHERE attributes ? attribute_name';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS strip_user_attribute(TEXT);
+DROP FUNCTION IF EXISTS user_organization_id(UUID);
+DROP POLICY IF EXISTS users_organization_isolation ON users;
+ALTER TABLE users DISABLE ROW LEVEL SECURITY;
+
+DROP INDEX IF EXISTS idx_users_organization_age_id;
+DROP INDEX IF EXISTS idx_users_organization_name_id;
+DROP INDEX IF EXISTS idx_users_organization_created_at_id;
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_users_name_id ON users (name, id);
+CREATE INDEX IF NOT EXISTS idx_users_age_id ON users (age, id);
+CREATE INDEX IF NOT EXISTS idx_users_login_id ON users (login, id);
+
+-- Откат возможен, только пока логины и почты уникальны во всей таблице
+DROP INDEX IF EXISTS idx_users_organization_email_lower;
+DROP INDEX IF EXISTS idx_users_organization_login;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users (lower(email));
+ALTER TABLE users ADD CONSTRAINT users_login_key UNIQUE (login);
+
+ALTER TABLE users DROP COLUMN IF EXISTS organization_id;
+DROP TABLE IF EXISTS organizations;
+-- +goose StatementEnd
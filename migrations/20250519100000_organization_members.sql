@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Роль пользователя в его организации. Запись создаётся вместе с пользователем
+CREATE TABLE IF NOT EXISTS organization_members
+(
+    user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    organization_id UUID NOT NULL REFERENCES organizations (id),
+    role VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'admin', 'member')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Владелец у организации один, передать его можно только явно
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organization_members_owner
+    ON organization_members (organization_id) WHERE role = 'owner';
+CREATE INDEX IF NOT EXISTS idx_organization_members_organization ON organization_members (organization_id);
+
+INSERT INTO organization_members (user_id, organization_id, role)
+SELECT id, organization_id, 'member' FROM users
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE organization_members ENABLE ROW LEVEL SECURITY;
+CREATE POLICY organization_members_isolation ON organization_members
+    USING (organization_id = NULLIF(current_setting('app.organization_id', true), '')::UUID);
+
+-- Приглашение принимают по ссылке из письма, в БД хранится только хэш токена.
+-- Закрытое приглашение (принято, отклонено, отозвано) остаётся для истории
+CREATE TABLE IF NOT EXISTS organization_invitations
+(
+    id UUID PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organizations (id),
+    email VARCHAR(320) NOT NULL,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'admin', 'member')),
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    invited_by UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    accepted_at TIMESTAMPTZ,
+    declined_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_organization_invitations_pending
+    ON organization_invitations (organization_id, lower(email))
+    WHERE accepted_at IS NULL AND declined_at IS NULL AND revoked_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS organization_invitations;
+DROP TABLE IF EXISTS organization_members;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Административные разделы проверяют право, а не роль из токена. Роль admin получает его сразу
+INSERT INTO role_permissions (role, permission) VALUES ('admin', 'admin:manage')
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM role_permissions WHERE permission = 'admin:manage';
+-- +goose StatementEnd
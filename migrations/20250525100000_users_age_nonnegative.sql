@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Возраст пользователей, пришедших через внешний вход, неизвестен и хранится как 0
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_age_check;
+ALTER TABLE users ADD CONSTRAINT users_age_check CHECK (age >= 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- NOT VALID: уже созданные пользователи с возрастом 0 не мешают откату
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_age_check;
+ALTER TABLE users ADD CONSTRAINT users_age_check CHECK (age > 0) NOT VALID;
+-- +goose StatementEnd
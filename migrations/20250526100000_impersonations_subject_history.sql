@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Как и у actor_id, у subject_id нет внешнего ключа: история имперсонаций
+-- должна пережить окончательное удаление пользователя.
+ALTER TABLE impersonations DROP CONSTRAINT IF EXISTS impersonations_subject_id_fkey;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- NOT VALID: записи об уже стёртых пользователях не мешают откату
+ALTER TABLE impersonations
+    ADD CONSTRAINT impersonations_subject_id_fkey FOREIGN KEY (subject_id)
+    REFERENCES users (id) ON DELETE CASCADE NOT VALID;
+-- +goose StatementEnd
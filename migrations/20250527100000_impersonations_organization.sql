@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Журнал имперсонаций переживает удаление пользователей, поэтому организацию
+-- он хранит сам, а не берёт из users.
+ALTER TABLE impersonations ADD COLUMN IF NOT EXISTS organization_id UUID;
+UPDATE impersonations SET organization_id = users.organization_id
+FROM users
+WHERE users.id = impersonations.subject_id;
+
+CREATE INDEX IF NOT EXISTS idx_impersonations_organization_started_at
+    ON impersonations (organization_id, started_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_impersonations_organization_started_at;
+ALTER TABLE impersonations DROP COLUMN IF EXISTS organization_id;
+-- +goose StatementEnd
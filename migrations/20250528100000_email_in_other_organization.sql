@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Приглашение не создаёт второй учётной записи на почту, занятую в другой организации.
+-- Как и user_organization_id, функция видит всех пользователей, но отдаёт только да или нет.
+CREATE OR REPLACE FUNCTION email_in_other_organization(address TEXT, organization UUID) RETURNS BOOLEAN
+    LANGUAGE sql STABLE SECURITY DEFINER
+    SET search_path = pg_catalog, public
+AS 'SELECT EXISTS (SELECT 1 FROM users WHERE lower(email) = lower(address)
+    AND organization_id <> organization AND deleted_at IS NULL)';
+
+CREATE INDEX IF NOT EXISTS idx_users_lower_email ON users (lower(email));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_lower_email;
+DROP FUNCTION IF EXISTS email_in_other_organization(TEXT, UUID);
+-- +goose StatementEnd